@@ -0,0 +1,50 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchmarkSource returns a synthetic JavaScript file of the given number
+// of lines, mixing imports, requires, dynamic imports, comments and code.
+func benchmarkSource(lines int) string {
+	var src strings.Builder
+	for i := 0; i < lines; i++ {
+		switch i % 10 {
+		case 0:
+			fmt.Fprintf(&src, "import { f%d } from \"pkg-%d/sub\";\n", i, i)
+		case 1:
+			fmt.Fprintf(&src, "const m%d = require(\"pkg-%d\");\n", i, i)
+		case 2:
+			fmt.Fprintf(&src, "const lazy%d = await import(`./locales/${lang}-%d.js`);\n", i, i)
+		case 3:
+			fmt.Fprintf(&src, "// require(\"commented-out-%d\")\n", i)
+		default:
+			fmt.Fprintf(&src, "export const v%d = compute(%d, \"a string\", { key: [1, 2, 3] });\n", i, i)
+		}
+	}
+	return src.String()
+}
+
+func BenchmarkJavaScriptExtract(b *testing.B) {
+	src := benchmarkSource(5000)
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := (JavaScript{}).Extract(strings.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarkdownExtract(b *testing.B) {
+	src := "# Guide\n\nSome prose.\n\n```js\n" + benchmarkSource(1000) + "```\n"
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := (Markdown{}).Extract(strings.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package extract finds the packages used by source files.
+//
+// Every language and file format has its own Extractor, registered for the
+// languages whose projects it applies to. Extractors for new languages or
+// file formats can live in their own package, and register themselves from
+// an init function, without touching the scanner of depose:
+//
+//	func init() {
+//		extract.Register("node", myExtractor{})
+//	}
+package extract
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Any is the extension matching every file
+// which has no more specific extractor.
+const Any = "*"
+
+// Specifier is a reference to a package found in a file.
+type Specifier struct {
+	// Path is the specifier as written in the file, e.g. "lodash/fp".
+	Path string
+	// Line is the line of the file the specifier was found on.
+	Line int
+	// Dynamic is true when the specifier is computed at runtime, e.g.
+	// require(`./locales/${lang}`), in which case Path is only its
+	// static prefix, possibly empty.
+	Dynamic bool
+	// SideEffect is true when the package is loaded for its side effects
+	// only, without binding anything, e.g. import "core-js/stable" or
+	// require("dotenv").config().
+	SideEffect bool
+}
+
+// Extractor extracts the specifiers of the packages used by a file.
+type Extractor interface {
+	// Extensions returns the extensions of the files handled by the
+	// extractor, e.g. ".js". Any matches files without a more specific one.
+	Extensions() []string
+	// Extract returns the specifiers found in the content of the file.
+	Extract(r io.Reader) ([]Specifier, error)
+}
+
+var (
+	mu sync.RWMutex
+	// registry maps languages to the extractors of each file extension.
+	registry = make(map[string]map[string]Extractor)
+)
+
+// Register registers the extractor for the files of its extensions,
+// in the projects of the given language.
+//
+// Registering an extractor for an extension which already has one
+// replaces the previous extractor.
+func Register(language string, e Extractor) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if registry[language] == nil {
+		registry[language] = make(map[string]Extractor)
+	}
+	for _, ext := range e.Extensions() {
+		registry[language][ext] = e
+	}
+}
+
+// For returns the extractor of the file, in the projects of the given language.
+// It returns false when the file should not be scanned.
+func For(language, path string) (Extractor, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	extractors := registry[language]
+	if e, ok := extractors[filepath.Ext(path)]; ok {
+		return e, true
+	}
+	e, ok := extractors[Any]
+	return e, ok
+}
+
+// shebangRe matches the shebang line starting an executable, capturing
+// its interpreter, e.g. "node" for "#!/usr/bin/env node" or
+// "#!/usr/bin/env -S node --no-warnings".
+var shebangRe = regexp.MustCompile(`^#!\s*(?:\S*/)?(?:env\s+(?:-\S+\s+)*)?([^\s/]+)`)
+
+// interpreterExtensions maps the interpreters of shebang lines to the
+// extension of the files they run.
+var interpreterExtensions = map[string]string{
+	"node": ".js", "nodejs": ".js", "bun": ".ts", "ts-node": ".ts", "tsx": ".ts",
+	"python": ".py", "python3": ".py",
+}
+
+// Interpreter returns the interpreter of the shebang line starting the
+// content of a file, e.g. "node" for "#!/usr/bin/env node", or "" if the
+// file doesn't start with one.
+func Interpreter(head []byte) string {
+	if m := shebangRe.FindSubmatch(head); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// ForShebang returns the extractor of a file without extension, chosen by
+// the interpreter of the shebang line starting its content, in the
+// projects of the given language, e.g. the JavaScript one for an
+// executable starting with "#!/usr/bin/env node".
+func ForShebang(language string, head []byte) (Extractor, bool) {
+	ext, ok := interpreterExtensions[Interpreter(head)]
+	if !ok {
+		return nil, false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := registry[language][ext]
+	return e, ok
+}
+
+// scanLines calls the function with every line of the reader, along with its
+// number. Lines are allowed to be much longer than the bufio default, so
+// that bundled or minified files are read entirely.
+func scanLines(r io.Reader, fn func(line string, lineNo int)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fn(scanner.Text(), lineNo)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,61 @@
+package extract
+
+import (
+	"io"
+	"testing"
+)
+
+type testExtractor struct{}
+
+func (testExtractor) Extensions() []string                   { return []string{".test"} }
+func (testExtractor) Extract(io.Reader) ([]Specifier, error) { return nil, nil }
+
+func TestFor(t *testing.T) {
+	Register("test-language", testExtractor{})
+
+	tests := []struct {
+		language string
+		path     string
+		want     Extractor
+	}{
+		{"node", "src/app.ts", JavaScript{}},
+		{"node", "src/App.vue", Component{}},
+		{"node", "styles/main.scss", CSS{}},
+		{"node", ".babelrc", JavaScript{}},
+		{"go", "main.go", Go{}},
+		{"go", "README.md", nil},
+		{"python", "app.py", Python{}},
+		{"python", "app.js", nil},
+		{"test-language", "a.test", testExtractor{}},
+		{"unknown", "a.js", nil},
+	}
+	for _, tt := range tests {
+		got, ok := For(tt.language, tt.path)
+		if ok != (tt.want != nil) || got != tt.want {
+			t.Errorf("For(%q, %q) = %v, %v, want %v", tt.language, tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+func TestForShebang(t *testing.T) {
+	tests := []struct {
+		language string
+		head     string
+		want     Extractor
+	}{
+		{"node", "#!/usr/bin/env node\nrequire('yargs')", JavaScript{}},
+		{"node", "#!/usr/local/bin/node", JavaScript{}},
+		{"node", "#!/usr/bin/env -S node --no-warnings", JavaScript{}},
+		{"node", "#! /usr/bin/env tsx", JavaScript{}},
+		{"node", "#!/bin/sh\nexec node cli.js", nil},
+		{"node", "require('yargs')", nil},
+		{"python", "#!/usr/bin/env python3", Python{}},
+		{"python", "#!/usr/bin/env node", nil},
+	}
+	for _, tt := range tests {
+		got, ok := ForShebang(tt.language, []byte(tt.head))
+		if ok != (tt.want != nil) || got != tt.want {
+			t.Errorf("ForShebang(%q, %q) = %v, %v, want %v", tt.language, tt.head, got, ok, tt.want)
+		}
+	}
+}
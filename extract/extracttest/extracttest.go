@@ -0,0 +1,55 @@
+// Package extracttest runs table-driven tests of extractors, so that the
+// extractors of depose and the ones registered by other packages are
+// tested the same way:
+//
+//	func TestExtract(t *testing.T) {
+//		extracttest.Run(t, myExtractor{}, []extracttest.Case{
+//			{Name: "import", Src: `use "pkg";`, Want: []extract.Specifier{{Path: "pkg", Line: 1}}},
+//		})
+//	}
+package extracttest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+// Case is a source file, and the specifiers an extractor finds in it.
+type Case struct {
+	Name string
+	Src  string
+	// Want are the specifiers found, in order, with their line.
+	// Nil and empty both mean that none is found.
+	Want []extract.Specifier
+	// WantErr expects the extraction to fail.
+	WantErr bool
+}
+
+// Run extracts the specifiers of the source of every case, each in its
+// own subtest, and reports the ones which differ from the expected ones.
+func Run(t *testing.T, e extract.Extractor, cases []Case) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got, err := e.Extract(strings.NewReader(c.Src))
+			if c.WantErr {
+				if err == nil {
+					t.Errorf("Extract(%q) succeeded, want an error", c.Src)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Extract(%q) failed: %v", c.Src, err)
+			}
+			if len(got) == 0 && len(c.Want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, c.Want) {
+				t.Errorf("Extract(%q) = %+v, want %+v", c.Src, got, c.Want)
+			}
+		})
+	}
+}
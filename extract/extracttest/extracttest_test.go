@@ -0,0 +1,80 @@
+package extracttest_test
+
+import (
+	"testing"
+
+	"github.com/CoderParth/depose/extract"
+	"github.com/CoderParth/depose/extract/extracttest"
+)
+
+// at returns the static specifiers of the paths, found on the line.
+func at(line int, paths ...string) []extract.Specifier {
+	var specifiers []extract.Specifier
+	for _, p := range paths {
+		specifiers = append(specifiers, extract.Specifier{Path: p, Line: line})
+	}
+	return specifiers
+}
+
+func TestJavaScript(t *testing.T) {
+	extracttest.Run(t, extract.JavaScript{}, []extracttest.Case{
+		// Quotes
+		{Name: "double quotes", Src: `const a = require("a");`, Want: at(1, "a")},
+		{Name: "single quotes", Src: `import b from 'b';`, Want: at(1, "b")},
+		{Name: "template literal", Src: "const c = await import(`c`);", Want: at(1, "c")},
+		{Name: "no space before the quote", Src: `import d from"d"`, Want: at(1, "d")},
+		// Scopes and subpaths
+		{Name: "scoped package", Src: `import { Button } from "@acme/ui";`, Want: at(1, "@acme/ui")},
+		{Name: "subpath", Src: `const fp = require("lodash/fp");`, Want: at(1, "lodash/fp")},
+		{Name: "scoped subpath", Src: `import { theme } from "@acme/ui/theme";`, Want: at(1, "@acme/ui/theme")},
+		{Name: "several on a line", Src: `const a = require("a"), b = require("@s/b");`, Want: at(1, "a", "@s/b")},
+		// Side effects
+		{Name: "side-effect import", Src: `import "core-js/stable";`, Want: []extract.Specifier{{Path: "core-js/stable", Line: 1, SideEffect: true}}},
+		{Name: "side-effect require", Src: `require("dotenv").config();`, Want: []extract.Specifier{{Path: "dotenv", Line: 1, SideEffect: true}}},
+		{Name: "called require", Src: `require("debug")("app");`, Want: at(1, "debug")},
+		{Name: "bound require", Src: `const config = require("dotenv").config();`, Want: at(1, "dotenv")},
+		// Multi-line statements
+		{Name: "multi-line import", Src: "import {\n  a,\n  b,\n} from \"pkg\";\n", Want: at(4, "pkg")},
+		{Name: "type import", Src: "import type {\n  Options,\n} from \"@types/pkg\";", Want: at(3, "@types/pkg")},
+		{Name: "re-export", Src: `export { default } from "pkg";`, Want: at(1, "pkg")},
+		{Name: "multi-line re-export", Src: "export {\n  a,\n} from \"pkg\";", Want: at(3, "pkg")},
+		{Name: "line numbers", Src: "const a = require(\"a\");\n\nimport b from 'b';\n", Want: append(at(1, "a"), at(3, "b")...)},
+		// Comments
+		{Name: "line comment", Src: `// const old = require("old");`, Want: nil},
+		{Name: "block comment", Src: "/*\n * @example\n * import x from 'x';\n */\nimport y from 'y';", Want: at(5, "y")},
+		{Name: "one-line block comment", Src: `/* eslint-disable */ const z = require("z");`, Want: at(1, "z")},
+		{Name: "trailing comment", Src: `import a from "a"; // was "b"`, Want: at(1, "a")},
+		{Name: "URL", Src: `import x from "https://esm.sh/x";`, Want: at(1, "https://esm.sh/x")},
+		// Dynamic imports
+		{Name: "dynamic import", Src: `const mod = await import("chart.js");`, Want: at(1, "chart.js")},
+		{Name: "computed require", Src: `require(name)`, Want: []extract.Specifier{{Line: 1, Dynamic: true}}},
+		{Name: "template prefix", Src: "require(`./locales/${lang}.json`)", Want: []extract.Specifier{{Path: "./locales/", Line: 1, Dynamic: true}}},
+		{Name: "concatenation", Src: `require("eslint-plugin-" + name)`, Want: []extract.Specifier{{Path: "eslint-plugin-", Line: 1, Dynamic: true}}},
+		{Name: "require.resolve", Src: `require.resolve(name)`, Want: nil},
+	})
+}
+
+func TestCSS(t *testing.T) {
+	extracttest.Run(t, extract.CSS{}, []extracttest.Case{
+		{Name: "import", Src: `@import "~bootstrap/scss/bootstrap";`, Want: at(1, "bootstrap/scss/bootstrap")},
+		{Name: "url", Src: `@import url('~normalize.css');`, Want: at(1, "normalize.css")},
+		{Name: "local", Src: `@import "./local.css";`, Want: nil},
+		{Name: "tailwind plugin", Src: `@plugin "@tailwindcss/forms";`, Want: at(1, "@tailwindcss/forms")},
+	})
+}
+
+func TestPython(t *testing.T) {
+	extracttest.Run(t, extract.Python{}, []extracttest.Case{
+		{Name: "import", Src: `import os.path, yaml as y`, Want: at(1, "os.path", "yaml")},
+		{Name: "from", Src: `from PIL import Image`, Want: at(1, "PIL")},
+		{Name: "relative", Src: `from .utils import helper`, Want: at(1, ".utils")},
+		{Name: "not an import", Src: `important = True`, Want: nil},
+	})
+}
+
+func TestGo(t *testing.T) {
+	extracttest.Run(t, extract.Go{}, []extracttest.Case{
+		{Name: "imports", Src: "package main\n\nimport (\n\t\"fmt\"\n\terrs \"github.com/pkg/errors\"\n)\n", Want: append(at(4, "fmt"), at(5, "github.com/pkg/errors")...)},
+		{Name: "invalid", Src: "not go", WantErr: true},
+	})
+}
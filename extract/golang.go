@@ -0,0 +1,42 @@
+package extract
+
+import (
+	"go/parser"
+	"go/token"
+	"io"
+	"strconv"
+)
+
+func init() {
+	Register("go", Go{})
+}
+
+// Go extracts the import paths of Go files, using the parser of the standard library.
+type Go struct{}
+
+func (Go) Extensions() []string {
+	return []string{".go"}
+}
+
+func (Go) Extract(r io.Reader) ([]Specifier, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	var specifiers []Specifier
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		specifiers = append(specifiers, Specifier{Path: path, Line: fset.Position(spec.Pos()).Line})
+	}
+	return specifiers, nil
+}
@@ -0,0 +1,41 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGoExtract(t *testing.T) {
+	src := `package main
+
+import (
+	"fmt"
+	errs "github.com/pkg/errors"
+	_ "embed"
+)
+
+import "golang.org/x/sync/errgroup"
+
+func main() {}
+`
+	specifiers, err := Go{}.Extract(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Specifier{
+		{Path: "fmt", Line: 4},
+		{Path: "github.com/pkg/errors", Line: 5},
+		{Path: "embed", Line: 6},
+		{Path: "golang.org/x/sync/errgroup", Line: 9},
+	}
+	if !reflect.DeepEqual(specifiers, want) {
+		t.Errorf("Extract() = %v, want %v", specifiers, want)
+	}
+}
+
+func TestGoExtractInvalid(t *testing.T) {
+	if _, err := (Go{}).Extract(strings.NewReader("not go")); err == nil {
+		t.Errorf("Extract() of an invalid file succeeded")
+	}
+}
@@ -0,0 +1,254 @@
+package extract
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register("node", JavaScript{})
+	Register("node", Component{})
+	Register("node", CSS{})
+}
+
+// JavaScript extracts the packages used by JavaScript and TypeScript files
+// with require() calls, import statements, and the dependency arrays of
+// AMD modules, e.g. define(["jquery"], factory), including UMD wrappers.
+//
+// It is also used for the files which have no more specific extractor,
+// since configuration files often reference packages with require() calls.
+type JavaScript struct{}
+
+var (
+	// Regular expression to match module names in require calls
+	requireRe = regexp.MustCompile(`require\(\s*["']([^"']+)["']\s*\)`)
+	// Regular expression to match module names in import statements
+	importRe = regexp.MustCompile(`from\s*["']([^"']+)["']|import\s*["']([^"']+)["']`)
+	// Regular expression to match the last line of an import or export
+	// statement spanning several lines, e.g. `} from "module-name";`.
+	closingFromRe = regexp.MustCompile(`^\s*}\s*from\s*["']`)
+	// Regular expression to match the argument of require calls and dynamic
+	// imports, up to the first closing parenthesis.
+	callRe = regexp.MustCompile(`\b(?:require|import)\(\s*([^)]*)\)?`)
+	// Regular expression to match a plain string literal.
+	stringLiteralRe = regexp.MustCompile(`^["']([^"']*)["']\s*$`)
+	// Regular expression to match the leading string literal of an
+	// expression, e.g. "'pkg-' + name" or "path.join('pkg', file)".
+	leadingLiteralRe = regexp.MustCompile(`^(?:path\.(?:join|resolve)\(\s*)?["']([^"']*)["']`)
+	// Regular expression to match the start of the dependency array of an
+	// AMD module, possibly named, e.g. define("app", ["jquery"], ...), or
+	// of an AMD require call, e.g. require(["jquery"], ...).
+	amdStartRe = regexp.MustCompile(`\b(?:define|require|requirejs)\s*\(\s*(?:["'][^"']*["']\s*,\s*)?\[`)
+	// Regular expression to match the string literals of a dependency array.
+	amdDependencyRe = regexp.MustCompile(`["']([^"']+)["']`)
+	// Regular expression to match the template engine set on an Express
+	// app, e.g. app.set("view engine", "pug").
+	viewEngineRe = regexp.MustCompile(`\.set\(\s*["']view engine["']\s*,\s*["']([^"']+)["']`)
+)
+
+// viewEngines are the template engines which Express requires by their
+// name when they are set as the view engine of an app.
+var viewEngines = map[string]bool{"ejs": true, "pug": true, "jade": true, "hbs": true, "twig": true, "eta": true}
+
+func (JavaScript) Extensions() []string {
+	return []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts", Any}
+}
+
+func (JavaScript) Extract(r io.Reader) ([]Specifier, error) {
+	var specifiers []Specifier
+	inComment, inAMDArray := false, false
+	err := scanLines(r, func(line string, lineNo int) {
+		line, inComment = uncommentedCode(line, inComment)
+		var found []Specifier
+		found, inAMDArray = amdDependencies(line, inAMDArray)
+		for _, s := range append(extractJavaScriptLine(line), found...) {
+			s.Line = lineNo
+			specifiers = append(specifiers, s)
+		}
+	})
+	return specifiers, err
+}
+
+// amdSpecialDependencies are the dependencies provided by AMD loaders
+// themselves, which are not modules.
+var amdSpecialDependencies = map[string]bool{"require": true, "exports": true, "module": true}
+
+// amdDependencies returns the modules listed by the dependency arrays of
+// AMD modules found in the line, given whether an array spanning several
+// lines is open at its start, and whether one is open at its end.
+//
+// The dependencies loaded through a loader plugin, e.g. "text!./view.html",
+// use the module of the plugin, whose resource is resolved by the plugin.
+func amdDependencies(line string, inArray bool) ([]Specifier, bool) {
+	var specifiers []Specifier
+	for {
+		if !inArray {
+			loc := amdStartRe.FindStringIndex(line)
+			if loc == nil {
+				return specifiers, false
+			}
+			line, inArray = line[loc[1]:], true
+		}
+		array, rest, closed := strings.Cut(line, "]")
+		for _, match := range amdDependencyRe.FindAllStringSubmatch(array, -1) {
+			path, _, _ := strings.Cut(match[1], "!")
+			if !amdSpecialDependencies[path] {
+				specifiers = append(specifiers, Specifier{Path: path})
+			}
+		}
+		if !closed {
+			return specifiers, true
+		}
+		line, inArray = rest, false
+	}
+}
+
+// uncommentedCode returns the code of the line which is not commented
+// out, given whether a block comment is open at its start, and whether one
+// is open at its end. Only comments starting the line are recognized, e.g.
+// "// require('x')" or the lines of a JSDoc block, since "//" and "/*"
+// are common elsewhere, e.g. in URLs and globs.
+func uncommentedCode(line string, inComment bool) (string, bool) {
+	if inComment {
+		_, rest, closed := strings.Cut(line, "*/")
+		if !closed {
+			return "", true
+		}
+		line = rest
+	}
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "//"):
+		return "", false
+	case strings.HasPrefix(trimmed, "/*"):
+		_, rest, closed := strings.Cut(trimmed[2:], "*/")
+		if !closed {
+			return "", true
+		}
+		return uncommentedCode(rest, false)
+	}
+	return line, false
+}
+
+// extractJavaScriptLine checks if "require" keyword or "import" keyword
+// is present in the line, and returns the module names used with them.
+//
+// Calls whose argument is computed at runtime are returned as dynamic
+// specifiers, along with the static prefix of their argument.
+func extractJavaScriptLine(line string) []Specifier {
+	var specifiers []Specifier
+
+	// for case where "require" keyword is used.
+	// Requires starting a statement bind nothing, e.g. require("dotenv").config(),
+	// unless the module itself is called, e.g. require("debug")("app").
+	if strings.Contains(line, "require") {
+		for _, match := range requireRe.FindAllStringSubmatchIndex(line, -1) {
+			before := strings.TrimSpace(line[:match[0]])
+			called := strings.HasPrefix(strings.TrimSpace(line[match[1]:]), "(")
+			sideEffect := (before == "" || strings.HasSuffix(before, ";")) && !called
+			specifiers = append(specifiers, Specifier{Path: line[match[2]:match[3]], SideEffect: sideEffect})
+		}
+	}
+
+	// for case where "import" keyword is used, and for re-exports, and
+	// the last line of the imports and re-exports spanning several lines.
+	if strings.Contains(line, "import") || strings.Contains(line, "export") || closingFromRe.MatchString(line) {
+		for _, match := range importRe.FindAllStringSubmatch(line, -1) {
+			// The first submatch is the module name like "import ... from 'module-name'",
+			// and the second submatch is the module name like "import 'module-name'".
+			// One of them will be empty, and one will contain the module name.
+			// The latter imports the module for its side effects only.
+			if match[1] != "" {
+				specifiers = append(specifiers, Specifier{Path: match[1]})
+			} else {
+				specifiers = append(specifiers, Specifier{Path: match[2], SideEffect: true})
+			}
+		}
+	}
+
+	if strings.Contains(line, "view engine") {
+		for _, match := range viewEngineRe.FindAllStringSubmatch(line, -1) {
+			if viewEngines[match[1]] {
+				specifiers = append(specifiers, Specifier{Path: match[1]})
+			}
+		}
+	}
+
+	if strings.Contains(line, "require(") || strings.Contains(line, "import(") {
+		for _, match := range callRe.FindAllStringSubmatch(line, -1) {
+			if s, ok := callSpecifier(match[0], match[1]); ok {
+				specifiers = append(specifiers, s)
+			}
+		}
+	}
+	return specifiers
+}
+
+// callSpecifier returns the specifier of a require call or dynamic import
+// which is not a plain require of a string literal, already handled by
+// requireRe. The argument of dynamic imports of string literals is static.
+func callSpecifier(call, arg string) (Specifier, bool) {
+	arg = strings.TrimSpace(arg)
+	if m := stringLiteralRe.FindStringSubmatch(arg); m != nil {
+		if strings.HasPrefix(call, "require") {
+			return Specifier{}, false
+		}
+		return Specifier{Path: m[1]}, true
+	}
+	// The dependency arrays of AMD require calls are handled by amdDependencies.
+	if arg == "" || strings.HasPrefix(arg, "[") {
+		return Specifier{}, false
+	}
+
+	if strings.HasPrefix(arg, "`") {
+		template := strings.TrimPrefix(arg, "`")
+		prefix, _, dynamic := strings.Cut(template, "${")
+		if !dynamic {
+			return Specifier{Path: strings.TrimSuffix(strings.TrimSpace(template), "`")}, true
+		}
+		return Specifier{Path: prefix, Dynamic: true}, true
+	}
+	if m := leadingLiteralRe.FindStringSubmatch(arg); m != nil {
+		return Specifier{Path: m[1], Dynamic: true}, true
+	}
+	return Specifier{Dynamic: true}, true
+}
+
+// Component extracts the packages used by single file components of
+// Vue, Svelte and Astro, whose scripts use the JavaScript syntax.
+type Component struct{}
+
+func (Component) Extensions() []string {
+	return []string{".vue", ".svelte", ".astro"}
+}
+
+func (Component) Extract(r io.Reader) ([]Specifier, error) {
+	return JavaScript{}.Extract(r)
+}
+
+// CSS extracts the packages imported by stylesheets with the "~" prefix
+// understood by bundlers, e.g. @import "~bootstrap/scss/bootstrap", and
+// the Tailwind plugins they load, e.g. @plugin "@tailwindcss/typography".
+type CSS struct{}
+
+var (
+	cssImportRe = regexp.MustCompile(`@(?:import|use|forward)\s+(?:url\()?\s*["']~([^"']+)["']`)
+	cssPluginRe = regexp.MustCompile(`@plugin\s+["']([^"'./][^"']*)["']`)
+)
+
+func (CSS) Extensions() []string {
+	return []string{".css", ".scss", ".sass", ".less"}
+}
+
+func (CSS) Extract(r io.Reader) ([]Specifier, error) {
+	var specifiers []Specifier
+	err := scanLines(r, func(line string, lineNo int) {
+		for _, re := range []*regexp.Regexp{cssImportRe, cssPluginRe} {
+			for _, match := range re.FindAllStringSubmatch(line, -1) {
+				specifiers = append(specifiers, Specifier{Path: match[1], Line: lineNo})
+			}
+		}
+	})
+	return specifiers, err
+}
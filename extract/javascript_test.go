@@ -0,0 +1,127 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// paths returns the paths of the specifiers.
+func paths(specifiers []Specifier) []string {
+	result := []string{}
+	for _, s := range specifiers {
+		result = append(result, s.Path)
+	}
+	return result
+}
+
+func TestJavaScriptExtract(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{`const express = require("express");`, []string{"express"}},
+		{`const _ = require("lodash")`, []string{"lodash"}},
+		{`const chalk = require('chalk').default;`, []string{"chalk"}},
+		{`require( "dotenv" ).config();`, []string{"dotenv"}},
+		{`const a = require("a"), b = require("@scope/b/sub");`, []string{"a", "@scope/b/sub"}},
+		{`const local = require("./controllers/ProductController");`, []string{"./controllers/ProductController"}},
+		{`import defaultExport, * as name from "module-name-10";`, []string{"module-name-10"}},
+		{`import "module-name-11";`, []string{"module-name-11"}},
+	}
+	for _, tt := range tests {
+		specifiers, err := JavaScript{}.Extract(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("Extract(%q) failed: %v", tt.src, err)
+		}
+		if got := paths(specifiers); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestJavaScriptExtractLines(t *testing.T) {
+	src := "const a = require(\"a\");\n\nimport b from 'b';\n"
+	specifiers, err := JavaScript{}.Extract(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Specifier{{Path: "a", Line: 1}, {Path: "b", Line: 3}}
+	if !reflect.DeepEqual(specifiers, want) {
+		t.Errorf("Extract() = %v, want %v", specifiers, want)
+	}
+}
+
+func TestJavaScriptExtractDynamic(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []Specifier
+	}{
+		{"const dynamic = require(name);", []Specifier{{Path: "", Line: 1, Dynamic: true}}},
+		{"require(`./locales/${lang}.json`)", []Specifier{{Path: "./locales/", Line: 1, Dynamic: true}}},
+		{"require(path.join('plugins', file))", []Specifier{{Path: "plugins", Line: 1, Dynamic: true}}},
+		{"require('eslint-plugin-' + name)", []Specifier{{Path: "eslint-plugin-", Line: 1, Dynamic: true}}},
+		{"const mod = await import(\"chart.js\");", []Specifier{{Path: "chart.js", Line: 1}}},
+		{"const mod = await import(`dayjs`);", []Specifier{{Path: "dayjs", Line: 1}}},
+		{"require.resolve(name)", nil},
+	}
+	for _, tt := range tests {
+		specifiers, err := JavaScript{}.Extract(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(specifiers, tt.want) {
+			t.Errorf("Extract(%q) = %v, want %v", tt.src, specifiers, tt.want)
+		}
+	}
+}
+
+func TestCSSExtract(t *testing.T) {
+	src := `@import "~bootstrap/scss/bootstrap";
+@import url("~normalize.css");
+@use '~@fontsource/inter';
+@import "./local.css";
+@plugin "@tailwindcss/typography";
+@plugin "./plugins/local.js";
+`
+	specifiers, err := CSS{}.Extract(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bootstrap/scss/bootstrap", "normalize.css", "@fontsource/inter", "@tailwindcss/typography"}
+	if got := paths(specifiers); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %v, want %v", got, want)
+	}
+}
+
+func TestJavaScriptExtractAMD(t *testing.T) {
+	src := `define(["jquery", "underscore", "text!./templates/app.html", "require", "exports"], function ($, _, tmpl) {
+  require(["moment"], function (moment) {});
+});
+define("app/main", [
+  "backbone",
+  "./local"
+], function (Backbone) {});
+(function (root, factory) {
+  if (typeof define === "function" && define.amd) {
+    define(["lodash"], factory);
+  } else if (typeof module === "object" && module.exports) {
+    module.exports = factory(require("lodash"));
+  }
+})(this, function (_) {});
+`
+	specifiers, err := JavaScript{}.Extract(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Specifier{
+		{Path: "jquery", Line: 1}, {Path: "underscore", Line: 1}, {Path: "text", Line: 1},
+		{Path: "moment", Line: 2},
+		{Path: "backbone", Line: 5}, {Path: "./local", Line: 6},
+		{Path: "lodash", Line: 10},
+		{Path: "lodash", Line: 12},
+	}
+	if !reflect.DeepEqual(specifiers, want) {
+		t.Errorf("Extract() = %v, want %v", specifiers, want)
+	}
+}
@@ -0,0 +1,100 @@
+package extract
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register("node", MDX{})
+	Register("node", Markdown{})
+}
+
+var (
+	// fenceRe matches the fence opening or closing a code block of
+	// Markdown, capturing the fence and the language of the block, e.g.
+	// "```js" or "~~~ typescript title=app.ts".
+	fenceRe = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([\\w-]*)")
+	// inlineCodeRe matches the code spans of a line of prose, e.g.
+	// `require("x")`.
+	inlineCodeRe = regexp.MustCompile("`+[^`]*`+")
+)
+
+// codeBlockLanguages are the languages of the code blocks of Markdown
+// files holding JavaScript or TypeScript.
+var codeBlockLanguages = map[string]bool{
+	"js": true, "javascript": true, "jsx": true, "mjs": true, "cjs": true,
+	"ts": true, "typescript": true, "tsx": true, "mts": true, "cts": true,
+}
+
+// codeBlocks tracks the fenced code blocks of a Markdown document, line
+// by line.
+type codeBlocks struct {
+	fence, language string
+}
+
+// next reads the line, and reports whether it is a line of code of a
+// block, i.e. neither prose nor a fence, along with the language of the
+// block.
+func (c *codeBlocks) next(line string) (code bool, language string) {
+	m := fenceRe.FindStringSubmatch(line)
+	switch {
+	case c.fence == "" && m != nil:
+		c.fence, c.language = m[1], strings.ToLower(m[2])
+		return false, ""
+	case c.fence != "" && m != nil && strings.HasPrefix(m[1], c.fence) && m[2] == "":
+		c.fence, c.language = "", ""
+		return false, ""
+	}
+	return c.fence != "", c.language
+}
+
+// MDX extracts the packages used by MDX documents: the imports and the
+// JSX expressions of their prose. Their code blocks and code spans are
+// only shown to the readers, so they are skipped.
+type MDX struct{}
+
+func (MDX) Extensions() []string {
+	return []string{".mdx"}
+}
+
+func (MDX) Extract(r io.Reader) ([]Specifier, error) {
+	var blocks codeBlocks
+	var specifiers []Specifier
+	err := scanLines(r, func(line string, lineNo int) {
+		if code, _ := blocks.next(line); code || fenceRe.MatchString(line) {
+			return
+		}
+		for _, s := range extractJavaScriptLine(inlineCodeRe.ReplaceAllString(line, "")) {
+			s.Line = lineNo
+			specifiers = append(specifiers, s)
+		}
+	})
+	return specifiers, err
+}
+
+// Markdown extracts the packages used by the JavaScript and TypeScript
+// code blocks of Markdown documents, e.g. the examples of a guide. The
+// prose, and the code blocks of other languages, e.g. shell sessions,
+// are skipped.
+type Markdown struct{}
+
+func (Markdown) Extensions() []string {
+	return []string{".md", ".markdown"}
+}
+
+func (Markdown) Extract(r io.Reader) ([]Specifier, error) {
+	var blocks codeBlocks
+	var specifiers []Specifier
+	err := scanLines(r, func(line string, lineNo int) {
+		if code, language := blocks.next(line); !code || !codeBlockLanguages[language] {
+			return
+		}
+		for _, s := range extractJavaScriptLine(line) {
+			s.Line = lineNo
+			specifiers = append(specifiers, s)
+		}
+	})
+	return specifiers, err
+}
@@ -0,0 +1,59 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor Extractor
+		src       string
+		want      []Specifier
+	}{
+		{"mdx", MDX{}, `import { Chart } from "recharts";
+export { meta } from "./meta.js";
+
+# Usage
+
+Call ` + "`require(\"not-a-dependency\")`" + ` from your code:
+
+` + "```js" + `
+import shown from "only-shown";
+` + "```" + `
+
+<Chart data={require("./data.json")} />`, []Specifier{
+			{Path: "recharts", Line: 1}, {Path: "./meta.js", Line: 2}, {Path: "./data.json", Line: 12},
+		}},
+		{"markdown", Markdown{}, `# Guide
+
+Install it with ` + "`npm install zod`" + `, then import "prose" from the docs.
+
+` + "```sh" + `
+node -e 'require("from-shell")'
+` + "```" + `
+
+~~~ts title="schema.ts"
+import { z } from "zod";
+~~~
+
+` + "````javascript" + `
+const { parse } = require("yaml");
+` + "```" + `
+const ky = require("ky");
+` + "````", []Specifier{
+			{Path: "zod", Line: 10}, {Path: "yaml", Line: 14}, {Path: "ky", Line: 16},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := tt.extractor.Extract(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Extract() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
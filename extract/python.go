@@ -0,0 +1,50 @@
+package extract
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register("python", Python{})
+}
+
+// Python extracts the modules imported by Python files,
+// with "import" or "from ... import" statements.
+type Python struct{}
+
+var (
+	pythonImportRe     = regexp.MustCompile(`^\s*import\s+(.+)`)
+	pythonFromImportRe = regexp.MustCompile(`^\s*from\s+(\S+)\s+import\b`)
+)
+
+func (Python) Extensions() []string {
+	return []string{".py"}
+}
+
+// Extract returns the imported modules as written, e.g. "os.path"
+// or ".utils" for relative imports.
+//
+// Example:
+//
+//	import os.path, yaml as y  -> "os.path", "yaml"
+//	from PIL import Image      -> "PIL"
+func (Python) Extract(r io.Reader) ([]Specifier, error) {
+	var specifiers []Specifier
+	err := scanLines(r, func(line string, lineNo int) {
+		if m := pythonFromImportRe.FindStringSubmatch(line); m != nil {
+			specifiers = append(specifiers, Specifier{Path: m[1], Line: lineNo})
+			return
+		}
+		if m := pythonImportRe.FindStringSubmatch(line); m != nil {
+			imports, _, _ := strings.Cut(m[1], "#")
+			for _, imported := range strings.Split(imports, ",") {
+				if fields := strings.Fields(imported); len(fields) > 0 {
+					specifiers = append(specifiers, Specifier{Path: fields[0], Line: lineNo})
+				}
+			}
+		}
+	})
+	return specifiers, err
+}
@@ -0,0 +1,30 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPythonExtract(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`import os.path, yaml as y`, []string{"os.path", "yaml"}},
+		{`from PIL import Image`, []string{"PIL"}},
+		{`    from django.db import models  # models`, []string{"django.db"}},
+		{`from . import views`, []string{"."}},
+		{`from .utils import helper`, []string{".utils"}},
+		{`important = True`, []string{}},
+	}
+	for _, tt := range tests {
+		specifiers, err := Python{}.Extract(strings.NewReader(tt.line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := paths(specifiers); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package extract
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Symbols are the symbols a file exports, and the ones it imports from
+// other files.
+type Symbols struct {
+	Exports []Export
+	Imports []Import
+}
+
+// Export is a symbol exported by a file. Default exports are named "default".
+type Export struct {
+	Name string
+	Line int
+}
+
+// Import is a set of symbols imported from a specifier.
+type Import struct {
+	Path string
+	// Names are the names of the imported symbols, as exported by the
+	// imported file, e.g. "default" for a default import.
+	Names []string
+	// All is true when every symbol may be used, e.g. for namespace
+	// imports, re-exports with "*", or plain require() calls.
+	All  bool
+	Line int
+}
+
+// SymbolExtractor is implemented by the extractors which also know the
+// symbols exported and imported by the files they handle.
+type SymbolExtractor interface {
+	Symbols(r io.Reader) (Symbols, error)
+}
+
+const identifier = `[A-Za-z_$][\w$]*`
+
+var (
+	// Declarations exported by name, e.g. "export async function handler".
+	exportDeclRe = regexp.MustCompile(`(?m)^\s*export\s+(?:declare\s+)?(?:default\s+)?(?:async\s+)?(?:abstract\s+)?` +
+		`(?:function\s*\*?|class|const|let|var|type|interface|enum|namespace)\s+(` + identifier + `)`)
+	exportDefaultRe = regexp.MustCompile(`(?m)^\s*export\s+default\b`)
+	// Export lists, optionally re-exported from another file, e.g. "export { a, b as c } from './x'".
+	exportListRe = regexp.MustCompile(`(?m)^\s*export\s+(?:type\s+)?\{([^}]*)\}(?:\s*from\s*["']([^"']+)["'])?`)
+	exportAllRe  = regexp.MustCompile(`(?m)^\s*export\s+\*\s*(?:as\s+(` + identifier + `)\s+)?from\s*["']([^"']+)["']`)
+	// CommonJS exports, e.g. "module.exports.handler =" or "exports.handler =".
+	cjsExportRe = regexp.MustCompile(`(?m)^\s*(?:module\.)?exports\.(` + identifier + `)\s*=`)
+	// Default CommonJS exports, e.g. "module.exports = { a, b }".
+	cjsDefaultRe = regexp.MustCompile(`(?m)^\s*module\.exports\s*=`)
+
+	importFromRe = regexp.MustCompile(`(?m)^\s*import\s+(?:type\s+)?([^"';]*?)\s*from\s*["']([^"']+)["']`)
+	// Destructured require calls, e.g. "const { a, b: c } = require('./x')".
+	requireDestructRe = regexp.MustCompile(`\{([^}]*)\}\s*=\s*require\(\s*["']([^"']+)["']\s*\)`)
+	requireAnyRe      = regexp.MustCompile(`require\(\s*["']([^"']+)["']\s*\)`)
+	dynamicImportRe   = regexp.MustCompile(`import\(\s*["']([^"']+)["']\s*\)`)
+)
+
+// Symbols returns the symbols exported and imported by a JavaScript or
+// TypeScript file, found with ES module and CommonJS syntax.
+func (JavaScript) Symbols(r io.Reader) (Symbols, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Symbols{}, err
+	}
+	src := string(data)
+	lineOf := func(offset int) int {
+		// Matches start with the indentation, and possibly blank lines, before the statement.
+		offset += len(src[offset:]) - len(strings.TrimLeft(src[offset:], " \t\r\n"))
+		return strings.Count(src[:offset], "\n") + 1
+	}
+
+	var s Symbols
+	exported := make(map[string]bool)
+	addExport := func(name string, offset int) {
+		if !exported[name] {
+			exported[name] = true
+			s.Exports = append(s.Exports, Export{Name: name, Line: lineOf(offset)})
+		}
+	}
+
+	for _, m := range exportDeclRe.FindAllStringSubmatchIndex(src, -1) {
+		if strings.Contains(src[m[0]:m[1]], "default") {
+			addExport("default", m[0])
+		} else {
+			addExport(src[m[2]:m[3]], m[0])
+		}
+	}
+	for _, m := range exportDefaultRe.FindAllStringIndex(src, -1) {
+		addExport("default", m[0])
+	}
+	for _, m := range exportListRe.FindAllStringSubmatchIndex(src, -1) {
+		var names []string
+		for _, item := range splitList(src[m[2]:m[3]]) {
+			local, exportedName := item, item
+			if before, after, ok := strings.Cut(item, " as "); ok {
+				local, exportedName = strings.TrimSpace(before), strings.TrimSpace(after)
+			}
+			addExport(exportedName, m[0])
+			names = append(names, local)
+		}
+		if m[4] != -1 {
+			s.Imports = append(s.Imports, Import{Path: src[m[4]:m[5]], Names: names, Line: lineOf(m[0])})
+		}
+	}
+	for _, m := range exportAllRe.FindAllStringSubmatchIndex(src, -1) {
+		if m[2] != -1 {
+			addExport(src[m[2]:m[3]], m[0])
+		}
+		s.Imports = append(s.Imports, Import{Path: src[m[4]:m[5]], All: true, Line: lineOf(m[0])})
+	}
+	for _, m := range cjsExportRe.FindAllStringSubmatchIndex(src, -1) {
+		addExport(src[m[2]:m[3]], m[0])
+	}
+	for _, m := range cjsDefaultRe.FindAllStringIndex(src, -1) {
+		addExport("default", m[0])
+	}
+
+	for _, m := range importFromRe.FindAllStringSubmatchIndex(src, -1) {
+		s.Imports = append(s.Imports, importClause(src[m[2]:m[3]], src[m[4]:m[5]], lineOf(m[0])))
+	}
+	destructured := make(map[int]bool)
+	for _, m := range requireDestructRe.FindAllStringSubmatchIndex(src, -1) {
+		var names []string
+		for _, item := range splitList(src[m[2]:m[3]]) {
+			name, _, _ := strings.Cut(item, ":")
+			names = append(names, strings.TrimSpace(name))
+		}
+		s.Imports = append(s.Imports, Import{Path: src[m[4]:m[5]], Names: names, Line: lineOf(m[0])})
+		destructured[m[4]] = true
+	}
+	for _, m := range requireAnyRe.FindAllStringSubmatchIndex(src, -1) {
+		if !destructured[m[2]] {
+			s.Imports = append(s.Imports, Import{Path: src[m[2]:m[3]], All: true, Line: lineOf(m[0])})
+		}
+	}
+	for _, m := range dynamicImportRe.FindAllStringSubmatchIndex(src, -1) {
+		s.Imports = append(s.Imports, Import{Path: src[m[2]:m[3]], All: true, Line: lineOf(m[0])})
+	}
+	return s, nil
+}
+
+// importClause parses the clause of an import statement, e.g.
+// "React, { useState as useLocalState }" or "* as path".
+func importClause(clause, path string, line int) Import {
+	imp := Import{Path: path, Line: line}
+	if open := strings.Index(clause, "{"); open != -1 {
+		end := strings.Index(clause, "}")
+		if end == -1 {
+			end = len(clause)
+		}
+		for _, item := range splitList(clause[open+1 : end]) {
+			name, _, _ := strings.Cut(item, " as ")
+			imp.Names = append(imp.Names, strings.TrimSpace(strings.TrimPrefix(name, "type ")))
+		}
+		clause = clause[:open]
+	}
+	for _, part := range splitList(clause) {
+		if strings.HasPrefix(part, "*") {
+			imp.All = true
+		} else {
+			imp.Names = append(imp.Names, "default")
+		}
+	}
+	return imp
+}
+
+// splitList splits a comma separated list of names, dropping the empty ones.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,55 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJavaScriptSymbols(t *testing.T) {
+	src := `import React, { useState as useLocalState, type FC } from "react";
+import * as utils from "./utils";
+import {
+  formatDate,
+  parseDate,
+} from "./dates";
+const { Pool, Client: PgClient } = require("pg");
+const config = require("./config");
+const lazy = import("./lazy");
+
+export async function handler() {}
+export const TIMEOUT = 10;
+export default class App {}
+export interface Props {}
+export { helper, internal as publicName };
+export { a as b } from "./reexported";
+export * from "./everything";
+module.exports.legacy = 1;
+`
+	s, err := JavaScript{}.Symbols(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantExports := []Export{
+		{"handler", 11}, {"TIMEOUT", 12}, {"default", 13}, {"Props", 14},
+		{"helper", 15}, {"publicName", 15}, {"b", 16}, {"legacy", 18},
+	}
+	if !reflect.DeepEqual(s.Exports, wantExports) {
+		t.Errorf("Exports = %v, want %v", s.Exports, wantExports)
+	}
+
+	wantImports := []Import{
+		{Path: "./reexported", Names: []string{"a"}, Line: 16},
+		{Path: "./everything", All: true, Line: 17},
+		{Path: "react", Names: []string{"useState", "FC", "default"}, Line: 1},
+		{Path: "./utils", All: true, Line: 2},
+		{Path: "./dates", Names: []string{"formatDate", "parseDate"}, Line: 3},
+		{Path: "pg", Names: []string{"Pool", "Client"}, Line: 7},
+		{Path: "./config", All: true, Line: 8},
+		{Path: "./lazy", All: true, Line: 9},
+	}
+	if !reflect.DeepEqual(s.Imports, wantImports) {
+		t.Errorf("Imports = %+v, want %+v", s.Imports, wantImports)
+	}
+}
@@ -0,0 +1,178 @@
+package extract
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register("node", EJS{})
+	Register("node", Pug{})
+	Register("node", Handlebars{})
+}
+
+// embeddedCode finds the code embedded in a template between an opening
+// and a closing delimiter, e.g. "<%" and "%>", line by line.
+type embeddedCode struct {
+	open, close string
+	// start returns where the code of a block starts in the text
+	// following its opening delimiter, and whether the block holds no
+	// code, e.g. an EJS comment "<%#".
+	start func(block string) (string, bool)
+
+	inside, skipping bool
+}
+
+// code returns the code of the line found inside the blocks, the code of
+// the different blocks being separated by a semicolon.
+func (e *embeddedCode) code(line string) string {
+	var code []string
+	for line != "" {
+		if !e.inside {
+			i := strings.Index(line, e.open)
+			if i == -1 {
+				break
+			}
+			line = line[i+len(e.open):]
+			e.inside, e.skipping = true, false
+			if e.start != nil {
+				line, e.skipping = e.start(line)
+			}
+			continue
+		}
+		block, rest, closed := strings.Cut(line, e.close)
+		if !e.skipping {
+			code = append(code, block)
+		}
+		if !closed {
+			break
+		}
+		e.inside, line = false, rest
+	}
+	return strings.Join(code, ";")
+}
+
+// scriptBlock returns the finder of the code of the <script> elements of
+// HTML, whose attributes are skipped.
+func scriptBlock() *embeddedCode {
+	return &embeddedCode{open: "<script", close: "</script>", start: func(block string) (string, bool) {
+		_, code, ok := strings.Cut(block, ">")
+		if !ok {
+			// The attributes continue on the next lines, which are
+			// searched like code, since they can't be told apart.
+			return block, false
+		}
+		return code, false
+	}}
+}
+
+// extractEmbedded extracts the specifiers of the JavaScript code embedded
+// in the lines of a template, found by the finders.
+func extractEmbedded(r io.Reader, finders ...*embeddedCode) ([]Specifier, error) {
+	var specifiers []Specifier
+	err := scanLines(r, func(line string, lineNo int) {
+		for _, f := range finders {
+			for _, s := range extractJavaScriptLine(f.code(line)) {
+				s.Line = lineNo
+				specifiers = append(specifiers, s)
+			}
+		}
+	})
+	return specifiers, err
+}
+
+// EJS extracts the packages used by the scriptlets of EJS templates, e.g.
+// <% const dayjs = require('dayjs') %>, and by their <script> elements.
+type EJS struct{}
+
+func (EJS) Extensions() []string {
+	return []string{".ejs"}
+}
+
+func (EJS) Extract(r io.Reader) ([]Specifier, error) {
+	scriptlets := &embeddedCode{open: "<%", close: "%>", start: func(block string) (string, bool) {
+		switch {
+		case strings.HasPrefix(block, "#"), strings.HasPrefix(block, "%"):
+			// A comment, or an escaped delimiter "<%%".
+			return block, true
+		case strings.HasPrefix(block, "=") || strings.HasPrefix(block, "-") || strings.HasPrefix(block, "_"):
+			return block[1:], false
+		}
+		return block, false
+	}}
+	return extractEmbedded(r, scriptlets, scriptBlock())
+}
+
+// Handlebars extracts the packages used by the <script> elements of
+// Handlebars templates. Their helpers are registered by the code of the
+// server, where the packages providing them are imported.
+type Handlebars struct{}
+
+func (Handlebars) Extensions() []string {
+	return []string{".hbs", ".handlebars"}
+}
+
+func (Handlebars) Extract(r io.Reader) ([]Specifier, error) {
+	return extractEmbedded(r, scriptBlock())
+}
+
+// Pug extracts the packages used by the code of Pug templates: the lines
+// of unbuffered code, e.g. - const moment = require('moment'), the code
+// blocks and the script blocks. The filters, e.g. :markdown-it, use the
+// jstransformer package of their name, e.g. jstransformer-markdown-it.
+type Pug struct{}
+
+var (
+	// pugFilterRe matches the filters starting a line, possibly chained,
+	// e.g. ":babel:uglify-js(compress)", following a tag, e.g.
+	// "p: :markdown-it", or filtering an include, e.g. "include:markdown-it".
+	pugFilterRe = regexp.MustCompile(`^(?:include|[\w.#-]+:\s+)?((?::[\w-]+(?:\([^)]*\))?)+)`)
+	// pugFilterNameRe matches the names of the filters of a chain.
+	pugFilterNameRe = regexp.MustCompile(`:([\w-]+)`)
+)
+
+func (Pug) Extensions() []string {
+	return []string{".pug", ".jade"}
+}
+
+func (Pug) Extract(r io.Reader) ([]Specifier, error) {
+	var specifiers []Specifier
+	// blockIndent is the indentation of the line starting the current
+	// code or script block, or -1 outside of them.
+	blockIndent := -1
+	err := scanLines(r, func(line string, lineNo int) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			return
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		var code string
+		switch {
+		case blockIndent != -1 && indent > blockIndent:
+			code = trimmed
+		case trimmed == "-" || trimmed == "script." || strings.HasPrefix(trimmed, "script(") && strings.HasSuffix(trimmed, ")."):
+			blockIndent = indent
+			return
+		case strings.HasPrefix(trimmed, "//"):
+			blockIndent = -1
+			return
+		case strings.HasPrefix(trimmed, "-"):
+			blockIndent = -1
+			code = trimmed[1:]
+		default:
+			blockIndent = -1
+			if m := pugFilterRe.FindStringSubmatch(trimmed); m != nil {
+				for _, name := range pugFilterNameRe.FindAllStringSubmatch(m[1], -1) {
+					specifiers = append(specifiers, Specifier{Path: "jstransformer-" + name[1], Line: lineNo})
+				}
+			}
+			return
+		}
+		for _, s := range extractJavaScriptLine(code) {
+			s.Line = lineNo
+			specifiers = append(specifiers, s)
+		}
+	})
+	return specifiers, err
+}
@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor Extractor
+		src       string
+		want      []Specifier
+	}{
+		{"ejs", EJS{}, `<% const dayjs = require('dayjs') %>
+<p>Imported from "the-docs", not a package</p>
+<%# const ignored = require('commented-out') %>
+<%%= require('escaped') %>
+<%- include('partials/header') %> <%= require("slugify")(title) %>
+<%
+  const { marked } = require('marked');
+%>
+<script type="module">
+  import confetti from "canvas-confetti";
+</script>`, []Specifier{
+			{Path: "dayjs", Line: 1}, {Path: "slugify", Line: 5}, {Path: "marked", Line: 7}, {Path: "canvas-confetti", Line: 10},
+		}},
+		{"handlebars", Handlebars{}, `{{> header}}
+<p>{{formatDate date}} require("not-code")</p>
+<script>import("htmx.org")</script>`, []Specifier{
+			{Path: "htmx.org", Line: 3},
+		}},
+		{"pug", Pug{}, `extends layout
+block content
+  - const moment = require('moment')
+  p= moment().format()
+  -
+    const _ = require("lodash")
+  p Import from "the-docs" is text
+  :markdown-it(linkify)
+    # Title
+  include:marked article.md
+  p: :babel:uglify-js
+  script.
+    import("htmx.org")
+  // - require('commented-out')`, []Specifier{
+			{Path: "moment", Line: 3}, {Path: "lodash", Line: 6}, {Path: "jstransformer-markdown-it", Line: 8},
+			{Path: "jstransformer-marked", Line: 10}, {Path: "jstransformer-babel", Line: 11},
+			{Path: "jstransformer-uglify-js", Line: 11}, {Path: "htmx.org", Line: 13},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.extractor.Extract(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJavaScriptExtractViewEngine(t *testing.T) {
+	src := "app.set('view engine', 'pug');\napp.set(\"view engine\", \"html\");\n"
+	specifiers, err := JavaScript{}.Extract(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Specifier{{Path: "pug", Line: 1}}; !reflect.DeepEqual(specifiers, want) {
+		t.Errorf("Extract() = %v, want %v", specifiers, want)
+	}
+}
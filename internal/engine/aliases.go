@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+)
+
+// aliasProtocol is the prefix of the ranges of the aliased dependencies,
+// e.g. "sw": "npm:string-width@^4.2.0", which install a package under
+// another name, the one it is imported with.
+const aliasProtocol = "npm:"
+
+// parseAlias returns the package and the range of an alias, e.g.
+// "string-width" and "^4.2.0" for "npm:string-width@^4.2.0", or
+// "@scope/pkg" and "" for "npm:@scope/pkg".
+func parseAlias(versionRange string) (pkgName, spec string, ok bool) {
+	target, ok := strings.CutPrefix(versionRange, aliasProtocol)
+	if !ok || target == "" {
+		return "", "", false
+	}
+	if at := strings.LastIndex(target, "@"); at > 0 {
+		return target[:at], target[at+1:], true
+	}
+	return target, "", true
+}
+
+// declaredRange returns the range of package.json of the dependency.
+func (sc *scanContext) declaredRange(dependency string) string {
+	if r, ok := sc.manifest.Dependencies[dependency]; ok {
+		return r
+	}
+	return sc.manifest.DevDependencies[dependency]
+}
+
+// registryName returns the name the dependency is published with on the
+// registry: the aliased package for the aliases, or else its own name.
+func (sc *scanContext) registryName(dependency string) string {
+	if pkgName, _, ok := parseAlias(sc.declaredRange(dependency)); ok {
+		return pkgName
+	}
+	return dependency
+}
+
+// aliasSpec returns the range of the aliased package for the aliases,
+// e.g. "^4.2.0" for "npm:string-width@^4.2.0", or else the range itself.
+func aliasSpec(versionRange string) string {
+	if _, spec, ok := parseAlias(versionRange); ok {
+		return spec
+	}
+	return versionRange
+}
+
+// aliasesOf returns the dependencies of package.json declared as aliases
+// of the package, sorted.
+func (sc *scanContext) aliasesOf(pkgName string) []string {
+	var aliases []string
+	for _, deps := range []map[string]string{sc.manifest.Dependencies, sc.manifest.DevDependencies} {
+		for dependency, r := range deps {
+			if target, _, ok := parseAlias(r); ok && target == pkgName && dependency != pkgName {
+				aliases = append(aliases, dependency)
+			}
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
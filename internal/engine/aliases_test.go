@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAlias(t *testing.T) {
+	tests := []struct {
+		versionRange, pkgName, spec string
+		ok                          bool
+	}{
+		{"npm:string-width@^4.2.0", "string-width", "^4.2.0", true},
+		{"npm:@babel/core@7", "@babel/core", "7", true},
+		{"npm:@scope/pkg", "@scope/pkg", "", true},
+		{"npm:lodash", "lodash", "", true},
+		{"^4.2.0", "", "", false},
+		{"npm:", "", "", false},
+	}
+	for _, tt := range tests {
+		pkgName, spec, ok := parseAlias(tt.versionRange)
+		if pkgName != tt.pkgName || spec != tt.spec || ok != tt.ok {
+			t.Errorf("parseAlias(%q) = %q, %q, %v, want %q, %q, %v", tt.versionRange, pkgName, spec, ok, tt.pkgName, tt.spec, tt.ok)
+		}
+	}
+}
+
+func TestAliases(t *testing.T) {
+	sc := newScanContext()
+	defer func(m Package) { sc.manifest = m }(sc.manifest)
+	sc.manifest = Package{
+		Dependencies:    map[string]string{"sw": "npm:string-width@^4.2.0", "string-width": "^7.0.0", "lodash": "^4.17.21"},
+		DevDependencies: map[string]string{"core": "npm:@babel/core@7"},
+	}
+	for dependency, want := range map[string]string{"sw": "string-width", "string-width": "string-width", "core": "@babel/core", "lodash": "lodash"} {
+		if got := sc.registryName(dependency); got != want {
+			t.Errorf("registryName(%q) = %q, want %q", dependency, got, want)
+		}
+	}
+	if got, want := sc.aliasesOf("string-width"), []string{"sw"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("aliasesOf(string-width) = %v, want %v", got, want)
+	}
+	if got := aliasSpec("npm:string-width@^4.2.0"); got != "^4.2.0" {
+		t.Errorf("aliasSpec() = %q, want ^4.2.0", got)
+	}
+}
+
+func TestCreateNewPackageJsonKeepsAliases(t *testing.T) {
+	data := "{\n  \"dependencies\": {\n    \"sw\": \"npm:string-width@^4.2.0\",\n    \"string-width\": \"^7.0.0\",\n    \"old\": \"npm:left-pad@1\"\n  }\n}\n"
+	got := string(removeTrailingCommas(createNewPackageJson([]string{"string-width", "old"}, []byte(data))))
+	if want := "{\n  \"dependencies\": {\n    \"sw\": \"npm:string-width@^4.2.0\"\n  }\n}\n"; got != want {
+		t.Errorf("createNewPackageJson() = %q, want %q", got, want)
+	}
+}
+
+func TestAliasFindings(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"dependencies\": {\n    \"sw\": \"npm:string-width@^4.2.0\",\n    \"legacy\": \"npm:left-pad@1\"\n  }\n}\n",
+	})
+	sc.lang, sc.manifestFile = sc.nodeLanguage, "package.json"
+	sc.manifest = Package{Dependencies: map[string]string{"sw": "npm:string-width@^4.2.0", "legacy": "npm:left-pad@1"}}
+	sc.d.mp = map[string]bool{"sw": false, "legacy": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.lang, sc.manifestFile, sc.manifest, sc.d.mp, sc.d.usages = nil, "", Package{}, nil, nil }()
+
+	sc.markModuleAsFound("sw", Location{File: "src/app.js", Line: 1})
+	sc.markModuleAsFound("left-pad", Location{File: "src/app.js", Line: 2})
+
+	var got []string
+	for _, f := range sc.buildFindings("app") {
+		got = append(got, f.RuleID+" "+f.Package+": "+f.Message+": "+f.SuggestedFix)
+	}
+	want := []string{
+		`unused-dependency legacy: "legacy" is declared in dependencies but never used: remove "legacy" from dependencies`,
+		`missing-dependency left-pad: "left-pad" is used but only declared as the alias "legacy" in package.json: import it as "legacy", or npm install left-pad`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildFindings() =\n%q\nwant\n%q", got, want)
+	}
+}
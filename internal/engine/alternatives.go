@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+)
+
+// alternative is a package suggested instead of a deprecated or
+// heavyweight one.
+type alternative struct {
+	Suggestion string
+	// Reason is why the package is worth replacing.
+	Reason string
+}
+
+// builtinAlternatives are the alternatives suggested for the common
+// deprecated or heavyweight packages. The "alternatives" of the config
+// extend them.
+var builtinAlternatives = map[string]alternative{
+	"request":         {"the native fetch, or axios", "deprecated"},
+	"request-promise": {"the native fetch, or axios", "deprecated"},
+	"node-fetch":      {"the native fetch of Node.js 18", "provided by the platform"},
+	"moment":          {"dayjs or date-fns", "in maintenance mode, and heavyweight"},
+	"lodash":          {"native functions, or the lodash.* package of each function", "heavyweight"},
+	"underscore":      {"native functions", "heavyweight"},
+	"left-pad":        {"String.prototype.padStart", "provided by the platform"},
+	"mkdirp":          {"fs.mkdir with the recursive option", "provided by the platform"},
+	"node-sass":       {"sass", "deprecated"},
+	"tslint":          {"eslint with typescript-eslint", "deprecated"},
+	"babel-eslint":    {"@babel/eslint-parser", "deprecated"},
+	"istanbul":        {"nyc or c8", "deprecated"},
+	"gulp-util":       {"the packages listed by its deprecation notice", "deprecated"},
+}
+
+// suggestion is an alternative suggested for a kept dependency.
+type suggestion struct {
+	Package     string `json:"package"`
+	Alternative string `json:"alternative"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+func (s suggestion) String() string {
+	if s.Reason == "" {
+		return fmt.Sprintf("%s: consider %s", s.Package, s.Alternative)
+	}
+	return fmt.Sprintf("%s: consider %s (%s)", s.Package, s.Alternative, s.Reason)
+}
+
+// suggestAlternatives returns the alternatives of the kept dependencies,
+// sorted by package. The alternatives of the config, e.g.
+// {"moment": "dayjs"}, replace the built-in ones, and an empty one
+// disables the suggestion for the package.
+func (sc *scanContext) suggestAlternatives(kept map[string]string, configured map[string]string) []suggestion {
+	var suggestions []suggestion
+	for dependency := range kept {
+		alt, ok := builtinAlternatives[sc.registryName(dependency)]
+		if s, configuredAlt := configured[dependency]; configuredAlt {
+			alt, ok = alternative{Suggestion: s}, s != ""
+		}
+		if ok {
+			suggestions = append(suggestions, suggestion{dependency, alt.Suggestion, alt.Reason})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Package < suggestions[j].Package })
+	return suggestions
+}
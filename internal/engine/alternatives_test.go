@@ -0,0 +1,26 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestAlternatives(t *testing.T) {
+	sc := newScanContext()
+	kept := map[string]string{"moment": "dependencies", "request": "dependencies", "express": "dependencies", "big-lib": "dependencies"}
+	configured := map[string]string{"request": "", "big-lib": "small-lib"}
+	got := sc.suggestAlternatives(kept, configured)
+	want := []suggestion{
+		{Package: "big-lib", Alternative: "small-lib"},
+		{Package: "moment", Alternative: "dayjs or date-fns", Reason: "in maintenance mode, and heavyweight"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("suggestAlternatives() = %+v, want %+v", got, want)
+	}
+	if s := got[0].String(); s != "big-lib: consider small-lib" {
+		t.Errorf("String() = %q", s)
+	}
+	if s := got[1].String(); s != "moment: consider dayjs or date-fns (in maintenance mode, and heavyweight)" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// baselineFile is the file where the findings accepted
+// with --update-baseline are recorded.
+const baselineFile = "depose-baseline.json"
+
+// baselineEntry identifies a finding in the baseline.
+//
+// Locations are deliberately left out, so that moving code around
+// does not turn a known finding into a new one.
+type baselineEntry struct {
+	RuleID  string `json:"ruleId"`
+	Package string `json:"package"`
+	Section string `json:"section,omitempty"`
+}
+
+// Baseline is the content of the baseline file.
+type Baseline struct {
+	Findings []baselineEntry `json:"findings"`
+}
+
+// writeBaseline records the findings into the baseline file.
+func writeBaseline(path string, findings []Finding) error {
+	baseline := Baseline{Findings: []baselineEntry{}}
+	for _, f := range findings {
+		baseline.Findings = append(baseline.Findings, baselineEntry{f.RuleID, f.Package, f.Section})
+	}
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// readBaseline reads the baseline file into a set of entries.
+// A missing baseline file is treated as an empty baseline.
+func readBaseline(path string) (map[baselineEntry]bool, error) {
+	entries := make(map[baselineEntry]bool)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	for _, entry := range baseline.Findings {
+		entries[entry] = true
+	}
+	return entries, nil
+}
+
+// applyBaseline splits the findings into new ones, and the ones
+// already recorded in the baseline, read from the file, which are marked
+// as suppressed.
+func applyBaseline(findings []Finding, baseline map[baselineEntry]bool, file string) (fresh, known []Finding) {
+	for _, f := range findings {
+		if baseline[baselineEntry{f.RuleID, f.Package, f.Section}] {
+			f.Suppression = "recorded in " + file
+			known = append(known, f)
+			continue
+		}
+		fresh = append(fresh, f)
+	}
+	return fresh, known
+}
+
+// staleBaselineEntries returns the entries of the baseline which no longer
+// match any of the known findings, sorted by rule then by package.
+func staleBaselineEntries(baseline map[baselineEntry]bool, known []Finding) []baselineEntry {
+	matched := make(map[baselineEntry]bool, len(known))
+	for _, f := range known {
+		matched[baselineEntry{f.RuleID, f.Package, f.Section}] = true
+	}
+
+	var stale []baselineEntry
+	for entry := range baseline {
+		if !matched[entry] {
+			stale = append(stale, entry)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		if stale[i].RuleID != stale[j].RuleID {
+			return stale[i].RuleID < stale[j].RuleID
+		}
+		return stale[i].Package < stale[j].Package
+	})
+	return stale
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), baselineFile)
+	recorded := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "pg", Section: "dependencies", Locations: []Location{{File: "package.json", Line: 17}}},
+		{RuleID: RuleMissingDependency, Package: "zod", Locations: []Location{{File: "a.js", Line: 1}}},
+	}
+	if err := writeBaseline(path, recorded); err != nil {
+		t.Fatalf("writeBaseline failed: %v", err)
+	}
+
+	baseline, err := readBaseline(path)
+	if err != nil {
+		t.Fatalf("readBaseline failed: %v", err)
+	}
+
+	// The same findings at other locations are still known,
+	// while findings for other packages are new.
+	findings := []Finding{
+		{RuleID: RuleMissingDependency, Package: "zod", Locations: []Location{{File: "b.js", Line: 9}}},
+		{RuleID: RuleMissingDependency, Package: "yup"},
+		{RuleID: RuleUnusedDevDependency, Package: "pg", Section: "devDependencies"},
+	}
+	fresh, known := applyBaseline(findings, baseline, baselineFile)
+	if len(known) != 1 || known[0].Package != "zod" || known[0].Suppression == "" {
+		t.Fatalf("unexpected known findings: %+v", known)
+	}
+	if len(fresh) != 2 {
+		t.Fatalf("unexpected new findings: %+v", fresh)
+	}
+
+	// The unused "pg" dependency was fixed, so its entry is stale.
+	stale := staleBaselineEntries(baseline, known)
+	if want := []baselineEntry{{RuleUnusedDependency, "pg", "dependencies"}}; !reflect.DeepEqual(stale, want) {
+		t.Fatalf("staleBaselineEntries() = %+v, want %+v", stale, want)
+	}
+}
+
+func TestReadMissingBaseline(t *testing.T) {
+	baseline, err := readBaseline(filepath.Join(t.TempDir(), baselineFile))
+	if err != nil || len(baseline) != 0 {
+		t.Fatalf("readBaseline() = %v, %v, want an empty baseline", baseline, err)
+	}
+}
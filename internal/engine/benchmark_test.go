@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBenchmarkProject writes a synthetic Node.js project to dir: a
+// package.json declaring the given number of dependencies, with scripts,
+// and the given number of source files, each importing a few of the
+// dependencies and a sibling file.
+func writeBenchmarkProject(tb testing.TB, dir string, dependencies, sources int) {
+	tb.Helper()
+	var deps, scripts []string
+	for i := 0; i < dependencies; i++ {
+		deps = append(deps, fmt.Sprintf("    \"dep-%d\": \"^1.%d.0\"", i, i))
+	}
+	for i := 0; i < 10; i++ {
+		scripts = append(scripts, fmt.Sprintf("    \"task-%d\": \"NODE_ENV=test dep-%d --config=dep-%d/config && npm run build\"", i, i, i+1))
+	}
+	manifest := fmt.Sprintf("{\n  \"name\": \"bench\",\n  \"scripts\": {\n%s\n  },\n  \"dependencies\": {\n%s\n  }\n}\n",
+		strings.Join(scripts, ",\n"), strings.Join(deps, ",\n"))
+	files := map[string]string{filepath.Join(dir, "package.json"): manifest}
+	for i := 0; i < sources; i++ {
+		var src strings.Builder
+		fmt.Fprintf(&src, "import { a } from \"dep-%d\";\nimport b from \"dep-%d/sub/path\";\n", i%dependencies, (i*7)%dependencies)
+		fmt.Fprintf(&src, "const c = require(\"./file-%d\");\n", (i+1)%sources)
+		for line := 0; line < 50; line++ {
+			fmt.Fprintf(&src, "export function f%d(x) { return a(x) + b(%d) + c.value; } // not require(\"x\")\n", line, line)
+		}
+		files[filepath.Join(dir, "src", fmt.Sprintf("dir-%d", i%10), fmt.Sprintf("file-%d.js", i))] = src.String()
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkScan measures a whole scan of a synthetic project: reading the
+// manifest, walking the files, extracting their packages and building the
+// findings.
+func BenchmarkScan(b *testing.B) {
+	sc := newScanContext()
+	dir := b.TempDir()
+	writeBenchmarkProject(b, dir, 100, 500)
+	chdir(b, dir)
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	defer func() { sc.d, sc.lang, sc.files, sc.manifest, sc.manifestFile = Dependency{}, nil, nil, Package{}, "" }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sc.d, sc.files = Dependency{}, nil
+		sc.scanProject()
+		sc.buildFindings("bench")
+	}
+}
+
+// BenchmarkScriptWordReferences measures the matching of the words of a
+// script against the declared dependencies.
+func BenchmarkScriptWordReferences(b *testing.B) {
+	declared := make(map[string]bool)
+	for i := 0; i < 500; i++ {
+		declared[fmt.Sprintf("dep-%d", i)] = false
+	}
+	bins := map[string]string{"tsc": "typescript"}
+	script := "NODE_ENV=production dep-1 --plugin=dep-2 -r dep-3/register && node_modules/.bin/tsc -p . && npm run build -- --reporter dep-4"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scriptWordReferences(script, declared, bins)
+	}
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// bisectCommand implements "depose bisect", which checks empirically the
+// unused dependencies found by the scan: their removals are bisected by
+// running the verification command with subsets of them removed from
+// package.json, which is restored afterwards. The removals failing the
+// verification are false positives of the scan.
+//
+//	depose bisect --verify "npm run build && npm test"
+func (sc *scanContext) bisectCommand(fs *flag.FlagSet) func(args []string) {
+	sc.defineFlags(fs)
+	return func(args []string) {
+		command := sc.verifyCommand
+		if command == "" {
+			logFatal("depose bisect needs the verification command, given with --verify")
+		}
+		if sc.toStdout || sc.outPath != "" || sc.check || sc.fixScripts || sc.fixOverrides {
+			logFatal("--stdout, --out, --check, --fix-scripts and --fix-overrides can't be used with depose bisect")
+		}
+
+		// The scan only lists the candidate removals, package.json is
+		// changed by the bisection.
+		sc.verifyCommand, sc.dryRun = "", true
+		sc.run()
+		if sc.lang.rewriteManifest == nil {
+			logFatalf("depose bisect is not supported for %s projects", sc.lang.name)
+		}
+		if len(sc.removed) == 0 {
+			fmt.Fprintln(sc.logOut, "No unused dependencies to bisect.")
+			return
+		}
+		original, err := sc.readProjectFile(sc.manifestFile)
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Fprintf(sc.logOut, "Bisecting the removal of %d unused dependencies with: %s\n", len(sc.removed), command)
+		if !sc.runVerifyCommand(command) {
+			logFatal("The verification fails before any removal, fix it first")
+		}
+		var writeErr error
+		safe, breaking := bisectCandidates(sc.removed, sc.trialRemoval(command, original, &writeErr))
+		if err := os.WriteFile("package.json", original, 0o644); err != nil {
+			logFatalf("package.json could not be restored: %v", err)
+		}
+		if writeErr != nil {
+			logFatalf("The bisection failed: %v", writeErr)
+		}
+
+		if len(safe) > 0 {
+			fmt.Printf("Safe to remove: %s\n", strings.Join(safe, ", "))
+		}
+		if len(breaking) > 0 {
+			fmt.Printf("Breaking the verification: %s\n", describeRemovals(breaking))
+			fmt.Fprintln(sc.logOut, "Keep them, and add them to the \"ignore\" list of the \"depose\" config of package.json.")
+		} else {
+			fmt.Fprintln(sc.logOut, "Every removal passes the verification.")
+		}
+	}
+}
+
+// bisectCandidates splits the candidate removals into the ones which can
+// be removed together, and the groups of removals which fail the
+// verification, given whether removing a subset of them fails it.
+func bisectCandidates(candidates []string, fails func(subset []string) bool) (safe []string, breaking [][]string) {
+	safe = candidates
+	for len(safe) > 0 && fails(safe) {
+		groups := breakingRemovals(safe, fails)
+		breaking = append(breaking, groups...)
+		drop := make(map[string]bool)
+		for _, group := range groups {
+			for _, pkg := range group {
+				drop[pkg] = true
+			}
+		}
+		var kept []string
+		for _, pkg := range safe {
+			if !drop[pkg] {
+				kept = append(kept, pkg)
+			}
+		}
+		// The remaining removals pass on their own, but may still fail
+		// together, so they are verified again.
+		safe = kept
+	}
+	return safe, breaking
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBisectCandidates(t *testing.T) {
+	candidates := []string{"a", "b", "c", "d"}
+	tests := []struct {
+		name     string
+		broken   func(subset []string) bool
+		safe     []string
+		breaking [][]string
+	}{
+		{"all safe", func(subset []string) bool { return false }, candidates, nil},
+		{"one culprit", func(subset []string) bool { return contains(subset, "c") }, []string{"a", "b", "d"}, [][]string{{"c"}}},
+		{"all broken", func(subset []string) bool { return true }, nil, [][]string{{"a"}, {"b"}, {"c"}, {"d"}}},
+		// a and d only fail together: the halves of the candidates pass,
+		// so the whole set is the group.
+		{"interaction", func(subset []string) bool { return contains(subset, "a") && contains(subset, "d") }, nil, [][]string{candidates}},
+	}
+	for _, tt := range tests {
+		safe, breaking := bisectCandidates(candidates, tt.broken)
+		if !reflect.DeepEqual(safe, tt.safe) || !reflect.DeepEqual(breaking, tt.breaking) {
+			t.Errorf("%s: bisectCandidates() = %v, %v, want %v, %v", tt.name, safe, breaking, tt.safe, tt.breaking)
+		}
+	}
+}
+
+func TestBisectCommand(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	original := "{\n  \"dependencies\": {\n    \"dotenv\": \"^16.0.0\",\n    \"express\": \"^4.18.2\",\n    \"lodash\": \"^4.17.21\",\n    \"moment\": \"^2.29.4\"\n  }\n}\n"
+	writeFiles(t, map[string]string{"package.json": original, "index.js": "require(\"express\");\n"})
+
+	// The verification needs lodash and moment, which are unused by the code.
+	out, err := exec.Command(deposePath, "bisect", "--no-history", "--verify", "grep -q lodash package.json && grep -q moment package.json").Output()
+	if err != nil {
+		t.Fatalf("depose bisect failed: %v", err)
+	}
+	for _, want := range []string{"Safe to remove: dotenv\n", "Breaking the verification: lodash, and moment\n"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if data, _ := os.ReadFile("package.json"); string(data) != original {
+		t.Errorf("package.json was not restored:\n%s", data)
+	}
+	if _, err := os.Stat("oldpackage.json"); !os.IsNotExist(err) {
+		t.Errorf("oldpackage.json was created: %v", err)
+	}
+
+	if out, err := exec.Command(deposePath, "bisect", "--no-history", "--verify", "false").CombinedOutput(); err == nil {
+		t.Errorf("depose bisect passed with a failing verification:\n%s", out)
+	}
+}
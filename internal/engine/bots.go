@@ -0,0 +1,494 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Update bots, which open the pull requests updating the dependencies.
+const (
+	botRenovate   = "renovate"
+	botDependabot = "dependabot"
+)
+
+// renovateFiles are the locations of the config of Renovate, in the
+// order it looks for them. The "renovate" key of package.json is read last.
+var renovateFiles = []string{
+	"renovate.json", "renovate.json5", ".github/renovate.json", ".github/renovate.json5",
+	".gitlab/renovate.json", ".gitlab/renovate.json5", ".renovaterc", ".renovaterc.json", ".renovaterc.json5",
+}
+
+// dependabotFiles are the locations of the config of Dependabot.
+var dependabotFiles = []string{".github/dependabot.yml", ".github/dependabot.yaml"}
+
+// botManagement tells that a dependency is updated by a bot, and in which
+// group of pull requests, if any.
+type botManagement struct {
+	Bot   string `json:"bot"`
+	Group string `json:"group,omitempty"`
+}
+
+func (m botManagement) String() string {
+	if m.Group == "" {
+		return m.Bot
+	}
+	return fmt.Sprintf("%s (group %q)", m.Bot, m.Group)
+}
+
+// updateBot is the config of an update bot, telling whether it manages a
+// dependency, and in which group.
+type updateBot struct {
+	name   string
+	manage func(dependency string) (group string, ok bool)
+}
+
+// readUpdateBots reads the configs of Renovate and Dependabot found in
+// the project. The bots without config are not returned.
+func (sc *scanContext) readUpdateBots() ([]updateBot, error) {
+	var bots []updateBot
+	renovate, err := sc.readRenovateConfig()
+	if err != nil {
+		return nil, err
+	}
+	if renovate != nil {
+		bots = append(bots, updateBot{name: botRenovate, manage: renovate.manage})
+	}
+	for _, file := range dependabotFiles {
+		data, err := sc.readProjectFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		updates := parseDependabotUpdates(data)
+		bots = append(bots, updateBot{name: botDependabot, manage: func(dependency string) (string, bool) {
+			for _, u := range updates {
+				if group, ok := u.manage(dependency); ok {
+					return group, true
+				}
+			}
+			return "", false
+		}})
+		break
+	}
+	return bots, nil
+}
+
+// renovateConfig is the subset of the config of Renovate telling which
+// dependencies it updates, and how it groups them:
+// https://docs.renovatebot.com/configuration-options/
+//
+// The presets it extends, e.g. "group:allNonMajor", are not resolved.
+type renovateConfig struct {
+	Enabled         *bool          `json:"enabled"`
+	EnabledManagers []string       `json:"enabledManagers"`
+	IgnoreDeps      []string       `json:"ignoreDeps"`
+	PackageRules    []renovateRule `json:"packageRules"`
+}
+
+// renovateRule is a rule of packageRules, which disables or groups the
+// updates of the packages it matches.
+type renovateRule struct {
+	MatchPackageNames      []string `json:"matchPackageNames"`
+	MatchPackagePatterns   []string `json:"matchPackagePatterns"`
+	MatchPackagePrefixes   []string `json:"matchPackagePrefixes"`
+	ExcludePackageNames    []string `json:"excludePackageNames"`
+	ExcludePackagePatterns []string `json:"excludePackagePatterns"`
+	ExcludePackagePrefixes []string `json:"excludePackagePrefixes"`
+	Enabled                *bool    `json:"enabled"`
+	GroupName              string   `json:"groupName"`
+}
+
+// readRenovateConfig reads the first config of Renovate found, or nil.
+func (sc *scanContext) readRenovateConfig() (*renovateConfig, error) {
+	for _, file := range renovateFiles {
+		data, err := sc.readProjectFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var config renovateConfig
+		if err := json.Unmarshal(stripJSON5(data), &config); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return &config, nil
+	}
+	if len(sc.manifest.Renovate) > 0 {
+		var config renovateConfig
+		if err := json.Unmarshal(sc.manifest.Renovate, &config); err != nil {
+			return nil, fmt.Errorf("package.json: renovate: %w", err)
+		}
+		return &config, nil
+	}
+	return nil, nil
+}
+
+// manage tells whether Renovate updates the dependency, and in which
+// group. The rules are applied in order, the later ones overriding the
+// earlier ones, like Renovate merges them.
+func (c *renovateConfig) manage(dependency string) (string, bool) {
+	if c.Enabled != nil && !*c.Enabled {
+		return "", false
+	}
+	if len(c.EnabledManagers) > 0 && !slices.Contains(c.EnabledManagers, "npm") {
+		return "", false
+	}
+	if slices.Contains(c.IgnoreDeps, dependency) {
+		return "", false
+	}
+	enabled, group := true, ""
+	for _, rule := range c.PackageRules {
+		if !rule.matches(dependency) {
+			continue
+		}
+		if rule.Enabled != nil {
+			enabled = *rule.Enabled
+		}
+		if rule.GroupName != "" {
+			group = rule.GroupName
+		}
+	}
+	return group, enabled
+}
+
+// matches reports whether the rule applies to the dependency. Rules
+// without package matchers apply to every dependency, and the names
+// negated with "!" never match.
+func (r renovateRule) matches(dependency string) bool {
+	var matchers, matched, excluded bool
+	for _, name := range r.MatchPackageNames {
+		if pattern, ok := strings.CutPrefix(name, "!"); ok {
+			excluded = excluded || renovateNameMatches(pattern, dependency)
+			continue
+		}
+		matchers = true
+		matched = matched || renovateNameMatches(name, dependency)
+	}
+	for _, pattern := range r.MatchPackagePatterns {
+		matchers = true
+		matched = matched || renovateNameMatches("/"+pattern+"/", dependency)
+	}
+	for _, prefix := range r.MatchPackagePrefixes {
+		matchers = true
+		matched = matched || strings.HasPrefix(dependency, prefix)
+	}
+	for _, pattern := range r.ExcludePackagePatterns {
+		excluded = excluded || renovateNameMatches("/"+pattern+"/", dependency)
+	}
+	for _, prefix := range r.ExcludePackagePrefixes {
+		excluded = excluded || strings.HasPrefix(dependency, prefix)
+	}
+	excluded = excluded || slices.Contains(r.ExcludePackageNames, dependency)
+	return (matched || !matchers) && !excluded
+}
+
+// renovateNameMatches reports whether the package name matches the
+// pattern of Renovate: a regular expression between slashes, e.g.
+// "/^eslint/", a glob, e.g. "@types/*", or an exact name.
+func renovateNameMatches(pattern, name string) bool {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && (strings.HasSuffix(pattern, "/") || strings.HasSuffix(pattern, "/i")) {
+		expr := strings.TrimPrefix(pattern, "/")
+		if rest, ok := strings.CutSuffix(expr, "/i"); ok {
+			expr = "(?i)" + rest
+		} else {
+			expr = strings.TrimSuffix(expr, "/")
+		}
+		re, err := regexp.Compile(expr)
+		return err == nil && re.MatchString(name)
+	}
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	return pattern == name
+}
+
+// stripJSON5 removes the comments and the trailing commas of a JSON5
+// document, e.g. renovate.json5, which are the only features of JSON5
+// the configs commonly use.
+func stripJSON5(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end == -1 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			// Drop the comma preceding the closing bracket, if any.
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// dependabotUpdate is an entry of the "updates" of the config of
+// Dependabot, for npm:
+// https://docs.github.com/en/code-security/dependabot/working-with-dependabot/dependabot-options-reference
+type dependabotUpdate struct {
+	// ignored are the dependencies whose updates are all ignored.
+	// The ones only ignored for some versions or update types are still
+	// managed.
+	ignored []string
+	// allowed are the dependencies which are updated, when not empty.
+	allowed []string
+	groups  []dependabotGroup
+}
+
+// dependabotGroup is a group of the updates of Dependabot.
+type dependabotGroup struct {
+	name            string
+	patterns        []string
+	excludePatterns []string
+}
+
+// manage tells whether the update manages the dependency, and in which
+// group: the first one whose patterns match it.
+func (u dependabotUpdate) manage(dependency string) (string, bool) {
+	if matchesAny(u.ignored, dependency) || len(u.allowed) > 0 && !matchesAny(u.allowed, dependency) {
+		return "", false
+	}
+	for _, g := range u.groups {
+		if matchesAny(g.patterns, dependency) && !matchesAny(g.excludePatterns, dependency) {
+			return g.name, true
+		}
+	}
+	return "", true
+}
+
+// parseDependabotUpdates returns the npm updates of the config of
+// Dependabot for the root of the project. Like pnpm-workspace.yaml, it is
+// read line by line: only the block style of YAML used by the
+// documentation is supported.
+func parseDependabotUpdates(data []byte) []dependabotUpdate {
+	var updates []dependabotUpdate
+	var current *dependabotUpdate
+	var npm, root, inUpdates bool
+	itemIndent := -1
+	// section is the key of the update being read, e.g. "ignore", entry
+	// the dependency of the item of ignore or allow being read, and
+	// groupKey the key of the group being read, e.g. "patterns".
+	var section, entry, groupKey string
+	var partial bool
+	groupIndent := -1
+
+	flushEntry := func() {
+		switch {
+		case current == nil || entry == "":
+		case section == "ignore" && !partial:
+			current.ignored = append(current.ignored, entry)
+		case section == "allow":
+			current.allowed = append(current.allowed, entry)
+		}
+		entry, partial = "", false
+	}
+	flushUpdate := func() {
+		flushEntry()
+		if current != nil && npm && root {
+			updates = append(updates, *current)
+		}
+		current, section = nil, ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := indentOf(line)
+		if indent == 0 && !strings.HasPrefix(trimmed, "- ") {
+			flushUpdate()
+			inUpdates = strings.HasPrefix(trimmed, "updates:")
+			continue
+		}
+		if !inUpdates {
+			continue
+		}
+		item, isItem := strings.CutPrefix(trimmed, "- ")
+		if isItem && (itemIndent == -1 || indent == itemIndent) {
+			// A new entry of "updates", whose keys are indented past the dash.
+			flushUpdate()
+			current, npm, root = &dependabotUpdate{}, false, true
+			itemIndent = indent
+			trimmed, isItem = item, false
+			indent += 2
+		}
+		if current == nil {
+			continue
+		}
+		key, value, _ := strings.Cut(strings.TrimPrefix(trimmed, "- "), ":")
+		value, _, _ = strings.Cut(value, " #")
+		key, value = strings.TrimSpace(key), yamlUnquote(value)
+
+		if indent == itemIndent+2 {
+			flushEntry()
+			section, groupIndent = key, -1
+			switch key {
+			case "package-ecosystem":
+				npm = value == "npm"
+			case "directory":
+				root = value == "/" || value == "." || value == ""
+			case "directories":
+				root = false
+			}
+			continue
+		}
+
+		switch section {
+		case "directories":
+			if isItem {
+				pattern := yamlUnquote(item)
+				root = root || pattern == "/" || pattern == "/**" || pattern == "**"
+			}
+		case "ignore", "allow":
+			if isItem {
+				flushEntry()
+			}
+			switch key {
+			case "dependency-name":
+				entry = value
+			case "versions", "update-types":
+				partial = true
+			}
+		case "groups":
+			if groupIndent == -1 {
+				groupIndent = indent
+			}
+			if indent == groupIndent {
+				current.groups = append(current.groups, dependabotGroup{name: key})
+				groupKey = ""
+				continue
+			}
+			g := &current.groups[len(current.groups)-1]
+			var items []string
+			if isItem {
+				items = []string{yamlUnquote(item)}
+			} else {
+				// A flow sequence, e.g. patterns: ["eslint*"], or the key
+				// of the block sequence which follows.
+				groupKey = key
+				items = tomlStrings(value)
+			}
+			switch groupKey {
+			case "patterns":
+				g.patterns = append(g.patterns, items...)
+			case "exclude-patterns":
+				g.excludePatterns = append(g.excludePatterns, items...)
+			}
+		}
+	}
+	flushUpdate()
+	return updates
+}
+
+// matchesAny reports whether the name matches one of the patterns, in
+// which "*" matches any characters, including slashes, like Dependabot.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+		if ok, _ := regexp.MatchString(expr, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateBots records the bots managing the declared dependency of
+// every finding, so their pull requests and the removals of depose
+// don't fight each other.
+func (sc *scanContext) annotateBots(findings []Finding, bots []updateBot) {
+	for i := range findings {
+		if _, declared := sc.d.mp[findings[i].Package]; !declared {
+			continue
+		}
+		for _, bot := range bots {
+			if group, ok := bot.manage(findings[i].Package); ok {
+				findings[i].ManagedBy = append(findings[i].ManagedBy, botManagement{Bot: bot.name, Group: group})
+			}
+		}
+	}
+}
+
+// writeBotPRBody writes the body of a pull request removing the unused
+// dependencies, listed by the groups of the bots updating them, so the
+// pending update pull requests of those groups can be found and closed.
+func writeBotPRBody(w io.Writer, findings []Finding) {
+	groups := make(map[string][]Finding)
+	for _, f := range findings {
+		if f.RuleID != RuleUnusedDependency && f.RuleID != RuleUnusedDevDependency {
+			continue
+		}
+		if len(f.ManagedBy) == 0 {
+			groups[""] = append(groups[""], f)
+		}
+		for _, m := range f.ManagedBy {
+			groups[m.String()] = append(groups[m.String()], f)
+		}
+	}
+	fmt.Fprintln(w, "## Remove unused dependencies")
+	fmt.Fprintln(w)
+	if len(groups) == 0 {
+		fmt.Fprintln(w, "depose found no unused dependencies.")
+		return
+	}
+	fmt.Fprintln(w, "depose found these dependencies unused. Close the pending update pull requests of their groups once this is merged, or the bots will keep updating them.")
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := groups[""]; ok {
+		names = append(names, "")
+	}
+	for _, name := range names {
+		fmt.Fprintln(w)
+		if name == "" {
+			fmt.Fprintln(w, "### Not managed by an update bot")
+		} else {
+			fmt.Fprintf(w, "### %s\n", name)
+		}
+		for _, f := range groups[name] {
+			fmt.Fprintf(w, "- [ ] `%s` (%s)\n", f.Package, f.Section)
+		}
+	}
+}
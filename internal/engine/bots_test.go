@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenovateManage(t *testing.T) {
+	config := &renovateConfig{
+		IgnoreDeps: []string{"left-pad"},
+		PackageRules: []renovateRule{
+			{MatchPackagePatterns: []string{"^eslint"}, GroupName: "linters"},
+			{MatchPackageNames: []string{"@types/*"}, GroupName: "types"},
+			{MatchPackageNames: []string{"/^@aws-sdk//"}, Enabled: ptr(false)},
+			{MatchPackagePrefixes: []string{"eslint-plugin-"}, ExcludePackageNames: []string{"eslint-plugin-react"}, GroupName: "eslint plugins"},
+			{MatchPackageNames: []string{"!react", "!react-dom"}, MatchPackagePrefixes: []string{"react"}, GroupName: "react tooling"},
+		},
+	}
+	tests := []struct {
+		dependency string
+		group      string
+		ok         bool
+	}{
+		{"lodash", "", true},
+		{"left-pad", "", false},
+		{"eslint", "linters", true},
+		{"eslint-plugin-import", "eslint plugins", true},
+		{"eslint-plugin-react", "linters", true},
+		{"@types/node", "types", true},
+		{"@aws-sdk/client-s3", "", false},
+		{"react", "", true},
+		{"react-router", "react tooling", true},
+	}
+	for _, tt := range tests {
+		group, ok := config.manage(tt.dependency)
+		if group != tt.group || ok != tt.ok {
+			t.Errorf("manage(%q) = %q, %v, want %q, %v", tt.dependency, group, ok, tt.group, tt.ok)
+		}
+	}
+	if _, ok := (&renovateConfig{EnabledManagers: []string{"dockerfile"}}).manage("lodash"); ok {
+		t.Errorf("lodash is managed without the npm manager")
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestStripJSON5(t *testing.T) {
+	src := "{\n  // comment\n  \"extends\": [\"config:recommended\",],\n  /* block */ \"url\": \"http://x//y\",\n}\n"
+	want := "{\n  \n  \"extends\": [\"config:recommended\"],\n   \"url\": \"http://x//y\"\n}\n"
+	if got := string(stripJSON5([]byte(src))); got != want {
+		t.Errorf("stripJSON5() = %q, want %q", got, want)
+	}
+}
+
+func TestParseDependabotUpdates(t *testing.T) {
+	config := `version: 2
+updates:
+  - package-ecosystem: "github-actions"
+    directory: "/"
+  - package-ecosystem: "npm" # the app
+    directory: "/"
+    ignore:
+      - dependency-name: "express"
+        versions: ["5.x"]
+      - dependency-name: "aws-sdk"
+    groups:
+      linters:
+        patterns:
+          - "eslint*"
+          - "prettier"
+        exclude-patterns:
+          - "eslint-plugin-legacy"
+      types:
+        patterns: ["@types/*"]
+  - package-ecosystem: "npm"
+    directory: "/docs"
+`
+	want := []dependabotUpdate{{
+		ignored: []string{"aws-sdk"},
+		groups: []dependabotGroup{
+			{name: "linters", patterns: []string{"eslint*", "prettier"}, excludePatterns: []string{"eslint-plugin-legacy"}},
+			{name: "types", patterns: []string{"@types/*"}},
+		},
+	}}
+	got := parseDependabotUpdates([]byte(config))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseDependabotUpdates() = %+v, want %+v", got, want)
+	}
+	for dependency, want := range map[string]string{"eslint-plugin-import": "linters", "eslint-plugin-legacy": "", "@types/node": "types", "express": ""} {
+		if group, ok := got[0].manage(dependency); !ok || group != want {
+			t.Errorf("manage(%q) = %q, %v, want %q", dependency, group, ok, want)
+		}
+	}
+	if _, ok := got[0].manage("aws-sdk"); ok {
+		t.Errorf("aws-sdk is managed, although ignored")
+	}
+}
+
+func TestUpdateBots(t *testing.T) {
+	sc := newScanContext()
+	sc.setProjectFS(fstest.MapFS{
+		"renovate.json":          {Data: []byte(`{"packageRules": [{"matchPackageNames": ["eslint*"], "groupName": "linters"}]}`)},
+		".github/dependabot.yml": {Data: []byte("version: 2\nupdates:\n  - package-ecosystem: npm\n    directory: /\n    allow:\n      - dependency-name: lodash\n")},
+	})
+	sc.d.mp = map[string]bool{"eslint-config-airbnb": false, "lodash": false, "moment": false}
+	defer func() { sc.setProjectFS(os.DirFS(".")); sc.d = Dependency{} }()
+
+	bots, err := sc.readUpdateBots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		{RuleID: RuleUnusedDevDependency, Package: "eslint-config-airbnb", Section: "devDependencies"},
+		{RuleID: RuleUnusedDependency, Package: "lodash", Section: "dependencies"},
+		{RuleID: RuleMissingDependency, Package: "chalk"},
+	}
+	sc.annotateBots(findings, bots)
+	want := [][]botManagement{
+		{{Bot: botRenovate, Group: "linters"}},
+		{{Bot: botRenovate}, {Bot: botDependabot}},
+		nil,
+	}
+	for i, f := range findings {
+		if !reflect.DeepEqual(f.ManagedBy, want[i]) {
+			t.Errorf("%s managed by %v, want %v", f.Package, f.ManagedBy, want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	writeBotPRBody(&buf, append(findings, Finding{RuleID: RuleUnusedDependency, Package: "moment", Section: "dependencies"}))
+	for _, want := range []string{
+		"### dependabot\n- [ ] `lodash` (dependencies)\n",
+		"### renovate\n- [ ] `lodash` (dependencies)\n",
+		"### renovate (group \"linters\")\n- [ ] `eslint-config-airbnb` (devDependencies)\n",
+		"### Not managed by an update bot\n- [ ] `moment` (dependencies)\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("PR body does not contain %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "chalk") {
+		t.Errorf("PR body lists the missing dependency:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// bundleSize is the cost of a runtime dependency in a front-end bundle.
+type bundleSize struct {
+	Package string `json:"package"`
+	Version string `json:"version,omitempty"`
+	// Size and Gzip are the sizes of the package minified, then gzipped,
+	// in bytes.
+	Size int64 `json:"size"`
+	Gzip int64 `json:"gzip"`
+	// Source tells where the sizes come from: "api", or "node_modules"
+	// when they are estimated from the entrypoint of the installed
+	// package, which isn't minified nor bundled with its dependencies.
+	Source string `json:"source"`
+}
+
+func (b bundleSize) String() string {
+	s := fmt.Sprintf("%s %s gzipped (%s minified)", b.Package, FormatBytes(uint64(b.Gzip)), FormatBytes(uint64(b.Size)))
+	if b.Source == "node_modules" {
+		s += ", estimated from node_modules"
+	}
+	return s
+}
+
+// fetchBundleSize queries the API for the size of the package, at the
+// version if any.
+func (sc *scanContext) fetchBundleSize(pkgName, version string) (bundleSize, error) {
+	spec := pkgName
+	if version != "" {
+		spec += "@" + version
+	}
+	var size struct {
+		Size int64 `json:"size"`
+		Gzip int64 `json:"gzip"`
+	}
+	u := sc.bundleSizeAPI + "?package=" + url.QueryEscape(spec)
+	if err := callAPI(http.MethodGet, u, http.Header{"Accept": {"application/json"}}, nil, &size); err != nil {
+		return bundleSize{}, err
+	}
+	return bundleSize{Package: pkgName, Version: version, Size: size.Size, Gzip: size.Gzip, Source: "api"}, nil
+}
+
+// estimateBundleSize estimates the size of the installed package from its
+// entrypoint, its "module" or its "main", gzipped.
+func (sc *scanContext) estimateBundleSize(pkgName string, pkg *installedPackage) (bundleSize, error) {
+	entry := pkg.Module
+	if entry == "" {
+		entry = pkg.Main
+	}
+	if entry == "" {
+		entry = "index.js"
+	}
+	file := path.Join("node_modules", pkgName, strings.TrimPrefix(entry, "./"))
+	data, err := sc.readProjectFile(filepath.FromSlash(file))
+	if err != nil && path.Ext(file) == "" {
+		data, err = sc.readProjectFile(filepath.FromSlash(file + ".js"))
+	}
+	if err != nil {
+		return bundleSize{}, err
+	}
+	counter := &countingWriter{}
+	zw := gzip.NewWriter(counter)
+	zw.Write(data)
+	zw.Close()
+	return bundleSize{Package: pkgName, Version: pkg.Version, Size: int64(len(data)), Gzip: counter.n, Source: "node_modules"}, nil
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// runtimeDependencies returns the sorted names of the kept dependencies
+// declared in "dependencies", the ones shipped to the bundle.
+func (sc *scanContext) runtimeDependencies(findings []Finding) []string {
+	kept, _ := sc.keptDependencies(findings)
+	var names []string
+	for pkgName, section := range kept {
+		if section == "dependencies" {
+			names = append(names, pkgName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findBundleSizes returns the sizes of the dependencies, queried from the
+// API unless bundleSizeAPI is empty, or estimated from node_modules when it
+// fails, sorted by decreasing gzipped size, so the heaviest come first.
+func (sc *scanContext) findBundleSizes(dependencies []string) []bundleSize {
+	var (
+		sizes []bundleSize
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+	)
+	sem := make(chan struct{}, outdatedConcurrency)
+	for _, pkgName := range dependencies {
+		wg.Add(1)
+		go func(pkgName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			installed := sc.readInstalledPackage(filepath.Join("node_modules", filepath.FromSlash(pkgName)))
+			version := ""
+			if installed != nil {
+				version = installed.Version
+			}
+			var size bundleSize
+			err := fmt.Errorf("not installed")
+			if sc.bundleSizeAPI != "" {
+				size, err = sc.fetchBundleSize(sc.registryName(pkgName), version)
+				size.Package = pkgName
+			}
+			if err != nil && installed != nil {
+				size, err = sc.estimateBundleSize(pkgName, installed)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Fprintf(sc.logOut, "Failed to find the bundle size of %q: %v\n", pkgName, err)
+				return
+			}
+			sizes = append(sizes, size)
+		}(pkgName)
+	}
+	wg.Wait()
+
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Gzip != sizes[j].Gzip {
+			return sizes[i].Gzip > sizes[j].Gzip
+		}
+		return sizes[i].Package < sizes[j].Package
+	})
+	return sizes
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFindBundleSizes(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"node_modules/react/package.json":     `{"version": "18.2.0", "main": "index.js"}`,
+		"node_modules/react/index.js":         "module.exports = {};\n",
+		"node_modules/left-pad/package.json":  `{"version": "1.3.0", "module": "./esm/index.mjs"}`,
+		"node_modules/left-pad/esm/index.mjs": "export default function leftPad() {}\n",
+		"node_modules/not-sized/package.json": `{"version": "1.0.0", "main": "missing.js"}`,
+	})
+	sizes := map[string]string{
+		"react@18.2.0": `{"size": 6000, "gzip": 2500}`,
+		"lodash":       `{"size": 70000, "gzip": 24000}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, ok := sizes[r.URL.Query().Get("package")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, size)
+	}))
+	defer server.Close()
+	defer func(url string) { sc.bundleSizeAPI = url }(sc.bundleSizeAPI)
+	sc.bundleSizeAPI = server.URL
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+
+	got := sc.findBundleSizes([]string{"left-pad", "lodash", "not-sized", "react"})
+	if len(got) != 3 {
+		t.Fatalf("findBundleSizes() = %v, want the sizes of lodash, react and left-pad", got)
+	}
+	want := []bundleSize{
+		{Package: "lodash", Size: 70000, Gzip: 24000, Source: "api"},
+		{Package: "react", Version: "18.2.0", Size: 6000, Gzip: 2500, Source: "api"},
+	}
+	if !reflect.DeepEqual(got[:2], want) {
+		t.Errorf("findBundleSizes() = %v, want %v first", got[:2], want)
+	}
+	if estimated := got[2]; estimated.Package != "left-pad" || estimated.Source != "node_modules" || estimated.Size != int64(len("export default function leftPad() {}\n")) || estimated.Gzip == 0 {
+		t.Errorf("findBundleSizes() estimated %+v for left-pad from node_modules", estimated)
+	}
+
+	sc.bundleSizeAPI = ""
+	got = sc.findBundleSizes([]string{"lodash", "react"})
+	if len(got) != 1 || got[0].Package != "react" || got[0].Source != "node_modules" {
+		t.Errorf("findBundleSizes() without API = %v, want the estimate of react", got)
+	}
+}
+
+func TestRuntimeDependencies(t *testing.T) {
+	sc := newScanContext()
+	defer func(m Package) { sc.manifest = m }(sc.manifest)
+	sc.manifest = Package{
+		Dependencies:    map[string]string{"react": "^18.0.0", "left-pad": "^1.0.0", "chalk": "^5.0.0"},
+		DevDependencies: map[string]string{"jest": "^29.0.0"},
+	}
+	findings := []Finding{{RuleID: RuleUnusedDependency, Package: "chalk"}}
+	if got, want := sc.runtimeDependencies(findings), []string{"left-pad", "react"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("runtimeDependencies() = %v, want %v", got, want)
+	}
+}
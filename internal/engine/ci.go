@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// yamlCommandKeyRe matches the keys of YAML files whose value is a command:
+// "run" of GitHub Actions, "command" and "entrypoint" of docker-compose,
+// and "script" of GitLab CI and similar services.
+var yamlCommandKeyRe = regexp.MustCompile(`^(\s*)(?:-\s+)?(run|command|entrypoint|script|before_script|after_script)\s*:\s*(.*)$`)
+
+// yamlCommand is a command line found in a YAML file.
+type yamlCommand struct {
+	Line    int
+	Command string
+}
+
+// isYAMLFile reports whether the file is a YAML file.
+func isYAMLFile(file string) bool {
+	ext := filepath.Ext(file)
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// yamlCommands returns the command lines of a YAML file, given either as
+// a plain or quoted scalar, a block scalar ("|" or ">"), a flow sequence
+// of words, e.g. ["npm", "start"], or a block sequence of commands.
+//
+// Only the subset of YAML used by CI services and docker-compose is supported.
+func yamlCommands(data []byte) []yamlCommand {
+	var commands []yamlCommand
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		m := yamlCommandKeyRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		indent, value := len(m[1]), strings.TrimSpace(m[3])
+
+		switch {
+		case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			// Block scalars span the following lines which are more indented than the key.
+			for i+1 < len(lines) && (strings.TrimSpace(lines[i+1]) == "" || indentOf(lines[i+1]) > indent) {
+				i++
+				if line := strings.TrimSpace(lines[i]); line != "" && !strings.HasPrefix(line, "#") {
+					commands = append(commands, yamlCommand{Line: i + 1, Command: line})
+				}
+			}
+		case value == "":
+			// Block sequences of commands, possibly at the same indentation as the key.
+			for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "- ") && indentOf(lines[i+1]) >= indent {
+				i++
+				item := strings.TrimPrefix(strings.TrimSpace(lines[i]), "- ")
+				commands = append(commands, yamlCommand{Line: i + 1, Command: yamlUnquote(item)})
+			}
+		case strings.HasPrefix(value, "["):
+			words := tomlStrings(value)
+			if len(words) == 0 {
+				words = strings.Split(strings.Trim(value, "[]"), ",")
+			}
+			commands = append(commands, yamlCommand{Line: i + 1, Command: strings.Join(words, " ")})
+		default:
+			if idx := strings.Index(value, " #"); idx != -1 {
+				value = value[:idx]
+			}
+			commands = append(commands, yamlCommand{Line: i + 1, Command: yamlUnquote(value)})
+		}
+	}
+	return commands
+}
+
+// indentOf returns the indentation of the line.
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// yamlUnquote removes the quotes around a YAML scalar.
+func yamlUnquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// markYAMLCommands marks the packages run by the commands of the YAML file as found.
+func (sc *scanContext) markYAMLCommands(file string, data []byte) {
+	for _, c := range yamlCommands(data) {
+		sc.markCommandReferences(c.Command, Location{File: file, Line: c.Line})
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestYAMLCommands(t *testing.T) {
+	workflow := `name: CI
+on: push
+jobs:
+  test:
+    steps:
+      - uses: actions/checkout@v4
+      - run: yarn jest --ci # unit tests
+      - name: Build
+        run: |
+          npx tsc -p .
+
+          node -r dotenv/config scripts/build.js
+      - run: "npm run lint"
+services:
+  app:
+    command: ["npm", "start"]
+    entrypoint: nodemon server.js
+test:
+  script:
+    - eslint .
+    - 'prettier --check .'
+  after_script:
+  - rimraf coverage
+`
+	want := []yamlCommand{
+		{7, "yarn jest --ci"},
+		{10, "npx tsc -p ."},
+		{12, "node -r dotenv/config scripts/build.js"},
+		{13, "npm run lint"},
+		{16, "npm start"},
+		{17, "nodemon server.js"},
+		{20, "eslint ."},
+		{21, "prettier --check ."},
+		{23, "rimraf coverage"},
+	}
+	if got := yamlCommands([]byte(workflow)); !reflect.DeepEqual(got, want) {
+		t.Errorf("yamlCommands() = %v, want %v", got, want)
+	}
+}
+
+func TestMarkYAMLCommands(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.bins = map[string]string{"tsc": "typescript"}
+	sc.d.mp = map[string]bool{"jest": false, "typescript": false, "dotenv": false, "eslint": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.d.unresolved = make(map[string][]Location)
+	defer func() {
+		sc.lang, sc.bins, sc.d.mp, sc.d.usages, sc.d.unresolved, sc.d.evidence = nil, nil, nil, nil, nil, nil
+	}()
+
+	sc.markYAMLCommands("ci.yml", []byte("steps:\n  - run: yarn jest\n  - run: npx tsc && node -r dotenv/config build.js\n"))
+
+	want := map[string]bool{"jest": true, "typescript": true, "dotenv": true, "eslint": false}
+	if !reflect.DeepEqual(sc.d.mp, want) {
+		t.Errorf("d.mp = %v, want %v", sc.d.mp, want)
+	}
+	if got := sc.d.usages["dotenv"]; !reflect.DeepEqual(got, []Location{{File: "ci.yml", Line: 3}}) {
+		t.Errorf("usages of dotenv = %v", got)
+	}
+}
@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupsDir is the directory where a copy of the manifest is kept every
+// time depose changes it, in addition to oldpackage.json, which only holds
+// the last one.
+var backupsDir = filepath.Join(".depose", "backups")
+
+// backupTimeLayout is the layout of the time of the backups in their name,
+// e.g. package-20240102T150405Z.json, which sorts them by time.
+const backupTimeLayout = "20060102T150405Z"
+
+// defaultKeptBackups is the number of backups "depose clean" keeps, when
+// the config doesn't set it.
+const defaultKeptBackups = 5
+
+// Retention sets what "depose clean" keeps under .depose/. It is set in
+// the "clean" key of the config.
+type Retention struct {
+	// KeepBackups is the number of the latest backups of the manifest
+	// which are kept, 5 when unset.
+	KeepBackups *int `json:"keepBackups"`
+	// KeepHistory is the number of the latest runs kept in the history.
+	// Every run is kept when unset.
+	KeepHistory *int `json:"keepHistory"`
+	// MaxAge is the age beyond which the backups and the runs of the
+	// history are removed, e.g. "90d" or "6mo". Nothing is removed
+	// because of its age when unset.
+	MaxAge string `json:"maxAge"`
+}
+
+// saveBackup writes a copy of the manifest to backupsDir, named after the
+// time, and returns its path.
+func saveBackup(data []byte, now time.Time) (string, error) {
+	if err := os.MkdirAll(backupsDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(backupsDir, "package-"+now.UTC().Format(backupTimeLayout)+".json")
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// backupTime returns the time of the backup, read from its name.
+func backupTime(name string) (time.Time, bool) {
+	stamp, ok := strings.CutPrefix(strings.TrimSuffix(name, ".json"), "package-")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeLayout, stamp)
+	return t, err == nil
+}
+
+// expiredBackups returns the backups which the retention doesn't keep at
+// the time, oldest first: the ones beyond the number to keep, and the ones
+// older than its maximum age. The files of backupsDir which aren't
+// backups are left alone.
+func expiredBackups(r Retention, now time.Time) ([]string, error) {
+	entries, err := os.ReadDir(backupsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cutoff time.Time
+	if r.MaxAge != "" {
+		age, err := parsePeriod(r.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+		cutoff = age.before(now)
+	}
+	keep := defaultKeptBackups
+	if r.KeepBackups != nil {
+		keep = *r.KeepBackups
+	}
+
+	type backup struct {
+		path string
+		time time.Time
+	}
+	var backups []backup
+	for _, e := range entries {
+		if t, ok := backupTime(e.Name()); ok && !e.IsDir() {
+			backups = append(backups, backup{filepath.Join(backupsDir, e.Name()), t})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].time.Before(backups[j].time) })
+
+	var expired []string
+	for i, b := range backups {
+		if i < len(backups)-keep || b.time.Before(cutoff) {
+			expired = append(expired, b.path)
+		}
+	}
+	return expired, nil
+}
+
+// trimHistory returns the runs of the history which the retention keeps
+// at the time: the latest ones, younger than its maximum age.
+func trimHistory(entries []historyEntry, r Retention, now time.Time) ([]historyEntry, error) {
+	if r.MaxAge != "" {
+		age, err := parsePeriod(r.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+		cutoff := age.before(now)
+		var kept []historyEntry
+		for _, e := range entries {
+			if !e.Time.Before(cutoff) {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+	if r.KeepHistory != nil && len(entries) > *r.KeepHistory {
+		entries = entries[len(entries)-*r.KeepHistory:]
+	}
+	return entries, nil
+}
+
+// cleanArtifacts removes the artifacts of depose which the retention
+// doesn't keep at the time: the expired backups, and the expired runs of
+// the history. With all, .depose/ and oldpackage.json are removed
+// entirely. It returns what it removed, or would remove with dryRun.
+func cleanArtifacts(r Retention, now time.Time, all, dryRun bool) ([]string, error) {
+	var removed []string
+	if all {
+		for _, path := range []string{".depose", "oldpackage.json"} {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			removed = append(removed, path)
+			if !dryRun {
+				if err := os.RemoveAll(path); err != nil {
+					return removed, err
+				}
+			}
+		}
+		return removed, nil
+	}
+
+	expired, err := expiredBackups(r, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range expired {
+		removed = append(removed, path)
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				return removed, err
+			}
+		}
+	}
+
+	entries, err := readHistory(historyFile)
+	if err != nil {
+		return removed, err
+	}
+	kept, err := trimHistory(entries, r, now)
+	if err != nil || len(kept) == len(entries) {
+		return removed, err
+	}
+	removed = append(removed, fmt.Sprintf("%d run(s) of %s", len(entries)-len(kept), historyFile))
+	if dryRun {
+		return removed, nil
+	}
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return removed, err
+	}
+	return removed, os.WriteFile(historyFile, append(data, '\n'), 0o644)
+}
+
+// cleanCommand implements "depose clean", which removes the backups of the
+// manifest and the runs of the history which the "clean" config doesn't
+// keep, so repeated runs don't clutter the repository:
+//
+//	depose clean --dry-run
+func (sc *scanContext) cleanCommand(fs *flag.FlagSet) func(args []string) {
+	dryRun := fs.Bool("dry-run", false, "print what would be removed, without removing it")
+	all := fs.Bool("all", false, "remove .depose/ and oldpackage.json entirely, whatever the retention")
+	return func(args []string) {
+		// The retention is read from the config of package.json, if any.
+		if data, err := sc.readProjectFile("package.json"); err == nil {
+			if err := json.Unmarshal(data, &sc.manifest); err != nil {
+				fatal(classifyError("package.json", err, true))
+			}
+		}
+		removed, err := cleanArtifacts(sc.manifest.Depose.Clean, time.Now(), *all, *dryRun)
+		verb := "Removed"
+		if *dryRun {
+			verb = "Would remove"
+		}
+		for _, path := range removed {
+			fmt.Printf("%s %s\n", verb, filepath.ToSlash(path))
+		}
+		if err != nil {
+			logFatal(err)
+		}
+		if len(removed) == 0 {
+			fmt.Fprintln(os.Stderr, "Nothing to clean")
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCleanArtifacts(t *testing.T) {
+	chdir(t, t.TempDir())
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	var backups []string
+	for _, age := range []int{200, 30, 3, 2, 1} {
+		path, err := saveBackup([]byte("{}\n"), now.AddDate(0, 0, -age))
+		if err != nil {
+			t.Fatal(err)
+		}
+		backups = append(backups, path)
+	}
+	if err := os.WriteFile(filepath.Join(backupsDir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, age := range []int{120, 10, 5} {
+		if err := appendHistory(historyFile, historyEntry{Time: now.AddDate(0, 0, -age)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keep := 3
+	r := Retention{KeepBackups: &keep, MaxAge: "90d"}
+	removed, err := cleanArtifacts(r, now, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{backups[0], backups[1], "1 run(s) of " + historyFile}
+	if !reflect.DeepEqual(removed, want) {
+		t.Fatalf("cleanArtifacts(dry run) = %q, want %q", removed, want)
+	}
+	if _, err := os.Stat(backups[0]); err != nil {
+		t.Fatal("the dry run removed a backup")
+	}
+
+	keep = 1
+	r.KeepHistory = &keep
+	r.MaxAge = ""
+	if _, err := cleanArtifacts(r, now, false, false); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(backupsDir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"notes.txt", filepath.Base(backups[4])}; !reflect.DeepEqual(names, want) {
+		t.Errorf("backups left = %q, want %q", names, want)
+	}
+	history, err := readHistory(historyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || !history[0].Time.Equal(now.AddDate(0, 0, -5)) {
+		t.Errorf("history left = %+v", history)
+	}
+
+	if err := os.WriteFile("oldpackage.json", []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	removed, err = cleanArtifacts(Retention{}, now, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{".depose", "oldpackage.json"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("cleanArtifacts(all) = %q, want %q", removed, want)
+	}
+	if _, err := os.Stat(".depose"); err == nil {
+		t.Error(".depose was not removed")
+	}
+}
@@ -0,0 +1,225 @@
+package engine
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// cliCommand is a command line found in a Makefile, a Dockerfile or a
+// shell script.
+type cliCommand struct {
+	Line    int
+	Command string
+}
+
+// CLIUsage is a declared dependency which is never imported, but whose
+// executables are run outside of package.json, e.g. by a Makefile.
+type CLIUsage struct {
+	Package string `json:"package"`
+	// References is the number of commands running its executables.
+	References int `json:"references"`
+	// DockerfileOnly is true when the commands are all in Dockerfiles,
+	// i.e. the dependency is only needed by the image, e.g. to build it.
+	DockerfileOnly bool       `json:"dockerfileOnly,omitempty"`
+	Locations      []Location `json:"locations"`
+}
+
+// dockerInstructionRe matches the instructions of Dockerfiles running a command.
+var dockerInstructionRe = regexp.MustCompile(`(?i)^\s*(RUN|CMD|ENTRYPOINT)\s+(.*)$`)
+
+// localBinRe matches the paths of the executables installed by the
+// package manager, e.g. "./node_modules/.bin/jest", which are run by
+// their name.
+var localBinRe = regexp.MustCompile(`\S*node_modules/\.bin/`)
+
+// scriptRunners are the commands running the script of package.json
+// given as their first argument, e.g. "npm run build" or "yarn build".
+var scriptRunners = map[string]bool{
+	"npm run": true, "npm run-script": true, "yarn run": true,
+	"pnpm run": true, "bun run": true, "yarn": true, "pnpm": true,
+}
+
+// npmLifecycleScripts are the scripts npm runs with a command of their
+// own name, e.g. "npm test".
+var npmLifecycleScripts = map[string]bool{"start": true, "stop": true, "restart": true, "test": true}
+
+// isMakefile reports whether the file is a Makefile.
+func isMakefile(file string) bool {
+	base := filepath.Base(file)
+	return base == "Makefile" || base == "makefile" || base == "GNUmakefile" || filepath.Ext(base) == ".mk"
+}
+
+// isDockerfile reports whether the file is a Dockerfile, e.g.
+// "Dockerfile", "Dockerfile.dev" or "api.Dockerfile".
+func isDockerfile(file string) bool {
+	base := filepath.Base(file)
+	return base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile.") || strings.HasSuffix(base, ".Dockerfile")
+}
+
+// isHuskyHook reports whether the file is a Git hook installed by husky,
+// e.g. .husky/pre-commit. The scripts of husky itself, under .husky/_,
+// are not.
+func isHuskyHook(file string) bool {
+	return filepath.Base(filepath.Dir(file)) == ".husky"
+}
+
+// isCLIFile reports whether the file runs commands: a Makefile, a
+// Dockerfile, a shell script or a husky hook.
+func isCLIFile(file string) bool {
+	return isMakefile(file) || isDockerfile(file) || filepath.Ext(file) == ".sh" || isHuskyHook(file)
+}
+
+// cliCommands returns the command lines of the file: the recipes of a
+// Makefile, the RUN, CMD and ENTRYPOINT instructions of a Dockerfile, or
+// the lines of a shell script. Lines continued with a backslash are
+// joined, and numbered after their first line.
+func cliCommands(file string, data []byte) []cliCommand {
+	var commands []cliCommand
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSuffix(lines[i], "\r")
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimRight(strings.TrimSuffix(line, "\\"), " \t") + " " + strings.TrimSpace(strings.TrimSuffix(lines[i], "\r"))
+		}
+
+		var command string
+		switch {
+		case isMakefile(file):
+			// Recipes are indented with a tab, and may be prefixed with
+			// "@", "-" or "+" to change how make runs them.
+			if !strings.HasPrefix(line, "\t") {
+				continue
+			}
+			command = strings.TrimLeft(strings.TrimSpace(line), "@-+")
+		case isDockerfile(file):
+			m := dockerInstructionRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			command = strings.TrimSpace(m[2])
+			// The flags of RUN, e.g. --mount=type=cache,target=/root/.npm.
+			for strings.HasPrefix(command, "--") {
+				_, command, _ = strings.Cut(command, " ")
+				command = strings.TrimSpace(command)
+			}
+			if strings.HasPrefix(command, "[") {
+				// The exec form, e.g. ["npx", "serve"].
+				command = strings.Join(tomlStrings(command), " ")
+			}
+			if delimiter, ok := strings.CutPrefix(command, "<<"); ok {
+				// A heredoc, whose lines are commands, e.g. "RUN <<EOF".
+				delimiter, _, _ = strings.Cut(strings.TrimPrefix(delimiter, "-"), " ")
+				delimiter = strings.Trim(delimiter, `"'`)
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != delimiter {
+					i++
+					if line := strings.TrimSpace(lines[i]); line != "" && !strings.HasPrefix(line, "#") {
+						commands = append(commands, cliCommand{Line: i + 1, Command: localBinRe.ReplaceAllString(line, "")})
+					}
+				}
+				i++
+				continue
+			}
+		default:
+			command = strings.TrimSpace(line)
+		}
+		if idx := strings.Index(command, " #"); idx != -1 {
+			command = command[:idx]
+		}
+		if command == "" || strings.HasPrefix(command, "#") {
+			continue
+		}
+		commands = append(commands, cliCommand{Line: lineNo, Command: localBinRe.ReplaceAllString(command, "")})
+	}
+	return commands
+}
+
+// expandScripts appends to the command line the scripts of package.json
+// it runs, e.g. with "npm run build", and the scripts they run in turn,
+// so the executables they run are attributed to the command. Seen holds
+// the scripts already expanded.
+func (sc *scanContext) expandScripts(command string, seen map[string]bool) string {
+	var scripts []string
+	for _, words := range splitCommands(shellWords(command)) {
+		if len(words) < 2 {
+			continue
+		}
+		program, args := words[0], words[1:]
+		if len(args) > 1 && scriptRunners[program+" "+args[0]] {
+			args = args[1:]
+		} else if !scriptRunners[program] && !(program == "npm" && npmLifecycleScripts[args[0]]) {
+			continue
+		}
+		for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+			args = args[1:]
+		}
+		if len(args) == 0 || seen[args[0]] {
+			continue
+		}
+		if script, ok := sc.manifest.Scripts[args[0]]; ok {
+			seen[args[0]] = true
+			scripts = append(scripts, sc.expandScripts(script, seen))
+		}
+	}
+	if len(scripts) == 0 {
+		return command
+	}
+	return command + " ; " + strings.Join(scripts, " ; ")
+}
+
+// markCLICommands records the declared dependencies whose executables are
+// run by the commands of the file, including through the scripts of
+// package.json, which are only considered as usage in lenient mode.
+// Preloaded modules are imports found on the location.
+//
+// The dependencies run by Dockerfiles, e.g. to build the image, are used
+// on EvidenceDockerfile rather than EvidenceCLI.
+func (sc *scanContext) markCLICommands(file string, data []byte) {
+	evidence := EvidenceCLI
+	if isDockerfile(file) {
+		evidence = EvidenceDockerfile
+	}
+	for _, c := range cliCommands(file, data) {
+		loc := Location{File: file, Line: c.Line}
+		for _, ref := range scriptReferences(sc.expandScripts(c.Command, make(map[string]bool)), sc.bins) {
+			if ref.Evidence == EvidenceImport {
+				sc.markModuleAsFound(ref.Package, loc)
+				continue
+			}
+			sc.d.mu.Lock()
+			if _, ok := sc.d.mp[ref.Package]; ok {
+				if sc.d.cliUsages == nil {
+					sc.d.cliUsages = make(map[string][]Location)
+				}
+				sc.d.cliUsages[ref.Package] = append(sc.d.cliUsages[ref.Package], loc)
+				if !sc.strict {
+					sc.markAsUsed(ref.Package, evidence, loc)
+				}
+			}
+			sc.d.mu.Unlock()
+		}
+	}
+}
+
+// cliOnlyUsages returns the declared dependencies run by Makefiles,
+// Dockerfiles or shell scripts, but never imported, sorted by name.
+func (sc *scanContext) cliOnlyUsages() []CLIUsage {
+	var usages []CLIUsage
+	for dependency, locations := range sc.d.cliUsages {
+		if len(sc.d.usages[dependency]) > 0 {
+			continue
+		}
+		locations = append([]Location(nil), locations...)
+		sortLocations(locations)
+		dockerfileOnly := true
+		for _, l := range locations {
+			dockerfileOnly = dockerfileOnly && isDockerfile(l.File)
+		}
+		usages = append(usages, CLIUsage{Package: dependency, References: len(locations), DockerfileOnly: dockerfileOnly, Locations: locations})
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Package < usages[j].Package })
+	return usages
+}
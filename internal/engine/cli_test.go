@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCLICommands(t *testing.T) {
+	tests := []struct {
+		file string
+		src  string
+		want []cliCommand
+	}{
+		{
+			file: "Makefile",
+			src: "BIN := node_modules/.bin\n\n" +
+				"test: build # run the tests\n" +
+				"\t@./node_modules/.bin/jest --ci\n" +
+				"\t-rimraf coverage \\\n\t  .nyc_output\n" +
+				"\t# eslint .\n",
+			want: []cliCommand{{4, "jest --ci"}, {5, "rimraf coverage .nyc_output"}},
+		},
+		{
+			file: "docker/api.Dockerfile",
+			src: "FROM node:20\n" +
+				"RUN --mount=type=cache,target=/root/.npm npm ci && \\\n    npx prisma generate\n" +
+				"# RUN eslint .\n" +
+				"ENTRYPOINT [\"pm2-runtime\", \"server.js\"]\n" +
+				"cmd serve -s build\n" +
+				"RUN <<EOF\n  npm run build\n  # comment\n  nx migrate\nEOF\n" +
+				"RUN --network=none [\"npx\", \"tsc\"]\n",
+			want: []cliCommand{
+				{2, "npm ci && npx prisma generate"}, {5, "pm2-runtime server.js"}, {6, "serve -s build"},
+				{8, "npm run build"}, {10, "nx migrate"}, {12, "npx tsc"},
+			},
+		},
+		{
+			file: ".husky/commit-msg",
+			src:  "#!/usr/bin/env sh\n. \"$(dirname -- \"$0\")/_/husky.sh\"\n\nnpx --no -- commitlint --edit \"$1\"\n",
+			want: []cliCommand{{2, `. "$(dirname -- "$0")/_/husky.sh"`}, {4, `npx --no -- commitlint --edit "$1"`}},
+		},
+		{
+			file: "scripts/release.sh",
+			src:  "#!/bin/sh\nset -e\n\nsemantic-release --dry-run # check\n",
+			want: []cliCommand{{2, "set -e"}, {4, "semantic-release --dry-run"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := cliCommands(tt.file, []byte(tt.src)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("cliCommands(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestIsCLIFile(t *testing.T) {
+	for file, want := range map[string]bool{
+		"Makefile": true, "build/rules.mk": true, "Dockerfile": true, "Dockerfile.dev": true,
+		"web.Dockerfile": true, "scripts/deploy.sh": true, "src/index.js": false, "docker-compose.yml": false,
+		".husky/pre-commit": true, ".husky/_/husky.sh": true, ".husky/_/h": false,
+	} {
+		if got := isCLIFile(file); got != want {
+			t.Errorf("isCLIFile(%q) = %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestMarkCLICommands(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.bins = map[string]string{"prisma": "prisma", "tsc": "typescript"}
+	sc.d.mp = map[string]bool{"prisma": false, "typescript": false, "jest": false, "dotenv": false, "lodash": false}
+	sc.d.usages = map[string][]Location{"typescript": {{File: "src/index.ts", Line: 1}}}
+	sc.d.unresolved = make(map[string][]Location)
+	defer func() {
+		sc.lang, sc.bins, sc.strict = nil, nil, false
+		sc.d.mp, sc.d.usages, sc.d.unresolved, sc.d.evidence, sc.d.cliUsages = nil, nil, nil, nil, nil
+	}()
+
+	sc.markCLICommands("Makefile", []byte("build:\n\tnpx prisma generate && tsc\n\tprisma migrate deploy\n\tnode -r dotenv/config dist/index.js\n"))
+	sc.markCLICommands("Dockerfile", []byte("RUN npx prisma generate\n"))
+
+	want := map[string]bool{"prisma": true, "typescript": true, "jest": false, "dotenv": true, "lodash": false}
+	if !reflect.DeepEqual(sc.d.mp, want) {
+		t.Errorf("d.mp = %v, want %v", sc.d.mp, want)
+	}
+	if got := sc.d.evidence["prisma"]; got != EvidenceCLI {
+		t.Errorf("evidence of prisma = %q, want %q", got, EvidenceCLI)
+	}
+	wantUsages := []CLIUsage{{Package: "prisma", References: 3, Locations: []Location{
+		{File: "Dockerfile", Line: 1}, {File: "Makefile", Line: 2}, {File: "Makefile", Line: 3},
+	}}}
+	if got := sc.cliOnlyUsages(); !reflect.DeepEqual(got, wantUsages) {
+		t.Errorf("cliOnlyUsages() = %v, want %v", got, wantUsages)
+	}
+
+	// In strict mode, they are recorded but not used.
+	sc.strict = true
+	sc.d.mp["prisma"], sc.d.cliUsages = false, nil
+	sc.markCLICommands("Makefile", []byte("gen:\n\tprisma generate\n"))
+	if sc.d.mp["prisma"] || len(sc.d.cliUsages["prisma"]) != 1 {
+		t.Errorf("strict mode: used = %v, CLI usages = %v", sc.d.mp["prisma"], sc.d.cliUsages["prisma"])
+	}
+}
+
+func TestReportTextCLIOnly(t *testing.T) {
+	sc := newScanContext()
+	var buf bytes.Buffer
+	r := &Report{CLIOnly: []CLIUsage{{Package: "prisma", References: 1, Locations: []Location{{File: "Makefile", Line: 2}}}}}
+	if err := sc.reportText(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "CLI-only usage:\n  prisma (1 reference(s))\n    at Makefile:2\n") {
+		t.Errorf("reportText() = %q", buf.String())
+	}
+}
+
+func TestExpandScripts(t *testing.T) {
+	sc := newScanContext()
+	sc.manifest.Scripts = map[string]string{
+		"build":   "npm run clean && tsc",
+		"clean":   "rimraf dist",
+		"test":    "jest",
+		"loop":    "yarn loop",
+		"release": "semantic-release",
+	}
+	defer func() { sc.manifest = Package{} }()
+
+	tests := map[string]string{
+		"npm run build -- --watch":         "npm run build -- --watch ; npm run clean && tsc ; rimraf dist",
+		"npm test":                         "npm test ; jest",
+		"yarn release && pnpm run -s test": "yarn release && pnpm run -s test ; semantic-release ; jest",
+		"yarn loop":                        "yarn loop ; yarn loop",
+		"npm install":                      "npm install",
+		"npx jest":                         "npx jest",
+	}
+	for command, want := range tests {
+		if got := sc.expandScripts(command, make(map[string]bool)); got != want {
+			t.Errorf("expandScripts(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+func TestMarkCLICommandsDockerfile(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.manifest.Scripts = map[string]string{"db:generate": "prisma generate"}
+	sc.d.mp = map[string]bool{"prisma": false, "serve": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() {
+		sc.lang, sc.manifest = nil, Package{}
+		sc.d.mp, sc.d.usages, sc.d.evidence, sc.d.cliUsages = nil, nil, nil, nil
+	}()
+
+	sc.markCLICommands("Dockerfile", []byte("FROM node:20\nRUN npm run db:generate\nCMD [\"npx\", \"serve\", \"-s\", \"build\"]\n"))
+
+	if want := map[string]bool{"prisma": true, "serve": true}; !reflect.DeepEqual(sc.d.mp, want) {
+		t.Errorf("d.mp = %v, want %v", sc.d.mp, want)
+	}
+	if got := sc.d.evidence["prisma"]; got != EvidenceDockerfile {
+		t.Errorf("evidence of prisma = %q, want %q", got, EvidenceDockerfile)
+	}
+	want := []CLIUsage{
+		{Package: "prisma", References: 1, DockerfileOnly: true, Locations: []Location{{File: "Dockerfile", Line: 2}}},
+		{Package: "serve", References: 1, DockerfileOnly: true, Locations: []Location{{File: "Dockerfile", Line: 3}}},
+	}
+	if got := sc.cliOnlyUsages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cliOnlyUsages() = %v, want %v", got, want)
+	}
+}
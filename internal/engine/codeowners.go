@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// codeownersFiles are the locations of the CODEOWNERS file, in the order
+// GitHub and GitLab look for it.
+var codeownersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// unowned is the owner of the findings no CODEOWNERS rule matches.
+const unowned = "(unowned)"
+
+// codeownersRule assigns owners to the paths matching its pattern.
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// readCodeowners reads the rules of the first CODEOWNERS file found.
+func (sc *scanContext) readCodeowners() ([]codeownersRule, error) {
+	for _, file := range codeownersFiles {
+		data, err := sc.readProjectFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return parseCodeowners(data), nil
+	}
+	return nil, nil
+}
+
+// parseCodeowners parses the rules of a CODEOWNERS file. Lines without
+// owners are kept, since they remove the ownership of the paths they match.
+func parseCodeowners(data []byte) []codeownersRule {
+	var rules []codeownersRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		// Sections of GitLab, e.g. "[Docs]", are not patterns.
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+		rules = append(rules, codeownersRule{pattern: codeownersPattern(fields[0]), owners: fields[1:]})
+	}
+	return rules
+}
+
+// codeownersPattern converts a pattern of CODEOWNERS, which follows the
+// rules of .gitignore, to a regular expression matching slash paths
+// relative to the root: patterns containing a slash other than a trailing
+// one are anchored to the root, the others match at any depth, and
+// directories match everything they contain.
+func codeownersPattern(pattern string) *regexp.Regexp {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if strings.HasSuffix(pattern, "/") {
+		sb.WriteString(".*")
+	} else {
+		sb.WriteString("(?:/.*)?")
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// ownersOf returns the owners of the path, given by the last matching rule.
+func ownersOf(rules []codeownersRule, path string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(path) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// assignOwners records in the findings the owners of their first location.
+func assignOwners(findings []Finding, rules []codeownersRule) {
+	for i, f := range findings {
+		if len(f.Locations) > 0 {
+			findings[i].Owners = ownersOf(rules, f.Locations[0].File)
+		}
+	}
+}
+
+// groupByOwner groups the findings by owner, sorted by owner, the
+// unowned findings last. A finding with several owners is in every group.
+func groupByOwner(findings []Finding) []findingGroup {
+	return groupFindings(findings, func(f Finding) []string {
+		if len(f.Owners) == 0 {
+			return []string{unowned}
+		}
+		return f.Owners
+	}, ownerLess)
+}
+
+// ownerLess orders the owners by name, the unowned last.
+func ownerLess(a, b string) bool {
+	if (a == unowned) != (b == unowned) {
+		return b == unowned
+	}
+	return a < b
+}
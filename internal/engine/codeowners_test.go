@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOwnersOf(t *testing.T) {
+	rules := parseCodeowners([]byte(`# Default owners
+*                     @org/platform
+*.js                  @org/js
+/packages/api/        @org/backend @alice
+packages/web/**/*.tsx @org/frontend
+docs/                 @org/docs
+/packages/api/legacy/
+[Docs]
+`))
+	tests := map[string][]string{
+		"package.json":                    {"@org/platform"},
+		"src/index.js":                    {"@org/js"},
+		"packages/api/package.json":       {"@org/backend", "@alice"},
+		"packages/api/src/server.js":      {"@org/backend", "@alice"},
+		"packages/api/legacy/old.js":      {},
+		"packages/web/src/ui/Button.tsx":  {"@org/frontend"},
+		"packages/web/package.json":       {"@org/platform"},
+		"guides/docs/intro.md":            {"@org/docs"},
+		"packages/apiserver/package.json": {"@org/platform"},
+	}
+	for path, want := range tests {
+		if got := ownersOf(rules, path); !reflect.DeepEqual(got, want) && !(len(got) == 0 && len(want) == 0) {
+			t.Errorf("ownersOf(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestReportTextGroupedByOwner(t *testing.T) {
+	sc := newScanContext()
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Severity: SeverityWarning, Package: "lodash", Message: `"lodash" is declared in dependencies but never used`,
+			Owners: []string{"@org/backend"}},
+		{RuleID: RuleMissingDependency, Severity: SeverityError, Package: "zod", Message: `"zod" is used but not declared in package.json`},
+		{RuleID: RuleUnusedDependency, Severity: SeverityWarning, Package: "moment", Message: `"moment" is declared in dependencies but never used`,
+			Owners: []string{"@org/backend", "@org/frontend"}},
+	}
+	var sb strings.Builder
+	if err := sc.reportText(&sb, &Report{Findings: findings, Groups: groupByOwner(findings)}); err != nil {
+		t.Fatal(err)
+	}
+	want := `@org/backend (2 finding(s)):
+warning [unused-dependency] "lodash" is declared in dependencies but never used
+warning [unused-dependency] "moment" is declared in dependencies but never used
+@org/frontend (1 finding(s)):
+warning [unused-dependency] "moment" is declared in dependencies but never used
+(unowned) (1 finding(s)):
+error [missing-dependency] "zod" is used but not declared in package.json
+3 finding(s)
+`
+	if sb.String() != want {
+		t.Errorf("reportText() =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// command defines the flags of a subcommand on the flag set, and returns
+// its implementation, which receives the arguments left after the flags.
+//
+// Flags are defined separately from running the command,
+// so that the shell completion can list them.
+type command func(fs *flag.FlagSet) func(args []string)
+
+// runCommand parses the flags of the subcommand, and runs it.
+func (sc *scanContext) runCommand(name string, args []string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	run := sc.commands[name](fs)
+	fs.Parse(args)
+	run(fs.Args())
+}
+
+// loadManifest reads package.json into "manifest" for the subcommands,
+// and returns its content.
+func (sc *scanContext) loadManifest() []byte {
+	data, err := sc.readProjectFile("package.json")
+	if err != nil {
+		fatal(classifyError("package.json", err, true))
+	}
+	if err := json.Unmarshal(data, &sc.manifest); err != nil {
+		fatal(classifyError("package.json", err, true))
+	}
+	return data
+}
+
+// filesCommand implements "depose files", which lists the source files of the
+// project which are not reachable from any entrypoint, i.e. dead modules.
+//
+//	depose files --entry 'test/*.test.js'
+func (sc *scanContext) filesCommand(fs *flag.FlagSet) func(args []string) {
+	var entries stringList
+	fs.Var(&entries, "entry", "entrypoint or pattern of entrypoints, in addition to the ones of package.json; can be repeated")
+	return func(args []string) {
+		sc.logOut = os.Stderr
+		sc.lang = sc.nodeLanguage
+		sc.loadManifest()
+
+		if err := sc.walkProject(); err != nil {
+			fmt.Fprintf(sc.logOut, "Error scanning the directory %v:\n", err)
+		}
+		orphans := orphanedFiles(sc.followImports(sc.entrypoints(entries, sc.files)), sc.files)
+		for _, file := range orphans {
+			fmt.Println(file)
+		}
+		fmt.Fprintf(sc.logOut, "%d file(s) not reachable from any entrypoint\n", len(orphans))
+	}
+}
@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// shellNames are the shells supported by "depose completion", sorted by name.
+var shellNames = []string{"bash", "fish", "powershell", "zsh"}
+
+// completionShells maps the shells supported by "depose completion"
+// to the functions writing their completion script.
+var completionShells = map[string]func(w io.Writer, spec completionSpec){
+	"bash":       writeBashCompletion,
+	"zsh":        writeZshCompletion,
+	"fish":       writeFishCompletion,
+	"powershell": writePowerShellCompletion,
+}
+
+// completionFlag is a flag completed by the shells.
+type completionFlag struct {
+	Name  string
+	Usage string
+	// Bool is true for the flags which take no value.
+	Bool bool
+}
+
+// completionSpec describes the command line of depose for the shells:
+// the flags of the main command, and every subcommand with its flags.
+type completionSpec struct {
+	Flags       []completionFlag
+	Subcommands []string
+	SubFlags    map[string][]completionFlag
+}
+
+// flagsOf returns the flags defined on the flag set, sorted by name.
+func flagsOf(fs *flag.FlagSet) []completionFlag {
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{Name: f.Name, Usage: f.Usage, Bool: ok && b.IsBoolFlag()})
+	})
+	return flags
+}
+
+// newCompletionSpec collects the flags of the main command and of the
+// subcommands, by defining them on throwaway flag sets.
+func (sc *scanContext) newCompletionSpec() completionSpec {
+	mainFlags := flag.NewFlagSet("depose", flag.ContinueOnError)
+	sc.defineFlags(mainFlags)
+	spec := completionSpec{Flags: flagsOf(mainFlags), SubFlags: make(map[string][]completionFlag)}
+	for name, cmd := range sc.commands {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		cmd(fs)
+		spec.Subcommands = append(spec.Subcommands, name)
+		spec.SubFlags[name] = flagsOf(fs)
+	}
+	sort.Strings(spec.Subcommands)
+	return spec
+}
+
+// flagNames returns the names of the flags with their dashes, separated by spaces.
+func flagNames(flags []completionFlag) string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "--" + f.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// dependencyCompletion is the command listing the declared
+// dependencies, run by the shells to complete "depose why".
+const dependencyCompletion = "depose why --list"
+
+func writeBashCompletion(w io.Writer, spec completionSpec) {
+	fmt.Fprint(w, `# bash completion for depose, load it with:
+#   source <(depose completion bash)
+_depose() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" sub="" i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            -*) ;;
+            *) sub="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+    case "$sub" in
+`)
+	fmt.Fprintf(w, "        \"\")\n            if [[ $cur == -* ]]; then\n                COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", flagNames(spec.Flags))
+	fmt.Fprintf(w, "            else\n                COMPREPLY=($(compgen -W %q -- \"$cur\"))\n            fi ;;\n", strings.Join(spec.Subcommands, " "))
+	for _, sub := range spec.Subcommands {
+		fmt.Fprintf(w, "        %s)\n            if [[ $cur == -* ]]; then\n                COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", sub, flagNames(spec.SubFlags[sub]))
+		switch sub {
+		case "why":
+			fmt.Fprintf(w, "            else\n                COMPREPLY=($(compgen -W \"$(%s 2>/dev/null)\" -- \"$cur\"))\n", dependencyCompletion)
+		case "completion":
+			fmt.Fprintf(w, "            else\n                COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(shellNames, " "))
+		}
+		fmt.Fprintln(w, "            fi ;;")
+	}
+	fmt.Fprint(w, `    esac
+}
+complete -o default -F _depose depose
+`)
+}
+
+// zshQuote escapes the text of an option description of _arguments.
+func zshQuote(s string) string {
+	return strings.NewReplacer(`'`, `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// zshFlags returns the option specs of _arguments for the flags.
+func zshFlags(flags []completionFlag) string {
+	var specs []string
+	for _, f := range flags {
+		spec := fmt.Sprintf("'--%s[%s]", f.Name, zshQuote(f.Usage))
+		if !f.Bool {
+			spec += ":" + f.Name + ":_files"
+		}
+		specs = append(specs, spec+"'")
+	}
+	return strings.Join(specs, " \\\n        ")
+}
+
+func writeZshCompletion(w io.Writer, spec completionSpec) {
+	fmt.Fprint(w, `#compdef depose
+# zsh completion for depose, load it with:
+#   source <(depose completion zsh)
+_depose() {
+    local -a subcommands
+`)
+	fmt.Fprintf(w, "    subcommands=(%s)\n", strings.Join(spec.Subcommands, " "))
+	fmt.Fprint(w, `    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then
+        _describe 'command' subcommands
+        return
+    fi
+    case $words[2] in
+`)
+	for _, sub := range spec.Subcommands {
+		args := zshFlags(spec.SubFlags[sub])
+		switch sub {
+		case "why":
+			args += fmt.Sprintf(" \\\n        '1:dependency:(${(f)\"$(%s 2>/dev/null)\"})'", dependencyCompletion)
+		case "completion":
+			args += fmt.Sprintf(" \\\n        '1:shell:(%s)'", strings.Join(shellNames, " "))
+		}
+		fmt.Fprintf(w, "    %s)\n        shift words; (( CURRENT-- ))\n        _arguments %s ;;\n", sub, args)
+	}
+	fmt.Fprintf(w, "    *)\n        _arguments %s ;;\n", zshFlags(spec.Flags))
+	fmt.Fprint(w, `    esac
+}
+compdef _depose depose
+`)
+}
+
+// fishQuote quotes a string for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// fishFlags writes the completions of the flags, under the condition.
+func fishFlags(w io.Writer, condition string, flags []completionFlag) {
+	for _, f := range flags {
+		required := ""
+		if !f.Bool {
+			required = " -r"
+		}
+		fmt.Fprintf(w, "complete -c depose -n %s -l %s%s -d %s\n", fishQuote(condition), f.Name, required, fishQuote(f.Usage))
+	}
+}
+
+func writeFishCompletion(w io.Writer, spec completionSpec) {
+	fmt.Fprint(w, `# fish completion for depose, load it with:
+#   depose completion fish | source
+complete -c depose -f
+`)
+	fishFlags(w, "__fish_use_subcommand", spec.Flags)
+	for _, sub := range spec.Subcommands {
+		fmt.Fprintf(w, "complete -c depose -n __fish_use_subcommand -a %s\n", sub)
+	}
+	for _, sub := range spec.Subcommands {
+		condition := "__fish_seen_subcommand_from " + sub
+		fishFlags(w, condition, spec.SubFlags[sub])
+		switch sub {
+		case "why":
+			fmt.Fprintf(w, "complete -c depose -n %s -a %s\n", fishQuote(condition), fishQuote("("+dependencyCompletion+" 2>/dev/null)"))
+		case "completion":
+			fmt.Fprintf(w, "complete -c depose -n %s -a %s\n", fishQuote(condition), fishQuote(strings.Join(shellNames, " ")))
+		}
+	}
+}
+
+// powerShellList formats the words as a PowerShell array.
+func powerShellList(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = "'" + strings.ReplaceAll(word, "'", "''") + "'"
+	}
+	return "@(" + strings.Join(quoted, ", ") + ")"
+}
+
+func writePowerShellCompletion(w io.Writer, spec completionSpec) {
+	fmt.Fprint(w, `# PowerShell completion for depose, load it with:
+#   depose completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName depose -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '' -and $words.Count -gt 0) { $words = @($words | Select-Object -SkipLast 1) }
+    $sub = $words | Where-Object { -not $_.StartsWith('-') } | Select-Object -First 1
+`)
+	flags := func(f []completionFlag) []string {
+		return strings.Fields(flagNames(f))
+	}
+	fmt.Fprintf(w, "    $flags = %s\n    $candidates = %s\n", powerShellList(flags(spec.Flags)), powerShellList(spec.Subcommands))
+	fmt.Fprintln(w, "    switch ($sub) {")
+	for _, sub := range spec.Subcommands {
+		fmt.Fprintf(w, "        '%s' {\n            $flags = %s\n", sub, powerShellList(flags(spec.SubFlags[sub])))
+		switch sub {
+		case "why":
+			fmt.Fprintf(w, "            $candidates = @(%s 2>$null)\n", dependencyCompletion)
+		case "completion":
+			fmt.Fprintf(w, "            $candidates = %s\n", powerShellList(shellNames))
+		default:
+			fmt.Fprintln(w, "            $candidates = @()")
+		}
+		fmt.Fprintln(w, "        }")
+	}
+	fmt.Fprint(w, `    }
+    if ($wordToComplete.StartsWith('-')) { $candidates = $flags }
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`)
+}
+
+// completionCommand implements "depose completion", which writes the
+// completion script of the shell, covering the subcommands and their flags,
+// and completing the declared dependencies for "depose why":
+//
+//	source <(depose completion bash)
+func (sc *scanContext) completionCommand(fs *flag.FlagSet) func(args []string) {
+	return func(args []string) {
+		if len(args) != 1 {
+			logFatalf("usage: depose completion %s", strings.Join(shellNames, "|"))
+		}
+		write, ok := completionShells[args[0]]
+		if !ok {
+			logFatalf("Unknown shell %q, supported shells: %s", args[0], strings.Join(shellNames, ", "))
+		}
+		write(os.Stdout, sc.newCompletionSpec())
+	}
+}
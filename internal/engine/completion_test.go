@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompletionSpec(t *testing.T) {
+	sc := newScanContext()
+	// "serve" is added to the commands by the main package.
+	spec := sc.newCompletionSpec()
+	if want := []string{"bisect", "check", "clean", "completion", "exports", "file", "files", "fix", "history", "licenses", "prune", "scan", "stats", "upgrade", "version", "why", "workspaces"}; !reflect.DeepEqual(spec.Subcommands, want) {
+		t.Errorf("Subcommands = %v, want %v", spec.Subcommands, want)
+	}
+	wantPrune := []completionFlag{
+		{Name: "entry", Usage: "entrypoint of the application, can be repeated (default: the \"main\" of package.json, or index.js)"},
+		{Name: "for", Usage: "environment the manifest is generated for: production"},
+		{Name: "out", Usage: "path of the generated manifest, stdout when empty"},
+	}
+	if !reflect.DeepEqual(spec.SubFlags["prune"], wantPrune) {
+		t.Errorf("SubFlags[prune] = %+v, want %+v", spec.SubFlags["prune"], wantPrune)
+	}
+	for _, f := range spec.Flags {
+		if f.Name == "dry-run" && !f.Bool || f.Name == "reporter" && f.Bool {
+			t.Errorf("flag %q has Bool = %v", f.Name, f.Bool)
+		}
+	}
+
+	for _, shell := range shellNames {
+		var sb strings.Builder
+		completionShells[shell](&sb, spec)
+		script := sb.String()
+		for _, want := range []string{"prune", "dry-run", "entry", dependencyCompletion} {
+			if !strings.Contains(script, want) {
+				t.Errorf("%s completion does not contain %q", shell, want)
+			}
+		}
+		if shell == "bash" {
+			if _, err := exec.LookPath("bash"); err != nil {
+				continue
+			}
+			cmd := exec.Command("bash", "-n")
+			cmd.Stdin = strings.NewReader(script)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("bash completion has syntax errors: %v\n%s", err, out)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package engine
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"identical", "a\nb\n", "a\nb\n", ""},
+		{
+			"removed lines",
+			"{\n  \"dependencies\": {\n    \"express\": \"^4\",\n    \"pg\": \"^8\"\n  }\n}\n",
+			"{\n  \"dependencies\": {\n    \"express\": \"^4\"\n  }\n}\n",
+			"--- package.json\n+++ package.json\n@@ -1,6 +1,5 @@\n {\n   \"dependencies\": {\n-    \"express\": \"^4\",\n-    \"pg\": \"^8\"\n+    \"express\": \"^4\"\n   }\n }\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			"--- package.json\n+++ package.json\n@@ -1,4 +1,3 @@\n-1\n 2\n 3\n 4\n@@ -9,4 +8,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := unifiedDiff("package.json", "package.json", []byte(tt.old), []byte(tt.new)); got != tt.want {
+			t.Errorf("%s: unifiedDiff() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
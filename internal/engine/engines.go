@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// readEngines selects the builtin modules of the runtime of the project,
+// from the "engines" and "packageManager" fields of package.json, e.g.
+// {"engines": {"node": ">=16"}} or {"packageManager": "bun@1.1.0"}.
+//
+// Engines in the legacy array form are ignored.
+func (sc *scanContext) readEngines(pkg Package) {
+	var engines map[string]string
+	json.Unmarshal(pkg.Engines, &engines)
+	sc.minNodeMajor = minMajor(engines["node"])
+	_, bunEngine := engines["bun"]
+	sc.bunRuntime = bunEngine || strings.HasPrefix(pkg.PackageManager, "bun@")
+}
+
+// lowerBoundRe matches the versions of a range which are lower bounds,
+// e.g. ">=16", "^18.0.0", "~20.1" or "14.x", but not "<20".
+var lowerBoundRe = regexp.MustCompile(`(?:^|\s)(?:>=?|\^|~|=|v)*\s*(\d+)`)
+
+// minMajor returns the oldest major version allowed by the semver range,
+// e.g. 16 for "^16.0.0 || >=18", or 0 when one of its alternatives has no
+// lower bound, e.g. "<20" or "*".
+func minMajor(versionRange string) int {
+	if strings.TrimSpace(versionRange) == "" {
+		return 0
+	}
+	result := -1
+	for _, alternative := range strings.Split(versionRange, "||") {
+		m := lowerBoundRe.FindStringSubmatch(strings.TrimSpace(alternative))
+		if m == nil {
+			return 0
+		}
+		major, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0
+		}
+		if result == -1 || major < result {
+			result = major
+		}
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package engine
+
+import "testing"
+
+func TestMinMajor(t *testing.T) {
+	tests := map[string]int{
+		"":                0,
+		">=16":            16,
+		">= 14.17.0":      14,
+		"^16.0.0 || >=18": 16,
+		"~20.1":           20,
+		"14.x":            14,
+		">=12 <20":        12,
+		"<20":             0,
+		"*":               0,
+		"^18 || <10":      0,
+	}
+	for versionRange, want := range tests {
+		if got := minMajor(versionRange); got != want {
+			t.Errorf("minMajor(%q) = %d, want %d", versionRange, got, want)
+		}
+	}
+}
+
+func TestIsBuiltinModuleEngines(t *testing.T) {
+	sc := newScanContext()
+	defer func() { sc.minNodeMajor, sc.bunRuntime = 0, false }()
+
+	sc.readEngines(Package{Engines: []byte(`{"node": ">=10"}`)})
+	for name, want := range map[string]bool{
+		"fs": true, "node:fs": true, "http2": true, "worker_threads": false,
+		"node:test": false, "node:sqlite": false, "bun:test": false, "test": false,
+	} {
+		if got := sc.isBuiltinModule(name); got != want {
+			t.Errorf("isBuiltinModule(%q) with Node.js 10 = %v, want %v", name, got, want)
+		}
+	}
+
+	sc.readEngines(Package{Engines: []byte(`["node >= 0.4"]`), PackageManager: "bun@1.1.0"})
+	for name, want := range map[string]bool{
+		"worker_threads": true, "node:test": true, "node:sqlite": true, "bun:test": true, "bun": true, "test": false,
+	} {
+		if got := sc.isBuiltinModule(name); got != want {
+			t.Errorf("isBuiltinModule(%q) with Bun = %v, want %v", name, got, want)
+		}
+	}
+}
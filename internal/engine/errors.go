@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The errors of the catalog, which stop depose with their own exit code
+// and a hint telling how to fix them. They are matched with errors.Is.
+var (
+	// ErrNoManifest is returned when the project has no manifest.
+	ErrNoManifest = errors.New("no manifest found")
+	// ErrManifestParse is returned when the manifest is not valid.
+	ErrManifestParse = errors.New("manifest can't be parsed")
+	// ErrPermission is returned when a file of the project can't be read.
+	ErrPermission = errors.New("permission denied")
+	// ErrScannerTokenTooLong is returned when a line of a file is longer
+	// than the buffer of the extractors.
+	ErrScannerTokenTooLong = errors.New("line too long to scan")
+)
+
+// catalogEntry describes an error of the catalog.
+type catalogEntry struct {
+	err error
+	// code identifies the error in the diagnostics of the reports.
+	code string
+	// exitCode is the status depose exits with. 1 is taken by the
+	// findings of --check, and 2 by the errors of usage.
+	exitCode int
+	hint     string
+}
+
+var errorCatalog = []catalogEntry{
+	{ErrNoManifest, "no-manifest", 3, "run depose from the root of the project, or select its language with --lang"},
+	{ErrManifestParse, "manifest-parse", 4, "fix the syntax of the manifest; package.json allows neither comments nor trailing commas"},
+	{ErrPermission, "permission", 5, "make the file readable by the user running depose"},
+	{ErrScannerTokenTooLong, "token-too-long", 6, "the file is probably generated or minified: lines are limited to 16 MiB"},
+}
+
+// catalogError is an error of the catalog, about a file.
+type catalogError struct {
+	kind error
+	path string
+	err  error
+}
+
+func (e *catalogError) Error() string {
+	// The errors of os already name the file.
+	if strings.Contains(e.err.Error(), e.path) {
+		return fmt.Sprintf("%v: %v", e.kind, e.err)
+	}
+	return fmt.Sprintf("%v: %s: %v", e.kind, e.path, e.err)
+}
+
+func (e *catalogError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// classifyError wraps the error met while reading the file into the
+// error of the catalog it matches, if any. Otherwise, it is returned as is.
+// A missing manifest is reported as ErrNoManifest.
+func classifyError(path string, err error, isManifest bool) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case isManifest && errors.Is(err, fs.ErrNotExist):
+		return &catalogError{ErrNoManifest, path, err}
+	case isManifest && (errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF)):
+		return &catalogError{ErrManifestParse, path, err}
+	case errors.Is(err, fs.ErrPermission):
+		return &catalogError{ErrPermission, path, err}
+	case errors.Is(err, bufio.ErrTooLong):
+		return &catalogError{ErrScannerTokenTooLong, path, err}
+	}
+	return err
+}
+
+// catalogEntryOf returns the entry of the catalog the error matches.
+func catalogEntryOf(err error) (catalogEntry, bool) {
+	for _, entry := range errorCatalog {
+		if errors.Is(err, entry.err) {
+			return entry, true
+		}
+	}
+	return catalogEntry{}, false
+}
+
+// exitError stops the run, which exits with code: the panics of fatal,
+// logFatal and exit unwind the scan up to Main, which prints err, or up
+// to Scan, which returns it.
+type exitError struct {
+	code int
+	err  error
+	// logged errors are printed like log.Fatal prints them, and the others
+	// along with the hint of the catalog.
+	logged bool
+}
+
+// print prints the error which stopped the run, if any, and returns the
+// exit code of the run.
+func (e *exitError) print() int {
+	switch {
+	case e.err == nil:
+	case e.logged:
+		log.Print(e.err)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", e.err)
+		if entry, ok := catalogEntryOf(e.err); ok {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", entry.hint)
+		}
+	}
+	return e.code
+}
+
+// fatal stops the run with the error, which is printed along with its
+// hint, and exits with its exit code. Errors which are not in the catalog
+// exit with 1, like log.Fatal.
+func fatal(err error) {
+	code := 1
+	if entry, ok := catalogEntryOf(err); ok {
+		code = entry.exitCode
+	}
+	panic(&exitError{code: code, err: err})
+}
+
+// logFatal and logFatalf stop the run like log.Fatal and log.Fatalf.
+func logFatal(v ...any) {
+	panic(&exitError{code: 1, err: errors.New(fmt.Sprint(v...)), logged: true})
+}
+
+func logFatalf(format string, v ...any) {
+	panic(&exitError{code: 1, err: fmt.Errorf(format, v...), logged: true})
+}
+
+// exit stops the run, which exits with the code, like os.Exit.
+func exit(code int) {
+	panic(&exitError{code: code})
+}
+
+// catchExit recovers the panic of fatal, logFatal or exit in a goroutine
+// of the scan into e, for the goroutine running the scan to stop it,
+// since a panic can't be recovered by another goroutine.
+func catchExit(e **exitError) {
+	if r := recover(); r != nil {
+		exit, ok := r.(*exitError)
+		if !ok {
+			panic(r)
+		}
+		*e = exit
+	}
+}
+
+// Diagnostic is a non-fatal error met during the run, e.g. a file which
+// was skipped. They are listed by the JSON report.
+type Diagnostic struct {
+	// Code is the code of the error in the catalog, or "error".
+	Code    string `json:"code"`
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// sortDiagnostics orders the diagnostics by file, since the files are
+// scanned concurrently.
+func (sc *scanContext) sortDiagnostics() {
+	sc.diagnosticsMu.Lock()
+	defer sc.diagnosticsMu.Unlock()
+	sort.SliceStable(sc.diagnostics, func(i, j int) bool { return sc.diagnostics[i].File < sc.diagnostics[j].File })
+}
+
+// recordDiagnostic records the non-fatal error met on the file.
+func (sc *scanContext) recordDiagnostic(file string, err error) {
+	diag := Diagnostic{Code: "error", File: file, Message: err.Error()}
+	if entry, ok := catalogEntryOf(err); ok {
+		diag.Code, diag.Hint = entry.code, entry.hint
+	}
+	sc.diagnosticsMu.Lock()
+	defer sc.diagnosticsMu.Unlock()
+	sc.diagnostics = append(sc.diagnostics, diag)
+}
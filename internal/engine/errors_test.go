@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{Offset: 3}
+	tests := []struct {
+		name       string
+		err        error
+		isManifest bool
+		want       error
+	}{
+		{"missing manifest", &fs.PathError{Op: "open", Path: "package.json", Err: fs.ErrNotExist}, true, ErrNoManifest},
+		{"invalid manifest", syntaxErr, true, ErrManifestParse},
+		{"unreadable file", &fs.PathError{Op: "open", Path: "src/a.js", Err: fs.ErrPermission}, false, ErrPermission},
+		{"long line", bufio.ErrTooLong, false, ErrScannerTokenTooLong},
+		{"missing file", &fs.PathError{Op: "open", Path: "src/a.js", Err: fs.ErrNotExist}, false, nil},
+	}
+	for _, tt := range tests {
+		got := classifyError("file", tt.err, tt.isManifest)
+		if tt.want == nil {
+			if _, ok := catalogEntryOf(got); ok {
+				t.Errorf("%s: classifyError() = %v, want no error of the catalog", tt.name, got)
+			}
+			continue
+		}
+		if !errors.Is(got, tt.want) || !errors.Is(got, tt.err) {
+			t.Errorf("%s: classifyError() = %v, want %v wrapping %v", tt.name, got, tt.want, tt.err)
+		}
+	}
+}
+
+func TestRecordDiagnostic(t *testing.T) {
+	sc := newScanContext()
+	defer func() { sc.diagnostics = nil }()
+
+	sc.recordDiagnostic("dist/bundle.js", classifyError("dist/bundle.js", bufio.ErrTooLong, false))
+	sc.recordDiagnostic(historyFile, fmt.Errorf("disk full"))
+	if len(sc.diagnostics) != 2 {
+		t.Fatalf("diagnostics = %+v, want 2", sc.diagnostics)
+	}
+	if d := sc.diagnostics[0]; d.Code != "token-too-long" || d.File != "dist/bundle.js" || d.Hint == "" {
+		t.Errorf("diagnostics[0] = %+v, want a token-too-long diagnostic with a hint", d)
+	}
+	if d := sc.diagnostics[1]; d.Code != "error" || d.Hint != "" {
+		t.Errorf("diagnostics[1] = %+v, want a generic diagnostic", d)
+	}
+}
+
+func TestFatalExitCodes(t *testing.T) {
+	depose := buildDepose(t)
+	tests := []struct {
+		name     string
+		manifest string
+		want     int
+	}{
+		{"no manifest", "", 3},
+		{"invalid manifest", `{"dependencies": {"lodash": "^4.17.21",}}`, 4},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if tt.manifest != "" {
+			if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(tt.manifest), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cmd := exec.Command(depose, "--dry-run", "--no-history")
+		cmd.Dir = dir
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != tt.want {
+			t.Errorf("%s: depose exited with %v, want status %d", tt.name, err, tt.want)
+		}
+	}
+}
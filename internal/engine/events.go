@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// The types of the events streamed with --output ndjson.
+const (
+	EventFileStarted    = "file-started"
+	EventSpecifierFound = "specifier-found"
+	EventFindingEmitted = "finding-emitted"
+	EventSummary        = "summary"
+)
+
+// event is a step of the scan, streamed as a line of JSON with --output
+// ndjson, so wrappers can show the progress of long scans.
+type event struct {
+	Type string `json:"type"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Specifier is the specifier found, or its static prefix when it is
+	// computed at runtime, which Dynamic tells.
+	Specifier string        `json:"specifier,omitempty"`
+	Dynamic   bool          `json:"dynamic,omitempty"`
+	Finding   *Finding      `json:"finding,omitempty"`
+	Summary   *eventSummary `json:"summary,omitempty"`
+}
+
+// eventSummary is the last event of the stream, counting the results of
+// the scan.
+type eventSummary struct {
+	Files        int `json:"files"`
+	Dependencies int `json:"dependencies"`
+	Unused       int `json:"unused"`
+	Missing      int `json:"missing"`
+	Findings     int `json:"findings"`
+	Suppressed   int `json:"suppressed"`
+	Diagnostics  int `json:"diagnostics"`
+}
+
+// eventStream writes the events as soon as they happen, one JSON document
+// per line. The files are scanned concurrently, so the events of
+// different files may interleave, but the events of a file keep their
+// order.
+type eventStream struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newEventStream(w io.Writer) *eventStream {
+	return &eventStream{enc: json.NewEncoder(w)}
+}
+
+// emit writes the event, if events are streamed.
+func (s *eventStream) emit(e event) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(e)
+}
+
+// emitResults writes the findings of the report, then the summary of the
+// scan, which ends the stream.
+func (s *eventStream) emitResults(r *Report, files, dependencies, suppressed int) {
+	if s == nil {
+		return
+	}
+	entry := newHistoryEntry(time.Now(), "", dependencies, r.Findings)
+	for i := range r.Findings {
+		s.emit(event{Type: EventFindingEmitted, Finding: &r.Findings[i]})
+	}
+	s.emit(event{Type: EventSummary, Summary: &eventSummary{
+		Files: files, Dependencies: dependencies, Unused: entry.Unused, Missing: entry.Missing,
+		Findings: entry.Findings, Suppressed: suppressed, Diagnostics: len(r.Diagnostics),
+	}})
+}
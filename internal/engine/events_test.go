@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+)
+
+func TestEventStream(t *testing.T) {
+	var events *eventStream
+	events.emit(event{Type: EventFileStarted}) // no stream, no panic
+
+	var buf bytes.Buffer
+	events = newEventStream(&buf)
+	events.emit(event{Type: EventSpecifierFound, File: "index.js", Line: 3, Specifier: "./locales/", Dynamic: true})
+	r := &Report{Findings: []Finding{
+		{RuleID: RuleUnusedDependency, Package: "moment"},
+		{RuleID: RuleMissingDependency, Package: "lodash"},
+	}}
+	events.emitResults(r, 4, 2, 1)
+
+	want := `{"type":"specifier-found","file":"index.js","line":3,"specifier":"./locales/","dynamic":true}
+{"type":"finding-emitted","finding":{"ruleId":"unused-dependency","severity":"","package":"moment","message":""}}
+{"type":"finding-emitted","finding":{"ruleId":"missing-dependency","severity":"","package":"lodash","message":""}}
+{"type":"summary","summary":{"files":4,"dependencies":2,"unused":1,"missing":1,"findings":2,"suppressed":1,"diagnostics":0}}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("events =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOutputNDJSON(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{"name": "app", "dependencies": {"express": "^4.18.2", "moment": "^2.29.4"}}`,
+		"index.js":     "const express = require(\"express\");\n",
+	})
+	out, err := exec.Command(deposePath, "--output", "ndjson", "--dry-run", "--no-history").Output()
+	if err != nil {
+		t.Fatalf("depose --output ndjson: %v\n%s", err, out)
+	}
+	var types []string
+	var last event
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		var e event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		types = append(types, e.Type)
+		last = e
+	}
+	want := []string{EventFileStarted, EventSpecifierFound, EventFindingEmitted, EventSummary}
+	if len(types) != len(want) {
+		t.Fatalf("events = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("event %d = %s, want %s", i, types[i], want[i])
+		}
+	}
+	if s := last.Summary; s == nil || s.Files != 1 || s.Dependencies != 2 || s.Unused != 1 || s.Findings != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+}
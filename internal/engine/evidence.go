@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Evidence is the kind of evidence a dependency is considered used on.
+type Evidence string
+
+const (
+	// EvidenceImport is an import or require of the package in a source file.
+	EvidenceImport Evidence = "import"
+	// EvidenceSideEffect is an import or require of the package binding
+	// nothing, e.g. import "core-js/stable", loading it for its side effects.
+	EvidenceSideEffect Evidence = "side-effect"
+	// EvidenceTool is a tool directive of go.mod.
+	EvidenceTool Evidence = "tool"
+	// EvidenceScript is an occurrence of the package name in the scripts of package.json.
+	EvidenceScript Evidence = "script"
+	// EvidenceConfig is a string naming the package in a config file.
+	EvidenceConfig Evidence = "config"
+	// EvidenceMarkdown is an import or require of the package in a code
+	// block of a Markdown document, scanned with --include-markdown.
+	EvidenceMarkdown Evidence = "markdown"
+	// EvidenceTypes is a package of DefinitelyTyped holding the types of a
+	// used package, e.g. @types/express, in a TypeScript project.
+	EvidenceTypes Evidence = "types"
+	// EvidenceDynamic is a specifier computed at runtime, whose static
+	// prefix matches the package, kept with --keep-dynamic.
+	EvidenceDynamic Evidence = "dynamic"
+	// EvidenceNative is a package shipping native code, linked into the
+	// app by the autolinking of React Native or Expo.
+	EvidenceNative Evidence = "native"
+	// EvidenceCLI is an executable of the package run by a Makefile or a
+	// shell script.
+	EvidenceCLI Evidence = "cli"
+	// EvidenceDockerfile is an executable of the package run by the RUN,
+	// CMD or ENTRYPOINT instructions of a Dockerfile.
+	EvidenceDockerfile Evidence = "dockerfile"
+)
+
+// Modes of the analysis, selected with the --strict flag.
+const (
+	// ModeStrict only counts concrete evidence, i.e. imports and tool directives.
+	ModeStrict = "strict"
+	// ModeLenient also counts the package names found in scripts and config
+	// files, trading missed unused dependencies for fewer false positives.
+	ModeLenient = "lenient"
+)
+
+// Mode returns the mode in which the evidence counts as usage.
+func (e Evidence) Mode() string {
+	if e == EvidenceImport || e == EvidenceSideEffect || e == EvidenceTool {
+		return ModeStrict
+	}
+	return ModeLenient
+}
+
+// Confidence returns how likely the evidence is a real usage: high for the
+// imports and tool directives, low for the dynamic specifiers and the code
+// blocks of Markdown documents, and medium for the others.
+func (e Evidence) Confidence() string {
+	switch e {
+	case EvidenceImport, EvidenceSideEffect, EvidenceTool:
+		return ConfidenceHigh
+	case EvidenceDynamic, EvidenceMarkdown:
+		return ConfidenceLow
+	}
+	return ConfidenceMedium
+}
+
+// Verdict tells why a declared dependency is considered used.
+type Verdict struct {
+	Package  string   `json:"package"`
+	Evidence Evidence `json:"evidence"`
+	Mode     string   `json:"mode"`
+}
+
+// markAsUsed marks the declared dependency as used because of the evidence
+// found on the location, which is recorded for --explain. The first
+// evidence is kept, unless concrete evidence replaces a lenient
+// one, any other evidence replaces the scripts naming the dependency, or an
+// import binding something replaces a side-effect import. So the script
+// evidence is only kept for the script-only dependencies.
+//
+// The mutex of "d" must be held by the caller.
+func (sc *scanContext) markAsUsed(dependency string, evidence Evidence, loc Location) {
+	sc.d.mp[dependency] = true
+	if sc.d.evidence == nil {
+		sc.d.evidence = make(map[string]Evidence)
+	}
+	if sc.d.trace == nil {
+		sc.d.trace = make(map[string][]evidenceRecord)
+	}
+	sc.d.trace[dependency] = append(sc.d.trace[dependency], evidenceRecord{Detector: evidence, Location: loc, Confidence: evidence.Confidence()})
+	if current, ok := sc.d.evidence[dependency]; !ok || current.Mode() == ModeLenient && evidence.Mode() == ModeStrict ||
+		current == EvidenceScript && evidence != EvidenceScript || current == EvidenceSideEffect && evidence == EvidenceImport {
+		sc.d.evidence[dependency] = evidence
+	}
+}
+
+// verdicts returns why each used dependency is considered used,
+// sorted by package name.
+func (sc *scanContext) verdicts() []Verdict {
+	var result []Verdict
+	for dependency, used := range sc.d.mp {
+		if used {
+			evidence := sc.d.evidence[dependency]
+			result = append(result, Verdict{Package: dependency, Evidence: evidence, Mode: evidence.Mode()})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Package < result[j].Package })
+	return result
+}
+
+// configStringRe matches the quoted strings of a config file.
+var configStringRe = regexp.MustCompile(`["']([^"'\s]+)["']`)
+
+// isConfigFile reports whether the file is a config file, e.g. .babelrc,
+// .eslintrc.json, jest.config.js, .github/workflows/ci.yml, or a file
+// matched by a config resolver such as .storybook/main.js.
+func (sc *scanContext) isConfigFile(file string) bool {
+	name := filepath.Base(file)
+	switch filepath.Ext(name) {
+	case ".json", ".yml", ".yaml", ".toml":
+		return true
+	}
+	return strings.HasPrefix(name, ".") || strings.Contains(name, ".config.") || strings.Contains(name, "rc.") ||
+		len(sc.resolversFor(file)) > 0
+}
+
+// markConfigStrings marks the declared dependencies named by the quoted
+// strings of the config file as used, in lenient mode.
+//
+// Shorthand names are expanded by the resolvers of the tool the file
+// configures, and the shareable configs it extends are followed. The
+// packages of the tool reading the file are used on its first line.
+func (sc *scanContext) markConfigStrings(file string, data []byte) {
+	resolvers := sc.resolversFor(file)
+	sc.markConfigLines(data, resolvers, isYAMLConfig(file), func(lineNo int) Location {
+		return Location{File: file, Line: lineNo}
+	})
+	sc.markConfigTools(resolvers, Location{File: file, Line: 1})
+}
+
+// markConfigTools marks the declared packages of the tools reading the
+// config as used at the location of the config.
+func (sc *scanContext) markConfigTools(resolvers []configResolver, loc Location) {
+	sc.d.mu.Lock()
+	defer sc.d.mu.Unlock()
+	for _, r := range resolvers {
+		for _, tool := range r.tools {
+			if _, declared := sc.d.mp[tool]; declared {
+				sc.markAsUsed(tool, EvidenceConfig, loc)
+				sc.d.usages[tool] = append(sc.d.usages[tool], loc)
+			}
+		}
+	}
+}
+
+// markConfigLines marks the declared dependencies named by the lines of a
+// config, expanded by the resolvers, as used at the location of the line.
+func (sc *scanContext) markConfigLines(data []byte, resolvers []configResolver, yaml bool, locate func(lineNo int) Location) {
+	visited := make(map[string]bool)
+	for lineNo, line := range strings.Split(string(data), "\n") {
+		loc := locate(lineNo + 1)
+		for _, name := range configNames(line, yaml, resolvers) {
+			for _, candidate := range expandConfigName(name, resolvers) {
+				dependency, ok := sc.lang.normalize(candidate)
+				if !ok {
+					continue
+				}
+				sc.d.mu.Lock()
+				if _, declared := sc.d.mp[dependency]; declared {
+					sc.markAsUsed(dependency, EvidenceConfig, loc)
+					sc.d.usages[dependency] = append(sc.d.usages[dependency], loc)
+				}
+				sc.d.mu.Unlock()
+				for _, r := range resolvers {
+					sc.followConfigPackage(dependency, r, loc, visited)
+				}
+			}
+		}
+	}
+}
+
+// isMarkdownFile reports whether the file is a Markdown document, whose
+// code blocks are only scanned with --include-markdown. MDX documents are
+// scanned as code.
+func isMarkdownFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// markMarkdownSpecifier marks the declared dependency imported by a code
+// block of a Markdown document as used on the location, in lenient mode,
+// with a low confidence: the examples of the docs may import packages the
+// project itself doesn't use. The undeclared packages are never reported
+// as missing.
+func (sc *scanContext) markMarkdownSpecifier(specifier string, loc Location) {
+	sc.d.mu.Lock()
+	defer sc.d.mu.Unlock()
+
+	dependency, ok := sc.lang.normalize(specifier)
+	if !ok || sc.strict {
+		return
+	}
+	if _, declared := sc.d.mp[dependency]; declared {
+		sc.markAsUsed(dependency, EvidenceMarkdown, loc)
+		loc.LowConfidence = true
+		sc.d.usages[dependency] = append(sc.d.usages[dependency], loc)
+	}
+}
+
+// markDynamicSpecifier records a specifier computed at runtime, found on
+// the location, along with its static prefix.
+//
+// With --keep-dynamic, the declared dependencies whose name starts with the
+// prefix are marked as used, e.g. every "eslint-plugin-*" dependency for
+// require("eslint-plugin-" + name). Local prefixes, e.g. "./locales/", and
+// empty ones keep nothing.
+func (sc *scanContext) markDynamicSpecifier(prefix string, loc Location) {
+	sc.d.mu.Lock()
+	defer sc.d.mu.Unlock()
+
+	if sc.d.dynamic == nil {
+		sc.d.dynamic = make(map[Location]string)
+	}
+	sc.d.dynamic[loc] = prefix
+
+	if !sc.keepDynamic || sc.strict || prefix == "" || isLocalSpecifier(prefix) {
+		return
+	}
+	for dependency := range sc.d.mp {
+		if strings.HasPrefix(dependency, prefix) {
+			sc.markAsUsed(dependency, EvidenceDynamic, loc)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsConfigFile(t *testing.T) {
+	sc := newScanContext()
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{".babelrc", true},
+		{"config/.eslintrc.js", true},
+		{"jest.config.ts", true},
+		{".github/workflows/ci.yml", true},
+		{"tsconfig.json", true},
+		{"src/index.js", false},
+		{"src/config.js", false},
+		{".storybook/main.ts", true},
+		{".storybook/preview.js", false},
+		{"src/main.js", false},
+	}
+	for _, tt := range tests {
+		if got := sc.isConfigFile(tt.file); got != tt.want {
+			t.Errorf("isConfigFile(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestVerdicts(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"express": false, "jest": false, "@babel/preset-env": false, "pg": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.d.evidence = nil
+	defer func() { sc.lang, sc.d.mp, sc.d.usages, sc.d.evidence = nil, nil, nil, nil }()
+
+	sc.markConfigStrings(".babelrc", []byte(`{"presets": ["@babel/preset-env/lib", "unknown"]}`))
+	sc.markConfigStrings("jest.config.js", []byte(`module.exports = { preset: "express" }`))
+	sc.markModuleAsFound("express", Location{File: "server.js", Line: 1})
+	sc.d.mu.Lock()
+	sc.markAsUsed("jest", EvidenceScript, Location{File: "package.json", Line: 3})
+	sc.d.mu.Unlock()
+	sc.markConfigStrings("ci.yml", []byte(`run: "jest"`))
+
+	want := []Verdict{
+		{Package: "@babel/preset-env", Evidence: EvidenceConfig, Mode: ModeLenient},
+		{Package: "express", Evidence: EvidenceImport, Mode: ModeStrict},
+		{Package: "jest", Evidence: EvidenceConfig, Mode: ModeLenient},
+	}
+	if got := sc.verdicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("verdicts() = %v, want %v", got, want)
+	}
+	if _, ok := sc.d.usages["unknown"]; ok {
+		t.Errorf("undeclared package of a config file was recorded as used")
+	}
+}
+
+func TestSideEffectVerdicts(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"core-js": false, "dotenv": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.d.evidence = nil
+	defer func() { sc.lang, sc.d.mp, sc.d.usages, sc.d.evidence = nil, nil, nil, nil }()
+
+	sc.markModuleAsFound("core-js/stable", Location{File: "src/index.js", Line: 1, SideEffect: true})
+	sc.markModuleAsFound("dotenv", Location{File: "src/index.js", Line: 2, SideEffect: true})
+	sc.markModuleAsFound("dotenv", Location{File: "src/env.js", Line: 1})
+
+	want := []Verdict{
+		{Package: "core-js", Evidence: EvidenceSideEffect, Mode: ModeStrict},
+		{Package: "dotenv", Evidence: EvidenceImport, Mode: ModeStrict},
+	}
+	if got := sc.verdicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("verdicts() = %v, want %v", got, want)
+	}
+	var out strings.Builder
+	if err := sc.reportText(&out, &Report{Usage: sc.d.usages}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "    at src/index.js:1 (side-effect usage)\n") {
+		t.Errorf("reportText() did not label the side-effect usage:\n%s", out.String())
+	}
+}
+
+func TestMarkDynamicSpecifier(t *testing.T) {
+	sc := newScanContext()
+	sc.d.mp = map[string]bool{"eslint-plugin-react": false, "eslint-plugin-vue": false, "eslint": false}
+	sc.d.dynamic = nil
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d.mp, sc.d.dynamic, sc.d.usages, sc.d.evidence, sc.keepDynamic = nil, nil, nil, nil, false }()
+
+	sc.markDynamicSpecifier("eslint-plugin-", Location{File: "a.js", Line: 1})
+	if sc.d.mp["eslint-plugin-react"] {
+		t.Errorf("dependencies were kept without --keep-dynamic")
+	}
+
+	sc.keepDynamic = true
+	sc.markDynamicSpecifier("eslint-plugin-", Location{File: "a.js", Line: 2})
+	sc.markDynamicSpecifier("./locales/", Location{File: "a.js", Line: 3})
+	want := map[string]bool{"eslint-plugin-react": true, "eslint-plugin-vue": true, "eslint": false}
+	if !reflect.DeepEqual(sc.d.mp, want) {
+		t.Errorf("d.mp = %v, want %v", sc.d.mp, want)
+	}
+
+	sc.lang, sc.manifestFile = sc.nodeLanguage, "package.json"
+	defer func() { sc.lang, sc.manifestFile = nil, "" }()
+	var got []string
+	for _, f := range sc.buildFindings("app") {
+		if f.RuleID == RuleDynamicImport {
+			got = append(got, f.Package)
+		}
+	}
+	if want := []string{"./locales/", "eslint-plugin-"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dynamic findings = %v, want %v", got, want)
+	}
+}
+
+func TestMarkdownCodeBlocks(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"docs/guide.md": "# Guide\n\nRequire \"express\" to serve it:\n\n```js\nimport { z } from \"zod\";\nconst pad = require(\"left-pad\");\n```\n",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"zod": false, "express": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.d.ignoredLines = make(map[Location][]string)
+	defer func() { sc.d, sc.lang, sc.includeMarkdown = Dependency{}, nil, false }()
+
+	if _, ok := sc.extractorFor("docs/guide.md"); ok {
+		t.Errorf("Markdown documents are scanned without --include-markdown")
+	}
+	sc.includeMarkdown = true
+	extractor, ok := sc.extractorFor("docs/guide.md")
+	if !ok {
+		t.Fatal("Markdown documents aren't scanned with --include-markdown")
+	}
+	sc.readFileAndExtractPackages(sc.logOut, "docs/guide.md", extractor)
+
+	if !sc.d.mp["zod"] || sc.d.evidence["zod"] != EvidenceMarkdown || sc.d.mp["express"] {
+		t.Errorf("d.mp = %v, evidence = %v", sc.d.mp, sc.d.evidence)
+	}
+	want := map[string][]Location{"zod": {{File: "docs/guide.md", Line: 6, LowConfidence: true}}}
+	if !reflect.DeepEqual(sc.d.usages, want) {
+		t.Errorf("usages = %v, want %v", sc.d.usages, want)
+	}
+}
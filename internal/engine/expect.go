@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// checkCommand implements "depose check", which is the same as --check,
+// and compares the findings to an expected report with --expect, failing
+// on drift: findings which aren't expected, and expected findings which
+// are no longer found. The expected report is a JSON report, committed
+// and reviewed like the code, e.g. to codify the unused dependencies which
+// are intentional:
+//
+//	depose check --expect expected-report.json --update
+//	depose check --expect expected-report.json
+//
+// The flags of the main command are accepted too, e.g. --strict.
+func (sc *scanContext) checkCommand(fs *flag.FlagSet) func(args []string) {
+	sc.defineFlags(fs)
+	fs.Set("check", "true")
+	fs.StringVar(&sc.expectFile, "expect", "", "expected report, e.g. written by \"depose scan\", the findings are compared to instead of the baseline")
+	fs.BoolVar(&sc.updateExpect, "update", false, "write the current findings into the expected report of --expect")
+	return func(args []string) {
+		if sc.updateExpect && sc.expectFile == "" {
+			logFatal("--update needs the expected report, given with --expect")
+		}
+		sc.run()
+	}
+}
+
+// excludeExpectedReport leaves the expected report out of the scan, when it
+// is in the project, since it names the packages of its findings.
+func (sc *scanContext) excludeExpectedReport() {
+	path := sc.expectFile
+	if filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		if path, err = filepath.Rel(wd, path); err != nil {
+			return
+		}
+	}
+	sc.filesToExclude[filepath.ToSlash(filepath.Clean(path))] = 0
+}
+
+// readExpectedReport reads the findings of the expected report into a set
+// of entries, which leave the locations out, like the ones of the baseline.
+func readExpectedReport(path string) (map[baselineEntry]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	entries := make(map[baselineEntry]bool)
+	for _, f := range r.Findings {
+		entries[baselineEntry{f.RuleID, f.Package, f.Section}] = true
+	}
+	return entries, nil
+}
+
+// writeExpectedReport writes the findings as the JSON report expected by
+// depose check --expect.
+func writeExpectedReport(path string, findings []Finding) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := reportJSON(f, &Report{Findings: findings}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
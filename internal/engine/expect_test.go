@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCheckExpect(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"dependencies\": {\n    \"express\": \"^4.18.2\",\n    \"lodash\": \"^4.17.21\",\n    \"moment\": \"^2.29.4\"\n  }\n}\n",
+		"index.js":     "require(\"express\");\n",
+	})
+	check := func() (string, error) {
+		out, err := exec.Command(deposePath, "check", "--no-history", "--expect", "expected-report.json").CombinedOutput()
+		return string(out), err
+	}
+
+	if out, err := exec.Command(deposePath, "check", "--no-history", "--expect", "expected-report.json", "--update").CombinedOutput(); err != nil {
+		t.Fatalf("depose check --update failed: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile("expected-report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"package": "lodash"`) || !strings.Contains(string(data), `"package": "moment"`) {
+		t.Errorf("expected report misses the unused dependencies:\n%s", data)
+	}
+	if out, err := check(); err != nil {
+		t.Fatalf("depose check failed on the expected findings: %v\n%s", err, out)
+	}
+
+	// lodash is now used, so its expected finding is gone.
+	writeFiles(t, map[string]string{"index.js": "require(\"express\");\nrequire(\"lodash\");\n"})
+	out, err := check()
+	if err == nil || !strings.Contains(out, `Expected finding [unused-dependency] "lodash" is no longer found`) ||
+		!strings.Contains(out, "1 expected finding(s) no longer found") {
+		t.Errorf("depose check passed without the expected finding of lodash: %v\n%s", err, out)
+	}
+
+	// express is now unused, which isn't expected.
+	writeFiles(t, map[string]string{"index.js": "require(\"lodash\");\n"})
+	out, err = check()
+	if err == nil || !strings.Contains(out, "1 unused-dependency finding(s)") {
+		t.Errorf("depose check passed with an unexpected finding: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// evidenceRecord is a piece of evidence found for a declared dependency:
+// the detector which found it, i.e. the kind of evidence, its location,
+// and its confidence.
+type evidenceRecord struct {
+	Detector   Evidence `json:"detector"`
+	Location   Location `json:"location"`
+	Confidence string   `json:"confidence"`
+}
+
+// Explanation tells how the verdict on a declared dependency was reached,
+// for --explain: where it is declared, the evidence found for it, and the
+// verdict.
+type Explanation struct {
+	Package  string           `json:"package"`
+	Section  string           `json:"section"`
+	Declared Location         `json:"declared"`
+	Evidence []evidenceRecord `json:"evidence"`
+	Verdict  string           `json:"verdict"`
+}
+
+// explanations returns the explanations of the declared dependencies,
+// sorted by name, given the findings and the suppressed findings of the
+// run. The evidence of each dependency is sorted by location.
+func (sc *scanContext) explanations(findings, suppressed []Finding) []Explanation {
+	unused := make(map[string]Finding)
+	for _, f := range findings {
+		if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+			unused[f.Package] = f
+		}
+	}
+	suppressions := make(map[string]Finding)
+	for _, f := range suppressed {
+		if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+			suppressions[f.Package] = f
+		}
+	}
+
+	declared := sc.lang.declaredLines()
+	var result []Explanation
+	for dependency, used := range sc.d.mp {
+		section := sc.lang.sectionOf(dependency)
+		e := Explanation{
+			Package:  dependency,
+			Section:  section,
+			Declared: Location{File: sc.manifestFile, Line: declared[section][dependency]},
+			Evidence: append([]evidenceRecord(nil), sc.d.trace[dependency]...),
+		}
+		sort.SliceStable(e.Evidence, func(i, j int) bool {
+			a, b := e.Evidence[i].Location, e.Evidence[j].Location
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			return a.Line < b.Line
+		})
+		// The same evidence may be found twice on a line, e.g. by the
+		// commands of a script and by its words.
+		e.Evidence = slices.Compact(e.Evidence)
+		switch f, isUnused := unused[dependency]; {
+		case used:
+			evidence := sc.d.evidence[dependency]
+			e.Verdict = fmt.Sprintf("kept on %s evidence (%s)", evidence, evidence.Mode())
+		case isUnused:
+			e.Verdict = fmt.Sprintf("unused, reported as %s: %s", f.RuleID, f.Message)
+		case suppressions[dependency].RuleID != "":
+			e.Verdict = "kept: unused, but suppressed by " + suppressions[dependency].Suppression
+		default:
+			e.Verdict = "kept: unused, but not reported, e.g. filtered out"
+		}
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Package < result[j].Package })
+	return result
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExplanations(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"dependencies\": {\n    \"express\": \"^4.0.0\",\n    \"left-pad\": \"^1.0.0\",\n    \"moment\": \"^2.0.0\"\n  }\n}\n",
+	})
+	sc.lang, sc.manifestFile = sc.nodeLanguage, "package.json"
+	sc.manifest = Package{Dependencies: map[string]string{"express": "^4.0.0", "left-pad": "^1.0.0", "moment": "^2.0.0"}}
+	sc.d.mp = map[string]bool{"express": false, "left-pad": false, "moment": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d, sc.lang, sc.manifestFile, sc.manifest = Dependency{}, nil, "", Package{} }()
+
+	sc.markModuleAsFound("express", Location{File: "src/server.js", Line: 3})
+	sc.d.mu.Lock()
+	sc.markAsUsed("express", EvidenceConfig, Location{File: ".eslintrc.json", Line: 2})
+	sc.markAsUsed("express", EvidenceConfig, Location{File: ".eslintrc.json", Line: 2})
+	sc.d.mu.Unlock()
+	unused := sc.newFinding(RuleUnusedDependency, "left-pad", "dependencies", `"left-pad" is declared in dependencies but never used`, nil, "")
+	suppressed := sc.newFinding(RuleUnusedDependency, "moment", "dependencies", `"moment" is declared in dependencies but never used`, nil, "")
+	suppressed.Suppression = "ignored by the config"
+
+	got := sc.explanations([]Finding{unused}, []Finding{suppressed})
+	want := []Explanation{
+		{Package: "express", Section: "dependencies", Declared: Location{File: "package.json", Line: 3}, Evidence: []evidenceRecord{
+			{Detector: EvidenceConfig, Location: Location{File: ".eslintrc.json", Line: 2}, Confidence: ConfidenceMedium},
+			{Detector: EvidenceImport, Location: Location{File: "src/server.js", Line: 3}, Confidence: ConfidenceHigh},
+		}, Verdict: "kept on import evidence (strict)"},
+		{Package: "left-pad", Section: "dependencies", Declared: Location{File: "package.json", Line: 4},
+			Verdict: `unused, reported as unused-dependency: "left-pad" is declared in dependencies but never used`},
+		{Package: "moment", Section: "dependencies", Declared: Location{File: "package.json", Line: 5},
+			Verdict: "kept: unused, but suppressed by ignored by the config"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("explanations() = %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := sc.reportText(&buf, &Report{Explanations: got[1:2]}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Explanations:\n  left-pad\n    declared in dependencies at package.json:4\n    no evidence\n    verdict: unused"; !strings.HasPrefix(buf.String(), "No findings.\n"+want) {
+		t.Errorf("reportText() = %q", buf.String())
+	}
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+// unusedExport is a symbol exported by a file, which no other file imports.
+type unusedExport struct {
+	Location
+	Name string
+}
+
+// findUnusedExports returns the symbols exported by the source files which
+// no other file of the project imports, sorted by location.
+//
+// The exports of the entrypoints are the public API of the project, so they
+// are never reported. Files imported with a namespace import, a plain
+// require() call or a re-export with "*" have all their exports used.
+func (sc *scanContext) findUnusedExports(files []string, entries []string) []unusedExport {
+	symbols := make(map[string]extract.Symbols)
+	for _, file := range files {
+		if !sourceExtensions[filepath.Ext(file)] {
+			continue
+		}
+		extractor, ok := extract.For("node", file)
+		if !ok {
+			continue
+		}
+		symbolExtractor, ok := extractor.(extract.SymbolExtractor)
+		if !ok {
+			continue
+		}
+		f, err := sc.openProjectFile(file)
+		if err != nil {
+			continue
+		}
+		s, err := symbolExtractor.Symbols(f)
+		f.Close()
+		if err == nil {
+			symbols[filepath.ToSlash(file)] = s
+		}
+	}
+
+	used := make(map[string]map[string]bool)
+	allUsed := make(map[string]bool)
+	for file, s := range symbols {
+		for _, imp := range s.Imports {
+			for _, target := range sc.localTargets(file, imp.Path) {
+				if imp.All {
+					allUsed[target] = true
+					continue
+				}
+				if used[target] == nil {
+					used[target] = make(map[string]bool)
+				}
+				for _, name := range imp.Names {
+					used[target][name] = true
+				}
+			}
+		}
+	}
+
+	public := make(map[string]bool)
+	for _, entry := range entries {
+		public[filepath.ToSlash(filepath.Clean(entry))] = true
+	}
+
+	var unused []unusedExport
+	for file, s := range symbols {
+		if public[file] || allUsed[file] {
+			continue
+		}
+		for _, e := range s.Exports {
+			if !used[file][e.Name] {
+				unused = append(unused, unusedExport{Location{File: file, Line: e.Line}, e.Name})
+			}
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		if unused[i].File != unused[j].File {
+			return unused[i].File < unused[j].File
+		}
+		return unused[i].Line < unused[j].Line
+	})
+	return unused
+}
+
+// localTargets returns the local files an import of the file refers to,
+// following "#" specifiers through the "imports" field of package.json.
+func (sc *scanContext) localTargets(file, specifier string) []string {
+	from, specifiers := file, []string{specifier}
+	if internal, ok := sc.packageImportsTargets(specifier); ok {
+		from, specifiers = "package.json", internal
+	}
+
+	var targets []string
+	for _, s := range specifiers {
+		if !isLocalSpecifier(s) {
+			continue
+		}
+		if target, ok := sc.resolveLocalFile(from, s); ok {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// exportsCommand implements "depose exports", which lists the symbols exported
+// by the source files of the project which no other file imports.
+//
+//	depose exports --entry src/index.ts
+func (sc *scanContext) exportsCommand(fs *flag.FlagSet) func(args []string) {
+	var entries stringList
+	fs.Var(&entries, "entry", "entrypoint or pattern of entrypoints whose exports are public, in addition to the ones of package.json; can be repeated")
+	return func(args []string) {
+		sc.logOut = os.Stderr
+		sc.lang = sc.nodeLanguage
+		sc.loadManifest()
+
+		if err := sc.walkProject(); err != nil {
+			fmt.Fprintf(sc.logOut, "Error scanning the directory %v:\n", err)
+		}
+		var roots []string
+		for _, root := range sc.entrypoints(entries, sc.files) {
+			if !sc.isConfigFile(root) {
+				roots = append(roots, root)
+			}
+		}
+
+		unused := sc.findUnusedExports(sc.files, roots)
+		for _, e := range unused {
+			fmt.Printf("%s %s\n", e.Location, e.Name)
+		}
+		fmt.Fprintf(sc.logOut, "%d unused export(s)\n", len(unused))
+	}
+}
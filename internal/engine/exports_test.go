@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindUnusedExports(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"src/index.ts":   "import { formatDate } from \"./dates\";\nimport * as strings from \"./strings\";\nimport db from \"#db\";\nexport const version = 1;\n",
+		"src/dates.ts":   "export function formatDate() {}\nexport function parseDate() {}\n",
+		"src/strings.ts": "export const pad = 1;\n",
+		"src/db.js":      "module.exports = {};\nmodule.exports.close = () => {};\n",
+		"src/dead.js":    "export default function dead() {}\n",
+	})
+	sc.manifest = Package{Imports: map[string]any{"#db": "./src/db.js"}}
+	defer func() { sc.manifest = Package{} }()
+
+	files := []string{"src/dates.ts", "src/db.js", "src/dead.js", "src/index.ts", "src/strings.ts"}
+	want := []unusedExport{
+		{Location{File: "src/dates.ts", Line: 2}, "parseDate"},
+		{Location{File: "src/db.js", Line: 2}, "close"},
+		{Location{File: "src/dead.js", Line: 1}, "default"},
+	}
+	if got := sc.findUnusedExports(files, []string{"src/index.ts"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("findUnusedExports() = %v, want %v", got, want)
+	}
+}
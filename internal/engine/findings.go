@@ -0,0 +1,325 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule IDs are stable identifiers attached to every finding.
+//
+// They never change between releases, so downstream tools can rely on them
+// to suppress or route specific kinds of findings.
+const (
+	RuleUnusedDependency    = "unused-dependency"
+	RuleUnusedDevDependency = "unused-dev-dependency"
+	RuleMissingDependency   = "missing-dependency"
+	RulePhantomDependency   = "phantom-dependency"
+	RuleUnresolvedImport    = "unresolved-import"
+	RuleOrphanedFile        = "orphaned-file"
+	RuleDynamicImport       = "dynamic-import"
+	RuleBannedDependency    = "banned-dependency"
+	RuleRequiredDependency  = "required-dependency"
+	RuleStaleScript         = "stale-script"
+	RuleStaleConfig         = "stale-config"
+	RuleMisplacedDependency = "misplaced-dependency"
+	RuleStaleOverride       = "stale-override"
+	RuleInconsistentRange   = "inconsistent-range"
+)
+
+// Severity represents how serious a finding is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNote    Severity = "note"
+)
+
+// Rule describes a kind of finding depose can report.
+type Rule struct {
+	ID              string   `json:"id"`
+	Description     string   `json:"description"`
+	DefaultSeverity Severity `json:"defaultSeverity"`
+}
+
+// ruleByID returns the rule registered with the given ID.
+func (sc *scanContext) ruleByID(id string) (Rule, bool) {
+	for _, r := range sc.rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Location points to a line in a file, relative to the project root.
+type Location struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+	// SideEffect is true when the package is loaded there for its side
+	// effects only, e.g. import "core-js/stable".
+	SideEffect bool `json:"sideEffect,omitempty"`
+	// LowConfidence is true when the package is only used there by a code
+	// block of a Markdown document, scanned with --include-markdown.
+	LowConfidence bool `json:"lowConfidence,omitempty"`
+}
+
+func (l Location) String() string {
+	if l.Line == 0 {
+		return l.File
+	}
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// Finding is a single problem detected in the project.
+//
+// Every reporter renders the same findings, so what is shown in the
+// terminal, in JSON, in SARIF, or as GitHub annotations is always consistent.
+type Finding struct {
+	RuleID       string     `json:"ruleId"`
+	Severity     Severity   `json:"severity"`
+	Package      string     `json:"package"`
+	Section      string     `json:"section,omitempty"`
+	Message      string     `json:"message"`
+	Locations    []Location `json:"locations,omitempty"`
+	SuggestedFix string     `json:"suggestedFix,omitempty"`
+	// Suppression describes why the finding was suppressed, if it was.
+	Suppression string `json:"suppression,omitempty"`
+	// LastReference is the commit which last referenced an unused
+	// dependency. It is only populated with --last-referenced.
+	LastReference *lastReference `json:"lastReference,omitempty"`
+	// Owners are the owners of the first location of the finding, read
+	// from CODEOWNERS. It is only populated with --group-by owner.
+	Owners []string `json:"owners,omitempty"`
+	// ManagedBy are the update bots updating the dependency, e.g.
+	// Renovate. It is only populated with --update-bots.
+	ManagedBy []botManagement `json:"managedBy,omitempty"`
+	// Confidence is how likely the finding is right: high, medium or low.
+	// It is only populated with --group-by confidence.
+	Confidence string `json:"confidence,omitempty"`
+}
+
+// buildFindings turns the state collected while scanning into findings.
+//
+// Declared dependencies which were never marked as used are reported as
+// unused, while bare packages found in the source files but missing from
+// package.json are reported either as phantom dependencies (when they are
+// installed anyway, e.g. hoisted from another package) or as missing ones.
+// The severity of the phantom dependencies depends on the layout of
+// node_modules, see phantomSeverity.
+//
+// The project name is the name of the scanned project itself,
+// which is never reported as missing.
+//
+// Specifiers which the installed packages do not provide, e.g. subpaths
+// missing from the "exports" of the package, are reported as unresolved,
+// and the ones computed at runtime are reported as dynamic.
+//
+// The locations of every usage are sorted first, so that
+// both the findings and the usage map are deterministic.
+func (sc *scanContext) buildFindings(projectName string) []Finding {
+	for _, locations := range sc.d.usages {
+		sortLocations(locations)
+	}
+	declared := sc.lang.declaredLines()
+
+	var findings []Finding
+	for dependency, used := range sc.d.mp {
+		if used {
+			continue
+		}
+		section := sc.lang.sectionOf(dependency)
+		message := fmt.Sprintf("%q is declared in %s but never used", dependency, section)
+		if sc.d.evidence[dependency] == EvidenceScript {
+			// Reported with --treat-script-only-as-unused.
+			message = fmt.Sprintf("%q is declared in %s but only named by the scripts of package.json", dependency, section)
+		} else if version, ok := sc.localVersion(dependency); ok {
+			message = fmt.Sprintf("%q is a local package (%s) declared in %s but never used", dependency, version, section)
+		}
+		findings = append(findings, sc.newFinding(sc.lang.unusedRule(section), dependency, section, message,
+			[]Location{{File: sc.manifestFile, Line: declared[section][dependency]}},
+			sc.lang.removeFix(dependency, section)))
+	}
+
+	for pkgName, locations := range sc.d.usages {
+		if _, ok := sc.d.mp[pkgName]; ok || pkgName == projectName || sc.lang.isBuiltin(pkgName) {
+			continue
+		}
+		if sc.lang.isInstalled(pkgName) {
+			// The layout of node_modules decides whether it is hoisted on
+			// purpose, or only resolves by chance.
+			severity, reason := sc.layout.phantomSeverity(pkgName)
+			message := fmt.Sprintf("%q is used but only installed as a transitive dependency", pkgName)
+			if reason != "" {
+				message += "; " + reason
+			}
+			f := sc.newFinding(RulePhantomDependency, pkgName, "", message, locations, sc.lang.phantomFix(pkgName))
+			f.Severity = severity
+			findings = append(findings, f)
+			continue
+		}
+		message, fix := fmt.Sprintf("%q is used but not declared in %s", pkgName, sc.manifestFile), sc.lang.addFix(pkgName)
+		if aliases := sc.aliasesOf(pkgName); len(aliases) > 0 {
+			// The aliased package is only installed under its alias.
+			message = fmt.Sprintf("%q is used but only declared as the alias %q in %s", pkgName, aliases[0], sc.manifestFile)
+			fix = fmt.Sprintf("import it as %q, or %s", aliases[0], fix)
+		} else if len(sc.importMaps.files) > 0 {
+			// The project maps its bare specifiers, which this one misses.
+			message = fmt.Sprintf("%q is used but neither declared in %s nor mapped by %s", pkgName, sc.manifestFile, strings.Join(sc.importMaps.files, ", "))
+			fix += ", or map it in " + sc.importMaps.files[0]
+		}
+		findings = append(findings, sc.newFinding(RuleMissingDependency, pkgName, "", message, locations, fix))
+	}
+
+	for specifier, locations := range sc.d.unresolved {
+		sortLocations(locations)
+		pkgName := packageName(specifier)
+		findings = append(findings, sc.newFinding(RuleUnresolvedImport, pkgName, "",
+			fmt.Sprintf("%q is not provided by the installed %q package", specifier, pkgName),
+			locations, fmt.Sprintf("use a path exported by %q", pkgName)))
+	}
+
+	dynamic := make(map[string][]Location)
+	for loc, prefix := range sc.d.dynamic {
+		dynamic[prefix] = append(dynamic[prefix], loc)
+	}
+	for prefix, locations := range dynamic {
+		sortLocations(locations)
+		message, fix := "specifier computed at runtime can't be resolved", "use a static specifier"
+		if prefix != "" {
+			message = fmt.Sprintf("specifier computed at runtime, starting with %q, can't be resolved", prefix)
+		}
+		if prefix != "" && !isLocalSpecifier(prefix) {
+			fix = fmt.Sprintf("use a static specifier, or run with --keep-dynamic to keep the dependencies starting with %q", prefix)
+		}
+		findings = append(findings, sc.newFinding(RuleDynamicImport, prefix, "", message, locations, fix))
+	}
+
+	sc.sortFindings(findings)
+	return findings
+}
+
+// orphanedFileFindings reports the source files which are not
+// reachable from any entrypoint.
+func (sc *scanContext) orphanedFileFindings(orphans []string) []Finding {
+	var findings []Finding
+	for _, file := range orphans {
+		findings = append(findings, sc.newFinding(RuleOrphanedFile, file, "",
+			fmt.Sprintf("%s is not reachable from any entrypoint", file),
+			[]Location{{File: file}}, "delete the file, or add it with --entry"))
+	}
+	return findings
+}
+
+// newFinding creates a finding using the default severity of its rule.
+func (sc *scanContext) newFinding(ruleID, pkgName, section, message string, locations []Location, fix string) Finding {
+	rule, _ := sc.ruleByID(ruleID)
+	return Finding{
+		RuleID:       ruleID,
+		Severity:     rule.DefaultSeverity,
+		Package:      pkgName,
+		Section:      section,
+		Message:      message,
+		Locations:    locations,
+		SuggestedFix: fix,
+	}
+}
+
+// sortLocations orders locations by file, then by line.
+func sortLocations(locations []Location) {
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].File != locations[j].File {
+			return locations[i].File < locations[j].File
+		}
+		return locations[i].Line < locations[j].Line
+	})
+}
+
+// sortFindings orders findings by rule, then by package name, then by
+// their first location and message, so the reports are the same from one
+// run to the next.
+func (sc *scanContext) sortFindings(findings []Finding) {
+	order := make(map[string]int, len(sc.rules))
+	for i, r := range sc.rules {
+		order[r.ID] = i
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].RuleID != findings[j].RuleID {
+			return order[findings[i].RuleID] < order[findings[j].RuleID]
+		}
+		if findings[i].Package != findings[j].Package {
+			return findings[i].Package < findings[j].Package
+		}
+		if li, lj := firstLocation(findings[i]), firstLocation(findings[j]); li != lj {
+			return li.File < lj.File || li.File == lj.File && li.Line < lj.Line
+		}
+		return findings[i].Message < findings[j].Message
+	})
+}
+
+// firstLocation returns the first location of the finding, if any.
+func firstLocation(f Finding) Location {
+	if len(f.Locations) == 0 {
+		return Location{}
+	}
+	return f.Locations[0]
+}
+
+// sectionOf returns the package.json section a dependency is declared in.
+func (sc *scanContext) sectionOf(dependency string) string {
+	if _, ok := sc.manifest.Dependencies[dependency]; ok {
+		return "dependencies"
+	}
+	return "devDependencies"
+}
+
+// isInstalled reports whether the package is present in node_modules.
+func (sc *scanContext) isInstalled(pkgName string) bool {
+	_, err := sc.statProjectFile(filepath.Join("node_modules", filepath.FromSlash(pkgName), "package.json"))
+	return err == nil
+}
+
+var (
+	sectionLineRe = regexp.MustCompile(`^\s*"([^"]+)"\s*:\s*\{`)
+	keyLineRe     = regexp.MustCompile(`^\s*"([^"]+)"\s*:`)
+)
+
+// declaredLines reads the manifest and returns the line number on which
+// each key is declared, grouped by the top-level object it belongs to.
+//
+// Example: declaredLines("package.json")["devDependencies"]["jest"] == 21
+func (sc *scanContext) declaredLines(file string) map[string]map[string]int {
+	data, err := sc.readProjectFile(file)
+	if err != nil {
+		return make(map[string]map[string]int)
+	}
+	return declaredLinesOf(data)
+}
+
+// declaredLinesOf is like declaredLines, for the content of the manifest.
+func declaredLinesOf(data []byte) map[string]map[string]int {
+	lines := make(map[string]map[string]int)
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if m := sectionLineRe.FindStringSubmatch(line); m != nil {
+			section = m[1]
+			lines[section] = make(map[string]int)
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "}") {
+			section = ""
+			continue
+		}
+		if m := keyLineRe.FindStringSubmatch(line); m != nil && section != "" {
+			lines[section][m[1]] = lineNo
+		}
+	}
+	return lines
+}
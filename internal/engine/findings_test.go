@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t testing.TB, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestBuildFindings(t *testing.T) {
+	sc := newScanContext()
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	packageJSON := `{
+  "name": "my-app",
+  "dependencies": {
+    "express": "^4.18.2",
+    "pg": "^8.11.0"
+  },
+  "devDependencies": {
+    "jest": "^29.7.0"
+  }
+}
+`
+	if err := os.WriteFile("package.json", []byte(packageJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// "debug" is installed as a dependency of express, without being declared.
+	if err := os.MkdirAll(filepath.Join("node_modules", "debug"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("node_modules", "debug", "package.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sc.lang, sc.manifestFile = sc.nodeLanguage, "package.json"
+	sc.manifest = Package{
+		Name:            "my-app",
+		Dependencies:    map[string]string{"express": "^4.18.2", "pg": "^8.11.0"},
+		DevDependencies: map[string]string{"jest": "^29.7.0"},
+	}
+	sc.d.mp = map[string]bool{"express": false, "pg": false, "jest": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.lang, sc.manifestFile, sc.manifest, sc.d.mp, sc.d.usages = nil, "", Package{}, nil, nil }()
+
+	for i, specifier := range []string{
+		"express",
+		"fs",
+		"node:path",
+		"fs/promises",
+		"my-app/lib/util",
+		"debug",
+		"zod",
+		"./local",
+	} {
+		sc.markModuleAsFound(specifier, Location{File: "src/app.js", Line: i + 1})
+	}
+
+	type result struct {
+		RuleID    string
+		Package   string
+		Section   string
+		Locations []Location
+	}
+	var got []result
+	for _, f := range sc.buildFindings(sc.manifest.Name) {
+		got = append(got, result{f.RuleID, f.Package, f.Section, f.Locations})
+	}
+	want := []result{
+		{RuleUnusedDependency, "pg", "dependencies", []Location{{File: "package.json", Line: 5}}},
+		{RuleUnusedDevDependency, "jest", "devDependencies", []Location{{File: "package.json", Line: 8}}},
+		{RuleMissingDependency, "zod", "", []Location{{File: "src/app.js", Line: 7}}},
+		{RulePhantomDependency, "debug", "", []Location{{File: "src/app.js", Line: 6}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildFindings() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSortFindingsTies(t *testing.T) {
+	sc := newScanContext()
+	findings := []Finding{
+		{RuleID: RuleUnresolvedImport, Package: "lodash", Message: "b", Locations: []Location{{File: "src/b.js", Line: 1}}},
+		{RuleID: RuleUnresolvedImport, Package: "lodash", Message: "a", Locations: []Location{{File: "src/a.js", Line: 9}}},
+		{RuleID: RuleUnresolvedImport, Package: "lodash", Message: "c", Locations: []Location{{File: "src/a.js", Line: 2}}},
+		{RuleID: RuleUnusedDependency, Package: "zod"},
+	}
+	sc.sortFindings(findings)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Message)
+	}
+	if want := []string{"", "c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortFindings() = %v, want %v", got, want)
+	}
+}
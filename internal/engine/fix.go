@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// githubAPIURL is the URL of the API of GitHub, where the pull requests of
+// "depose fix --open-pr" are opened. GITHUB_API_URL overrides it, e.g. on
+// GitHub Enterprise Server.
+var githubAPIURL = "https://api.github.com"
+
+// lockfileUpdates are the commands updating the lockfile of each package
+// manager after package.json changes, without installing the packages
+// when the package manager supports it. The first lockfile found wins.
+var lockfileUpdates = []struct {
+	lockfile string
+	command  []string
+}{
+	{"package-lock.json", []string{"npm", "install", "--package-lock-only", "--ignore-scripts"}},
+	{"pnpm-lock.yaml", []string{"pnpm", "install", "--lockfile-only", "--ignore-scripts"}},
+	{"yarn.lock", []string{"yarn", "install", "--ignore-scripts"}},
+	{"bun.lock", []string{"bun", "install", "--lockfile-only"}},
+	{"bun.lockb", []string{"bun", "install", "--lockfile-only"}},
+}
+
+// fixCommand implements "depose fix", which removes the unused dependencies
+// on a new git branch, and commits the change, ready to be pushed:
+//
+//	depose fix --git-branch chore/remove-unused-deps --lockfile --open-pr
+//
+// With --open-pr, the branch is pushed to origin, and a pull request is
+// opened on GitHub with the token of GITHUB_TOKEN. The flags of the main
+// command are accepted too, e.g. --strict.
+func (sc *scanContext) fixCommand(fs *flag.FlagSet) func(args []string) {
+	sc.defineFlags(fs)
+	branch := fs.String("git-branch", "chore/remove-unused-deps", "name of the branch created for the cleanup")
+	lockfile := fs.Bool("lockfile", false, "also update the lockfile, with the package manager of the project")
+	openPR := fs.Bool("open-pr", false, "push the branch to origin, and open a GitHub pull request with the token of GITHUB_TOKEN")
+	return func(args []string) {
+		if sc.dryRun || sc.toStdout || sc.outPath != "" || sc.check {
+			logFatal("--dry-run, --stdout, --out and --check can't be used with depose fix")
+		}
+		if *openPR && githubToken() == "" {
+			logFatal("--open-pr needs a GitHub token in GITHUB_TOKEN or GH_TOKEN")
+		}
+		if status, err := runGit("status", "--porcelain", "--untracked-files=no"); err != nil {
+			logFatalf("depose fix needs a git repository: %v", err)
+		} else if status != "" {
+			logFatal("The working tree has uncommitted changes, commit or stash them first")
+		}
+		base, err := runGit("rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			logFatal(err)
+		}
+
+		// The change is committed, so package.json needs no backup.
+		sc.noBackup = true
+		sc.run()
+		if len(sc.removed) == 0 {
+			fmt.Fprintln(sc.logOut, "Nothing to remove, no branch has been created.")
+			return
+		}
+
+		if _, err := runGit("checkout", "-b", *branch); err != nil {
+			logFatal(err)
+		}
+		changed := append([]string{sc.manifestFile}, sc.deletedConfigs...)
+		if *lockfile {
+			file, err := sc.updateLockfile()
+			if err != nil {
+				logFatalf("Failed to update the lockfile: %v", err)
+			}
+			if file != "" {
+				changed = append(changed, file)
+			}
+		}
+		if _, err := runGit(append([]string{"add", "--"}, changed...)...); err != nil {
+			logFatal(err)
+		}
+		title, body := fixMessage(sc.removed, sc.removedOverrides)
+		if _, err := runGit("commit", "-q", "-m", title+"\n\n"+body); err != nil {
+			logFatal(err)
+		}
+		fmt.Fprintf(sc.logOut, "Committed the removal of %d dependencies on the branch %s.\n", len(sc.removed), *branch)
+
+		if !*openPR {
+			fmt.Fprintf(sc.logOut, "Push it with: git push -u origin %s\n", *branch)
+			return
+		}
+		if _, err := runGit("push", "-u", "origin", *branch); err != nil {
+			logFatal(err)
+		}
+		remote, err := runGit("remote", "get-url", "origin")
+		if err != nil {
+			logFatal(err)
+		}
+		url, err := openPullRequest(remote, base, *branch, title, body)
+		if err != nil {
+			logFatalf("Failed to open the pull request: %v", err)
+		}
+		fmt.Fprintf(sc.logOut, "Opened the pull request %s\n", url)
+	}
+}
+
+// runGit runs git with the arguments, and returns its output, trimmed.
+// The error includes the output, which explains it.
+func runGit(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, bytes.TrimSpace(out))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// updateLockfile updates the lockfile of the project with its package
+// manager, and returns its name, or "" if the project has none.
+func (sc *scanContext) updateLockfile() (string, error) {
+	for _, u := range lockfileUpdates {
+		if _, err := os.Stat(u.lockfile); err != nil {
+			continue
+		}
+		fmt.Fprintf(sc.logOut, "Updating %s: %s\n", u.lockfile, strings.Join(u.command, " "))
+		cmd := exec.Command(u.command[0], u.command[1:]...)
+		cmd.Stdout, cmd.Stderr = sc.logOut, sc.logOut
+		return u.lockfile, cmd.Run()
+	}
+	return "", nil
+}
+
+// fixMessage returns the title and the body of the commit and of the pull
+// request removing the dependencies, and their overrides, if any.
+func fixMessage(removed, overrides []string) (title, body string) {
+	title = "chore: remove unused dependencies"
+	if len(removed) == 1 {
+		title = "chore: remove unused dependency " + removed[0]
+	}
+	var sb strings.Builder
+	sb.WriteString("depose found these dependencies unused, and removed them:\n\n")
+	for _, dependency := range removed {
+		fmt.Fprintf(&sb, "- %s\n", dependency)
+	}
+	if len(overrides) > 0 {
+		sb.WriteString("\nalong with their overrides, which became dead configuration:\n\n")
+		for _, override := range overrides {
+			fmt.Fprintf(&sb, "- %s\n", override)
+		}
+	}
+	return title, sb.String()
+}
+
+// githubToken returns the token of the GitHub API, read from the
+// environment like the GitHub CLI does.
+func githubToken() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// githubRemoteRe matches the URLs of GitHub repositories, over HTTPS or
+// SSH, e.g. git@github.com:owner/repo.git.
+var githubRemoteRe = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// openPullRequest opens a pull request of the head branch into the base
+// branch of the GitHub repository of the remote, and returns its URL.
+func openPullRequest(remote, base, head, title, body string) (string, error) {
+	m := githubRemoteRe.FindStringSubmatch(remote)
+	if m == nil {
+		return "", fmt.Errorf("%s is not a GitHub repository", remote)
+	}
+	api := githubAPIURL
+	if env := os.Getenv("GITHUB_API_URL"); env != "" {
+		api = env
+	}
+	header := http.Header{"Authorization": {"Bearer " + githubToken()}, "Accept": {"application/vnd.github+json"}}
+	payload := map[string]string{"title": title, "head": head, "base": base, "body": body}
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := callAPI(http.MethodPost, fmt.Sprintf("%s/repos/%s/%s/pulls", strings.TrimSuffix(api, "/"), m[1], m[2]), header, payload, &pr); err != nil {
+		return "", err
+	}
+	if pr.HTMLURL == "" {
+		return "", errors.New("no URL in the response")
+	}
+	return pr.HTMLURL, nil
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestFixCommand(t *testing.T) {
+	deposePath := buildDepose(t)
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"express\": \"^4.18.2\",\n    \"moment\": \"^2.29.4\"\n  }\n}\n",
+		"index.js":     "const express = require(\"express\");\n",
+	})
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+			"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	cmd := exec.Command(deposePath, "fix", "--git-branch", "chore/cleanup", "--no-history")
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("depose fix: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "Push it with: git push -u origin chore/cleanup") {
+		t.Errorf("output does not tell how to push the branch:\n%s", out)
+	}
+	if got := git("rev-parse", "--abbrev-ref", "HEAD"); got != "chore/cleanup" {
+		t.Errorf("branch = %q, want chore/cleanup", got)
+	}
+	if got, want := git("log", "-1", "--format=%B"), "chore: remove unused dependency moment\n\ndepose found these dependencies unused, and removed them:\n\n- moment"; got != want {
+		t.Errorf("commit message = %q, want %q", got, want)
+	}
+	if got := git("status", "--porcelain"); got != "" {
+		t.Errorf("the working tree is not clean:\n%s", got)
+	}
+	if data := git("show", "HEAD:package.json"); strings.Contains(data, "moment") || !strings.Contains(data, "express") {
+		t.Errorf("committed package.json = %s", data)
+	}
+
+	// Nothing is left to remove on the branch.
+	out, err = exec.Command(deposePath, "fix", "--git-branch", "chore/again", "--no-history").CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "Nothing to remove") {
+		t.Errorf("depose fix without unused dependencies: %v\n%s", err, out)
+	}
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/acme/app/pulls" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"html_url": "https://github.com/acme/app/pull/7"}`)
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	url, err := openPullRequest("git@github.com:acme/app.git", "main", "chore/cleanup", "chore: cleanup", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://github.com/acme/app/pull/7" {
+		t.Errorf("url = %q", url)
+	}
+	if got["head"] != "chore/cleanup" || got["base"] != "main" || got["title"] != "chore: cleanup" {
+		t.Errorf("payload = %v", got)
+	}
+	if _, err := openPullRequest("https://gitlab.com/acme/app.git", "main", "x", "t", "b"); err == nil {
+		t.Errorf("openPullRequest() accepted a GitLab remote")
+	}
+}
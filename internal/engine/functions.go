@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// serverlessConfigFiles are the configs of the Serverless Framework, at the
+// project root, whose functions name their handlers, e.g.
+// "handler: src/users.create" for the create export of src/users.js.
+var serverlessConfigFiles = []string{"serverless.yml", "serverless.yaml"}
+
+// serverlessHandlerRe matches the handlers of the functions of a Serverless
+// config, capturing the module and the export, e.g. "src/users" and "create".
+var serverlessHandlerRe = regexp.MustCompile(`^\s*handler\s*:\s*["']?([\w./@-]+)\.(\w+)["']?\s*(?:#.*)?$`)
+
+// firebaseFunctionsSources returns the directories of the functions of
+// Firebase, read from the "functions" of firebase.json: a single codebase,
+// e.g. {"source": "functions"}, or several ones. The source defaults to
+// "functions".
+func (sc *scanContext) firebaseFunctionsSources() []string {
+	data, err := sc.readProjectFile("firebase.json")
+	if err != nil {
+		return nil
+	}
+	var config struct {
+		Functions json.RawMessage `json:"functions"`
+	}
+	if json.Unmarshal(data, &config) != nil || len(config.Functions) == 0 {
+		return nil
+	}
+	type codebase struct {
+		Source string `json:"source"`
+	}
+	var codebases []codebase
+	if json.Unmarshal(config.Functions, &codebases) != nil {
+		var single codebase
+		if json.Unmarshal(config.Functions, &single) != nil {
+			return nil
+		}
+		codebases = []codebase{single}
+	}
+	var sources []string
+	for _, c := range codebases {
+		source := path.Clean(filepath.ToSlash(c.Source))
+		if c.Source == "" {
+			source = "functions"
+		}
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// serverlessHandlerFiles returns the files of the handlers of the functions
+// of the Serverless config of the project, which are its entrypoints.
+func (sc *scanContext) serverlessHandlerFiles() []string {
+	var handlers []string
+	for _, config := range serverlessConfigFiles {
+		data, err := sc.readProjectFile(config)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			m := serverlessHandlerRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			if file, ok := sc.resolveLocalFile(config, "./"+strings.TrimPrefix(m[1], "./")); ok && !slices.Contains(handlers, file) {
+				handlers = append(handlers, file)
+			}
+		}
+	}
+	return handlers
+}
+
+// nestedManifestDir returns the closest directory of the file, below the
+// project root, having its own package.json, if any.
+func (sc *scanContext) nestedManifestDir(file string) (string, bool) {
+	for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, err := sc.statProjectFile(path.Join(dir, "package.json")); err == nil {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// functionsManifestDirs returns the directories of the functions of the
+// project having their own package.json: the sources of the functions of
+// Firebase, and the directories of the handlers of the Serverless config.
+// They are scanned against their own manifest, like the ones given with
+// --manifest, rather than against the root one.
+func (sc *scanContext) functionsManifestDirs() []string {
+	var dirs []string
+	for _, source := range sc.firebaseFunctionsSources() {
+		if _, err := sc.statProjectFile(path.Join(source, "package.json")); err == nil && source != "." {
+			dirs = append(dirs, source)
+		}
+	}
+	for _, handler := range sc.serverlessHandlerFiles() {
+		if dir, ok := sc.nestedManifestDir(handler); ok && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// addFunctionsManifests adds the directories of the functions having their
+// own package.json to the ones of the extra manifests, printing them.
+func (sc *scanContext) addFunctionsManifests(dirs []string) []string {
+	for _, dir := range sc.functionsManifestDirs() {
+		if slices.Contains(dirs, dir) {
+			continue
+		}
+		fmt.Fprintf(sc.logOut, "Detected the functions in %s/, scanned against %s/package.json\n", dir, dir)
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
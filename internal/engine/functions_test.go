@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionsManifestDirs(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{"name": "app"}`,
+		"firebase.json": `{"functions": [
+  {"source": "functions", "codebase": "default"},
+  {"source": "billing/", "codebase": "billing"}
+]}`,
+		"functions/package.json": `{"main": "lib/index.js"}`,
+		"billing/index.js":       "",
+		"serverless.yml": `service: api
+functions:
+  createUser:
+    handler: services/users/src/handler.create # the users service
+  ping:
+    handler: "src/ping.main"
+  missing:
+    handler: src/missing.main
+`,
+		"services/users/package.json":   `{"name": "users"}`,
+		"services/users/src/handler.ts": "",
+		"src/ping.js":                   "",
+	})
+
+	if got, want := sc.firebaseFunctionsSources(), []string{"functions", "billing"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("firebaseFunctionsSources() = %q, want %q", got, want)
+	}
+	if got, want := sc.serverlessHandlerFiles(), []string{"services/users/src/handler.ts", "src/ping.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("serverlessHandlerFiles() = %q, want %q", got, want)
+	}
+	// billing has no package.json of its own, so it is scanned against the
+	// root one.
+	if got, want := sc.functionsManifestDirs(), []string{"functions", "services/users"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("functionsManifestDirs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,247 @@
+package engine
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+// localExtensions are the extensions tried, in order, when resolving
+// a local specifier without extension, e.g. "./db".
+var localExtensions = []string{".js", ".ts", ".tsx", ".jsx", ".mjs", ".cjs", ".mts", ".cts", ".json"}
+
+// stringList is a flag which can be repeated, e.g. "--entry a --entry b".
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// importGraph is the result of following the local imports of entrypoints.
+type importGraph struct {
+	// files are the local files reachable from the entrypoints.
+	files map[string]bool
+	// packages maps the packages imported by the reachable files
+	// to the locations where they are imported.
+	packages map[string][]Location
+}
+
+// followImports follows the local imports of the entrypoints, and returns
+// the files they reach and the packages those files import.
+//
+// Internal "#" specifiers are followed through the "imports" field of
+// package.json. Builtin modules are not recorded.
+func (sc *scanContext) followImports(entries []string) importGraph {
+	g := importGraph{files: make(map[string]bool), packages: make(map[string][]Location)}
+	queue := append([]string{}, entries...)
+	for len(queue) > 0 {
+		file := filepath.ToSlash(filepath.Clean(queue[0]))
+		queue = queue[1:]
+		if g.files[file] {
+			continue
+		}
+		g.files[file] = true
+
+		extractor, ok := extract.For("node", file)
+		if !ok {
+			continue
+		}
+		data, err := sc.readProjectFile(file)
+		if err != nil {
+			continue
+		}
+		specifiers, err := extractor.Extract(strings.NewReader(string(data)))
+		if err != nil {
+			continue
+		}
+
+		for _, s := range specifiers {
+			if s.Dynamic {
+				continue
+			}
+			targets := []string{s.Path}
+			if internal, ok := sc.packageImportsTargets(s.Path); ok {
+				targets = internal
+			}
+			for _, target := range targets {
+				switch {
+				case isLocalSpecifier(target):
+					from := file
+					if target != s.Path {
+						// The targets of the "imports" field are relative to package.json.
+						from = "package.json"
+					}
+					if local, ok := sc.resolveLocalFile(from, target); ok {
+						queue = append(queue, local)
+					}
+				case !sc.isBuiltinModule(packageName(target)):
+					pkgName := packageName(target)
+					g.packages[pkgName] = append(g.packages[pkgName], Location{File: file, Line: s.Line})
+				}
+			}
+		}
+	}
+	return g
+}
+
+// sortedFiles returns the reachable files in alphabetical order.
+func (g importGraph) sortedFiles() []string {
+	files := make([]string, 0, len(g.files))
+	for file := range g.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// resolveLocalFile resolves a relative specifier, e.g. "./db", found in the
+// file, to the file it refers to, trying the extensions and the index files
+// like Node.js and TypeScript do.
+func (sc *scanContext) resolveLocalFile(from, specifier string) (string, bool) {
+	base := filepath.Join(filepath.Dir(from), filepath.FromSlash(specifier))
+	if strings.HasPrefix(specifier, "/") {
+		base = filepath.FromSlash(strings.TrimPrefix(specifier, "/"))
+	}
+
+	candidates := []string{base}
+	// TypeScript sources are imported with the extension of their output.
+	for js, ts := range map[string]string{".js": ".ts", ".jsx": ".tsx", ".mjs": ".mts", ".cjs": ".cts"} {
+		if strings.HasSuffix(base, js) {
+			candidates = append(candidates, strings.TrimSuffix(base, js)+ts)
+		}
+	}
+	for _, ext := range localExtensions {
+		candidates = append(candidates, base+ext)
+	}
+	for _, ext := range localExtensions {
+		candidates = append(candidates, filepath.Join(base, "index"+ext))
+	}
+
+	for _, c := range candidates {
+		if info, err := sc.statProjectFile(c); err == nil && !info.IsDir() {
+			return filepath.ToSlash(c), true
+		}
+	}
+	return "", false
+}
+
+// sourceExtensions are the extensions of the source files which are
+// expected to be imported by another file, or to be an entrypoint.
+var sourceExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+	".vue": true, ".svelte": true, ".astro": true,
+}
+
+// manifestEntrypoints returns the local files package.json declares as
+// entrypoints: its "main", "module", the targets of its "exports",
+// and its "bin" executables.
+func (sc *scanContext) manifestEntrypoints() []string {
+	var entries []string
+	for _, entry := range []string{sc.manifest.Main, sc.manifest.Module} {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	entries = append(entries, targetStrings(sc.manifest.Exports)...)
+	entries = append(entries, targetStrings(sc.manifest.Bin)...)
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if file, ok := sc.resolveLocalFile("package.json", "./"+strings.TrimPrefix(entry, "./")); ok && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// binFiles returns the files of the "bin" executables of package.json, and
+// the local files they import, which are missing from the walked files.
+// They are scanned anyway, since the executables often live in paths
+// which are excluded, e.g. dist/cli.js, or have no extension, e.g. bin/cli
+// starting with a shebang line.
+func (sc *scanContext) binFiles(files []string) []string {
+	var entries []string
+	for _, target := range targetStrings(sc.manifest.Bin) {
+		if file, ok := sc.resolveLocalFile("package.json", "./"+strings.TrimPrefix(target, "./")); ok {
+			entries = append(entries, file)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	walked := make(map[string]bool, len(files))
+	for _, file := range files {
+		walked[filepath.ToSlash(file)] = true
+	}
+	var missing []string
+	for _, file := range sc.followImports(entries).sortedFiles() {
+		if !walked[file] && !slices.Contains(strings.Split(file, "/"), "node_modules") {
+			missing = append(missing, filepath.FromSlash(file))
+		}
+	}
+	return missing
+}
+
+// isNodeExecutable reports whether the file has no extension, and starts
+// with the shebang line of node or of its wrappers, e.g.
+// "#!/usr/bin/env node".
+func (sc *scanContext) isNodeExecutable(file string) bool {
+	if filepath.Ext(file) != "" {
+		return false
+	}
+	_, ok := extract.ForShebang("node", sc.fileHead(file))
+	return ok
+}
+
+// entrypoints returns the roots of the module graph of the project: the
+// entrypoints of package.json, the handlers of the Serverless config, the
+// files matching the --entry patterns, the config files, which are loaded
+// by the tools of the project, and the executables run by node, e.g.
+// tools/release starting with a shebang line.
+// When there is none, index.js is the entrypoint, like for Node.js.
+func (sc *scanContext) entrypoints(patterns []string, files []string) []string {
+	roots := append(sc.manifestEntrypoints(), sc.serverlessHandlerFiles()...)
+	for _, file := range files {
+		slashed := filepath.ToSlash(file)
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(filepath.ToSlash(filepath.Clean(pattern)), slashed); ok {
+				roots = append(roots, file)
+				break
+			}
+		}
+	}
+	if len(roots) == 0 {
+		roots = append(roots, "index.js")
+	}
+	for _, file := range files {
+		if sc.isConfigFile(file) || sc.isNodeExecutable(file) {
+			roots = append(roots, file)
+		}
+	}
+	return roots
+}
+
+// orphanedFiles returns the source files of the project which
+// are not reachable from any entrypoint, sorted by path.
+func orphanedFiles(g importGraph, files []string) []string {
+	var orphans []string
+	for _, file := range files {
+		slashed := filepath.ToSlash(file)
+		if sourceExtensions[filepath.Ext(file)] && !strings.HasSuffix(file, ".d.ts") && !g.files[slashed] {
+			orphans = append(orphans, slashed)
+		}
+	}
+	sort.Strings(orphans)
+	return orphans
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+func TestFollowImports(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"index.js":        "require(\"./lib\");\nrequire(\"./missing\");\n",
+		"lib/index.js":    "require(\"../util/strings.js\");\n",
+		"util/strings.js": "require(\"@scope/text/case\");\n",
+		"unreachable.js":  "require(\"left-pad\");\n",
+	})
+
+	g := sc.followImports([]string{"index.js"})
+	if got, want := g.sortedFiles(), []string{"index.js", "lib/index.js", "util/strings.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	want := map[string][]Location{"@scope/text": {{File: "util/strings.js", Line: 1}}}
+	if !reflect.DeepEqual(g.packages, want) {
+		t.Errorf("packages = %v, want %v", g.packages, want)
+	}
+}
+
+func TestEntrypointsAndOrphanedFiles(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"lib/main.js":      "require(\"./used\");\n",
+		"lib/used.js":      "",
+		"lib/esm.mjs":      "",
+		"bin/cli.js":       "require(\"chalk\");\n",
+		"src/old.js":       "require(\"moment\");\n",
+		"src/types.d.ts":   "",
+		"test/app.test.js": "require(\"../lib/used\");\n",
+		"jest.config.js":   "module.exports = {};\n",
+	})
+	sc.manifest = Package{
+		Main:    "lib/main.js",
+		Exports: map[string]any{".": map[string]any{"import": "./lib/esm.mjs", "require": "./lib/main.js"}},
+		Bin:     map[string]any{"cli": "bin/cli.js"},
+	}
+	defer func() { sc.manifest = Package{} }()
+
+	files := []string{"bin/cli.js", "jest.config.js", "lib/esm.mjs", "lib/main.js", "lib/used.js", "src/old.js", "src/types.d.ts", "test/app.test.js"}
+	roots := sc.entrypoints(stringList{"test/*.test.js"}, files)
+	wantRoots := []string{"lib/main.js", "lib/esm.mjs", "bin/cli.js", "test/app.test.js", "jest.config.js"}
+	if !reflect.DeepEqual(roots, wantRoots) {
+		t.Errorf("entrypoints() = %v, want %v", roots, wantRoots)
+	}
+
+	g := sc.followImports(roots)
+	if got, want := orphanedFiles(g, files), []string{"src/old.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orphanedFiles() = %v, want %v", got, want)
+	}
+	if _, ok := g.packages["moment"]; ok {
+		t.Errorf("the imports of an orphaned file were followed")
+	}
+}
+
+func TestBinFiles(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"bin/tool":         "#!/usr/bin/env node\nrequire(\"../dist/commands\");\nrequire(\"../src/util\");\n",
+		"dist/commands.js": "require(\"yargs\");\n",
+		"src/util.js":      "require(\"chalk\");\n",
+	})
+	sc.manifest = Package{Bin: map[string]any{"tool": "./bin/tool", "missing": "bin/missing.js"}}
+	defer func() { sc.manifest = Package{} }()
+
+	// The walk skipped dist/, and found the others.
+	if got, want := sc.binFiles([]string{"bin/tool", "src/util.js"}), []string{"dist/commands.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("binFiles() = %v, want %v", got, want)
+	}
+	if got, want := sc.binFiles(nil), []string{"bin/tool", "dist/commands.js", "src/util.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("binFiles(nil) = %v, want %v", got, want)
+	}
+}
+
+func TestNodeExecutableEntrypoints(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"index.js":      "",
+		"tools/release": "#!/usr/bin/env node\nrequire(\"./changelog\");\n",
+		"tools/deploy":  "#!/bin/sh\nnpx wrangler deploy\n",
+		"Makefile":      "all:\n\tnode index.js\n",
+	})
+	files := []string{"Makefile", "index.js", "tools/deploy", "tools/release"}
+	if got, want := sc.entrypoints(nil, files), []string{"index.js", "tools/release"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entrypoints() = %v, want %v", got, want)
+	}
+
+	sc.lang = sc.nodeLanguage
+	defer func() { sc.lang = nil }()
+	if extractor, ok := sc.extractorFor("tools/release"); !ok || extractor != (extract.JavaScript{}) {
+		t.Errorf("extractorFor(tools/release) = %v, %v", extractor, ok)
+	}
+}
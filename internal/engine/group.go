@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// findingGroup is the findings of a group of the report, e.g. of an owner
+// with --group-by owner, or of a section with --group-by section.
+type findingGroup struct {
+	Name     string    `json:"name"`
+	Findings []Finding `json:"findings"`
+}
+
+// groupings are the values accepted by --group-by.
+var groupings = []string{"owner", "section", "workspace", "confidence", "severity"}
+
+// groupFindings groups the findings by the names returned by groupsOf,
+// sorted with less. A finding in several groups is in every one of them.
+func groupFindings(findings []Finding, groupsOf func(Finding) []string, less func(a, b string) bool) []findingGroup {
+	byName := make(map[string][]Finding)
+	for _, f := range findings {
+		for _, name := range groupsOf(f) {
+			byName[name] = append(byName[name], f)
+		}
+	}
+	groups := make([]findingGroup, 0, len(byName))
+	for name, fs := range byName {
+		groups = append(groups, findingGroup{Name: name, Findings: fs})
+	}
+	sort.Slice(groups, func(i, j int) bool { return less(groups[i].Name, groups[j].Name) })
+	return groups
+}
+
+// noSection is the section of the findings about no declaration, e.g. the
+// missing dependencies.
+const noSection = "(no section)"
+
+// groupBySection groups the findings by the section of the manifest they
+// are about, the findings about no section last.
+func groupBySection(findings []Finding) []findingGroup {
+	return groupFindings(findings, func(f Finding) []string {
+		if f.Section == "" {
+			return []string{noSection}
+		}
+		return []string{f.Section}
+	}, func(a, b string) bool {
+		if (a == noSection) != (b == noSection) {
+			return b == noSection
+		}
+		return a < b
+	})
+}
+
+// rootWorkspace is the workspace of the findings outside of the workspaces
+// of a monorepo, e.g. about the root package.json.
+const rootWorkspace = "(root)"
+
+// groupByWorkspace groups the findings by the workspace of the monorepo
+// holding their first location, sorted by directory, the findings of the
+// root first.
+func (sc *scanContext) groupByWorkspace(findings []Finding) ([]findingGroup, error) {
+	data, err := sc.readProjectFile("package.json")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	workspaces, err := findWorkspaces(workspacePatterns(data))
+	if err != nil {
+		return nil, err
+	}
+	return groupFindings(findings, func(f Finding) []string {
+		file := firstLocation(f).File
+		for _, w := range workspaces {
+			if strings.HasPrefix(file, w.Dir+"/") {
+				return []string{w.Dir}
+			}
+		}
+		return []string{rootWorkspace}
+	}, func(a, b string) bool {
+		if (a == rootWorkspace) != (b == rootWorkspace) {
+			return a == rootWorkspace
+		}
+		return a < b
+	}), nil
+}
+
+// Confidences of the findings, assigned with --group-by confidence.
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// ruleConfidences are the confidences of the findings of the rules, which
+// depend on heuristics, e.g. reading node_modules or following the imports.
+// The findings of the other rules, including the ones of the plugins, have
+// a medium confidence.
+var ruleConfidences = map[string]string{
+	RuleUnusedDependency:    ConfidenceHigh,
+	RuleUnusedDevDependency: ConfidenceHigh,
+	RuleMissingDependency:   ConfidenceHigh,
+	RuleBannedDependency:    ConfidenceHigh,
+	RuleRequiredDependency:  ConfidenceHigh,
+	RuleDynamicImport:       ConfidenceLow,
+}
+
+// findingConfidence returns how likely the finding is right. The unused
+// dependencies have a low confidence when something may still use them: a
+// specifier computed at runtime starting like their name, or a Makefile,
+// Dockerfile or shell script running them, which only counts as usage in
+// lenient mode.
+func (sc *scanContext) findingConfidence(f Finding) string {
+	if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+		if len(sc.d.cliUsages[f.Package]) > 0 {
+			return ConfidenceLow
+		}
+		for _, prefix := range sc.d.dynamic {
+			if prefix != "" && !isLocalSpecifier(prefix) && strings.HasPrefix(f.Package, prefix) {
+				return ConfidenceLow
+			}
+		}
+	}
+	if confidence, ok := ruleConfidences[f.RuleID]; ok {
+		return confidence
+	}
+	return ConfidenceMedium
+}
+
+// assignConfidences records in the findings their confidence.
+func (sc *scanContext) assignConfidences(findings []Finding) {
+	for i, f := range findings {
+		findings[i].Confidence = sc.findingConfidence(f)
+	}
+}
+
+// groupByConfidence groups the findings by confidence, the most confident
+// first.
+func groupByConfidence(findings []Finding) []findingGroup {
+	order := map[string]int{ConfidenceHigh: 0, ConfidenceMedium: 1, ConfidenceLow: 2}
+	return groupFindings(findings, func(f Finding) []string {
+		return []string{f.Confidence}
+	}, func(a, b string) bool { return order[a] < order[b] })
+}
+
+// groupBySeverity groups the findings by severity, the most severe first.
+func groupBySeverity(findings []Finding) []findingGroup {
+	order := map[string]int{string(SeverityError): 0, string(SeverityWarning): 1, string(SeverityNote): 2}
+	return groupFindings(findings, func(f Finding) []string {
+		return []string{string(f.Severity)}
+	}, func(a, b string) bool { return order[a] < order[b] })
+}
+
+// parseOnly parses the kinds of findings given to --only, e.g.
+// "unused,missing", into the rules reporting them.
+func parseOnly(kinds string) (map[string]bool, error) {
+	if kinds == "" {
+		return nil, nil
+	}
+	rules := make(map[string]bool)
+	for _, kind := range strings.Split(kinds, ",") {
+		kindRules, ok := findingKinds[strings.TrimSpace(kind)]
+		if !ok {
+			return nil, fmt.Errorf("unknown kind of finding %q, available kinds: %s", kind, strings.Join(findingKindNames(), ", "))
+		}
+		for _, rule := range kindRules {
+			rules[rule] = true
+		}
+	}
+	return rules, nil
+}
+
+// filterFindings returns the findings of the rules, when there are any,
+// about the packages matching one of the patterns, when there are any.
+// Patterns are matched with path.Match, so "@scope/*" matches the packages
+// of the scope.
+func filterFindings(findings []Finding, rules map[string]bool, patterns []string) []Finding {
+	if len(rules) == 0 && len(patterns) == 0 {
+		return findings
+	}
+	var kept []Finding
+	for _, f := range findings {
+		if len(rules) > 0 && !rules[f.RuleID] {
+			continue
+		}
+		if len(patterns) > 0 && !matchesAnyPattern(f.Package, patterns) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
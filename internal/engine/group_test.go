@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func groupNames(groups []findingGroup) []string {
+	var names []string
+	for _, g := range groups {
+		var pkgs []string
+		for _, f := range g.Findings {
+			pkgs = append(pkgs, f.Package)
+		}
+		names = append(names, g.Name+": "+strings.Join(pkgs, ","))
+	}
+	return names
+}
+
+func TestGroupBySection(t *testing.T) {
+	findings := []Finding{
+		{RuleID: RuleUnusedDevDependency, Package: "jest", Section: "devDependencies"},
+		{RuleID: RuleMissingDependency, Package: "chalk"},
+		{RuleID: RuleUnusedDependency, Package: "moment", Section: "dependencies"},
+	}
+	want := []string{"dependencies: moment", "devDependencies: jest", "(no section): chalk"}
+	if got := groupNames(groupBySection(findings)); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupBySection() = %v, want %v", got, want)
+	}
+}
+
+func TestGroupByWorkspace(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json":              `{"workspaces": ["packages/*"]}`,
+		"packages/api/package.json": `{"name": "api"}`,
+		"packages/web/package.json": `{"name": "web"}`,
+	})
+	findings := []Finding{
+		{RuleID: RuleMissingDependency, Package: "react", Locations: []Location{{File: "packages/web/src/app.js", Line: 1}}},
+		{RuleID: RuleUnusedDependency, Package: "lodash", Locations: []Location{{File: "package.json", Line: 3}}},
+		{RuleID: RuleMissingDependency, Package: "express", Locations: []Location{{File: "packages/api/index.js", Line: 1}}},
+	}
+	groups, err := sc.groupByWorkspace(findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"(root): lodash", "packages/api: express", "packages/web: react"}
+	if got := groupNames(groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByWorkspace() = %v, want %v", got, want)
+	}
+}
+
+func TestGroupByConfidence(t *testing.T) {
+	sc := newScanContext()
+	sc.d.cliUsages = map[string][]Location{"rimraf": {{File: "Makefile", Line: 2}}}
+	sc.d.dynamic = map[Location]string{{File: "index.js", Line: 4}: "eslint-plugin-"}
+	defer func() { sc.d = Dependency{} }()
+
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "moment"},
+		{RuleID: RuleUnusedDevDependency, Package: "rimraf"},
+		{RuleID: RuleUnusedDevDependency, Package: "eslint-plugin-react"},
+		{RuleID: RulePhantomDependency, Package: "debug"},
+	}
+	sc.assignConfidences(findings)
+	want := []string{"high: moment", "medium: debug", "low: rimraf,eslint-plugin-react"}
+	if got := groupNames(groupByConfidence(findings)); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByConfidence() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterFindings(t *testing.T) {
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "@acme/ui"},
+		{RuleID: RuleMissingDependency, Package: "@acme/api"},
+		{RuleID: RulePhantomDependency, Package: "debug"},
+		{RuleID: RuleUnusedDevDependency, Package: "jest"},
+	}
+	tests := []struct {
+		only    string
+		filters []string
+		want    []string
+	}{
+		{"", nil, []string{"@acme/ui", "@acme/api", "debug", "jest"}},
+		{"unused", nil, []string{"@acme/ui", "jest"}},
+		{"unused,missing", []string{"@acme/*"}, []string{"@acme/ui", "@acme/api"}},
+		{"", []string{"@acme/*", "debug"}, []string{"@acme/ui", "@acme/api", "debug"}},
+	}
+	for _, tt := range tests {
+		rules, err := parseOnly(tt.only)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range filterFindings(findings, rules, tt.filters) {
+			got = append(got, f.Package)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterFindings(%q, %v) = %v, want %v", tt.only, tt.filters, got, tt.want)
+		}
+	}
+	if _, err := parseOnly("unused,bogus"); err == nil {
+		t.Error("parseOnly() accepted an unknown kind")
+	}
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// historyFile is the file where the counts of every run are recorded.
+var historyFile = filepath.Join(".depose", "history.json")
+
+// historyEntry is the summary of a run of depose.
+type historyEntry struct {
+	Time time.Time `json:"time"`
+	// Commit is the git commit the project was at, if it is a git repository.
+	Commit       string `json:"commit,omitempty"`
+	Dependencies int    `json:"dependencies"`
+	Unused       int    `json:"unused"`
+	Missing      int    `json:"missing"`
+	Findings     int    `json:"findings"`
+}
+
+// newHistoryEntry summarizes the findings of a run, with
+// the number of dependencies declared in the manifest.
+func newHistoryEntry(now time.Time, commit string, dependencies int, findings []Finding) historyEntry {
+	entry := historyEntry{Time: now.UTC(), Commit: commit, Dependencies: dependencies, Findings: len(findings)}
+	for _, f := range findings {
+		switch f.RuleID {
+		case RuleUnusedDependency, RuleUnusedDevDependency:
+			entry.Unused++
+		case RuleMissingDependency:
+			entry.Missing++
+		}
+	}
+	return entry
+}
+
+// readHistory reads the recorded runs, oldest first.
+// A missing history file is treated as an empty history.
+func readHistory(path string) ([]historyEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []historyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// appendHistory records a run at the end of the history file.
+func appendHistory(path string, entry historyEntry) error {
+	entries, err := readHistory(path)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(append(entries, entry), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// gitCommit returns the short hash of the current git commit,
+// or an empty string outside of a git repository.
+func gitCommit() string {
+	out, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// writeHistory prints the recorded runs from the given index as a table,
+// with the change of every count since the previous run.
+func writeHistory(w io.Writer, entries []historyEntry, from int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tCOMMIT\tDEPENDENCIES\tUNUSED\tMISSING\tFINDINGS")
+	for i := from; i < len(entries); i++ {
+		entry := entries[i]
+		var prev *historyEntry
+		if i > 0 {
+			prev = &entries[i-1]
+		}
+		commit := entry.Commit
+		if commit == "" {
+			commit = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04"), commit,
+			trend(entry.Dependencies, prev, func(e *historyEntry) int { return e.Dependencies }),
+			trend(entry.Unused, prev, func(e *historyEntry) int { return e.Unused }),
+			trend(entry.Missing, prev, func(e *historyEntry) int { return e.Missing }),
+			trend(entry.Findings, prev, func(e *historyEntry) int { return e.Findings }))
+	}
+	return tw.Flush()
+}
+
+// trend formats a count with its change since the previous run, e.g. "12 (-3)".
+func trend(count int, prev *historyEntry, field func(*historyEntry) int) string {
+	if prev == nil || field(prev) == count {
+		return fmt.Sprint(count)
+	}
+	return fmt.Sprintf("%d (%+d)", count, count-field(prev))
+}
+
+// historyCommand implements "depose history", which prints the counts
+// recorded by the previous runs of depose in the project.
+//
+//	depose history --last 10
+func historyCommand(fs *flag.FlagSet) func(args []string) {
+	last := fs.Int("last", 0, "only print the last N runs")
+	return func(args []string) {
+		entries, err := readHistory(historyFile)
+		if err != nil {
+			logFatalf("Failed to read %s: %v", historyFile, err)
+		}
+		if len(entries) == 0 {
+			fmt.Fprintf(os.Stderr, "No runs recorded in %s yet\n", historyFile)
+			return
+		}
+		from := 0
+		if *last > 0 && *last < len(entries) {
+			from = len(entries) - *last
+		}
+		if err := writeHistory(os.Stdout, entries, from); err != nil {
+			logFatal(err)
+		}
+	}
+}
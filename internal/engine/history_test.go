@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".depose", "history.json")
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	runs := []historyEntry{
+		newHistoryEntry(day, "abc1234", 12, []Finding{
+			{RuleID: RuleUnusedDependency}, {RuleID: RuleUnusedDevDependency}, {RuleID: RuleMissingDependency},
+		}),
+		newHistoryEntry(day.Add(24*time.Hour), "def5678", 10, []Finding{{RuleID: RuleMissingDependency}, {RuleID: RuleOrphanedFile}}),
+		newHistoryEntry(day.Add(48*time.Hour), "", 10, []Finding{{RuleID: RuleMissingDependency}, {RuleID: RuleOrphanedFile}}),
+	}
+	for _, run := range runs {
+		if err := appendHistory(path, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := readHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Unused != 2 || entries[0].Missing != 1 || entries[0].Findings != 3 {
+		t.Fatalf("readHistory() = %+v", entries)
+	}
+
+	var sb strings.Builder
+	if err := writeHistory(&sb, entries, 1); err != nil {
+		t.Fatal(err)
+	}
+	want := `DATE              COMMIT   DEPENDENCIES  UNUSED  MISSING  FINDINGS
+2024-03-02 12:00  def5678  10 (-2)       0 (-2)  1        2 (-1)
+2024-03-03 12:00  -        10            0       1        2
+`
+	if sb.String() != want {
+		t.Errorf("writeHistory() =\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	if entries, err := readHistory(filepath.Join(t.TempDir(), "missing.json")); err != nil || entries != nil {
+		t.Errorf("readHistory(missing) = %v, %v, want no entries", entries, err)
+	}
+}
@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultPublicHoistPatterns are the packages which pnpm hoists to the root
+// of node_modules, when .npmrc doesn't set public-hoist-pattern.
+var defaultPublicHoistPatterns = []string{"*eslint*", "*prettier*"}
+
+// nodeModulesLayout is how the package manager of the project lays out
+// node_modules, read from .npmrc and .yarnrc.yml. It decides whether the
+// undeclared packages which are installed anyway, i.e. the phantom
+// dependencies, are hoisted on purpose or only by chance.
+type nodeModulesLayout struct {
+	// setting is the setting deciding the layout, e.g. "node-linker=hoisted
+	// of .npmrc", empty for the default layout of npm, which hoists every
+	// package it can.
+	setting string
+	// pnp is true with the Plug'n'Play linker of Yarn, which resolves the
+	// declared packages only.
+	pnp bool
+	// isolated is true when only the declared packages are at the root of
+	// node_modules, along with the ones matching publicHoist, e.g. with
+	// the default linker of pnpm.
+	isolated bool
+	// publicHoist are the patterns of the packages hoisted to the root of
+	// node_modules by pnpm, e.g. "*eslint*". The ones starting with "!"
+	// exclude packages.
+	publicHoist []string
+}
+
+// readNodeModulesLayout reads the layout of node_modules from the settings
+// of the package manager of the project, at its root: .npmrc for npm and
+// pnpm, .yarnrc.yml for Yarn 2 and later.
+func (sc *scanContext) readNodeModulesLayout(pkg Package) nodeModulesLayout {
+	if data, err := sc.readProjectFile(".yarnrc.yml"); err == nil {
+		return yarnLayout(data)
+	}
+	npmrc, _ := sc.readProjectFile(".npmrc")
+	settings := npmrcSettings(npmrc)
+	_, err := sc.statProjectFile("pnpm-lock.yaml")
+	if err == nil || strings.HasPrefix(pkg.PackageManager, "pnpm@") {
+		return pnpmLayout(settings)
+	}
+	switch {
+	case settings["install-strategy"] == "nested" || settings["install-strategy"] == "shallow" || settings["global-style"] == "true":
+		setting := "install-strategy=" + settings["install-strategy"]
+		if settings["global-style"] == "true" {
+			setting = "global-style=true"
+		}
+		return nodeModulesLayout{setting: setting + " of .npmrc", isolated: true}
+	case settings["install-strategy"] == "linked":
+		return nodeModulesLayout{setting: "install-strategy=linked of .npmrc", isolated: true}
+	}
+	return nodeModulesLayout{}
+}
+
+// pnpmLayout returns the layout of node_modules of pnpm, whose default
+// linker isolates the packages, hoisting only the ones matching
+// public-hoist-pattern to the root.
+func pnpmLayout(settings map[string]string) nodeModulesLayout {
+	switch {
+	case settings["node-linker"] == "hoisted":
+		return nodeModulesLayout{setting: "node-linker=hoisted of .npmrc"}
+	case settings["node-linker"] == "pnp":
+		return nodeModulesLayout{setting: "node-linker=pnp of .npmrc", pnp: true}
+	case settings["shamefully-hoist"] == "true":
+		return nodeModulesLayout{setting: "shamefully-hoist=true of .npmrc"}
+	}
+	l := nodeModulesLayout{setting: "the isolated node-linker of pnpm", isolated: true, publicHoist: defaultPublicHoistPatterns}
+	if patterns, ok := settings["public-hoist-pattern"]; ok {
+		l.publicHoist = strings.Fields(patterns)
+	}
+	return l
+}
+
+// yarnLayout returns the layout of node_modules set by .yarnrc.yml, whose
+// default linker is Plug'n'Play.
+func yarnLayout(data []byte) nodeModulesLayout {
+	linker, limits := "pnp", ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value, _, _ = strings.Cut(value, " #")
+		switch key {
+		case "nodeLinker":
+			linker = yamlUnquote(value)
+		case "nmHoistingLimits":
+			limits = yamlUnquote(value)
+		}
+	}
+	switch {
+	case linker == "pnp":
+		return nodeModulesLayout{setting: "nodeLinker: pnp of .yarnrc.yml", pnp: true}
+	case linker == "pnpm":
+		return nodeModulesLayout{setting: "nodeLinker: pnpm of .yarnrc.yml", isolated: true}
+	case limits == "dependencies":
+		return nodeModulesLayout{setting: "nmHoistingLimits: dependencies of .yarnrc.yml", isolated: true}
+	}
+	return nodeModulesLayout{setting: "nodeLinker: node-modules of .yarnrc.yml"}
+}
+
+// npmrcSettings returns the settings of the .npmrc file. The values of the
+// arrays, e.g. public-hoist-pattern[]=*types*, are separated by spaces.
+func npmrcSettings(data []byte) map[string]string {
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), yamlUnquote(value)
+		if name, isArray := strings.CutSuffix(key, "[]"); isArray {
+			settings[name] = strings.TrimSpace(settings[name] + " " + value)
+			continue
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// hoistPatternMatch returns the pattern of public-hoist-pattern hoisting
+// the package, if any. Like pnpm, "*" matches any sequence of characters,
+// including "/", and a later pattern starting with "!" excludes packages.
+func hoistPatternMatch(patterns []string, pkgName string) (string, bool) {
+	match := ""
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		glob := strings.TrimPrefix(pattern, "!")
+		re, err := regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(glob), `\*`, ".*") + "$")
+		if err != nil || !re.MatchString(pkgName) {
+			continue
+		}
+		if negated {
+			match = ""
+		} else {
+			match = pattern
+		}
+	}
+	return match, match != ""
+}
+
+// phantomSeverity returns the severity of the phantom dependency, and why
+// it differs from the default one, if it does: the packages hoisted on
+// purpose by public-hoist-pattern are only noted, while the ones which a
+// strict layout doesn't hoist, i.e. which only resolve from a stale
+// node_modules, are errors.
+func (l nodeModulesLayout) phantomSeverity(pkgName string) (Severity, string) {
+	switch {
+	case l.pnp:
+		return SeverityError, fmt.Sprintf("it won't resolve with %s", l.setting)
+	case l.isolated:
+		if pattern, ok := hoistPatternMatch(l.publicHoist, pkgName); ok {
+			return SeverityNote, fmt.Sprintf("it is hoisted on purpose by public-hoist-pattern %q", pattern)
+		}
+		return SeverityError, fmt.Sprintf("it won't resolve after a clean install with %s", l.setting)
+	case l.setting != "":
+		return SeverityWarning, fmt.Sprintf("it only resolves because of %s", l.setting)
+	}
+	return SeverityWarning, ""
+}
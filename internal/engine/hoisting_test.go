@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPhantomSeverity(t *testing.T) {
+	sc := newScanContext()
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		pkg      Package
+		pkgName  string
+		severity Severity
+		reason   string
+	}{
+		{"npm", fstest.MapFS{}, Package{}, "debug", SeverityWarning, ""},
+		{"npm nested", fstest.MapFS{".npmrc": {Data: []byte("install-strategy=nested\n")}}, Package{}, "debug",
+			SeverityError, "it won't resolve after a clean install with install-strategy=nested of .npmrc"},
+		{"pnpm", fstest.MapFS{"pnpm-lock.yaml": {}}, Package{}, "debug",
+			SeverityError, "it won't resolve after a clean install with the isolated node-linker of pnpm"},
+		{"pnpm default pattern", fstest.MapFS{}, Package{PackageManager: "pnpm@9.1.0"}, "@typescript-eslint/parser",
+			SeverityNote, `it is hoisted on purpose by public-hoist-pattern "*eslint*"`},
+		{"pnpm patterns", fstest.MapFS{"pnpm-lock.yaml": {}, ".npmrc": {Data: []byte("; hoisting\npublic-hoist-pattern[]=@types/*\npublic-hoist-pattern[]=!@types/react\n")}},
+			Package{}, "@types/node", SeverityNote, `it is hoisted on purpose by public-hoist-pattern "@types/*"`},
+		{"pnpm negated pattern", fstest.MapFS{"pnpm-lock.yaml": {}, ".npmrc": {Data: []byte("public-hoist-pattern[]=@types/*\npublic-hoist-pattern[]=!@types/react\n")}},
+			Package{}, "@types/react", SeverityError, "it won't resolve after a clean install with the isolated node-linker of pnpm"},
+		{"pnpm shamefully hoist", fstest.MapFS{"pnpm-lock.yaml": {}, ".npmrc": {Data: []byte("shamefully-hoist = true\n")}}, Package{}, "debug",
+			SeverityWarning, "it only resolves because of shamefully-hoist=true of .npmrc"},
+		{"yarn pnp", fstest.MapFS{".yarnrc.yml": {Data: []byte("enableTelemetry: false\n")}}, Package{}, "debug",
+			SeverityError, "it won't resolve with nodeLinker: pnp of .yarnrc.yml"},
+		{"yarn node-modules", fstest.MapFS{".yarnrc.yml": {Data: []byte("nodeLinker: \"node-modules\" # hoisted\n")}}, Package{}, "debug",
+			SeverityWarning, "it only resolves because of nodeLinker: node-modules of .yarnrc.yml"},
+		{"yarn hoisting limits", fstest.MapFS{".yarnrc.yml": {Data: []byte("nodeLinker: node-modules\nnmHoistingLimits: dependencies\n")}}, Package{}, "debug",
+			SeverityError, "it won't resolve after a clean install with nmHoistingLimits: dependencies of .yarnrc.yml"},
+	}
+	defer sc.setProjectFS(os.DirFS("."))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc.setProjectFS(tt.files)
+			severity, reason := sc.readNodeModulesLayout(tt.pkg).phantomSeverity(tt.pkgName)
+			if severity != tt.severity || reason != tt.reason {
+				t.Errorf("phantomSeverity(%q) = %s, %q, want %s, %q", tt.pkgName, severity, reason, tt.severity, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// importMapFiles are the files of the project root declaring the import
+// maps of the browser or of SystemJS, which map the bare specifiers of the
+// code to URLs.
+var importMapFiles = []string{
+	"importmap.json", "import-map.json", "index.html", "public/index.html",
+	"systemjs.config.js", "system.config.js",
+}
+
+// importMap maps bare specifiers, or their prefixes ending with "/", to
+// the URLs they are loaded from.
+type importMap struct {
+	// files are the files declaring the map.
+	files    []string
+	mappings map[string]string
+}
+
+var (
+	// importMapScriptRe matches the inline import maps of HTML pages.
+	importMapScriptRe = regexp.MustCompile(`(?s)<script[^>]*type=["'](?:importmap|systemjs-importmap)["'][^>]*>(.*?)</script>`)
+	// systemJSMapRe matches the "map" of a SystemJS config, whose
+	// mappings are read with systemJSMappingRe.
+	systemJSMapRe     = regexp.MustCompile(`(?s)\bmap\s*:\s*\{(.*?)\}`)
+	systemJSMappingRe = regexp.MustCompile(`["']?([\w@./-]+)["']?\s*:\s*["']([^"']+)["']`)
+	// cdnPackageRe matches the URLs of the packages served by the CDNs
+	// of npm, e.g. https://cdn.jsdelivr.net/npm/lodash@4/lodash.js.
+	cdnPackageRe = regexp.MustCompile(`^https?://(?:cdn\.jsdelivr\.net/npm/|unpkg\.com/|esm\.sh/(?:v\d+/)?|esm\.run/|cdn\.skypack\.dev/|ga\.jspm\.io/npm:)((?:@[^/@]+/)?[^/@?]+)`)
+	// installedPackageRe matches the targets loading an installed package,
+	// e.g. /node_modules/lodash/lodash.js, or npm:lodash@4 for SystemJS.
+	installedPackageRe = regexp.MustCompile(`(?:^npm:|node_modules/)((?:@[^/@]+/)?[^/@]+)`)
+)
+
+// readImportMaps reads the import maps of the project. The scopes of the
+// maps apply to every file, after the top-level imports.
+func (sc *scanContext) readImportMaps() importMap {
+	m := importMap{mappings: make(map[string]string)}
+	for _, file := range importMapFiles {
+		data, err := sc.readProjectFile(file)
+		if err != nil {
+			continue
+		}
+		var docs [][]byte
+		switch {
+		case strings.HasSuffix(file, ".json"):
+			docs = [][]byte{data}
+		case strings.HasSuffix(file, ".html"):
+			for _, match := range importMapScriptRe.FindAllSubmatch(data, -1) {
+				docs = append(docs, match[1])
+			}
+		default:
+			for _, match := range systemJSMapRe.FindAllSubmatch(data, -1) {
+				for _, mapping := range systemJSMappingRe.FindAllSubmatch(match[1], -1) {
+					m.add(file, string(mapping[1]), string(mapping[2]))
+				}
+			}
+		}
+		for _, data := range docs {
+			var doc struct {
+				Imports map[string]string            `json:"imports"`
+				Scopes  map[string]map[string]string `json:"scopes"`
+			}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				err = fmt.Errorf("invalid import map: %v", err)
+				fmt.Fprintf(sc.logOut, "Skipping %s: %v\n", file, err)
+				sc.recordDiagnostic(file, err)
+				continue
+			}
+			for specifier, target := range doc.Imports {
+				m.add(file, specifier, target)
+			}
+			scopes := make([]string, 0, len(doc.Scopes))
+			for scope := range doc.Scopes {
+				scopes = append(scopes, scope)
+			}
+			sort.Strings(scopes)
+			for _, scope := range scopes {
+				for specifier, target := range doc.Scopes[scope] {
+					if _, ok := m.mappings[specifier]; !ok {
+						m.add(file, specifier, target)
+					}
+				}
+			}
+		}
+	}
+	return m
+}
+
+// add records the mapping of the specifier declared by the file.
+func (m *importMap) add(file, specifier, target string) {
+	if len(m.files) == 0 || m.files[len(m.files)-1] != file {
+		m.files = append(m.files, file)
+	}
+	m.mappings[specifier] = target
+}
+
+// resolve returns the target the specifier is mapped to: the target of
+// the specifier itself, or of its longest prefix ending with "/", followed
+// by the rest of the specifier.
+func (m importMap) resolve(specifier string) (string, bool) {
+	if target, ok := m.mappings[specifier]; ok {
+		return target, true
+	}
+	best := ""
+	for prefix := range m.mappings {
+		if strings.HasSuffix(prefix, "/") && strings.HasPrefix(specifier, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return m.mappings[best] + strings.TrimPrefix(specifier, best), true
+}
+
+// mappedPackage returns the package loaded by the target of a mapping,
+// and whether it is loaded from the installed packages rather than from a
+// CDN. It returns "" for the other targets, e.g. the files of the project.
+func mappedPackage(target string) (pkgName string, installed bool) {
+	if m := installedPackageRe.FindStringSubmatch(target); m != nil {
+		return m[1], true
+	}
+	if m := cdnPackageRe.FindStringSubmatch(target); m != nil {
+		return m[1], false
+	}
+	return "", false
+}
+
+// applyImportMap returns the module the specifier found in a file uses,
+// given the import map of the project, and whether it uses one. Mapped
+// specifiers use the package they are mapped to: the installed ones are
+// used like imports, and the ones served by a CDN only count as usage of
+// the declared dependencies, since they needn't be installed. The ones
+// mapped to other URLs, e.g. the files of the project, use no module.
+//
+// It must be called with d.mu held.
+func (sc *scanContext) applyImportMap(specifier string) (string, bool) {
+	target, ok := sc.importMaps.resolve(specifier)
+	if !ok {
+		return specifier, true
+	}
+	pkgName, installed := mappedPackage(target)
+	if pkgName == "" {
+		return "", false
+	}
+	if _, declared := sc.d.mp[pkgName]; !installed && !declared {
+		return "", false
+	}
+	return pkgName, true
+}
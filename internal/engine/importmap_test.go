@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadImportMaps(t *testing.T) {
+	sc := newScanContext()
+	sc.setProjectFS(fstest.MapFS{
+		"importmap.json": {Data: []byte(`{
+  "imports": {"react": "https://esm.sh/react@18.2.0", "utils/": "/node_modules/lodash-es/"},
+  "scopes": {"/legacy/": {"react": "https://unpkg.com/react@16/umd/react.js", "jquery": "https://code.jquery.com/jquery.js"}}
+}`)},
+		"index.html": {Data: []byte(`<script type="importmap">{"imports": {"app/": "/js/app/"}}</script>`)},
+		"systemjs.config.js": {Data: []byte(`System.config({
+  paths: { 'npm:': 'node_modules/' },
+  map: {
+    '@angular/core': 'npm:@angular/core/bundles/core.umd.js',
+    rxjs: 'npm:rxjs@7'
+  }
+});`)},
+	})
+	defer sc.setProjectFS(os.DirFS("."))
+
+	m := sc.readImportMaps()
+	if want := []string{"importmap.json", "index.html", "systemjs.config.js"}; !reflect.DeepEqual(m.files, want) {
+		t.Errorf("files = %v, want %v", m.files, want)
+	}
+	tests := []struct {
+		specifier, target string
+		ok                bool
+	}{
+		{"react", "https://esm.sh/react@18.2.0", true},
+		{"utils/debounce.js", "/node_modules/lodash-es/debounce.js", true},
+		{"app/main.js", "/js/app/main.js", true},
+		{"jquery", "https://code.jquery.com/jquery.js", true},
+		{"@angular/core", "npm:@angular/core/bundles/core.umd.js", true},
+		{"dayjs", "", false},
+	}
+	for _, tt := range tests {
+		if target, ok := m.resolve(tt.specifier); target != tt.target || ok != tt.ok {
+			t.Errorf("resolve(%q) = %q, %v, want %q, %v", tt.specifier, target, ok, tt.target, tt.ok)
+		}
+	}
+}
+
+func TestMappedPackage(t *testing.T) {
+	tests := []struct {
+		target    string
+		pkgName   string
+		installed bool
+	}{
+		{"/node_modules/lodash-es/debounce.js", "lodash-es", true},
+		{"npm:@angular/core@17/bundles/core.umd.js", "@angular/core", true},
+		{"https://cdn.jsdelivr.net/npm/lodash@4.17.21/lodash.min.js", "lodash", false},
+		{"https://esm.sh/v135/@preact/signals@1.2.0", "@preact/signals", false},
+		{"https://ga.jspm.io/npm:react@18.2.0/index.js", "react", false},
+		{"/js/app/main.js", "", false},
+		{"https://code.jquery.com/jquery.js", "", false},
+	}
+	for _, tt := range tests {
+		if pkgName, installed := mappedPackage(tt.target); pkgName != tt.pkgName || installed != tt.installed {
+			t.Errorf("mappedPackage(%q) = %q, %v, want %q, %v", tt.target, pkgName, installed, tt.pkgName, tt.installed)
+		}
+	}
+}
+
+func TestImportMapUsage(t *testing.T) {
+	sc := newScanContext()
+	sc.setProjectFS(fstest.MapFS{
+		"package.json":   {Data: []byte(`{"dependencies": {"react": "^18.2.0", "lodash-es": "^4.17.21"}}`)},
+		"importmap.json": {Data: []byte(`{"imports": {"react": "https://esm.sh/react@18.2.0", "preact": "https://esm.sh/preact", "utils/": "/node_modules/lodash-es/", "app/": "/js/app/"}}`)},
+	})
+	sc.lang, sc.manifestFile = sc.nodeLanguage, "package.json"
+	defer func(w io.Writer) {
+		sc.setProjectFS(os.DirFS("."))
+		sc.lang, sc.manifestFile, sc.logOut = nil, "", w
+		sc.d, sc.importMaps = Dependency{}, importMap{}
+	}(sc.logOut)
+	sc.logOut = io.Discard
+	sc.d.mp = map[string]bool{"react": false, "lodash-es": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.importMaps = sc.readImportMaps()
+
+	for _, specifier := range []string{"react", "preact", "utils/debounce.js", "app/main.js", "dayjs"} {
+		sc.markModuleAsFound(specifier, Location{File: "src/main.js", Line: 1})
+	}
+	if want := map[string]bool{"react": true, "lodash-es": true}; !reflect.DeepEqual(sc.d.mp, want) {
+		t.Errorf("dependencies = %v, want %v", sc.d.mp, want)
+	}
+	var used []string
+	for pkgName := range sc.d.usages {
+		used = append(used, pkgName)
+	}
+	if len(used) != 3 || sc.d.usages["dayjs"] == nil {
+		t.Errorf("usages = %v, want react, lodash-es and dayjs", sc.d.usages)
+	}
+
+	findings := sc.buildFindings("")
+	if len(findings) != 1 || findings[0].RuleID != RuleMissingDependency || findings[0].Package != "dayjs" ||
+		!strings.Contains(findings[0].Message, "nor mapped by importmap.json") || !strings.HasSuffix(findings[0].SuggestedFix, "or map it in importmap.json") {
+		t.Errorf("findings = %+v, want dayjs missing from the import map", findings)
+	}
+}
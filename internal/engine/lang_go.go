@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// GoMod represents the parts of the go.mod file used by depose.
+type GoMod struct {
+	// Module is the path of the main module.
+	Module string
+	// Requires maps the required modules to whether they are
+	// marked as "// indirect".
+	Requires map[string]bool
+	// Lines maps the required modules to the line they are required on.
+	Lines map[string]int
+	// Tools lists the packages declared with tool directives.
+	Tools []string
+}
+
+// isExcludedGoPath reports whether the path is ignored by the go command,
+// i.e. any directory named vendor or testdata, or any file or directory
+// whose name starts with "_" or ".", at any depth.
+func isExcludedGoPath(path string, isDir bool) bool {
+	if path == "." {
+		return false
+	}
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+		return true
+	}
+	return isDir && (name == "vendor" || name == "testdata" || name == "node_modules")
+}
+
+// readGoMod reads the go.mod file, and populates the map of "d" with the
+// direct requirements. The modules providing tools declared with tool
+// directives are marked as used, as they are not imported anywhere.
+func (sc *scanContext) readGoMod(path string) string {
+	file, err := sc.openProjectFile(path)
+	if err != nil {
+		fatal(classifyError(path, err, true))
+	}
+	defer file.Close()
+
+	fmt.Fprintln(sc.logOut, "Reading go.mod")
+	sc.goMod = parseGoMod(bufio.NewScanner(file))
+
+	for module, indirect := range sc.goMod.Requires {
+		if !indirect {
+			sc.d.mp[module] = false
+		}
+	}
+	for _, tool := range sc.goMod.Tools {
+		if module, ok := sc.goModuleOf(tool); ok {
+			if _, declared := sc.d.mp[module]; declared {
+				sc.markAsUsed(module, EvidenceTool, Location{File: "go.mod"})
+			}
+		}
+	}
+	return sc.goMod.Module
+}
+
+// parseGoMod parses the module, require and tool directives of a go.mod file,
+// in both their single line and block forms.
+func parseGoMod(scanner *bufio.Scanner) GoMod {
+	mod := GoMod{Requires: make(map[string]bool), Lines: make(map[string]int)}
+
+	block := ""
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		indirect := strings.Contains(line, "// indirect")
+		if idx := strings.Index(line, "//"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		switch {
+		case fields[0] == "module" && len(fields) > 1:
+			mod.Module = unquoteGoModPath(fields[1])
+		case fields[0] == "require" && len(fields) > 2:
+			mod.Requires[unquoteGoModPath(fields[1])] = indirect
+			mod.Lines[unquoteGoModPath(fields[1])] = lineNo
+		case fields[0] == "tool" && len(fields) > 1:
+			mod.Tools = append(mod.Tools, unquoteGoModPath(fields[1]))
+		}
+	}
+	return mod
+}
+
+// unquoteGoModPath removes the optional quotes around a path in go.mod.
+func unquoteGoModPath(path string) string {
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+// goModuleOf returns the required module providing the imported package,
+// i.e. the longest module path which is a prefix of the import path.
+//
+// Packages of the main module are local, so they are skipped. Import paths
+// not provided by any required module are returned as is.
+func (sc *scanContext) goModuleOf(importPath string) (string, bool) {
+	if sc.goMod.Module != "" && (importPath == sc.goMod.Module || strings.HasPrefix(importPath, sc.goMod.Module+"/")) {
+		return "", false
+	}
+
+	best := ""
+	for module := range sc.goMod.Requires {
+		if (importPath == module || strings.HasPrefix(importPath, module+"/")) && len(module) > len(best) {
+			best = module
+		}
+	}
+	if best == "" {
+		return importPath, true
+	}
+	return best, true
+}
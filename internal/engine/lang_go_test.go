@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+const testGoMod = `module example.com/app
+
+go 1.22
+
+require (
+	github.com/pkg/errors v0.9.1
+	"golang.org/x/sync" v0.7.0
+)
+
+require (
+	github.com/indirect/thing v1.0.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+require github.com/single/line v1.2.3
+
+tool golang.org/x/tools/cmd/stringer
+`
+
+func TestParseGoMod(t *testing.T) {
+	mod := parseGoMod(bufio.NewScanner(strings.NewReader(testGoMod)))
+
+	if mod.Module != "example.com/app" {
+		t.Errorf("Module = %q, want %q", mod.Module, "example.com/app")
+	}
+	wantRequires := map[string]bool{
+		"github.com/pkg/errors":     false,
+		"golang.org/x/sync":         false,
+		"github.com/indirect/thing": true,
+		"golang.org/x/text":         true,
+		"github.com/single/line":    false,
+	}
+	if !reflect.DeepEqual(mod.Requires, wantRequires) {
+		t.Errorf("Requires = %v, want %v", mod.Requires, wantRequires)
+	}
+	// The lines of the first block are kept when the second block is parsed.
+	wantLines := map[string]int{
+		"github.com/pkg/errors":     6,
+		"golang.org/x/sync":         7,
+		"github.com/indirect/thing": 11,
+		"golang.org/x/text":         12,
+		"github.com/single/line":    15,
+	}
+	if !reflect.DeepEqual(mod.Lines, wantLines) {
+		t.Errorf("Lines = %v, want %v", mod.Lines, wantLines)
+	}
+	if want := []string{"golang.org/x/tools/cmd/stringer"}; !reflect.DeepEqual(mod.Tools, want) {
+		t.Errorf("Tools = %v, want %v", mod.Tools, want)
+	}
+}
+
+func TestGoModuleOf(t *testing.T) {
+	sc := newScanContext()
+	sc.goMod = GoMod{
+		Module: "example.com/app",
+		Requires: map[string]bool{
+			"golang.org/x/tools":       false,
+			"golang.org/x/tools/gopls": false,
+		},
+	}
+	defer func() { sc.goMod = GoMod{} }()
+
+	tests := []struct {
+		importPath string
+		module     string
+		ok         bool
+	}{
+		{"example.com/app", "", false},
+		{"example.com/app/internal/x", "", false},
+		{"golang.org/x/tools/go/packages", "golang.org/x/tools", true},
+		{"golang.org/x/tools/gopls/internal", "golang.org/x/tools/gopls", true},
+		{"golang.org/x/toolsmith", "golang.org/x/toolsmith", true},
+		{"fmt", "fmt", true},
+	}
+	for _, tt := range tests {
+		module, ok := sc.goModuleOf(tt.importPath)
+		if module != tt.module || ok != tt.ok {
+			t.Errorf("goModuleOf(%q) = %q, %v, want %q, %v", tt.importPath, module, ok, tt.module, tt.ok)
+		}
+	}
+}
+
+func TestIsExcludedGoPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{".", true, false},
+		{"internal", true, false},
+		{"vendor", true, true},
+		{filepath.Join("internal", "x", "testdata"), true, true},
+		{filepath.Join("pkg", "_old"), true, true},
+		{filepath.Join("pkg", ".cache"), true, true},
+		{filepath.Join("pkg", "_gen.go"), false, true},
+		{filepath.Join("pkg", "vendor.go"), false, false},
+	}
+	for _, tt := range tests {
+		if got := isExcludedGoPath(tt.path, tt.isDir); got != tt.excluded {
+			t.Errorf("isExcludedGoPath(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.excluded)
+		}
+	}
+}
+
+func TestReadGoFileAndExtractPackages(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.goLanguage
+	sc.goMod = GoMod{
+		Module:   "example.com/app",
+		Requires: map[string]bool{"github.com/pkg/errors": false},
+	}
+	sc.d.mp = map[string]bool{"github.com/pkg/errors": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.d.ignoredLines = make(map[Location][]string)
+	defer func() { sc.lang, sc.goMod, sc.d.mp, sc.d.usages, sc.d.ignoredLines = nil, GoMod{}, nil, nil, nil }()
+
+	file := "main.go"
+	src := `package main
+
+import (
+	"fmt"
+	"example.com/app/internal/x"
+	"github.com/pkg/errors/sub"
+	// depose-ignore next-line
+	"github.com/undeclared/mod"
+)
+`
+	sc.setProjectFS(fstest.MapFS{file: {Data: []byte(src)}})
+	defer sc.setProjectFS(os.DirFS("."))
+	extractor, ok := extract.For("go", file)
+	if !ok {
+		t.Fatalf("no extractor registered for Go files")
+	}
+	sc.readFileAndExtractPackages(sc.logOut, file, extractor)
+
+	if !sc.d.mp["github.com/pkg/errors"] {
+		t.Errorf("github.com/pkg/errors was not marked as used")
+	}
+	if _, ok := sc.d.usages["example.com/app"]; ok {
+		t.Errorf("packages of the main module were recorded as usages")
+	}
+	wantUsages := []string{"fmt", "github.com/pkg/errors", "github.com/undeclared/mod"}
+	var got []string
+	for name := range sc.d.usages {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, wantUsages) {
+		t.Errorf("usages = %v, want %v", got, wantUsages)
+	}
+	if loc := (Location{File: filepath.ToSlash(file), Line: 8}); !sc.isLineIgnored(loc, RuleMissingDependency) {
+		t.Errorf("import after the ignore directive was not ignored")
+	}
+}
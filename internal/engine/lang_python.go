@@ -0,0 +1,314 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pythonModules maps distribution names, as declared in requirements.txt or
+// pyproject.toml, to the top-level modules they provide, for the well-known
+// distributions whose module name is not derived from their distribution name.
+//
+// Other distributions are expected to provide a module named after them,
+// e.g. "typing-extensions" provides "typing_extensions".
+var pythonModules = map[string][]string{
+	"attrs":                    {"attr", "attrs"},
+	"beautifulsoup4":           {"bs4"},
+	"django-cors-headers":      {"corsheaders"},
+	"djangorestframework":      {"rest_framework"},
+	"google-api-python-client": {"googleapiclient"},
+	"grpcio":                   {"grpc"},
+	"msgpack-python":           {"msgpack"},
+	"opencv-contrib-python":    {"cv2"},
+	"opencv-python":            {"cv2"},
+	"opencv-python-headless":   {"cv2"},
+	"pillow":                   {"PIL"},
+	"protobuf":                 {"google"},
+	"psycopg2-binary":          {"psycopg2"},
+	"pycryptodome":             {"Crypto"},
+	"pyjwt":                    {"jwt"},
+	"pymupdf":                  {"fitz"},
+	"pyopenssl":                {"OpenSSL"},
+	"python-dateutil":          {"dateutil"},
+	"python-dotenv":            {"dotenv"},
+	"python-jose":              {"jose"},
+	"python-magic":             {"magic"},
+	"python-multipart":         {"multipart"},
+	"pyyaml":                   {"yaml"},
+	"pyzmq":                    {"zmq"},
+	"scikit-image":             {"skimage"},
+	"scikit-learn":             {"sklearn"},
+	"setuptools":               {"setuptools", "pkg_resources"},
+}
+
+// pythonStdlib is the list of top-level modules of the Python standard
+// library: sys.stdlib_module_names of Python 3.11, which still holds the
+// modules removed by 3.12 and 3.13, e.g. distutils, along with the ones
+// added since, annotationlib and compression.
+var pythonStdlib = map[string]bool{
+	"__future__": true, "_abc": true, "_aix_support": true, "_ast": true, "_asyncio": true,
+	"_bisect": true, "_blake2": true, "_bootsubprocess": true, "_bz2": true, "_codecs": true,
+	"_codecs_cn": true, "_codecs_hk": true, "_codecs_iso2022": true, "_codecs_jp": true,
+	"_codecs_kr": true, "_codecs_tw": true, "_collections": true, "_collections_abc": true,
+	"_compat_pickle": true, "_compression": true, "_contextvars": true, "_crypt": true,
+	"_csv": true, "_ctypes": true, "_curses": true, "_curses_panel": true, "_datetime": true,
+	"_dbm": true, "_decimal": true, "_elementtree": true, "_frozen_importlib": true,
+	"_frozen_importlib_external": true, "_functools": true, "_gdbm": true, "_hashlib": true,
+	"_heapq": true, "_imp": true, "_io": true, "_json": true, "_locale": true, "_lsprof": true,
+	"_lzma": true, "_markupbase": true, "_md5": true, "_msi": true, "_multibytecodec": true,
+	"_multiprocessing": true, "_opcode": true, "_operator": true, "_osx_support": true,
+	"_overlapped": true, "_pickle": true, "_posixshmem": true, "_posixsubprocess": true,
+	"_py_abc": true, "_pydecimal": true, "_pyio": true, "_queue": true, "_random": true,
+	"_scproxy": true, "_sha1": true, "_sha256": true, "_sha3": true, "_sha512": true,
+	"_signal": true, "_sitebuiltins": true, "_socket": true, "_sqlite3": true, "_sre": true,
+	"_ssl": true, "_stat": true, "_statistics": true, "_string": true, "_strptime": true,
+	"_struct": true, "_symtable": true, "_thread": true, "_threading_local": true,
+	"_tkinter": true, "_tokenize": true, "_tracemalloc": true, "_typing": true, "_uuid": true,
+	"_warnings": true, "_weakref": true, "_weakrefset": true, "_winapi": true, "_zoneinfo": true,
+	"abc": true, "aifc": true, "annotationlib": true, "antigravity": true, "argparse": true,
+	"array": true, "ast": true, "asynchat": true, "asyncio": true, "asyncore": true,
+	"atexit": true, "audioop": true, "base64": true, "bdb": true, "binascii": true,
+	"bisect": true, "builtins": true, "bz2": true, "cProfile": true, "calendar": true,
+	"cgi": true, "cgitb": true, "chunk": true, "cmath": true, "cmd": true, "code": true,
+	"codecs": true, "codeop": true, "collections": true, "colorsys": true, "compileall": true,
+	"compression": true, "concurrent": true, "configparser": true, "contextlib": true,
+	"contextvars": true, "copy": true, "copyreg": true, "crypt": true, "csv": true,
+	"ctypes": true, "curses": true, "dataclasses": true, "datetime": true, "dbm": true,
+	"decimal": true, "difflib": true, "dis": true, "distutils": true, "doctest": true,
+	"email": true, "encodings": true, "ensurepip": true, "enum": true, "errno": true,
+	"faulthandler": true, "fcntl": true, "filecmp": true, "fileinput": true, "fnmatch": true,
+	"fractions": true, "ftplib": true, "functools": true, "gc": true, "genericpath": true,
+	"getopt": true, "getpass": true, "gettext": true, "glob": true, "graphlib": true, "grp": true,
+	"gzip": true, "hashlib": true, "heapq": true, "hmac": true, "html": true, "http": true,
+	"idlelib": true, "imaplib": true, "imghdr": true, "imp": true, "importlib": true,
+	"inspect": true, "io": true, "ipaddress": true, "itertools": true, "json": true,
+	"keyword": true, "lib2to3": true, "linecache": true, "locale": true, "logging": true,
+	"lzma": true, "mailbox": true, "mailcap": true, "marshal": true, "math": true,
+	"mimetypes": true, "mmap": true, "modulefinder": true, "msilib": true, "msvcrt": true,
+	"multiprocessing": true, "netrc": true, "nis": true, "nntplib": true, "nt": true,
+	"ntpath": true, "nturl2path": true, "numbers": true, "opcode": true, "operator": true,
+	"optparse": true, "os": true, "ossaudiodev": true, "pathlib": true, "pdb": true,
+	"pickle": true, "pickletools": true, "pipes": true, "pkgutil": true, "platform": true,
+	"plistlib": true, "poplib": true, "posix": true, "posixpath": true, "pprint": true,
+	"profile": true, "pstats": true, "pty": true, "pwd": true, "py_compile": true, "pyclbr": true,
+	"pydoc": true, "pydoc_data": true, "pyexpat": true, "queue": true, "quopri": true,
+	"random": true, "re": true, "readline": true, "reprlib": true, "resource": true,
+	"rlcompleter": true, "runpy": true, "sched": true, "secrets": true, "select": true,
+	"selectors": true, "shelve": true, "shlex": true, "shutil": true, "signal": true,
+	"site": true, "smtpd": true, "smtplib": true, "sndhdr": true, "socket": true,
+	"socketserver": true, "spwd": true, "sqlite3": true, "sre_compile": true,
+	"sre_constants": true, "sre_parse": true, "ssl": true, "stat": true, "statistics": true,
+	"string": true, "stringprep": true, "struct": true, "subprocess": true, "sunau": true,
+	"symtable": true, "sys": true, "sysconfig": true, "syslog": true, "tabnanny": true,
+	"tarfile": true, "telnetlib": true, "tempfile": true, "termios": true, "textwrap": true,
+	"this": true, "threading": true, "time": true, "timeit": true, "tkinter": true, "token": true,
+	"tokenize": true, "tomllib": true, "trace": true, "traceback": true, "tracemalloc": true,
+	"tty": true, "turtle": true, "turtledemo": true, "types": true, "typing": true,
+	"unicodedata": true, "unittest": true, "urllib": true, "uu": true, "uuid": true, "venv": true,
+	"warnings": true, "wave": true, "weakref": true, "webbrowser": true, "winreg": true,
+	"winsound": true, "wsgiref": true, "xdrlib": true, "xml": true, "xmlrpc": true,
+	"zipapp": true, "zipfile": true, "zipimport": true, "zlib": true, "zoneinfo": true,
+}
+
+// pythonProject holds the requirements of the scanned Python project.
+type pythonProject struct {
+	// sections maps each requirement to the section it is declared in.
+	sections map[string]string
+	// lines maps each section to the line every requirement is declared on.
+	lines map[string]map[string]int
+	// modules maps the top-level modules provided by the requirements
+	// to their distribution name.
+	modules map[string]string
+}
+
+var (
+	pythonRequirementRe = regexp.MustCompile(`^\s*["']?([A-Za-z0-9][A-Za-z0-9._-]*)`)
+	pythonSeparatorRe   = regexp.MustCompile(`[-_.]+`)
+	tomlTableRe         = regexp.MustCompile(`^\s*\[+([^\]]+)\]+\s*$`)
+	tomlKeyRe           = regexp.MustCompile(`^\s*["']?([A-Za-z0-9._-]+)["']?\s*=\s*(.*)$`)
+)
+
+// canonicalDistName normalizes a distribution name as described in PEP 503,
+// e.g. "Flask_SQLAlchemy" becomes "flask-sqlalchemy".
+func canonicalDistName(name string) string {
+	return pythonSeparatorRe.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// readPythonRequirements reads the requirements of the project, populates
+// the map of "d" with them, and maps the modules they provide back to them.
+func (sc *scanContext) readPythonRequirements(path string) string {
+	file, err := sc.openProjectFile(path)
+	if err != nil {
+		fatal(classifyError(path, err, true))
+	}
+	defer file.Close()
+
+	fmt.Fprintf(sc.logOut, "Reading %s\n", path)
+	var name string
+	if filepath.Base(path) == "pyproject.toml" {
+		name, sc.pyProject = parsePyProject(bufio.NewScanner(file))
+	} else {
+		sc.pyProject = parseRequirementsTxt(bufio.NewScanner(file))
+	}
+
+	for dist := range sc.pyProject.sections {
+		sc.d.mp[dist] = false
+	}
+	return name
+}
+
+// newPythonProject creates an empty Python project.
+func newPythonProject() pythonProject {
+	return pythonProject{
+		sections: make(map[string]string),
+		lines:    make(map[string]map[string]int),
+		modules:  make(map[string]string),
+	}
+}
+
+// addRequirement records the requirement string declared in the section,
+// e.g. "requests[socks]>=2.31; python_version > '3.8'".
+func (p *pythonProject) addRequirement(requirement, section string, lineNo int) {
+	m := pythonRequirementRe.FindStringSubmatch(requirement)
+	if m == nil {
+		return
+	}
+	dist := canonicalDistName(m[1])
+	if dist == "python" {
+		return
+	}
+
+	p.sections[dist] = section
+	if p.lines[section] == nil {
+		p.lines[section] = make(map[string]int)
+	}
+	p.lines[section][dist] = lineNo
+
+	modules, ok := pythonModules[dist]
+	if !ok {
+		modules = []string{strings.ReplaceAll(dist, "-", "_")}
+	}
+	for _, module := range modules {
+		p.modules[module] = dist
+	}
+}
+
+// parseRequirementsTxt parses a requirements.txt file.
+// Options such as "-r other.txt" or "-e ." are skipped.
+func parseRequirementsTxt(scanner *bufio.Scanner) pythonProject {
+	p := newPythonProject()
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		p.addRequirement(line, "requirements", lineNo)
+	}
+	return p
+}
+
+// parsePyProject parses the dependencies of a pyproject.toml file, declared
+// either in the PEP 621 [project] table or in the Poetry tables.
+// It returns the name of the project along with its requirements.
+//
+// Only the subset of TOML used to declare dependencies is supported.
+func parsePyProject(scanner *bufio.Scanner) (string, pythonProject) {
+	p := newPythonProject()
+	name := ""
+	table := ""
+	arraySection := "" // section of the multi-line array being read, if any
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if arraySection != "" {
+			for _, item := range tomlStrings(line) {
+				p.addRequirement(item, arraySection, lineNo)
+			}
+			if strings.Contains(line, "]") {
+				arraySection = ""
+			}
+			continue
+		}
+
+		if m := tomlTableRe.FindStringSubmatch(line); m != nil {
+			table = strings.TrimSpace(m[1])
+			continue
+		}
+		m := tomlKeyRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		key, value := m[1], strings.TrimSpace(m[2])
+
+		section := ""
+		switch {
+		case table == "project" && key == "name":
+			if values := tomlStrings(value); len(values) > 0 {
+				name = values[0]
+			}
+		case table == "project" && key == "dependencies":
+			section = "dependencies"
+		case table == "project.optional-dependencies":
+			section = "optional-dependencies." + key
+		case table == "dependency-groups":
+			section = "dependency-groups." + key
+		case table == "tool.poetry.dependencies",
+			table == "tool.poetry.dev-dependencies",
+			strings.HasPrefix(table, "tool.poetry.group.") && strings.HasSuffix(table, ".dependencies"):
+			// Poetry declares one dependency per key.
+			p.addRequirement(key, table, lineNo)
+			continue
+		}
+		if section == "" || !strings.HasPrefix(value, "[") {
+			continue
+		}
+
+		for _, item := range tomlStrings(value) {
+			p.addRequirement(item, section, lineNo)
+		}
+		if !strings.Contains(value, "]") {
+			arraySection = section
+		}
+	}
+	return name, p
+}
+
+// tomlStrings returns the quoted strings found in a TOML line.
+func tomlStrings(line string) []string {
+	var values []string
+	for {
+		start := strings.IndexAny(line, `"'`)
+		if start == -1 {
+			return values
+		}
+		quote := line[start]
+		end := strings.IndexByte(line[start+1:], quote)
+		if end == -1 {
+			return values
+		}
+		values = append(values, line[start+1:start+1+end])
+		line = line[start+end+2:]
+	}
+}
+
+// isLocalPythonModule reports whether the module is part of the project
+// itself, either at its root or in a "src" directory.
+func (sc *scanContext) isLocalPythonModule(module string) bool {
+	for _, dir := range []string{".", "src"} {
+		if _, err := sc.statProjectFile(filepath.Join(dir, module+".py")); err == nil {
+			return true
+		}
+		if info, err := sc.statProjectFile(filepath.Join(dir, module)); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRequirementsTxt(t *testing.T) {
+	requirements := `# web
+Flask==3.0.0
+requests[socks]>=2.31 ; python_version > "3.8"
+-r dev-requirements.txt
+-e .
+PyYAML  # config files
+`
+	p := parseRequirementsTxt(bufio.NewScanner(strings.NewReader(requirements)))
+
+	wantLines := map[string]map[string]int{
+		"requirements": {"flask": 2, "requests": 3, "pyyaml": 6},
+	}
+	if !reflect.DeepEqual(p.lines, wantLines) {
+		t.Errorf("lines = %v, want %v", p.lines, wantLines)
+	}
+	wantModules := map[string]string{"flask": "flask", "requests": "requests", "yaml": "pyyaml"}
+	if !reflect.DeepEqual(p.modules, wantModules) {
+		t.Errorf("modules = %v, want %v", p.modules, wantModules)
+	}
+}
+
+func TestParsePyProject(t *testing.T) {
+	pyproject := `[project]
+name = "my-app"
+dependencies = [
+    "fastapi>=0.110",
+    "python-dotenv", # settings
+]
+
+[project.optional-dependencies]
+test = ["pytest", "coverage[toml]"]
+
+[tool.poetry.dependencies]
+python = "^3.11"
+Pillow = "^10.0"
+
+[tool.poetry.group.dev.dependencies]
+ruff = "^0.3"
+
+[tool.ruff]
+line-length = 100
+`
+	name, p := parsePyProject(bufio.NewScanner(strings.NewReader(pyproject)))
+
+	if name != "my-app" {
+		t.Errorf("name = %q, want %q", name, "my-app")
+	}
+	wantSections := map[string]string{
+		"fastapi":       "dependencies",
+		"python-dotenv": "dependencies",
+		"pytest":        "optional-dependencies.test",
+		"coverage":      "optional-dependencies.test",
+		"pillow":        "tool.poetry.dependencies",
+		"ruff":          "tool.poetry.group.dev.dependencies",
+	}
+	if !reflect.DeepEqual(p.sections, wantSections) {
+		t.Errorf("sections = %v, want %v", p.sections, wantSections)
+	}
+	if line := p.lines["dependencies"]["python-dotenv"]; line != 5 {
+		t.Errorf("python-dotenv declared on line %d, want 5", line)
+	}
+	if p.modules["dotenv"] != "python-dotenv" || p.modules["PIL"] != "pillow" {
+		t.Errorf("unexpected modules: %v", p.modules)
+	}
+}
+
+func TestPythonNormalize(t *testing.T) {
+	sc := newScanContext()
+	sc.pyProject = newPythonProject()
+	sc.pyProject.addRequirement("PyYAML", "requirements", 1)
+	defer func() { sc.pyProject = pythonProject{} }()
+
+	tests := []struct {
+		module string
+		name   string
+		ok     bool
+	}{
+		{"os.path", "os", true},
+		{"yaml", "pyyaml", true},
+		{"yaml.constructor", "pyyaml", true},
+		{"PIL", "PIL", true},
+		{".utils", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := sc.pythonLanguage.normalize(tt.module)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("normalize(%q) = %q, %v, want %q, %v", tt.module, name, ok, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestPythonStdlibImports(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"requirements.txt": "requests==2.31.0\n",
+		"app.py": `import pdb, getopt, optparse, binascii, pkgutil
+import doctest
+import cProfile, pstats
+import mmap, resource, pwd, shelve, runpy, tokenize, tracemalloc
+from copyreg import pickle
+from reprlib import repr
+import code, sched
+from wsgiref.simple_server import make_server
+import xmlrpc.client
+import webbrowser, posixpath, ntpath
+import requests
+`,
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.languages["python"]
+	defer func() { sc.lang = sc.nodeLanguage }()
+	sc.d = Dependency{mp: make(map[string]bool), usages: make(map[string][]Location), ignoredLines: make(map[Location][]string), unresolved: make(map[string][]Location)}
+	defer func() { sc.d = Dependency{}; sc.pyProject = pythonProject{} }()
+
+	sc.manifestFile = sc.findManifest(sc.lang)
+	projectName := sc.lang.readManifest(sc.manifestFile)
+	sc.extractPackages([]string{"app.py"})
+	if findings := sc.buildFindings(projectName); len(findings) != 0 {
+		t.Errorf("findings = %+v, want none for the standard library", findings)
+	}
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// language bundles everything depose needs to know to analyze
+// the projects of a language: how to read their manifest, which
+// files to scan, and how to turn what is found into findings.
+//
+// The walker, the findings and the reporters are shared by every language.
+type language struct {
+	// name is the name of the language, used to look up its extractors.
+	name string
+	// manifestFiles are the names of the files which can declare the
+	// dependencies, by order of preference.
+	manifestFiles []string
+	// readManifest reads the manifest found at the path, and populates "d"
+	// with the declared dependencies. It returns the name of the project itself.
+	readManifest func(path string) string
+	// excluded reports whether the file or directory is skipped while scanning.
+	excluded func(path string, isDir bool) bool
+	// normalize turns a specifier found in a file into the name of the
+	// dependency providing it. It returns false for specifiers which do
+	// not refer to a dependency, e.g. local files.
+	normalize func(specifier string) (string, bool)
+	// internalTargets returns the specifiers an internal specifier maps to,
+	// e.g. the targets of "#db" in the "imports" field of package.json.
+	// It returns false for specifiers which are not internal.
+	internalTargets func(specifier string) ([]string, bool)
+	// isUnresolvable reports whether the specifier, found in the file, is not
+	// provided by the installed package it refers to. It is nil when the
+	// installed packages can't be inspected.
+	isUnresolvable func(specifier, file string) bool
+	// isBuiltin reports whether the package ships with the language itself.
+	isBuiltin func(pkgName string) bool
+	// isInstalled reports whether an undeclared package is available anyway,
+	// in which case it is reported as a phantom dependency.
+	isInstalled func(pkgName string) bool
+	// sectionOf returns the section of the manifest a dependency is declared in.
+	sectionOf func(dependency string) string
+	// unusedRule returns the rule reported for unused dependencies of the section.
+	unusedRule func(section string) string
+	// declaredLines returns the line of the manifest each dependency is
+	// declared on, grouped by section.
+	declaredLines func() map[string]map[string]int
+	// removeFix, addFix and phantomFix describe how to fix unused,
+	// missing and phantom dependencies.
+	removeFix  func(dependency, section string) string
+	addFix     func(pkgName string) string
+	phantomFix func(pkgName string) string
+	// rewriteManifest removes the dependencies from the manifest.
+	// It is nil when depose can't change the manifest by itself.
+	rewriteManifest func(depsToRemove []string)
+}
+
+// findManifest returns the first manifest file of the language present in
+// the project. When none is present, the preferred one is returned, so that
+// reading it reports a meaningful error.
+func (sc *scanContext) findManifest(l *language) string {
+	for _, file := range l.manifestFiles {
+		if _, err := sc.statProjectFile(file); err == nil {
+			return file
+		}
+	}
+	return l.manifestFiles[0]
+}
+
+// caseInsensitivePaths is true on the platforms whose file systems ignore
+// the case of file names by default, e.g. "Node_Modules" is "node_modules".
+var caseInsensitivePaths = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// isExcludedDir reports whether the directory is one of dirsToExclude,
+// which are skipped at the project root, or at any depth with excludeNested.
+func (sc *scanContext) isExcludedDir(path string) bool {
+	rel := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+	if !sc.excludeNested && strings.Contains(rel, "/") {
+		return false
+	}
+	name := filepath.Base(path)
+	for dir := range sc.dirsToExclude {
+		if name == dir || caseInsensitivePaths && strings.EqualFold(name, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedPath reports whether the path, relative to the project root,
+// is one of filesToExclude, or lies under one of them.
+//
+// Paths are compared component by component with forward slashes, whatever
+// the separator of the platform, so that scans behave the same everywhere.
+func (sc *scanContext) isExcludedPath(path string) bool {
+	rel := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+	for {
+		for excluded := range sc.filesToExclude {
+			if rel == excluded || caseInsensitivePaths && strings.EqualFold(rel, excluded) {
+				return true
+			}
+		}
+		parent := strings.LastIndex(rel, "/")
+		if parent == -1 {
+			return false
+		}
+		rel = rel[:parent]
+	}
+}
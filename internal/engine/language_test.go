@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsExcludedPath(t *testing.T) {
+	sc := newScanContext()
+	defer func(insensitive bool) { caseInsensitivePaths = insensitive }(caseInsensitivePaths)
+	caseInsensitivePaths = false
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"package.json", true},
+		{filepath.FromSlash("./depose-baseline.json"), true},
+		{"index.js", false},
+		{filepath.FromSlash("packages/app/package.json"), false},
+		{filepath.FromSlash("src/README.md.js"), false},
+		{"Readme.md", false},
+	}
+	for _, tt := range tests {
+		if got := sc.isExcludedPath(tt.path); got != tt.want {
+			t.Errorf("isExcludedPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	caseInsensitivePaths = true
+	if !sc.isExcludedPath("Readme.md") {
+		t.Errorf("isExcludedPath() is case sensitive on case insensitive platforms")
+	}
+}
+
+func TestNodeExcludedDirs(t *testing.T) {
+	sc := newScanContext()
+	defer func(insensitive, nested bool) { caseInsensitivePaths, sc.excludeNested = insensitive, nested }(caseInsensitivePaths, sc.excludeNested)
+	caseInsensitivePaths = false
+
+	tests := []struct {
+		path         string
+		isDir        bool
+		want, nested bool
+	}{
+		{"node_modules", true, true, true},
+		{filepath.FromSlash("packages/app/node_modules"), true, true, false},
+		{filepath.FromSlash("packages/app/.git"), true, true, false},
+		{filepath.FromSlash("src/node_modules_helpers"), true, false, false},
+		{filepath.FromSlash("src/node_modules"), false, false, false},
+	}
+	for _, tt := range tests {
+		sc.excludeNested = true
+		if got := sc.nodeLanguage.excluded(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("excluded(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+		sc.excludeNested = false
+		if got := sc.nodeLanguage.excluded(tt.path, tt.isDir); got != tt.nested {
+			t.Errorf("excluded(%q) with --exclude-nested=false = %v, want %v", tt.path, got, tt.nested)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lastReference is the last commit which added or removed a reference
+// to a package in the sources of the project, outside of its manifests.
+type lastReference struct {
+	Commit string    `json:"commit"`
+	Author string    `json:"author"`
+	Date   time.Time `json:"date"`
+}
+
+func (r lastReference) String() string {
+	return fmt.Sprintf("unused since %s, last reference removed in %s by %s", r.Date.Format("2006-01-02"), r.Commit, r.Author)
+}
+
+// lastReferenceConcurrency bounds the number of concurrent git processes.
+const lastReferenceConcurrency = 8
+
+// manifestPathspecs exclude the manifests and lockfiles from the history
+// searched for references, since they always name the dependencies.
+var manifestPathspecs = []string{
+	":(exclude,glob)**/package.json", ":(exclude,glob)**/package-lock.json",
+	":(exclude,glob)**/yarn.lock", ":(exclude,glob)**/pnpm-lock.yaml",
+	":(exclude,glob)**/go.mod", ":(exclude,glob)**/go.sum",
+	":(exclude,glob)**/requirements*.txt", ":(exclude,glob)**/pyproject.toml",
+	":(exclude)" + baselineFile, ":(exclude,glob).depose/**",
+}
+
+// referencePattern returns the basic regular expression matching the
+// package name used as a quoted specifier, e.g. "lodash" or 'lodash/fp'.
+func referencePattern(pkgName string) string {
+	return "[\"'`]" + strings.ReplaceAll(pkgName, ".", `\.`) + "[\"'`/]"
+}
+
+// findLastReference returns the last commit changing a line which
+// references the package, if the project is a git repository.
+func findLastReference(pkgName string) (lastReference, bool) {
+	args := append([]string{"log", "-1", "--format=%h%x00%an%x00%aI", "-G", referencePattern(pkgName), "--", "."}, manifestPathspecs...)
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return lastReference{}, false
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), "\x00")
+	if len(fields) != 3 {
+		return lastReference{}, false
+	}
+	date, err := time.Parse(time.RFC3339, fields[2])
+	if err != nil {
+		return lastReference{}, false
+	}
+	return lastReference{Commit: fields[0], Author: fields[1], Date: date}, true
+}
+
+// annotateLastReferences records in the unused dependency findings the
+// commit which last referenced the dependency, giving reviewers context
+// about the removal.
+func annotateLastReferences(findings []Finding) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, lastReferenceConcurrency)
+	for i := range findings {
+		if findings[i].RuleID != RuleUnusedDependency && findings[i].RuleID != RuleUnusedDevDependency {
+			continue
+		}
+		wg.Add(1)
+		go func(f *Finding) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if ref, ok := findLastReference(f.Package); ok {
+				f.LastReference = &ref
+			}
+		}(&findings[i])
+	}
+	wg.Wait()
+}
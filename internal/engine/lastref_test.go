@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestFindLastReference(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	chdir(t, t.TempDir())
+	commit := func(date, message string, files map[string]string) {
+		t.Helper()
+		writeFiles(t, files)
+		for _, args := range [][]string{
+			{"add", "-A"},
+			{"-c", "user.name=Alice", "-c", "user.email=alice@example.com", "commit", "-q", "-m", message},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+	if out, err := exec.Command("git", "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	commit("2021-03-04T10:00:00Z", "add moment", map[string]string{
+		"package.json": `{"dependencies": {"moment": "^2.29.4"}}`,
+		"src/date.js":  "const moment = require(\"moment\");\n",
+	})
+	commit("2021-06-01T10:00:00Z", "drop moment", map[string]string{
+		"src/date.js": "const format = (d) => d.toISOString();\n",
+	})
+	commit("2022-01-01T10:00:00Z", "bump moment", map[string]string{
+		"package.json": `{"dependencies": {"moment": "^2.30.1"}}`,
+	})
+
+	ref, ok := findLastReference("moment")
+	if !ok {
+		t.Fatal("findLastReference(moment) found nothing")
+	}
+	if want := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC); !ref.Date.Equal(want) || ref.Author != "Alice" {
+		t.Errorf("findLastReference(moment) = %+v, want the commit of %v by Alice", ref, want)
+	}
+	if _, ok := findLastReference("lodash"); ok {
+		t.Error("findLastReference(lodash) found a commit for a package never referenced")
+	}
+}
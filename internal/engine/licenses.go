@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// unknownLicense is the license of the packages which declare none,
+// or whose manifest could not be read.
+const unknownLicense = "UNKNOWN"
+
+// licenseEntry is the license of a dependency.
+type licenseEntry struct {
+	Package string `json:"package"`
+	License string `json:"license"`
+	// Source tells where the license was read: "node_modules" or "registry".
+	Source string `json:"source,omitempty"`
+	Denied bool   `json:"denied,omitempty"`
+}
+
+// licenseOf returns the license declared by a package.json, with the
+// "license" field, either an SPDX expression or a legacy {"type": ...}
+// object, or the legacy "licenses" list.
+func licenseOf(license, licenses json.RawMessage) string {
+	var expr string
+	var legacy struct {
+		Type string `json:"type"`
+	}
+	var list []struct {
+		Type string `json:"type"`
+	}
+	switch {
+	case json.Unmarshal(license, &expr) == nil && expr != "":
+		return expr
+	case json.Unmarshal(license, &legacy) == nil && legacy.Type != "":
+		return legacy.Type
+	case json.Unmarshal(licenses, &list) == nil && len(list) > 0:
+		var types []string
+		for _, l := range list {
+			types = append(types, l.Type)
+		}
+		if len(types) == 1 {
+			return types[0]
+		}
+		return "(" + strings.Join(types, " OR ") + ")"
+	}
+	return unknownLicense
+}
+
+// isDeniedLicense reports whether the SPDX expression only allows licenses
+// of the deny-list: every alternative of an "OR" has to be denied, while a
+// single denied license of an "AND" is enough. Comparisons ignore case.
+func isDeniedLicense(expr string, deny []string) bool {
+	denied := func(id string) bool {
+		id = strings.Trim(strings.TrimSpace(id), "()")
+		for _, d := range deny {
+			if strings.EqualFold(id, d) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, alternative := range strings.Split(expr, " OR ") {
+		ok := true
+		for _, term := range strings.Split(alternative, " AND ") {
+			if denied(term) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return false
+		}
+	}
+	return true
+}
+
+// collectLicenses reads the license of every dependency from its installed
+// package.json, falling back to the latest version published to the
+// registry unless offline, and flags the ones of the deny-list. Local
+// packages, e.g. "file:../lib", are never looked up on the registry, which
+// may publish an unrelated package with the same name.
+func (sc *scanContext) collectLicenses(dependencies []string, deny []string, offline bool) []licenseEntry {
+	var entries []licenseEntry
+	for _, dependency := range dependencies {
+		entry := licenseEntry{Package: dependency, License: unknownLicense}
+		_, local := sc.localVersion(dependency)
+		if data, err := sc.readProjectFile(filepath.Join("node_modules", filepath.FromSlash(dependency), "package.json")); err == nil {
+			var pkg registryPackage
+			if json.Unmarshal(data, &pkg) == nil {
+				entry.License, entry.Source = licenseOf(pkg.License, pkg.Licenses), "node_modules"
+			}
+		} else if !offline && !local {
+			if pkg, err := sc.fetchLatest(sc.registryName(dependency)); err == nil {
+				entry.License, entry.Source = licenseOf(pkg.License, pkg.Licenses), "registry"
+			} else {
+				fmt.Fprintf(sc.logOut, "Failed to fetch the license of %q: %v\n", dependency, err)
+			}
+		}
+		entry.Denied = entry.License != unknownLicense && isDeniedLicense(entry.License, deny)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// writeLicenses prints the dependencies grouped by license, the most
+// common licenses first, followed by the dependencies with a denied license.
+func writeLicenses(w io.Writer, entries []licenseEntry) {
+	groups := make(map[string][]string)
+	for _, e := range entries {
+		groups[e.License] = append(groups[e.License], e.Package)
+	}
+	licenses := make([]string, 0, len(groups))
+	for license := range groups {
+		licenses = append(licenses, license)
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		if len(groups[licenses[i]]) != len(groups[licenses[j]]) {
+			return len(groups[licenses[i]]) > len(groups[licenses[j]])
+		}
+		return licenses[i] < licenses[j]
+	})
+
+	for _, license := range licenses {
+		sort.Strings(groups[license])
+		fmt.Fprintf(w, "%s (%d)\n", license, len(groups[license]))
+		for _, pkgName := range groups[license] {
+			fmt.Fprintf(w, "  %s\n", pkgName)
+		}
+	}
+	for _, e := range entries {
+		if e.Denied {
+			fmt.Fprintf(w, "Denied license: %s is licensed under %s\n", e.Package, e.License)
+		}
+	}
+}
+
+// licensesCommand implements "depose licenses", which lists the licenses of the
+// dependencies of package.json, and fails when one of them is denied by the
+// "denyLicenses" list of the config, for compliance reviews:
+//
+//	depose licenses --production
+func (sc *scanContext) licensesCommand(fs *flag.FlagSet) func(args []string) {
+	production := fs.Bool("production", false, "only list the dependencies, without the devDependencies")
+	offline := fs.Bool("offline", false, "do not query the registry for the packages which are not installed")
+	asJSON := fs.Bool("json", false, "write the licenses as JSON")
+	fs.StringVar(&sc.registryURL, "registry", sc.registryURL, "URL of the npm registry")
+	return func(args []string) {
+		sc.logOut = os.Stderr
+		sc.lang = sc.nodeLanguage
+		sc.loadManifest()
+
+		var dependencies []string
+		for dependency := range sc.manifest.Dependencies {
+			dependencies = append(dependencies, dependency)
+		}
+		if !*production {
+			for dependency := range sc.manifest.DevDependencies {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+		sort.Strings(dependencies)
+
+		entries := sc.collectLicenses(dependencies, sc.manifest.Depose.DenyLicenses, *offline)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(entries); err != nil {
+				logFatal(err)
+			}
+		} else {
+			writeLicenses(os.Stdout, entries)
+		}
+
+		for _, e := range entries {
+			if e.Denied {
+				exit(1)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLicenseOf(t *testing.T) {
+	tests := []struct {
+		license, licenses string
+		want              string
+	}{
+		{`"MIT"`, ``, "MIT"},
+		{`{"type": "BSD-3-Clause", "url": "..."}`, ``, "BSD-3-Clause"},
+		{``, `[{"type": "MIT"}, {"type": "Apache-2.0"}]`, "(MIT OR Apache-2.0)"},
+		{``, ``, unknownLicense},
+	}
+	for _, tt := range tests {
+		if got := licenseOf(json.RawMessage(tt.license), json.RawMessage(tt.licenses)); got != tt.want {
+			t.Errorf("licenseOf(%s, %s) = %q, want %q", tt.license, tt.licenses, got, tt.want)
+		}
+	}
+}
+
+func TestIsDeniedLicense(t *testing.T) {
+	deny := []string{"GPL-3.0-only", "AGPL-3.0-only"}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"MIT", false},
+		{"gpl-3.0-only", true},
+		{"(MIT OR GPL-3.0-only)", false},
+		{"(GPL-3.0-only OR AGPL-3.0-only)", true},
+		{"(MIT AND GPL-3.0-only)", true},
+	}
+	for _, tt := range tests {
+		if got := isDeniedLicense(tt.expr, deny); got != tt.want {
+			t.Errorf("isDeniedLicense(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCollectLicenses(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"node_modules/express/package.json":    `{"name": "express", "license": "MIT"}`,
+		"node_modules/@scope/gpl/package.json": `{"name": "@scope/gpl", "license": "GPL-3.0-only"}`,
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/left-pad/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"name": "left-pad", "version": "1.3.0", "license": "WTFPL"}`)
+	}))
+	defer server.Close()
+	defer func(url string) { sc.registryURL = url }(sc.registryURL)
+	sc.registryURL = server.URL
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+
+	entries := sc.collectLicenses([]string{"@scope/gpl", "express", "left-pad", "gone"}, []string{"GPL-3.0-only"}, false)
+	want := []licenseEntry{
+		{Package: "@scope/gpl", License: "GPL-3.0-only", Source: "node_modules", Denied: true},
+		{Package: "express", License: "MIT", Source: "node_modules"},
+		{Package: "left-pad", License: "WTFPL", Source: "registry"},
+		{Package: "gone", License: unknownLicense},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("collectLicenses() = %+v, want %+v", entries, want)
+	}
+
+	var sb strings.Builder
+	writeLicenses(&sb, entries)
+	wantText := `GPL-3.0-only (1)
+  @scope/gpl
+MIT (1)
+  express
+UNKNOWN (1)
+  gone
+WTFPL (1)
+  left-pad
+Denied license: @scope/gpl is licensed under GPL-3.0-only
+`
+	if sb.String() != wantText {
+		t.Errorf("writeLicenses() =\n%s\nwant\n%s", sb.String(), wantText)
+	}
+}
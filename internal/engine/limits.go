@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// The reasons the paths of the project were left out of the scan.
+const (
+	skippedMaxDepth = "max-depth"
+	skippedMaxFiles = "max-files"
+)
+
+// skippedPath is a directory or a file left out of the scan by the
+// limits of --max-depth and --max-files.
+type skippedPath struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// depth returns the number of directories between the project root and
+// the path, e.g. 0 for a file of the root and 2 for src/lib.
+func depth(path string) int {
+	if path == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(path), "/") + 1
+}
+
+// checkLimits returns fs.SkipDir for the directories nested deeper than
+// --max-depth, and fs.SkipAll once --max-files files have been collected,
+// recording what is skipped and warning about it. It returns nil for the
+// paths within the limits.
+func (sc *scanContext) checkLimits(path string, entry fs.DirEntry) error {
+	if sc.maxDepth > 0 && entry.IsDir() && depth(path) > sc.maxDepth {
+		fmt.Fprintf(sc.logOut, "Warning: skipping %s, nested deeper than --max-depth %d\n", path, sc.maxDepth)
+		sc.skipped = append(sc.skipped, skippedPath{Path: filepath.ToSlash(path), Reason: skippedMaxDepth})
+		return fs.SkipDir
+	}
+	if sc.maxFiles > 0 && !entry.IsDir() && len(sc.files) >= sc.maxFiles {
+		fmt.Fprintf(sc.logOut, "Warning: stopping the walk at %s, --max-files %d files have been found; the rest of the project is not scanned\n", path, sc.maxFiles)
+		sc.skipped = append(sc.skipped, skippedPath{Path: filepath.ToSlash(path), Reason: skippedMaxFiles})
+		return fs.SkipAll
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestScanLimits(t *testing.T) {
+	sc := newScanContext()
+	sc.setProjectFS(fstest.MapFS{
+		"index.js":           {},
+		"src/app.js":         {},
+		"src/lib/util.js":    {},
+		"src/lib/deep/x.js":  {},
+		"vendor/huge/a.js":   {},
+		"vendor/huge/b/c.js": {},
+	})
+	sc.lang = sc.nodeLanguage
+	defer func(w io.Writer) {
+		sc.setProjectFS(os.DirFS("."))
+		sc.lang, sc.logOut = nil, w
+		sc.maxDepth, sc.maxFiles = 0, 0
+		sc.files, sc.skipped = nil, nil
+	}(sc.logOut)
+	sc.logOut = io.Discard
+
+	sc.maxDepth = 1
+	if err := sc.walkProject(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"index.js", "src/app.js"}; !reflect.DeepEqual(sc.files, want) {
+		t.Errorf("files with --max-depth 1 = %v, want %v", sc.files, want)
+	}
+	wantSkipped := []skippedPath{{"src/lib", skippedMaxDepth}, {"vendor/huge", skippedMaxDepth}}
+	if !reflect.DeepEqual(sc.skipped, wantSkipped) {
+		t.Errorf("skipped with --max-depth 1 = %v, want %v", sc.skipped, wantSkipped)
+	}
+
+	sc.maxDepth, sc.maxFiles = 0, 3
+	sc.files, sc.skipped = nil, nil
+	if err := sc.walkProject(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"index.js", "src/app.js", "src/lib/deep/x.js"}; !reflect.DeepEqual(sc.files, want) {
+		t.Errorf("files with --max-files 3 = %v, want %v", sc.files, want)
+	}
+	if want := []skippedPath{{"src/lib/util.js", skippedMaxFiles}}; !reflect.DeepEqual(sc.skipped, want) {
+		t.Errorf("skipped with --max-files 3 = %v, want %v", sc.skipped, want)
+	}
+}
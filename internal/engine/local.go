@@ -0,0 +1,41 @@
+package engine
+
+import "strings"
+
+// localProtocols are the prefixes of the versions of package.json naming
+// local packages instead of published ones, e.g. "workspace:*" or
+// "file:../lib".
+var localProtocols = []string{"workspace:", "file:", "link:", "portal:"}
+
+// localVersion returns the version of the dependency when it is a local
+// package, e.g. "file:../lib".
+func (sc *scanContext) localVersion(dependency string) (string, bool) {
+	version, ok := sc.manifest.Dependencies[dependency]
+	if !ok {
+		version, ok = sc.manifest.DevDependencies[dependency]
+	}
+	if !ok || !isLocalRange(version) {
+		return "", false
+	}
+	return version, true
+}
+
+// isLocalRange reports whether the range of package.json names a local
+// package, e.g. "workspace:*" or "file:../lib".
+func isLocalRange(r string) bool {
+	for _, protocol := range localProtocols {
+		if strings.HasPrefix(r, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnusedLocal reports whether the finding reports an unused local package.
+func (sc *scanContext) isUnusedLocal(f Finding) bool {
+	if f.RuleID != RuleUnusedDependency && f.RuleID != RuleUnusedDevDependency {
+		return false
+	}
+	_, ok := sc.localVersion(f.Package)
+	return ok
+}
@@ -0,0 +1,1723 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+// Dependency struct uses map to store name of dependencies and
+// a mutex for concurrent access.
+//
+// Dependencies with falsy values are deleted at the end.
+//
+// The usages map records every location where a package was found,
+// including packages which are not declared in package.json, and the
+// ignoredLines map records the lines preceded by an ignore directive.
+// The evidence map records why each used dependency is considered used,
+// the unresolved map records the specifiers which the installed
+// packages do not provide, and the dynamic map records the static prefix
+// of the specifiers computed at runtime, by location. The cliUsages map
+// records where the executables of the declared dependencies are run by
+// Makefiles, Dockerfiles and shell scripts.
+type Dependency struct {
+	mp           map[string]bool
+	usages       map[string][]Location
+	ignoredLines map[Location][]string
+	evidence     map[string]Evidence
+	unresolved   map[string][]Location
+	dynamic      map[Location]string
+	cliUsages    map[string][]Location
+	scriptUsages map[string][]Location
+	trace        map[string][]evidenceRecord
+	mu           sync.Mutex
+}
+
+// Package struct represents the keys of the package.json file,
+// which are accessed when readPackages() is called.
+//
+// Its instance is used to Unmarshal the JSON data from the package.json file.
+type Package struct {
+	Name            string            `json:"name"`
+	Depose          Config            `json:"depose"`
+	Imports         map[string]any    `json:"imports"`
+	Main            string            `json:"main"`
+	Module          string            `json:"module"`
+	Exports         any               `json:"exports"`
+	Bin             any               `json:"bin"`
+	Scripts         map[string]string `json:"scripts"`
+	ESLintConfig    json.RawMessage   `json:"eslintConfig"`
+	Babel           json.RawMessage   `json:"babel"`
+	Prettier        json.RawMessage   `json:"prettier"`
+	PostCSS         json.RawMessage   `json:"postcss"`
+	Build           json.RawMessage   `json:"build"`   // electron-builder
+	NPMConfig       json.RawMessage   `json:"config"`  // Electron Forge, under "forge"
+	NWBuild         json.RawMessage   `json:"nwbuild"` // nw-builder
+	LintStaged      json.RawMessage   `json:"lint-staged"`
+	Commitlint      json.RawMessage   `json:"commitlint"`
+	Jest            json.RawMessage   `json:"jest"`
+	Release         json.RawMessage   `json:"release"` // semantic-release
+	ReleaseIt       json.RawMessage   `json:"release-it"`
+	Renovate        json.RawMessage   `json:"renovate"`
+	Browserslist    json.RawMessage   `json:"browserslist"`
+	Engines         json.RawMessage   `json:"engines"`
+	PackageManager  string            `json:"packageManager"`
+	Workspaces      json.RawMessage   `json:"workspaces"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+// scanContext is the state of a single scan: the options set by its
+// flags, the manifest and the files of the project, and the usages found
+// in them. Every scan has its own, so that a process runs several scans,
+// even concurrently, e.g. the ones of pkg/depose.
+//
+// The tables whose entries call the methods of the context, such as the
+// languages and the commands, are built for every scan too.
+type scanContext struct {
+	// ctx is the context of the scan, whose cancellation stops it, and dir
+	// is the directory of the project on disk: the working directory, which
+	// the command line enters, unless the scan is run by Scan.
+	ctx context.Context
+	dir string
+	d   Dependency
+	// manifest is the content of the package.json file of the scanned project.
+	manifest Package
+	// reporterName is the name of the reporter used to print the findings,
+	// selected with the --reporter flag.
+	reporterName string
+	// formatTemplate is the Go template rendering the text report instead
+	// of the default one.
+	formatTemplate string
+	// verbose makes the reporters also list suppressed findings,
+	// and where every package is used.
+	verbose bool
+	// check only reports the findings, without changing package.json,
+	// and fails when findings not recorded in the baseline are found.
+	check bool
+	// updateBaseline records the current findings into the baseline file.
+	updateBaseline bool
+	// expectFile is the expected report the findings are compared to by
+	// depose check, instead of the baseline, and updateExpect writes the
+	// findings into it.
+	expectFile   string
+	updateExpect bool
+	// strict only counts imports as usage, ignoring the package names
+	// found in scripts and config files.
+	strict bool
+	// scanCI looks for the commands run by the YAML files,
+	// e.g. GitHub Actions workflows or docker-compose files.
+	scanCI bool
+	// dryRun prints the change of the manifest, without applying it.
+	dryRun bool
+	// toStdout writes the new manifest to stdout instead of changing it.
+	toStdout bool
+	// outPath is the path the new manifest is written to instead of changing it.
+	outPath string
+	// reachable only counts the imports of the files reachable from the
+	// entrypoints of the project, and reports the other files as orphaned.
+	reachable bool
+	// entryPatterns are the entrypoints given with --entry, in addition
+	// to the ones declared in package.json.
+	entryPatterns stringList
+	// extraManifests are the manifests given with --manifest, outside of
+	// the workspaces, e.g. functions/package.json for Firebase. Each one
+	// is scanned against its own directory, which the root scan skips.
+	extraManifests stringList
+	// explainDecisions reports the decision taken for every declared
+	// dependency, along with every piece of evidence found for it.
+	explainDecisions bool
+	// includeMarkdown scans the JavaScript and TypeScript code blocks of
+	// the Markdown documents, whose imports keep the dependencies with a
+	// low confidence.
+	includeMarkdown bool
+	// treatScriptOnlyAsUnused reports the dependencies only named by the
+	// scripts of package.json as unused, instead of keeping them.
+	treatScriptOnlyAsUnused bool
+	// keepDynamic marks the dependencies matching the static prefix of
+	// the specifiers computed at runtime as used.
+	keepDynamic bool
+	// noPlugins disables the plugins found on PATH.
+	noPlugins bool
+	// outdated compares the kept dependencies to their latest version.
+	outdated bool
+	// lastPublished reports when the kept dependencies were last
+	// published, flagging the ones with no publish during the period of
+	// unmaintainedAfter as unmaintained.
+	lastPublished     bool
+	unmaintainedAfter string
+	// bundleSizes reports the minified and gzipped size of the kept
+	// runtime dependencies, the heaviest first.
+	bundleSizes bool
+	// noHistory disables the recording of the run into the history file.
+	noHistory bool
+	// noStats disables the recording of the run into the local stats of
+	// the user, see "depose stats".
+	noStats bool
+	// notifyURL is the webhook notified of the results of the run,
+	// with the body rendered by notifyTemplate, if any, when the run
+	// has at least notifyMinFindings findings.
+	notifyURL         string
+	notifyTemplate    string
+	notifyMinFindings int
+	// failOn, maxUnused and maxMissing override the thresholds of the
+	// config, when they are set.
+	failOn     string
+	maxUnused  int
+	maxMissing int
+	// noAscend disables the search of the manifest in the parent
+	// directories, when depose runs from a subdirectory of the project.
+	noAscend bool
+	// timings reports the metrics of the run: the files scanned, the
+	// bytes read, the duration of its phases and its peak goroutines and
+	// memory. metricsTextfile and metricsPushgateway export them to
+	// Prometheus.
+	timings            bool
+	metricsTextfile    string
+	metricsPushgateway string
+	// pprofDir is the directory the CPU and heap profiles of the run are
+	// written to.
+	pprofDir string
+	// groupBy groups the findings of the report: by "owner" of CODEOWNERS,
+	// by "section" of the manifest, by "workspace" of the monorepo, by
+	// "confidence", or by "severity".
+	groupBy string
+	// onlyKinds and filterPatterns restrict the report to the kinds of
+	// findings, e.g. "unused,missing", and to the packages matching the
+	// patterns, e.g. "@scope/*".
+	onlyKinds      string
+	filterPatterns stringList
+	// misplacedChecks reports the devDependencies imported by the
+	// production code, and fixSections moves them to the dependencies of
+	// package.json, which are listed in dependenciesToPromote.
+	misplacedChecks       bool
+	fixSections           bool
+	dependenciesToPromote []string
+	// rangeChecks reports the dependencies whose version ranges differ from
+	// the style of the others, and fixRanges rewrites the ranges of
+	// package.json in its style, which are listed in rangesToRewrite.
+	rangeChecks     bool
+	fixRanges       string
+	rangesToRewrite map[string]map[string]string
+	// includeLocal reports the unused local packages, e.g. "file:../lib".
+	includeLocal bool
+	// lastReferenced annotates the unused dependencies with the commit
+	// which last referenced them.
+	lastReferenced bool
+	// updateBots annotates the findings of the dependencies updated by
+	// Renovate or Dependabot, and botPRBody is where the body of a pull
+	// request removing the unused ones, grouped like their updates, is
+	// written.
+	updateBots bool
+	botPRBody  string
+	// staleChecks reports the scripts and the config files left behind by
+	// the removal of the unused dependencies, and fixScripts removes them
+	// along with the dependencies.
+	staleChecks bool
+	fixScripts  bool
+	// verifyCommand is the command run once the unused dependencies have
+	// been removed, which restores package.json when it fails.
+	verifyCommand string
+	// noBackup writes package.json in place, without keeping the original
+	// in oldpackage.json, e.g. when depose fix commits the change.
+	noBackup bool
+	// removed are the dependencies removed from the manifest by the run.
+	// linesToRemove are the lines of package.json removed along with them:
+	// the stale scripts with --fix-scripts, and the stale overrides with
+	// --fix-overrides. With --fix-scripts, deletedConfigs are the stale
+	// config files deleted.
+	removed        []string
+	linesToRemove  map[int]bool
+	deletedConfigs []string
+	// fixOverrides removes the stale overrides and resolutions along with
+	// the unused dependencies, which are listed in removedOverrides.
+	fixOverrides     bool
+	removedOverrides []string
+	// since only reports the findings introduced since the git revision,
+	// and prID is the pull request the pr-comment reporter comments on.
+	since string
+	prID  string
+	// outputFormat streams the events of the scan to stdout, in place
+	// of the report, selected with the --output flag.
+	outputFormat string
+	// graphFormat is the format of the usage graph written instead of
+	// the report, selected with the --graph flag.
+	graphFormat string
+	// graphLockfile adds the dependencies between packages, read from
+	// package-lock.json, to the usage graph.
+	graphLockfile bool
+	// langName is the name of the language of the scanned project,
+	// selected with the --lang flag.
+	langName string
+	// logOut is where progress messages are written. It is switched to stderr
+	// when a machine readable reporter owns stdout.
+	logOut io.Writer
+	// filesToExclude represents a map of file names/directories
+	// which are supposed to be skipped during the process of scanning
+	// the whole directory. They are paths relative to the project root,
+	// with forward slashes, matched by isExcludedPath.
+	//
+	// dirsToExclude are the names of the directories which are skipped
+	// at any depth, e.g. the node_modules of the packages of a monorepo.
+	//
+	// Both are set by the exclusion profiles selected with --profile.
+	filesToExclude map[string]int
+	dirsToExclude  map[string]bool
+	// profileNamesFlag are the exclusion profiles selected with --profile.
+	profileNamesFlag string
+	// noAutoDetect disables the detection of the traits of the project,
+	// e.g. Next.js, selecting the exclusion profiles when --profile isn't
+	// given.
+	noAutoDetect bool
+	// typesChecks keeps the @types packages of the used packages, in the
+	// TypeScript projects.
+	typesChecks bool
+	// maxDepth and maxFiles limit the directories walked and the files
+	// scanned, e.g. when depose runs by mistake in a home directory.
+	// Zero disables them.
+	maxDepth int
+	maxFiles int
+	// excludeNested skips dirsToExclude at any depth, rather than only at
+	// the project root. It is disabled with --exclude-nested=false.
+	excludeNested bool
+	// files are the files of the project which are not excluded,
+	// collected while walking the directory.
+	files []string
+	// wg is a collection of go routines, which is also used
+	// to wait for all the goroutines to finish their processes.
+	wg sync.WaitGroup
+
+	// bundleSizeAPI is the URL of the bundlephobia-style API queried for the
+	// size of the packages, with their name and version in the "package"
+	// parameter, e.g. ?package=react@18.2.0.
+	bundleSizeAPI string
+
+	// commands maps the subcommands of depose to their implementation.
+	commands map[string]command
+
+	// minNodeMajor is the oldest major version of Node.js supported by the
+	// project, read from "engines" of package.json. It is 0 when unknown,
+	// in which case every builtin module of Node.js is available.
+	minNodeMajor int
+	// bunRuntime is true when the project runs on Bun, whose packageManager
+	// or engines of package.json name it.
+	bunRuntime bool
+
+	// diagnostics are the non-fatal errors of the run, guarded by diagnosticsMu.
+	diagnostics   []Diagnostic
+	diagnosticsMu sync.Mutex
+
+	// events is the stream of --output ndjson, or nil without it.
+	events *eventStream
+
+	// rules is the catalogue of every rule known to depose,
+	// in the order they are presented by the reporters.
+	// The custom rules declared by plugins are appended to it.
+	rules []Rule
+
+	// layout is the layout of node_modules of the project.
+	layout nodeModulesLayout
+
+	// importMaps is the import map of the project, empty when it has none.
+	importMaps importMap
+
+	// goMod is the content of the go.mod file of the scanned project.
+	goMod GoMod
+	// goLanguage analyzes Go modules, based on their go.mod file.
+	//
+	// Only direct requirements are checked, since indirect ones are
+	// managed by "go mod tidy". Packages imported from modules which are
+	// only required indirectly are reported as phantom dependencies.
+	goLanguage *language
+
+	// pyProject is the Python project being scanned.
+	pyProject pythonProject
+	// pythonLanguage analyzes Python projects, based on their requirements.txt
+	// or, when there is none, their pyproject.toml file.
+	pythonLanguage *language
+
+	// languages maps the names accepted by the --lang flag to their definition.
+	languages map[string]*language
+	// lang is the language of the scanned project, selected with the --lang flag.
+	lang *language
+	// manifestFile is the manifest of the scanned project, found by findManifest.
+	manifestFile string
+	// nodeLanguage analyzes Node.js projects, based on their package.json file.
+	nodeLanguage *language
+
+	// skipped are the paths left out by the limits of the scan.
+	skipped []skippedPath
+
+	// stopProfiling stops the profiling of the run started by startProfiling,
+	// if any, and writes its profiles.
+	stopProfiling func()
+
+	// projectFS is the file system of the scanned project, rooted at its
+	// root. It is dir, unless the project is read from elsewhere, e.g. from
+	// a tarball held in memory by "depose scan".
+	//
+	// The scanner reads the project through it only, so the project doesn't
+	// need to be on disk. Changing package.json still needs the working
+	// directory to be the project.
+	projectFS fs.FS
+
+	// registryURL is the npm registry queried for the latest versions of the
+	// packages, e.g. for the licenses of the packages which are not installed.
+	registryURL string
+
+	// reporters maps the names accepted by the --reporter flag
+	// to their implementations.
+	reporters map[string]Reporter
+
+	// installedPackages caches the package.json files read from node_modules,
+	// by absolute path. A nil entry means the package is not installed there.
+	installedPackages struct {
+		mp map[string]*installedPackage
+		mu sync.Mutex
+	}
+
+	// configResolvers are the resolvers of the tools whose config
+	// files reference packages with shorthand names.
+	configResolvers []configResolver
+	// lintStagedResolver finds the packages run by the commands of the
+	// lint-staged configs, e.g. "*.js": "eslint --fix" in .lintstagedrc:
+	// https://github.com/lint-staged/lint-staged#configuration
+	lintStagedResolver configResolver
+
+	// bins maps the executables of the installed dependencies to the package
+	// providing them. It is populated when package.json is read.
+	bins map[string]string
+
+	// ruleSeverities are the severities set by the "rules" of the config, by
+	// rule ID, read by parseRuleSeverities.
+	ruleSeverities map[string]Severity
+
+	// definedFlags is the flag set of the run, whose flags are passed to the
+	// scan of the revision of --since.
+	definedFlags *flag.FlagSet
+
+	// metrics measures the run, when --timings or a Prometheus output is set.
+	metrics *runMetrics
+
+	// urlDependencyNames maps the real names of the git and tarball
+	// dependencies to the names they are declared with, when they differ,
+	// so their imports mark the declared dependencies as used.
+	urlDependencyNames map[string]string
+	// fetchURLManifests fetches the manifests of the git and tarball
+	// dependencies which aren't installed, to find their real names.
+	fetchURLManifests bool
+
+	// version is the version of depose, set when building a release, see
+	// Main. When empty, the version of the module recorded by "go install"
+	// is used.
+	version string
+}
+
+// newScanContext returns the context of a new scan, with the defaults of
+// its options.
+func newScanContext() *scanContext {
+	sc := &scanContext{ctx: context.Background(), dir: "."}
+	sc.bundleSizeAPI = "https://bundlephobia.com/api/size"
+	sc.rules = []Rule{
+		{RuleUnusedDependency, "Dependency is declared but never used", SeverityWarning},
+		{RuleUnusedDevDependency, "Dev dependency is declared but never used", SeverityWarning},
+		{RuleMissingDependency, "Package is used but not declared in the manifest", SeverityError},
+		{RulePhantomDependency, "Package is used and installed, but only as a transitive dependency", SeverityWarning},
+		{RuleUnresolvedImport, "Import is not provided by the installed package, e.g. not part of its exports", SeverityWarning},
+		{RuleOrphanedFile, "Source file is not reachable from any entrypoint", SeverityNote},
+		{RuleDynamicImport, "Import or require whose specifier is computed at runtime", SeverityWarning},
+		{RuleBannedDependency, "Dependency is declared but banned by the policy", SeverityError},
+		{RuleRequiredDependency, "Dependency is required by the policy but not declared", SeverityError},
+		{RuleStaleScript, "Script runs the executable of an unused dependency", SeverityNote},
+		{RuleStaleConfig, "Config file of a tool whose dependencies are unused", SeverityNote},
+		{RuleMisplacedDependency, "Dev dependency is imported by the production code", SeverityWarning},
+		{RuleStaleOverride, "Override or resolution of an unused dependency", SeverityWarning},
+		{RuleInconsistentRange, "Version range whose style differs from the other dependencies, or unpinned", SeverityNote},
+	}
+	sc.logOut = os.Stdout
+	sc.excludeNested = true
+	sc.stopProfiling = func() {}
+	sc.filesToExclude, sc.dirsToExclude, _ = profileExclusions(defaultProfile)
+	sc.projectFS = os.DirFS(".")
+	sc.goLanguage = &language{
+		name:          "go",
+		manifestFiles: []string{"go.mod"},
+		readManifest:  sc.readGoMod,
+		excluded:      isExcludedGoPath,
+		normalize:     sc.goModuleOf,
+		isBuiltin: func(pkgName string) bool {
+			// Paths of the standard library have no dot in their first element.
+			return !strings.Contains(strings.Split(pkgName, "/")[0], ".")
+		},
+		isInstalled: func(pkgName string) bool {
+			indirect, ok := sc.goMod.Requires[pkgName]
+			return ok && indirect
+		},
+		sectionOf: func(string) string { return "require" },
+		declaredLines: func() map[string]map[string]int {
+			return map[string]map[string]int{"require": sc.goMod.Lines}
+		},
+		unusedRule: func(string) string { return RuleUnusedDependency },
+		removeFix: func(dependency, _ string) string {
+			return "go mod edit -droprequire=" + dependency
+		},
+		addFix: func(pkgName string) string {
+			return "go get " + pkgName
+		},
+		phantomFix: func(pkgName string) string {
+			return "go get " + pkgName
+		},
+	}
+	sc.pythonLanguage = &language{
+		name:          "python",
+		manifestFiles: []string{"requirements.txt", "pyproject.toml"},
+		readManifest:  sc.readPythonRequirements,
+		excluded: func(path string, isDir bool) bool {
+			name := filepath.Base(path)
+			return isDir && path != "." && (strings.HasPrefix(name, ".") || name == "__pycache__" ||
+				name == "venv" || name == "site-packages" || name == "node_modules")
+		},
+		normalize: func(module string) (string, bool) {
+			if strings.HasPrefix(module, ".") {
+				return "", false
+			}
+			module, _, _ = strings.Cut(module, ".")
+			if sc.isLocalPythonModule(module) {
+				return "", false
+			}
+			if dist, ok := sc.pyProject.modules[module]; ok {
+				return dist, true
+			}
+			return module, true
+		},
+		isBuiltin:   func(module string) bool { return pythonStdlib[module] },
+		isInstalled: func(string) bool { return false },
+		sectionOf:   func(dist string) string { return sc.pyProject.sections[dist] },
+		declaredLines: func() map[string]map[string]int {
+			return sc.pyProject.lines
+		},
+		unusedRule: func(section string) string {
+			if strings.Contains(section, "dev") || strings.Contains(section, "test") {
+				return RuleUnusedDevDependency
+			}
+			return RuleUnusedDependency
+		},
+		removeFix: func(dist, section string) string {
+			return fmt.Sprintf("remove %q from %s", dist, section)
+		},
+		addFix: func(module string) string {
+			return "pip install " + module
+		},
+		phantomFix: func(module string) string {
+			return "pip install " + module
+		},
+	}
+	sc.registryURL = "https://registry.npmjs.org"
+	sc.reporters = map[string]Reporter{
+		"text":   sc.reportText,
+		"json":   reportJSON,
+		"sarif":  sc.reportSARIF,
+		"github": reportGitHub,
+		// pr-comment posts the report as a comment of a pull request.
+		"pr-comment": sc.reportPRComment,
+	}
+	sc.installedPackages = struct {
+		mp map[string]*installedPackage
+		mu sync.Mutex
+	}{mp: make(map[string]*installedPackage)}
+	sc.lintStagedResolver = configResolver{
+		matches: func(file string) bool {
+			name := filepath.Base(file)
+			return strings.HasPrefix(name, ".lintstagedrc") || strings.HasPrefix(name, "lint-staged.config.")
+		},
+		names: sc.lintStagedNames,
+		tools: []string{"lint-staged"},
+	}
+	sc.configResolvers = []configResolver{
+		eslintResolver, babelResolver, postcssResolver, storybookResolver, electronBuilderResolver,
+		graphqlCodegenResolver, prismaResolver, typeormResolver, drizzleResolver,
+		sc.lintStagedResolver, commitlintResolver, browserslistResolver, jestResolver, vitestResolver,
+		nxResolver, turboResolver, lernaResolver, changesetsResolver, semanticReleaseResolver, releaseItResolver,
+	}
+	sc.nodeLanguage = &language{
+		name:          "node",
+		manifestFiles: []string{"package.json"},
+		readManifest: func(string) string {
+			sc.readPackages()
+			return sc.manifest.Name
+		},
+		excluded: func(path string, isDir bool) bool {
+			return sc.isExcludedPath(path) || isDir && sc.isExcludedDir(path)
+		},
+		normalize: func(specifier string) (string, bool) {
+			if isLocalSpecifier(specifier) {
+				return "", false
+			}
+			return packageName(specifier), true
+		},
+		internalTargets: sc.packageImportsTargets,
+		isUnresolvable:  sc.isUnresolvableSpecifier,
+		isBuiltin:       sc.isBuiltinModule,
+		isInstalled:     sc.isInstalled,
+		sectionOf:       sc.sectionOf,
+		declaredLines: func() map[string]map[string]int {
+			return sc.declaredLines("package.json")
+		},
+		unusedRule: func(section string) string {
+			if section == "devDependencies" {
+				return RuleUnusedDevDependency
+			}
+			return RuleUnusedDependency
+		},
+		removeFix: func(dependency, section string) string {
+			return fmt.Sprintf("remove %q from %s", dependency, section)
+		},
+		addFix: func(pkgName string) string {
+			return "npm install " + pkgName
+		},
+		phantomFix: func(pkgName string) string {
+			return fmt.Sprintf("add %q to dependencies", pkgName)
+		},
+		rewriteManifest: sc.deleteDepsFromPackageJSON,
+	}
+	sc.languages = map[string]*language{
+		"node":   sc.nodeLanguage,
+		"go":     sc.goLanguage,
+		"python": sc.pythonLanguage,
+	}
+	sc.commands = map[string]command{
+		"bisect":     sc.bisectCommand,
+		"check":      sc.checkCommand,
+		"clean":      sc.cleanCommand,
+		"completion": sc.completionCommand,
+		"prune":      sc.pruneCommand,
+		"files":      sc.filesCommand,
+		"fix":        sc.fixCommand,
+		"exports":    sc.exportsCommand,
+		"file":       sc.fileCommand,
+		"history":    historyCommand,
+		"licenses":   sc.licensesCommand,
+		"version":    sc.versionCommand,
+		"upgrade":    sc.upgradeCommand,
+		"scan":       sc.scanCommand,
+		"stats":      statsCommand,
+		"why":        sc.whyCommand,
+		"workspaces": sc.workspacesCommand,
+	}
+	return sc
+}
+
+// readPackages reads the package.json file,
+// unmarshals the data to the instance of Package called "pkg",
+// and populates the map of the Dependency of the scan, called "d".
+//
+// The dependencies and dev dependencies found in package.json file
+// are stored initially in the map with falsy values. Later, in the Program
+// when those dependencies are found in other files, these values are updated
+// to true.
+//
+// The dependencies seen in "scripts" section of the package.json file
+// is initialzed as true because though the dependency might not be required
+// elsewhere in other files, it might still have other external duties in the project.
+// These type of external dependencies are not deleted.
+// In strict mode, only the modules preloaded by the scripts are considered
+// as usage, see scriptReferences.
+func (sc *scanContext) readPackages() {
+	jsonFile, err := sc.openProjectFile("package.json")
+	if err != nil {
+		fatal(classifyError("package.json", err, true))
+	}
+
+	defer jsonFile.Close()
+
+	fmt.Fprintln(sc.logOut, "Reading Package.json")
+
+	byteValue, err := io.ReadAll(jsonFile)
+	if err != nil {
+		fatal(classifyError("package.json", err, true))
+	}
+	var pkg Package
+	if err := json.Unmarshal(byteValue, &pkg); err != nil {
+		fatal(classifyError("package.json", err, true))
+	}
+	sc.manifest = pkg
+	sc.readEngines(pkg)
+
+	for dependency := range pkg.Dependencies {
+		sc.d.mp[dependency] = false
+	}
+
+	for dependency := range pkg.DevDependencies {
+		sc.d.mp[dependency] = false
+	}
+	// The git and tarball dependencies are matched by their real names too.
+	ranges := maps.Clone(pkg.DevDependencies)
+	if ranges == nil {
+		ranges = make(map[string]string)
+	}
+	maps.Copy(ranges, pkg.Dependencies)
+	sc.urlDependencyNames = sc.resolveURLDependencies(ranges, sc.fetchURLManifests)
+
+	// Mark the dependencies used in the scripts section as true i.e. do not remove them.
+	// The modules preloaded by node, e.g. with -r, are imports,
+	// so they count as usage even in strict mode.
+	sc.bins = sc.installedBins(sc.d.mp)
+	scriptLines := sc.declaredLines("package.json")["scripts"]
+	for name, script := range pkg.Scripts {
+		sc.markCommandReferences(script, Location{File: "package.json", Line: scriptLines[name]})
+		if sc.strict {
+			continue
+		}
+		sc.d.mu.Lock()
+		for _, ref := range scriptWordReferences(script, sc.d.mp, sc.bins) {
+			sc.markScriptUsage(ref.Package, Location{File: "package.json", Line: scriptLines[name]})
+		}
+		sc.d.mu.Unlock()
+	}
+
+	// The configs of ESLint, Babel, Prettier, PostCSS, of the packagers
+	// of desktop apps, of the commit and release tools, of Jest and of
+	// browserslist embedded in package.json reference packages like their config files do.
+	if !sc.strict {
+		sc.markConfigLines(pkg.ESLintConfig, []configResolver{eslintResolver}, false, keyLocation(byteValue, "eslintConfig"))
+		sc.markConfigLines(pkg.Babel, []configResolver{babelResolver}, false, keyLocation(byteValue, "babel"))
+		sc.markConfigLines(pkg.Prettier, nil, false, keyLocation(byteValue, "prettier"))
+		sc.markConfigLines(pkg.PostCSS, []configResolver{postcssResolver}, false, keyLocation(byteValue, "postcss"))
+		sc.markConfigLines(pkg.Build, nil, false, keyLocation(byteValue, "build"))
+		sc.markConfigLines(pkg.NPMConfig, nil, false, keyLocation(byteValue, "config"))
+		sc.markConfigLines(pkg.NWBuild, nil, false, keyLocation(byteValue, "nwbuild"))
+		sc.markConfigLines(pkg.LintStaged, []configResolver{sc.lintStagedResolver}, false, keyLocation(byteValue, "lint-staged"))
+		sc.markConfigLines(pkg.Commitlint, []configResolver{commitlintResolver}, false, keyLocation(byteValue, "commitlint"))
+		sc.markConfigLines(pkg.Jest, []configResolver{jestResolver}, false, keyLocation(byteValue, "jest"))
+		// The release tools are kept by their key, like browserslist.
+		for _, c := range []struct {
+			key      string
+			config   json.RawMessage
+			resolver configResolver
+		}{{"release", pkg.Release, semanticReleaseResolver}, {"release-it", pkg.ReleaseIt, releaseItResolver}} {
+			if len(c.config) > 0 {
+				locate := keyLocation(byteValue, c.key)
+				sc.markConfigLines(c.config, []configResolver{c.resolver}, false, locate)
+				sc.markConfigTools([]configResolver{c.resolver}, locate(1))
+			}
+		}
+		if len(pkg.Browserslist) > 0 {
+			locate := keyLocation(byteValue, "browserslist")
+			sc.markConfigLines(pkg.Browserslist, []configResolver{browserslistResolver}, false, locate)
+			sc.markConfigTools([]configResolver{browserslistResolver}, locate(1))
+		}
+	}
+	if !sc.strict && isReactNativeProject(pkg) {
+		sc.markNativeModules()
+	}
+}
+
+// keyLocation returns the function locating the lines of the value of
+// a top-level key of package.json, whose first line is the key's.
+func keyLocation(data []byte, key string) func(lineNo int) Location {
+	keyLine := 0
+	// Walk the keys of the top-level object, skipping their values,
+	// since the key may also be the name of a dependency, e.g. "prettier".
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err == nil {
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				break
+			}
+			if tok == key {
+				keyLine = bytes.Count(data[:dec.InputOffset()], []byte("\n")) + 1
+				break
+			}
+			var value json.RawMessage
+			if dec.Decode(&value) != nil {
+				break
+			}
+		}
+	}
+	return func(lineNo int) Location {
+		if keyLine == 0 {
+			return Location{File: "package.json"}
+		}
+		return Location{File: "package.json", Line: keyLine + lineNo - 1}
+	}
+}
+
+// scanDir is the function called by fs.WalkDir to visit each
+// file or directory of projectFS.
+//
+// The files and dirs excluded by the language of the project are skipped,
+// and the other files are collected, to be scanned once the walk is done.
+// The walk stops at the limits of --max-depth and --max-files.
+func (sc *scanContext) scanDir(path string, entry fs.DirEntry, e error) error {
+	if e != nil {
+		// Unreadable directories and files are skipped, unless excluded anyway.
+		if entry == nil || !sc.lang.excluded(path, entry.IsDir()) {
+			e = classifyError(path, e, false)
+			fmt.Fprintf(sc.logOut, "Skipping %s: %v\n", path, e)
+			sc.recordDiagnostic(filepath.ToSlash(path), e)
+		}
+		return nil
+	}
+	if sc.lang.excluded(path, entry.IsDir()) {
+		if entry.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	}
+	if err := sc.checkLimits(path, entry); err != nil {
+		return err
+	}
+
+	if !entry.IsDir() {
+		sc.files = append(sc.files, path)
+	}
+	return nil
+}
+
+// extractPackages reads the files having an extractor registered
+// for the language, and extracts packages from them concurrently.
+//
+// The progress messages of every file are buffered, and written in the
+// order of the files as soon as the preceding files are done, so the
+// output is the same from one run to the next.
+func (sc *scanContext) extractPackages(files []string) {
+	logs := make([]bytes.Buffer, len(files))
+	done := make([]chan struct{}, len(files))
+	exits := make([]*exitError, len(files))
+	for i, file := range files {
+		extractor, ok := sc.extractorFor(file)
+		if !ok {
+			continue
+		}
+		done[i] = make(chan struct{})
+		sc.wg.Add(1)
+		go func() {
+			defer sc.wg.Done()
+			defer close(done[i])
+			defer catchExit(&exits[i])
+			sc.readFileAndExtractPackages(&logs[i], file, extractor)
+		}()
+	}
+	sc.metrics.sample() // the goroutines peak once all are started
+	for i := range files {
+		if done[i] != nil {
+			<-done[i]
+			sc.logOut.Write(logs[i].Bytes())
+			if exits[i] != nil {
+				// The run stops with the first file failing it.
+				sc.wg.Wait()
+				panic(exits[i])
+			}
+		}
+	}
+	sc.wg.Wait() // wait for all goroutines to finish
+	sc.sortDiagnostics()
+}
+
+// extractorFor returns the extractor of the file for the language of the
+// project. The files without extension are sniffed for a shebang line
+// first, so the executables, e.g. bin/cli starting with
+// "#!/usr/bin/env node", are scanned as the files of their interpreter.
+// Markdown documents are only scanned with --include-markdown.
+func (sc *scanContext) extractorFor(file string) (extract.Extractor, bool) {
+	if filepath.Ext(file) == "" {
+		if extractor, ok := extract.ForShebang(sc.lang.name, sc.fileHead(file)); ok {
+			return extractor, true
+		}
+	}
+	if isMarkdownFile(file) && !sc.includeMarkdown {
+		return nil, false
+	}
+	return extract.For(sc.lang.name, file)
+}
+
+// readFileAndExtractPackages is a concurrent process, which
+// reads the file provided as the argument to the function,
+// records its ignore directives, and then passes its content to the
+// extractor. The packages it finds are marked as found, along with
+// their location. Progress messages are written to w.
+//
+// In lenient mode, the declared dependencies named in config files
+// are marked as used too, and with --scan-ci, the packages run by the
+// commands of YAML files. The executables run by Makefiles, Dockerfiles
+// and shell scripts are recorded, see markCLICommands.
+func (sc *scanContext) readFileAndExtractPackages(w io.Writer, file string, extractor extract.Extractor) {
+	data, err := sc.readProjectFile(file)
+	if err != nil {
+		fatal(classifyError(file, err, false))
+	}
+	sc.metrics.recordFile(len(data))
+
+	fmt.Fprintf(w, "Reading file: %s\n", file)
+	sc.events.emit(event{Type: EventFileStarted, File: filepath.ToSlash(file)})
+	sc.recordIgnoreDirectives(w, filepath.ToSlash(file), data)
+
+	specifiers, err := extractor.Extract(bytes.NewReader(data))
+	if err != nil {
+		err = classifyError(file, err, false)
+		fmt.Fprintf(w, "Skipping %s: %v\n", file, err)
+		sc.recordDiagnostic(filepath.ToSlash(file), err)
+		return
+	}
+	for _, specifier := range specifiers {
+		if isMarkdownFile(file) {
+			if !specifier.Dynamic {
+				fmt.Fprintf(w, "Found a package in a code block: %v\n", specifier.Path)
+				sc.markMarkdownSpecifier(specifier.Path, Location{File: filepath.ToSlash(file), Line: specifier.Line})
+			}
+			continue
+		}
+		if specifier.Dynamic {
+			fmt.Fprintf(w, "Found a dynamic specifier: %q...\n", specifier.Path)
+			sc.events.emit(event{Type: EventSpecifierFound, File: filepath.ToSlash(file), Line: specifier.Line, Specifier: specifier.Path, Dynamic: true})
+			sc.markDynamicSpecifier(specifier.Path, Location{File: filepath.ToSlash(file), Line: specifier.Line})
+			continue
+		}
+		fmt.Fprintf(w, "Found a package: %v\n", specifier.Path)
+		sc.events.emit(event{Type: EventSpecifierFound, File: filepath.ToSlash(file), Line: specifier.Line, Specifier: specifier.Path})
+		sc.markModuleAsFound(specifier.Path, Location{File: filepath.ToSlash(file), Line: specifier.Line, SideEffect: specifier.SideEffect})
+	}
+	if !sc.strict && sc.isConfigFile(file) {
+		sc.markConfigStrings(filepath.ToSlash(file), data)
+	}
+	if sc.scanCI && isYAMLFile(file) {
+		sc.markYAMLCommands(filepath.ToSlash(file), data)
+	}
+	if isCLIFile(file) {
+		sc.markCLICommands(filepath.ToSlash(file), data)
+	}
+}
+
+// markModuleAsFound locks the mutex of the Dependency of the scan,
+// called "d", updates the module/dependency as true,
+// records the location where it was found, and then unlocks it again.
+//
+// The specifier is resolved by the import map of the project first, if
+// any, see applyImportMap, then normalized by the language of the project,
+// e.g. specifiers pointing to local files are ignored, and subpaths like
+// "lodash/fp" are attributed to their package, "lodash".
+func (sc *scanContext) markModuleAsFound(moduleName string, loc Location) {
+	sc.d.mu.Lock()
+	defer sc.d.mu.Unlock()
+
+	moduleName, ok := sc.applyImportMap(moduleName)
+	if !ok {
+		return
+	}
+	if moduleName, ok = sc.lang.normalize(moduleName); !ok {
+		return
+	}
+	if declared, ok := sc.urlDependencyNames[moduleName]; ok {
+		moduleName = declared
+	}
+	if _, ok := sc.d.mp[moduleName]; ok {
+		evidence := EvidenceImport
+		if loc.SideEffect {
+			evidence = EvidenceSideEffect
+		}
+		sc.markAsUsed(moduleName, evidence, loc)
+	}
+	sc.d.usages[moduleName] = append(sc.d.usages[moduleName], loc)
+}
+
+// Create a list of dependencies to remove, based on the unused
+// dependencies reported in the findings.
+//
+// Suppressed findings are not part of the list, so those dependencies are kept.
+func (sc *scanContext) createDepsToRemoveList(findings []Finding) []string {
+	var depsToRemove []string
+	for _, f := range findings {
+		if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+			depsToRemove = append(depsToRemove, f.Package)
+			fmt.Fprintf(sc.logOut, "Removing Package: %v\n", f.Package)
+		}
+	}
+	return depsToRemove
+}
+
+// deleteDepsFromPackageJSON reads the package.json file, and
+// creates its new content in memory, without the lines containing
+// the dependencies from "depsToRemove".
+//
+// The removeTrailingCommas function is called inside deleteDepsFromPackageJSON
+// to fix the syntax of the new content.
+//
+// A unified diff of the change is printed first. In dry-run mode, nothing
+// else happens. With --stdout or --out, the new content is written there,
+// leaving package.json untouched. Otherwise, the current package.json file is
+// renamed to oldpackage.json for further reviews and for the users to make
+// final changes, before deleting that file, and the new content is written
+// to package.json. A copy is kept under .depose/backups too, see
+// "depose clean".
+func (sc *scanContext) deleteDepsFromPackageJSON(depsToRemove []string) {
+	data, err := sc.readProjectFile("package.json")
+	if err != nil {
+		logFatal(err)
+	}
+
+	newData := removeTrailingCommas(createNewPackageJson(depsToRemove, promoteDependencies(rewriteRanges(removeLines(data, sc.linesToRemove), sc.rangesToRewrite), sc.dependenciesToPromote)))
+	diff := unifiedDiff("package.json", "package.json", data, newData)
+	if diff == "" {
+		fmt.Fprintln(sc.logOut, "No changes to package.json.")
+	}
+	fmt.Fprint(sc.logOut, diff)
+	if sc.dryRun {
+		fmt.Fprintln(sc.logOut, "Dry run: package.json has not been changed.")
+		return
+	}
+
+	switch {
+	case sc.toStdout:
+		if _, err := os.Stdout.Write(newData); err != nil {
+			logFatal(err)
+		}
+		return
+	case sc.outPath != "":
+		if err := os.WriteFile(sc.outPath, newData, 0o644); err != nil {
+			logFatal(err)
+		}
+		fmt.Fprintf(sc.logOut, "The new package.json has been written to %s.\n", sc.outPath)
+		return
+	case diff == "":
+		return
+	}
+
+	if !sc.noBackup {
+		if err := os.Rename("package.json", "oldpackage.json"); err != nil {
+			logFatal(err)
+		}
+		// Unlike oldpackage.json, the backups under .depose/ survive the
+		// next runs, until "depose clean" removes them.
+		if _, err := saveBackup(data, time.Now()); err != nil {
+			fmt.Fprintf(sc.logOut, "Could not back up package.json: %v\n", err)
+		}
+	}
+	if err := os.WriteFile("package.json", newData, 0o644); err != nil {
+		logFatal(err)
+	}
+	fmt.Fprintln(sc.logOut, "Package.json has been changed.")
+	if !sc.noBackup {
+		fmt.Fprintln(sc.logOut, "Refer to oldpackage.json for the old original file.")
+	}
+}
+
+// createNewPackageJson copies the contents of the package.json,
+// except the lines containing a dependency from "depsToRemove".
+//
+// The entries of the overrides and of the resolutions are kept, since
+// removing a line of a nested override would break the JSON. The stale
+// ones are reported, and removed beforehand with --fix-overrides. So are
+// the aliases of the packages to remove, e.g. "sw": "npm:string-width@^4"
+// when string-width is removed, unless they are removed too.
+func createNewPackageJson(depsToRemove []string, data []byte) []byte {
+	overrideLines := make(map[int]bool)
+	for _, e := range overrideEntries(data) {
+		for l := e.Line; l <= e.EndLine; l++ {
+			overrideLines[l] = true
+		}
+	}
+	var newData bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ { // scan line by line
+		line := scanner.Text()
+		if m := keyLineRe.FindStringSubmatch(line); m != nil && strings.Contains(line, `"`+aliasProtocol) && !slices.Contains(depsToRemove, m[1]) {
+			newData.WriteString(line + "\n")
+			continue
+		}
+		// Check if the line contains a dependency to remove
+		shouldWrite := true
+		for _, dep := range depsToRemove {
+			if strings.Contains(line, dep) && !overrideLines[lineNo] {
+				shouldWrite = false
+				break // skip the line
+			}
+		}
+		if shouldWrite {
+			newData.WriteString(line + "\n")
+		}
+	}
+	return newData.Bytes()
+}
+
+// The removeTrailingCommas function is called from inside deleteDepsFromPackageJSON.
+// It fixes the syntax of the new package.json content.
+//
+// With this function, the trailing commas which remain after the deletion of the dependency
+// are removed to fix the syntax.
+//
+// The whitespace around the comma is kept, so the original formatting is preserved.
+//
+// Example:
+//
+//	"devDependencies": {
+//	  "jest": "^29.7.0", <- In cases like this, this comma here is removed
+//	}
+func removeTrailingCommas(data []byte) []byte {
+	// Use a regular expression to remove trailing commas before closed curlybraces "}"
+	re := regexp.MustCompile(`,(\s*})`)
+	return re.ReplaceAll(data, []byte("$1"))
+}
+
+// defineFlags defines the flags of the main command of depose on the flag set.
+func (sc *scanContext) defineFlags(fs *flag.FlagSet) {
+	sc.definedFlags = fs
+	fs.StringVar(&sc.reporterName, "reporter", "text", "format of the report: text, json, sarif, github or pr-comment")
+	fs.StringVar(&sc.formatTemplate, "format-template", "", "Go template file rendering the text report, e.g. to change its wording or language")
+	fs.BoolVar(&sc.verbose, "verbose", false, "also report suppressed findings and where every package is used")
+	fs.BoolVar(&sc.check, "check", false, "report findings without changing package.json, and fail on findings not in the baseline")
+	fs.BoolVar(&sc.updateBaseline, "update-baseline", false, "record the current findings into "+baselineFile)
+	fs.BoolVar(&sc.strict, "strict", false, "only count imports as usage, not package names found in scripts and config files")
+	fs.BoolVar(&sc.scanCI, "scan-ci", false, "mark the packages run by the commands of YAML files, e.g. CI workflows, as used")
+	fs.BoolVar(&sc.dryRun, "dry-run", false, "print the diff of package.json, without changing it")
+	fs.BoolVar(&sc.toStdout, "stdout", false, "write the new package.json to stdout, without changing it")
+	fs.StringVar(&sc.outPath, "out", "", "write the new package.json to the path, without changing it")
+	fs.BoolVar(&sc.reachable, "reachable", false, "only count the imports of the files reachable from the entrypoints, and report orphaned files")
+	fs.Var(&sc.extraManifests, "manifest", "extra manifest, or its directory, scanned against its own directory, e.g. functions/package.json; can be repeated")
+	fs.Var(&sc.entryPatterns, "entry", "entrypoint or pattern of entrypoints for --reachable, in addition to the ones of package.json; can be repeated")
+	fs.BoolVar(&sc.explainDecisions, "explain", false, "report for every dependency where it is declared, every piece of evidence found for it, and the verdict")
+	fs.BoolVar(&sc.includeMarkdown, "include-markdown", false, "scan the JavaScript and TypeScript code blocks of the Markdown documents, keeping the dependencies they import with a low confidence")
+	fs.BoolVar(&sc.treatScriptOnlyAsUnused, "treat-script-only-as-unused", false, "report the dependencies only named by the scripts of package.json as unused, instead of keeping them")
+	fs.BoolVar(&sc.keepDynamic, "keep-dynamic", false, "keep the dependencies matching the static prefix of dynamic requires, e.g. require(\"eslint-plugin-\" + name)")
+	fs.BoolVar(&sc.noPlugins, "no-plugins", false, "do not run the "+pluginPrefix+"* plugins found on PATH")
+	fs.BoolVar(&sc.fetchURLManifests, "fetch-url-manifests", false, "fetch the manifests of the git and tarball dependencies which aren't installed, to match their imports by their real names")
+	fs.BoolVar(&sc.outdated, "outdated", false, "also report how far the kept dependencies are behind their latest version on the registry")
+	fs.BoolVar(&sc.lastPublished, "last-publish", false, "also report when the kept dependencies were last published on the registry")
+	fs.StringVar(&sc.unmaintainedAfter, "unmaintained-after", "2y", "period without publish after which --last-publish flags a dependency as unmaintained, e.g. 18mo or 90d")
+	fs.BoolVar(&sc.bundleSizes, "bundle-size", false, "also report the minified and gzipped size of the kept runtime dependencies, the heaviest first")
+	fs.StringVar(&sc.bundleSizeAPI, "bundle-size-api", sc.bundleSizeAPI, "URL of the bundlephobia-style API queried by --bundle-size; empty to only estimate the sizes from node_modules")
+	fs.StringVar(&sc.registryURL, "registry", sc.registryURL, "URL of the npm registry queried by --outdated and --last-publish")
+	fs.StringVar(&sc.profileNamesFlag, "profile", defaultProfile, "exclusion profiles of the project, separated by commas: "+strings.Join(profileNames(), ", "))
+	fs.BoolVar(&sc.noAutoDetect, "no-auto-detect", false, "do not detect the kind of project, e.g. Next.js or a monorepo, to select the profiles when --profile isn't given")
+	fs.BoolVar(&sc.excludeNested, "exclude-nested", true, "skip the directories excluded by the profiles, e.g. node_modules, at any depth, not only at the project root")
+	fs.IntVar(&sc.maxDepth, "max-depth", 0, "skip the directories nested deeper than this below the project root (0 for no limit)")
+	fs.IntVar(&sc.maxFiles, "max-files", 0, "stop the walk once this many files have been found (0 for no limit)")
+	fs.BoolVar(&sc.noHistory, "no-history", false, "do not record the counts of the run into "+historyFile)
+	fs.BoolVar(&sc.noStats, "no-stats", false, "do not record the run into the local stats printed by depose stats")
+	fs.StringVar(&sc.failOn, "fail-on", "", "kinds of findings failing --check, separated by commas: "+strings.Join(findingKindNames(), ", ")+" (default: any)")
+	fs.IntVar(&sc.maxUnused, "max-unused", -1, "number of unused dependencies tolerated by --check")
+	fs.IntVar(&sc.maxMissing, "max-missing", -1, "number of missing dependencies tolerated by --check")
+	fs.StringVar(&sc.notifyURL, "notify-webhook", "", "URL of a webhook to POST the results of the run to, e.g. a Slack incoming webhook")
+	fs.StringVar(&sc.notifyTemplate, "notify-template", "", "Go template of the body posted to --notify-webhook (default: the report as JSON)")
+	fs.IntVar(&sc.notifyMinFindings, "notify-min-findings", 1, "only notify --notify-webhook when the run has at least this many findings")
+	fs.BoolVar(&sc.noAscend, "no-ascend", false, "only look for the manifest in the current directory, not in its parents")
+	fs.BoolVar(&sc.timings, "timings", false, "report the files scanned, the bytes read, the duration of the phases and the peak goroutines and memory of the run")
+	fs.StringVar(&sc.pprofDir, "pprof-dir", "", "write the CPU and heap profiles of the run to the directory, for go tool pprof")
+	fs.StringVar(&sc.metricsTextfile, "metrics-textfile", "", "write the metrics of the run to the path, for the textfile collector of the Prometheus node exporter")
+	fs.StringVar(&sc.metricsPushgateway, "metrics-pushgateway", "", "URL of a Prometheus pushgateway to push the metrics of the run to")
+	fs.StringVar(&sc.groupBy, "group-by", "", "group the findings of the report: owner, read from CODEOWNERS, section, workspace, confidence or severity")
+	fs.StringVar(&sc.onlyKinds, "only", "", "only report the kinds of findings, e.g. \"unused,missing\": "+strings.Join(findingKindNames(), ", "))
+	fs.Var(&sc.filterPatterns, "filter", "only report the findings about the packages matching the pattern, e.g. \"@scope/*\"; can be repeated")
+	fs.BoolVar(&sc.misplacedChecks, "misplaced", false, "report the devDependencies imported by the production code")
+	fs.BoolVar(&sc.fixSections, "fix-sections", false, "move the devDependencies imported by the production code to the dependencies of package.json along with the removal")
+	fs.BoolVar(&sc.rangeChecks, "ranges", false, "report the version ranges whose style differs from the other dependencies, e.g. ~ among ^, and the wildcards and git URLs")
+	fs.StringVar(&sc.fixRanges, "fix-ranges", "", "rewrite the version ranges of package.json in this style along with the removal: "+strings.Join(rangeFixStyles, " or "))
+	fs.BoolVar(&sc.includeLocal, "include-local", false, "also report and remove the unused local packages, declared with workspace:, file:, link: or portal:")
+	fs.BoolVar(&sc.lastReferenced, "last-referenced", false, "annotate the unused dependencies with the git commit which last referenced them")
+	fs.BoolVar(&sc.updateBots, "update-bots", false, "annotate the findings of the dependencies updated by Renovate or Dependabot, read from their configs")
+	fs.StringVar(&sc.botPRBody, "bot-pr-body", "", "write the body of a pull request removing the unused dependencies, grouped like the updates of Renovate or Dependabot, to the path (implies --update-bots)")
+	fs.BoolVar(&sc.staleChecks, "stale-scripts", false, "also report the scripts and the config files of the tools whose dependencies are unused")
+	fs.BoolVar(&sc.fixOverrides, "fix-overrides", false, "remove the overrides and resolutions of the unused dependencies along with them")
+	fs.BoolVar(&sc.fixScripts, "fix-scripts", false, "remove the scripts and delete the config files reported by --stale-scripts along with the unused dependencies")
+	fs.StringVar(&sc.verifyCommand, "verify", "", "command verifying the project once the dependencies are removed, e.g. \"npm run build && npm test\"; package.json is restored if it fails")
+	fs.StringVar(&sc.since, "since", "", "only report the findings introduced since the git revision, e.g. origin/main")
+	fs.StringVar(&sc.prID, "pr", "", "pull request commented by --reporter pr-comment: owner/repo#123 on GitHub, group/project!123 on GitLab (default: read from the CI)")
+	fs.StringVar(&sc.outputFormat, "output", "", "stream the events of the scan to stdout instead of the report, as they happen: ndjson")
+	fs.StringVar(&sc.graphFormat, "graph", "", "write the graph of the files to the packages they use, in the dot or mermaid format, instead of the report")
+	fs.BoolVar(&sc.graphLockfile, "graph-lockfile", false, "also link the packages of --graph to their dependencies, read from package-lock.json")
+	fs.StringVar(&sc.langName, "lang", "node", "language of the project: node, go or python")
+}
+
+// Main runs depose with the arguments of the command line, without the
+// name of the program, and returns the status it exits with. The commands
+// are added to the subcommands of depose, e.g. "serve", which is built on
+// pkg/depose. The version is the one set by the build of the release.
+func Main(version string, args []string, commands map[string]func(fs *flag.FlagSet) func(args []string)) (code int) {
+	sc := newScanContext()
+	sc.version = version
+	for name, cmd := range commands {
+		sc.commands[name] = cmd
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*exitError)
+			if !ok {
+				panic(r)
+			}
+			code = e.print()
+		}
+	}()
+
+	if len(args) > 0 {
+		if _, ok := sc.commands[args[0]]; ok {
+			sc.runCommand(args[0], args[1:])
+			return 0
+		}
+	}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	sc.defineFlags(fs)
+	fs.Parse(args)
+	sc.run()
+	return 0
+}
+
+// Scan scans the project in dir like the command line with the flags in
+// args, without changing the working directory, and returns its report
+// instead of printing it. The findings are never fixed, and the messages
+// of the scan are discarded. The errors which stop depose, e.g.
+// ErrNoManifest, are returned, like the error of ctx once it is canceled.
+func Scan(ctx context.Context, dir string, args []string) (r *Report, err error) {
+	sc := newScanContext()
+	sc.ctx, sc.dir, sc.logOut = ctx, dir, io.Discard
+	sc.projectFS = os.DirFS(dir)
+	fs := flag.NewFlagSet("depose", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	sc.defineFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*exitError)
+			if !ok {
+				panic(r)
+			}
+			err = e.err
+			if err == nil {
+				err = fmt.Errorf("depose exited with status %d", e.code)
+			}
+		}
+	}()
+	return sc.scan().report, nil
+}
+
+// stopIfCanceled stops the scan with the error of its context, once it
+// is canceled.
+func (sc *scanContext) stopIfCanceled() {
+	if err := sc.ctx.Err(); err != nil {
+		fatal(err)
+	}
+}
+
+// scanResult is the outcome of a scan, which run reports and fixes.
+type scanResult struct {
+	// report is nil when the run ends with the scan, e.g. with --graph.
+	report      *Report
+	projectName string
+	// findings are the findings of the manifest, and reported the ones
+	// reported, along with the findings of the extra manifests.
+	findings, reported, suppressed []Finding
+	// vanished are the expected findings no longer found by --check.
+	vanished []baselineEntry
+	scanned  int
+}
+
+// run scans the project in the working directory, with the options set by
+// the flags of defineFlags, reports the findings, and fixes the manifest.
+func (sc *scanContext) run() {
+	res := sc.scan()
+	defer func() { sc.emitMetrics(res.projectName, len(res.findings)) }()
+	if res.report == nil {
+		return
+	}
+	r, findings, projectName := res.report, res.findings, res.projectName
+	report := sc.reporters[sc.reporterName]
+	// The new manifest owns stdout with --stdout, so the report goes to stderr.
+	reportOut := io.Writer(os.Stdout)
+	if sc.toStdout {
+		reportOut = os.Stderr
+	}
+	sc.metrics.beginPhase("write")
+	if sc.events != nil {
+		sc.events.emitResults(r, res.scanned, len(sc.d.mp), len(res.suppressed))
+	} else if err := report(reportOut, r); err != nil {
+		logFatal(err)
+	}
+	if sc.botPRBody != "" {
+		var body bytes.Buffer
+		writeBotPRBody(&body, r.Findings)
+		if err := os.WriteFile(sc.botPRBody, body.Bytes(), 0o644); err != nil {
+			logFatalf("Failed to write the pull request body: %v", err)
+		}
+		fmt.Fprintf(sc.logOut, "The pull request body has been written to %s.\n", sc.botPRBody)
+	}
+	if sc.notifyURL != "" {
+		summary := newHistoryEntry(time.Now(), gitCommit(), len(sc.d.mp), findings)
+		sc.sendNotification(sc.notifyURL, sc.notifyTemplate, sc.notifyMinFindings, notification{Project: projectName, Summary: summary, Report: r})
+	}
+
+	if sc.check {
+		thresholds := sc.manifest.Depose.Check
+		if sc.failOn != "" {
+			thresholds.FailOn = strings.Split(sc.failOn, ",")
+		}
+		if sc.maxUnused >= 0 {
+			thresholds.MaxUnused = &sc.maxUnused
+		}
+		if sc.maxMissing >= 0 {
+			thresholds.MaxMissing = &sc.maxMissing
+		}
+		// The rules lowered to warnings or notes by the config never fail it.
+		failures, err := checkFailures(sc.failingFindings(res.reported, sc.ruleSeverities), thresholds)
+		if err != nil {
+			logFatal(err)
+		}
+		if len(res.vanished) > 0 {
+			failures = append(failures, fmt.Sprintf("%d expected finding(s) no longer found", len(res.vanished)))
+		}
+		if len(failures) > 0 {
+			fmt.Fprintf(sc.logOut, "Check failed: %s\n", strings.Join(failures, ", "))
+			exit(1)
+		}
+		return
+	}
+
+	if sc.lang.rewriteManifest == nil {
+		fmt.Fprintln(sc.logOut, "Program Complete....")
+		fmt.Fprintf(sc.logOut, "%s has not been changed, apply the suggested fixes to clean it up.\n", sc.manifestFile)
+		return
+	}
+
+	sc.removed = sc.createDepsToRemoveList(findings)
+	sc.linesToRemove = make(map[int]bool)
+	if sc.fixScripts {
+		maps.Copy(sc.linesToRemove, staleScriptLines(findings))
+	}
+	if sc.fixOverrides {
+		maps.Copy(sc.linesToRemove, sc.staleOverrideLines(findings))
+		sc.removedOverrides = sc.staleOverrideKeys(findings)
+	}
+	if sc.fixRanges != "" {
+		sc.rangesToRewrite = sc.rangeFixes(findings, sc.fixRanges)
+	}
+	if sc.fixSections {
+		sc.dependenciesToPromote = promotedDependencies(findings)
+	}
+	var original []byte
+	if sc.verifyCommand != "" {
+		var err error
+		if original, err = sc.readProjectFile(sc.manifestFile); err != nil {
+			logFatal(err)
+		}
+	}
+	sc.lang.rewriteManifest(sc.removed)
+	if sc.verifyCommand != "" && len(sc.removed) > 0 {
+		if err := sc.verifyRemoval(sc.verifyCommand, original, sc.removed); err != nil {
+			logFatal(err)
+		}
+	}
+	if !sc.noHistory && !sc.noStats && !sc.dryRun && !sc.toStdout && sc.outPath == "" && len(sc.removed) > 0 {
+		sc.updateStats(countRemoval(len(sc.removed), sc.installedSize(sc.removed)))
+	}
+	if sc.fixScripts && !sc.dryRun && !sc.toStdout && sc.outPath == "" {
+		var err error
+		if sc.deletedConfigs, err = sc.deleteStaleConfigs(findings); err != nil {
+			logFatal(err)
+		}
+	}
+	if stale := sc.staleOverrides(findings); len(stale) > 0 && !sc.fixOverrides {
+		fmt.Fprintf(sc.logOut, "Kept %d stale override(s) of the unused dependencies, remove them with --fix-overrides.\n", len(stale))
+	}
+
+	fmt.Fprintln(sc.logOut, "Program Complete....")
+}
+
+// scan scans the project of dir, with the options set
+// by the flags of defineFlags, and returns its findings and its report.
+func (sc *scanContext) scan() *scanResult {
+	if _, ok := sc.reporters[sc.reporterName]; !ok {
+		logFatalf("Unknown reporter %q", sc.reporterName)
+	}
+	var ok bool
+	if sc.lang, ok = sc.languages[sc.langName]; !ok {
+		logFatalf("Unknown language %q", sc.langName)
+	}
+	if sc.formatTemplate != "" {
+		if sc.reporterName != "text" {
+			logFatal("--format-template only applies to the text reporter")
+		}
+		if _, err := parseReportTemplate(sc.formatTemplate); err != nil {
+			logFatal(err)
+		}
+	}
+	if sc.check && sc.updateBaseline {
+		logFatal("--check and --update-baseline can't be used together")
+	}
+	if sc.toStdout && sc.outPath != "" {
+		logFatal("--stdout and --out can't be used together")
+	}
+	if (sc.toStdout || sc.outPath != "") && sc.lang.rewriteManifest == nil {
+		logFatalf("--stdout and --out are not supported for %s projects", sc.lang.name)
+	}
+	if sc.verifyCommand != "" && (sc.dryRun || sc.toStdout || sc.outPath != "" || sc.check || sc.lang.rewriteManifest == nil) {
+		logFatal("--verify needs package.json to be changed, so it can't be used with --dry-run, --stdout, --out, --check, nor other languages")
+	}
+	if sc.reachable && sc.lang != sc.nodeLanguage {
+		logFatalf("--reachable is not supported for %s projects", sc.lang.name)
+	}
+	if sc.profileNamesFlag != defaultProfile {
+		if sc.lang != sc.nodeLanguage {
+			logFatalf("--profile is not supported for %s projects", sc.lang.name)
+		}
+		var err error
+		if sc.filesToExclude, sc.dirsToExclude, err = profileExclusions(sc.profileNamesFlag); err != nil {
+			logFatal(err)
+		}
+	}
+	if sc.groupBy != "" && !slices.Contains(groupings, sc.groupBy) {
+		logFatalf("Unknown grouping %q, available groupings: %s", sc.groupBy, strings.Join(groupings, ", "))
+	}
+	onlyRules, err := parseOnly(sc.onlyKinds)
+	if err != nil {
+		logFatal(err)
+	}
+	if sc.outdated && sc.lang != sc.nodeLanguage {
+		logFatalf("--outdated is not supported for %s projects", sc.lang.name)
+	}
+	if len(sc.extraManifests) > 0 && sc.lang != sc.nodeLanguage {
+		logFatalf("--manifest is not supported for %s projects", sc.lang.name)
+	}
+	if sc.fixRanges != "" && !slices.Contains(rangeFixStyles, sc.fixRanges) {
+		logFatalf("Unknown range style %q, available styles: %s", sc.fixRanges, strings.Join(rangeFixStyles, ", "))
+	}
+	if sc.fixRanges != "" && sc.lang != sc.nodeLanguage {
+		logFatalf("--fix-ranges is not supported for %s projects", sc.lang.name)
+	}
+	if sc.fixSections && sc.lang != sc.nodeLanguage {
+		logFatalf("--fix-sections is not supported for %s projects", sc.lang.name)
+	}
+	if sc.lastPublished && sc.lang != sc.nodeLanguage {
+		logFatalf("--last-publish is not supported for %s projects", sc.lang.name)
+	}
+	if sc.bundleSizes && sc.lang != sc.nodeLanguage {
+		logFatalf("--bundle-size is not supported for %s projects", sc.lang.name)
+	}
+	maintenancePeriod, err := parsePeriod(sc.unmaintainedAfter)
+	if err != nil {
+		logFatal(err)
+	}
+	writeGraph, ok := graphWriters[sc.graphFormat]
+	if sc.graphFormat != "" && !ok {
+		logFatalf("Unknown graph format %q", sc.graphFormat)
+	}
+	if sc.outputFormat != "" && sc.outputFormat != "ndjson" {
+		logFatalf("Unknown output format %q", sc.outputFormat)
+	}
+	if sc.outputFormat != "" && (sc.reporterName != "text" || sc.toStdout || writeGraph != nil) {
+		logFatal("--output can't be used with --reporter, --stdout nor --graph")
+	}
+	// The messages leave stdout to the report, unless Scan discards them.
+	if sc.logOut == io.Writer(os.Stdout) && (sc.reporterName != "text" || sc.toStdout || writeGraph != nil || sc.outputFormat != "") {
+		sc.logOut = os.Stderr
+	}
+	sc.events = nil
+	if sc.outputFormat == "ndjson" {
+		sc.events = newEventStream(os.Stdout)
+	}
+
+	if sc.timings || sc.metricsTextfile != "" || sc.metricsPushgateway != "" {
+		sc.metrics = startMetrics()
+	}
+	if sc.pprofDir != "" {
+		if err := sc.startProfiling(sc.pprofDir); err != nil {
+			logFatalf("Failed to start profiling: %v", err)
+		}
+	}
+
+	// initialization of empty maps to store dependencies and their usages
+	sc.d.mp = make(map[string]bool)
+	sc.d.usages = make(map[string][]Location)
+	sc.d.ignoredLines = make(map[Location][]string)
+	sc.d.unresolved = make(map[string][]Location)
+
+	if !sc.noAscend {
+		sc.absFlagPaths()
+		sc.enterProjectRoot(sc.lang)
+	}
+	if sc.expectFile != "" {
+		sc.excludeExpectedReport()
+	}
+	sc.manifestFile = sc.findManifest(sc.lang)
+	projectName := sc.lang.readManifest(sc.manifestFile)
+	nestedDirs, err := extraManifestDirs(sc.dir, sc.extraManifests)
+	if err != nil {
+		logFatal(err)
+	}
+	if sc.lang == sc.nodeLanguage && !sc.noAutoDetect {
+		nestedDirs = sc.addFunctionsManifests(nestedDirs)
+	}
+	for _, dir := range nestedDirs {
+		sc.filesToExclude[dir] = 0
+	}
+	if sc.lang == sc.nodeLanguage && !sc.noAutoDetect && sc.profileNamesFlag == defaultProfile {
+		sc.autoDetectProject(sc.manifest)
+	}
+	sc.importMaps, sc.layout = importMap{}, nodeModulesLayout{}
+	if sc.lang == sc.nodeLanguage {
+		sc.importMaps = sc.readImportMaps()
+		sc.layout = sc.readNodeModulesLayout(sc.manifest)
+	}
+	if abs, err := filepath.Abs(filepath.Join(sc.dir, sc.manifestFile)); err == nil && sc.projectOnDisk() {
+		fmt.Fprintf(sc.logOut, "Using %s\n", abs)
+	} else {
+		fmt.Fprintf(sc.logOut, "Using %s\n", sc.manifestFile)
+	}
+	var findings []Finding
+
+	// Walk the directory, and scan each directory/file.
+	sc.metrics.beginPhase("walk")
+	if err := sc.walkProject(); err != nil {
+		fmt.Fprintf(sc.logOut, "Error scanning the directory %v:\n", err)
+	}
+	if sc.lang == sc.nodeLanguage {
+		if extra := sc.binFiles(sc.files); len(extra) > 0 {
+			fmt.Fprintf(sc.logOut, "Added %d file(s) of the bin executables which the walk left out\n", len(extra))
+			sc.files = append(sc.files, extra...)
+		}
+	}
+
+	sc.stopIfCanceled()
+	sc.metrics.beginPhase("parse")
+	// Plugins run first, so the rules they declare are known
+	// when the ignore directives of the files are parsed.
+	var pluginFindings []Finding
+	if !sc.noPlugins {
+		pluginFindings = sc.runPlugins(findPlugins(), sc.files)
+	}
+	// In reachability mode, only the files reachable from the entrypoints are scanned.
+	var orphans []string
+	scanned := sc.files
+	if sc.reachable {
+		graph := sc.followImports(sc.entrypoints(sc.entryPatterns, sc.files))
+		orphans = orphanedFiles(graph, sc.files)
+		scanned = nil
+		for _, file := range sc.files {
+			if graph.files[filepath.ToSlash(file)] {
+				scanned = append(scanned, file)
+			}
+		}
+	}
+	sc.extractPackages(scanned)
+	if sc.typesChecks && !sc.strict {
+		sc.markTypesPackages()
+	}
+	fmt.Fprintln(sc.logOut, "Finished walking the directory")
+
+	// The graph replaces the report, and package.json is left untouched.
+	if writeGraph != nil {
+		sc.metrics.beginPhase("write")
+		var lockDeps map[string][]string
+		if sc.graphLockfile {
+			var err error
+			if lockDeps, err = sc.readLockfileDependencies("package-lock.json"); err != nil {
+				logFatalf("Failed to read package-lock.json: %v", err)
+			}
+		}
+		if err := writeGraph(os.Stdout, buildUsageGraph(sc.d.usages, lockDeps)); err != nil {
+			logFatal(err)
+		}
+		return &scanResult{projectName: projectName}
+	}
+
+	sc.stopIfCanceled()
+	sc.metrics.beginPhase("resolve")
+	// The rules of the plugins are registered by now.
+	if sc.ruleSeverities, err = sc.parseRuleSeverities(sc.manifest.Depose.Rules); err != nil {
+		logFatal(err)
+	}
+	if severity, ok := sc.ruleSeverities[scriptOnlyRule]; sc.treatScriptOnlyAsUnused || ok && severity != severityOff {
+		sc.markScriptOnlyAsUnused()
+	}
+	findings = append(sc.buildFindings(projectName), pluginFindings...)
+	findings = append(findings, sc.orphanedFileFindings(orphans)...)
+	if (sc.misplacedChecks || sc.fixSections || onlyRules[RuleMisplacedDependency]) && sc.lang == sc.nodeLanguage {
+		findings = append(findings, sc.misplacedFindings(sc.runtimeFiles(sc.entryPatterns, sc.files))...)
+	}
+	if (sc.rangeChecks || sc.fixRanges != "" || onlyRules[RuleInconsistentRange]) && sc.lang == sc.nodeLanguage {
+		findings = append(findings, sc.rangeFindings(sc.fixRanges)...)
+	}
+	sc.sortFindings(findings)
+	findings, suppressed := sc.applySuppressions(sc.applyRuleSeverities(findings, sc.ruleSeverities), sc.manifest.Depose)
+	if (sc.staleChecks || sc.fixScripts) && sc.lang == sc.nodeLanguage {
+		stale, staleSuppressed := sc.applySuppressions(sc.applyRuleSeverities(sc.staleFindings(findings), sc.ruleSeverities), sc.manifest.Depose)
+		findings, suppressed = append(findings, stale...), append(suppressed, staleSuppressed...)
+		sc.sortFindings(findings)
+	}
+	if sc.lang == sc.nodeLanguage {
+		stale, staleSuppressed := sc.applySuppressions(sc.applyRuleSeverities(sc.staleOverrideFindings(findings), sc.ruleSeverities), sc.manifest.Depose)
+		findings, suppressed = append(findings, stale...), append(suppressed, staleSuppressed...)
+		sc.sortFindings(findings)
+	}
+
+	// The findings of the extra manifests are reported, but only the root
+	// manifest is fixed.
+	var nestedFindings []Finding
+	if len(nestedDirs) > 0 {
+		nested, nestedSuppressed, nestedDiagnostics := sc.scanExtraManifests(nestedDirs)
+		nestedFindings, suppressed = nested, append(suppressed, nestedSuppressed...)
+		sc.diagnostics = append(sc.diagnostics, nestedDiagnostics...)
+	}
+
+	if !sc.noHistory {
+		entry := newHistoryEntry(time.Now(), gitCommit(), len(sc.d.mp), findings)
+		if err := appendHistory(historyFile, entry); err != nil {
+			fmt.Fprintf(sc.logOut, "Failed to record the run into %s: %v\n", historyFile, err)
+			sc.recordDiagnostic(historyFile, err)
+		}
+	}
+	// The runs without history, e.g. the scans of the nested manifests,
+	// are not counted in the stats either.
+	if !sc.noHistory && !sc.noStats {
+		sc.updateStats(countRun(time.Now()))
+	}
+
+	if sc.updateExpect {
+		if err := writeExpectedReport(sc.expectFile, findings); err != nil {
+			logFatal(err)
+		}
+		fmt.Fprintf(sc.logOut, "Recorded %d finding(s) in %s\n", len(findings), sc.expectFile)
+		return &scanResult{projectName: projectName, findings: findings}
+	}
+	if sc.updateBaseline {
+		if err := writeBaseline(baselineFile, findings); err != nil {
+			logFatal(err)
+		}
+		fmt.Fprintf(sc.logOut, "Recorded %d finding(s) in %s\n", len(findings), baselineFile)
+		return &scanResult{projectName: projectName, findings: findings}
+	}
+
+	// With --since, the findings already found at the revision are
+	// accepted, unless the changes add usages of a missing dependency.
+	if sc.since != "" {
+		base, err := sc.findingsAt(sc.since)
+		if err != nil {
+			logFatalf("Failed to scan the project at %s: %v", sc.since, err)
+		}
+		var existing []Finding
+		findings, existing = applySince(findings, base, sc.since)
+		// The usages added by the changes are reported on their own lines.
+		added, err := linesAddedSince(sc.since)
+		if err != nil {
+			logFatalf("Failed to diff the project since %s: %v", sc.since, err)
+		}
+		findings, existing = sc.applyNewUsages(findings, existing, added, sc.since)
+		sc.sortFindings(findings)
+		suppressed = append(suppressed, existing...)
+	}
+
+	// In check mode, findings recorded in the baseline are accepted. With
+	// --expect, the expected findings are, but the ones no longer found
+	// fail the check too.
+	var vanished []baselineEntry
+	if sc.check {
+		if sc.expectFile != "" {
+			expected, err := readExpectedReport(sc.expectFile)
+			if err != nil {
+				logFatalf("Failed to read the expected report: %v", err)
+			}
+			var known []Finding
+			findings, known = applyBaseline(findings, expected, sc.expectFile)
+			suppressed = append(suppressed, known...)
+
+			vanished = staleBaselineEntries(expected, known)
+			for _, entry := range vanished {
+				fmt.Fprintf(sc.logOut, "Expected finding [%s] %q is no longer found, run depose check --update to remove it from %s\n",
+					entry.RuleID, entry.Package, sc.expectFile)
+			}
+		} else {
+			baseline, err := readBaseline(baselineFile)
+			if err != nil {
+				logFatalf("Failed to read %s: %v", baselineFile, err)
+			}
+			var known []Finding
+			findings, known = applyBaseline(findings, baseline, baselineFile)
+			suppressed = append(suppressed, known...)
+
+			for _, entry := range staleBaselineEntries(baseline, known) {
+				fmt.Fprintf(sc.logOut, "Stale baseline entry [%s] %q: it no longer matches any finding, run --update-baseline to remove it\n",
+					entry.RuleID, entry.Package)
+			}
+		}
+
+		// Policy violations can't be suppressed nor accepted by the baseline.
+		findings = append(findings, sc.policyFindings(sc.manifest.Depose.Policy)...)
+		sc.sortFindings(findings)
+	}
+
+	reported := findings
+	if len(nestedFindings) > 0 {
+		reported = append(slices.Clone(findings), nestedFindings...)
+		sc.sortFindings(reported)
+	}
+	r := &Report{Findings: filterFindings(reported, onlyRules, sc.filterPatterns), CLIOnly: sc.cliOnlyUsages(), ScriptOnly: sc.scriptOnlyUsages(), Skipped: sc.skipped, Diagnostics: sc.diagnostics}
+	if sc.verbose {
+		r.Suppressed = suppressed
+		r.Usage = sc.d.usages
+		r.Verdicts = sc.verdicts()
+	}
+	if sc.explainDecisions {
+		r.Explanations = sc.explanations(findings, suppressed)
+	}
+	if sc.lang == sc.nodeLanguage {
+		kept, _ := sc.keptDependencies(findings)
+		r.Alternatives = sc.suggestAlternatives(kept, sc.manifest.Depose.Alternatives)
+	}
+	if sc.lastReferenced {
+		annotateLastReferences(r.Findings)
+	}
+	if sc.updateBots || sc.botPRBody != "" {
+		bots, err := sc.readUpdateBots()
+		if err != nil {
+			logFatalf("Failed to read the configs of the update bots: %v", err)
+		}
+		sc.annotateBots(r.Findings, bots)
+		sc.annotateBots(r.Suppressed, bots)
+	}
+	switch sc.groupBy {
+	case "owner":
+		owners, err := sc.readCodeowners()
+		if err != nil {
+			logFatalf("Failed to read CODEOWNERS: %v", err)
+		}
+		assignOwners(r.Findings, owners)
+		assignOwners(r.Suppressed, owners)
+		r.Groups = groupByOwner(r.Findings)
+	case "section":
+		r.Groups = groupBySection(r.Findings)
+	case "workspace":
+		if r.Groups, err = sc.groupByWorkspace(r.Findings); err != nil {
+			logFatalf("Failed to read the workspaces: %v", err)
+		}
+	case "confidence":
+		sc.assignConfidences(r.Findings)
+		r.Groups = groupByConfidence(r.Findings)
+	case "severity":
+		r.Groups = groupBySeverity(r.Findings)
+	}
+	if sc.outdated {
+		r.Outdated = sc.findOutdated(sc.keptDependencies(findings))
+	}
+	if sc.lastPublished {
+		kept, _ := sc.keptDependencies(findings)
+		r.LastPublish = sc.findPublishStatus(kept, maintenancePeriod.before(time.Now()))
+	}
+	if sc.bundleSizes {
+		r.BundleSizes = sc.findBundleSizes(sc.runtimeDependencies(findings))
+	}
+	return &scanResult{report: r, projectName: projectName, findings: findings, reported: reported, suppressed: suppressed, vanished: vanished, scanned: len(scanned)}
+}
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMain(t *testing.T) {
+	deposePath := buildDepose(t)
+
+	// Copy the test directory, so the fixtures are left untouched
+	testDir := copyTestDir(t)
+
+	// Run the built file inside the copied test directory
+	cmd := exec.Command(deposePath)
+	cmd.Stderr = os.Stderr
+	cmd.Dir = testDir
+	err := cmd.Run()
+	if err != nil {
+		t.Fatalf("Failed to run built file: %v", err)
+	}
+
+	// Read the package.json file and expected.json file
+	// And Compare them
+	packageJSON, err := os.ReadFile(filepath.Join(testDir, "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to read package.json: %v", err)
+	}
+
+	expectedJSON, err := os.ReadFile("test/expected.json")
+	if err != nil {
+		t.Fatalf("Failed to read expected.json: %v", err)
+	}
+
+	// Unmarshal the JSON data
+	var packageData map[string]interface{}
+	var expectedData map[string]interface{}
+
+	err = json.Unmarshal(packageJSON, &packageData)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal package.json: %v", err)
+	}
+
+	err = json.Unmarshal(expectedJSON, &expectedData)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal expected.json: %v", err)
+	}
+
+	// Compare the data
+	if !reflect.DeepEqual(packageData, expectedData) {
+		t.Fatalf("package.json does not match expected.json")
+	}
+}
+
+func TestCheckWithBaseline(t *testing.T) {
+	deposePath := buildDepose(t)
+	testDir := copyTestDir(t)
+
+	run := func(args ...string) int {
+		cmd := exec.Command(deposePath, args...)
+		cmd.Dir = testDir
+		err := cmd.Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		if err != nil {
+			t.Fatalf("Failed to run built file: %v", err)
+		}
+		return 0
+	}
+
+	originalJSON, err := os.ReadFile(filepath.Join(testDir, "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to read package.json: %v", err)
+	}
+
+	// Without a baseline, the findings of the fixtures fail the check.
+	if code := run("--check"); code != 1 {
+		t.Fatalf("--check without baseline exited with %d, want 1", code)
+	}
+	if code := run("--update-baseline"); code != 0 {
+		t.Fatalf("--update-baseline exited with %d, want 0", code)
+	}
+	if code := run("--check"); code != 0 {
+		t.Fatalf("--check with baseline exited with %d, want 0", code)
+	}
+
+	// A new finding, not recorded in the baseline, fails the check again.
+	err = os.WriteFile(filepath.Join(testDir, "new.js"), []byte(`const zod = require("zod");`+"\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write new.js: %v", err)
+	}
+	if code := run("--check"); code != 1 {
+		t.Fatalf("--check with a new finding exited with %d, want 1", code)
+	}
+
+	if code := run("--check", "--update-baseline"); code == 0 {
+		t.Fatalf("--check --update-baseline was accepted")
+	}
+
+	// The check mode never changes package.json.
+	packageJSON, err := os.ReadFile(filepath.Join(testDir, "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to read package.json: %v", err)
+	}
+	if string(packageJSON) != string(originalJSON) {
+		t.Fatalf("package.json was changed in check mode")
+	}
+}
+
+// buildDepose builds the Go application outside of the scanned directory,
+// and returns the path of the built file.
+func buildDepose(t *testing.T) string {
+	t.Helper()
+
+	deposePath := filepath.Join(t.TempDir(), "depose")
+	cmd := exec.Command("go", "build", "-o", deposePath, "github.com/CoderParth/depose")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to build application: %v", err)
+	}
+	// The binary records its runs into the stats of the configuration
+	// directory of the user, which the tests must leave untouched: it is
+	// moved for the commands of the test, which inherit the environment.
+	config := t.TempDir()
+	for _, name := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+		t.Setenv(name, config)
+	}
+	return deposePath
+}
+
+// copyTestDir copies the files of the test directory
+// into a temporary directory, and returns its path.
+func copyTestDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	entries, err := os.ReadDir("test")
+	if err != nil {
+		t.Fatalf("Failed to read test directory: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == "expected.json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("test", entry.Name()))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", entry.Name(), err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, entry.Name()), data, 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", entry.Name(), err)
+		}
+	}
+	return dir
+}
+
+func TestStdoutAndOut(t *testing.T) {
+	deposePath := buildDepose(t)
+	testDir := copyTestDir(t)
+
+	originalJSON, err := os.ReadFile(filepath.Join(testDir, "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to read package.json: %v", err)
+	}
+	expectedJSON, err := os.ReadFile("test/expected.json")
+	if err != nil {
+		t.Fatalf("Failed to read expected.json: %v", err)
+	}
+	var expectedData map[string]interface{}
+	if err := json.Unmarshal(expectedJSON, &expectedData); err != nil {
+		t.Fatalf("Failed to unmarshal expected.json: %v", err)
+	}
+
+	cmd := exec.Command(deposePath, "--stdout")
+	cmd.Dir = testDir
+	stdout, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to run built file: %v", err)
+	}
+	var stdoutData map[string]interface{}
+	if err := json.Unmarshal(stdout, &stdoutData); err != nil {
+		t.Fatalf("Failed to unmarshal the output of --stdout: %v\n%s", err, stdout)
+	}
+	if !reflect.DeepEqual(stdoutData, expectedData) {
+		t.Fatalf("the output of --stdout does not match expected.json")
+	}
+
+	outPath := filepath.Join(t.TempDir(), "package.prod.json")
+	cmd = exec.Command(deposePath, "--out", outPath)
+	cmd.Dir = testDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to run built file: %v", err)
+	}
+	outJSON, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Failed to read the output of --out: %v", err)
+	}
+	if string(outJSON) != string(stdout) {
+		t.Fatalf("the outputs of --out and --stdout differ")
+	}
+
+	// package.json is left untouched.
+	packageJSON, err := os.ReadFile(filepath.Join(testDir, "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to read package.json: %v", err)
+	}
+	if string(packageJSON) != string(originalJSON) {
+		t.Fatalf("package.json was changed with --stdout or --out")
+	}
+	if _, err := os.Stat(filepath.Join(testDir, "oldpackage.json")); err == nil {
+		t.Fatalf("oldpackage.json was created with --stdout or --out")
+	}
+}
+
+func TestFilesCommand(t *testing.T) {
+	deposePath := buildDepose(t)
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json":    `{"name": "app", "main": "src/index.js"}`,
+		"src/index.js":    `require("./routes");`,
+		"src/routes.js":   ``,
+		"src/legacy.js":   `require("./routes");`,
+		"scripts/seed.ts": ``,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(deposePath, "files")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to run built file: %v", err)
+	}
+	if want := "scripts/seed.ts\nsrc/legacy.js\n"; string(out) != want {
+		t.Fatalf("depose files printed %q, want %q", out, want)
+	}
+
+	cmd = exec.Command(deposePath, "files", "--entry", "scripts/*.ts")
+	cmd.Dir = dir
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to run built file: %v", err)
+	}
+	if want := "src/legacy.js\n"; string(out) != want {
+		t.Fatalf("depose files --entry printed %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// publishStatus is when a kept dependency was last published to the
+// registry.
+type publishStatus struct {
+	Package     string    `json:"package"`
+	Section     string    `json:"section"`
+	LastPublish time.Time `json:"lastPublish"`
+	// Unmaintained is true when no version was published during the period
+	// of --unmaintained-after.
+	Unmaintained bool `json:"unmaintained"`
+}
+
+func (p publishStatus) String() string {
+	s := fmt.Sprintf("%s %s", p.Package, p.LastPublish.Format(time.DateOnly))
+	if p.Unmaintained {
+		s += " (unmaintained)"
+	}
+	return s
+}
+
+// period is a calendar period, e.g. 2 years or 18 months.
+type period struct {
+	years, months, days int
+}
+
+// periodRe matches the periods of --unmaintained-after, e.g. "2y", "18mo",
+// "6w" or "90d".
+var periodRe = regexp.MustCompile(`^(\d+)(y|mo|w|d)$`)
+
+// parsePeriod parses a period, e.g. "2y" or "18mo".
+func parsePeriod(s string) (period, error) {
+	m := periodRe.FindStringSubmatch(s)
+	if m == nil {
+		return period{}, fmt.Errorf("invalid period %q, expected a number of years, months, weeks or days, e.g. 2y, 18mo, 6w or 90d", s)
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "y":
+		return period{years: n}, nil
+	case "mo":
+		return period{months: n}, nil
+	case "w":
+		return period{days: 7 * n}, nil
+	}
+	return period{days: n}, nil
+}
+
+// before returns the time the period before t.
+func (p period) before(t time.Time) time.Time {
+	return t.AddDate(-p.years, -p.months, -p.days)
+}
+
+// lastPublish returns when the latest version of the package was
+// published, given the "time" of its registry document.
+func lastPublish(times map[string]time.Time) (time.Time, bool) {
+	var last time.Time
+	for version, t := range times {
+		if version != "created" && version != "modified" && t.After(last) {
+			last = t
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// findPublishStatus queries the registry for when the kept dependencies,
+// mapped to their section, were last published, and flags the ones with
+// no publish since the cutoff as unmaintained. They are sorted by package.
+func (sc *scanContext) findPublishStatus(kept map[string]string, cutoff time.Time) []publishStatus {
+	var (
+		statuses []publishStatus
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, outdatedConcurrency)
+	for pkgName, section := range kept {
+		wg.Add(1)
+		go func(pkgName, section string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			times, err := sc.fetchPublishTimes(sc.registryName(pkgName))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Fprintf(sc.logOut, "Failed to fetch the publish dates of %q: %v\n", pkgName, err)
+				return
+			}
+			if last, ok := lastPublish(times); ok {
+				statuses = append(statuses, publishStatus{Package: pkgName, Section: section, LastPublish: last, Unmaintained: last.Before(cutoff)})
+			}
+		}(pkgName, section)
+	}
+	wg.Wait()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Package < statuses[j].Package })
+	return statuses
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2y":   time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+		"18mo": time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC),
+		"6w":   time.Date(2026, 2, 17, 12, 0, 0, 0, time.UTC),
+		"90d":  time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC),
+	}
+	for s, want := range tests {
+		p, err := parsePeriod(s)
+		if err != nil {
+			t.Errorf("parsePeriod(%q) failed: %v", s, err)
+			continue
+		}
+		if got := p.before(now); !got.Equal(want) {
+			t.Errorf("parsePeriod(%q).before() = %v, want %v", s, got, want)
+		}
+	}
+	for _, s := range []string{"", "2", "2h", "y", "-1y"} {
+		if _, err := parsePeriod(s); err == nil {
+			t.Errorf("parsePeriod(%q) accepted an invalid period", s)
+		}
+	}
+}
+
+func TestFindPublishStatus(t *testing.T) {
+	sc := newScanContext()
+	docs := map[string]string{
+		"express":  `{"time": {"created": "2010-12-29T19:38:25Z", "modified": "2026-01-10T00:00:00Z", "4.18.2": "2022-10-08T20:09:00Z", "5.0.1": "2025-10-08T12:00:00Z"}}`,
+		"left-pad": `{"time": {"created": "2014-03-14T00:00:00Z", "modified": "2026-01-10T00:00:00Z", "1.3.0": "2018-04-09T00:00:00Z"}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := docs[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, doc)
+	}))
+	defer server.Close()
+	defer func(url string) { sc.registryURL = url }(sc.registryURL)
+	sc.registryURL = server.URL
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+
+	kept := map[string]string{"express": "dependencies", "left-pad": "dependencies", "unpublished": "devDependencies"}
+	got := sc.findPublishStatus(kept, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	want := []publishStatus{
+		{Package: "express", Section: "dependencies", LastPublish: time.Date(2025, 10, 8, 12, 0, 0, 0, time.UTC)},
+		{Package: "left-pad", Section: "dependencies", LastPublish: time.Date(2018, 4, 9, 0, 0, 0, 0, time.UTC), Unmaintained: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findPublishStatus() = %+v, want %+v", got, want)
+	}
+	if s := got[1].String(); s != "left-pad 2018-04-09 (unmaintained)" {
+		t.Errorf("String() = %q", s)
+	}
+}
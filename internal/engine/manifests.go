@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// extraManifestDirs returns the directories of the manifests given with
+// --manifest, relative to the project root, with forward slashes. A
+// manifest is given either as its path, e.g. functions/package.json, or as
+// its directory. The relative paths were made absolute by absFlagPaths,
+// before depose entered the project root, which is root.
+func extraManifestDirs(root string, paths []string) ([]string, error) {
+	wd, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, p := range paths {
+		if p, err = filepath.Abs(p); err != nil {
+			return nil, err
+		}
+		if filepath.Base(p) == "package.json" {
+			p = filepath.Dir(p)
+		}
+		if _, err := os.Stat(filepath.Join(p, "package.json")); err != nil {
+			return nil, fmt.Errorf("no package.json in %s, given with --manifest", p)
+		}
+		dir, err := filepath.Rel(wd, p)
+		if err != nil || dir == "." || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("the manifest %s, given with --manifest, is not in a subdirectory of the project", p)
+		}
+		dirs = append(dirs, filepath.ToSlash(dir))
+	}
+	return dirs, nil
+}
+
+// scanExtraManifests scans the projects of the extra manifests, e.g. the
+// functions of Firebase, each against its own directory and with its own
+// config, and returns their findings, their suppressed findings and their
+// diagnostics, with the paths of the project root. A project which fails
+// to scan is reported as a diagnostic.
+func (sc *scanContext) scanExtraManifests(dirs []string) (findings, suppressed []Finding, diags []Diagnostic) {
+	projects := make([]*ProjectContext, 0, len(dirs))
+	for _, dir := range dirs {
+		data, err := sc.readProjectFile(path.Join(dir, "package.json"))
+		if err == nil {
+			var project *ProjectContext
+			if project, err = newProjectContext(dir, data); err == nil {
+				projects = append(projects, project)
+				continue
+			}
+		}
+		diags = append(diags, Diagnostic{Code: "error", File: dir + "/package.json", Message: err.Error()})
+	}
+	scanProjects(sc.ctx, sc.dir, projects, sc.projectScanArgs(), runtime.NumCPU())
+
+	for _, p := range projects {
+		fmt.Fprintf(sc.logOut, "Scanned %s/package.json\n", p.Dir)
+		if p.Err != nil {
+			diags = append(diags, Diagnostic{Code: "error", File: p.Dir + "/package.json", Message: p.Err.Error()})
+			continue
+		}
+		for _, f := range p.Report.Findings {
+			findings = append(findings, nestedFinding(p.Dir, f))
+		}
+		for _, f := range p.Report.Suppressed {
+			suppressed = append(suppressed, nestedFinding(p.Dir, f))
+		}
+		for _, diag := range p.Report.Diagnostics {
+			if diag.File != "" {
+				diag.File = path.Join(p.Dir, diag.File)
+			}
+			diags = append(diags, diag)
+		}
+	}
+	return findings, suppressed, diags
+}
+
+// nestedFinding returns the finding of the project in the directory, with
+// the paths of the project root, e.g. "functions/package.json" rather than
+// "package.json".
+func nestedFinding(dir string, f Finding) Finding {
+	locations := make([]Location, len(f.Locations))
+	for i, loc := range f.Locations {
+		loc.File = path.Join(dir, loc.File)
+		locations[i] = loc
+	}
+	f.Locations = locations
+	f.Message = strings.ReplaceAll(f.Message, " package.json", " "+dir+"/package.json")
+	return f
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtraManifestDirs(t *testing.T) {
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json":              `{"name": "app"}`,
+		"functions/package.json":    `{"name": "functions"}`,
+		"infra/lambda/package.json": `{"name": "lambda"}`,
+		"docs/README.md":            "# Docs",
+	})
+
+	dirs, err := extraManifestDirs(".", []string{"functions/package.json", "infra/lambda"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"functions", "infra/lambda"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("extraManifestDirs() = %q, want %q", dirs, want)
+	}
+	for _, path := range []string{"docs", "package.json", ".."} {
+		if _, err := extraManifestDirs(".", []string{path}); err == nil {
+			t.Errorf("extraManifestDirs(%q) accepted a manifest outside of a subdirectory", path)
+		}
+	}
+}
+
+func TestNestedFinding(t *testing.T) {
+	f := nestedFinding("functions", Finding{
+		RuleID:    RuleMissingDependency,
+		Severity:  SeverityError,
+		Package:   "axios",
+		Message:   `"axios" is used but not declared in package.json`,
+		Locations: []Location{{File: "src/index.js", Line: 3}},
+	})
+	want := Finding{
+		RuleID:    RuleMissingDependency,
+		Severity:  SeverityError,
+		Package:   "axios",
+		Message:   `"axios" is used but not declared in functions/package.json`,
+		Locations: []Location{{File: "functions/src/index.js", Line: 3}},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("nestedFinding() = %+v, want %+v", f, want)
+	}
+}
@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// developmentDirs are the directories holding the code which only runs
+// during development, e.g. the tests, at any depth.
+var developmentDirs = map[string]bool{
+	"test": true, "tests": true, "__tests__": true, "__mocks__": true, "spec": true,
+	"e2e": true, "cypress": true, "scripts": true, "tools": true, ".storybook": true,
+}
+
+// developmentFilePatterns match the base names of the files which only
+// run during development.
+var developmentFilePatterns = []string{
+	"*.test.*", "*.spec.*", "*.stories.*", "*.story.*", "setupTests.*",
+	"gulpfile.*", "Gruntfile.*", "Jakefile.*",
+}
+
+// isDevelopmentFile reports whether the file only runs during development,
+// e.g. a test or a config file, so it may import the devDependencies.
+func (sc *scanContext) isDevelopmentFile(file string) bool {
+	file = filepath.ToSlash(file)
+	if sc.isConfigFile(file) || matchesAnyPattern(path.Base(file), developmentFilePatterns) {
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if developmentDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// misplacedFindings reports the devDependencies imported by the production
+// code, which are missing when the project is installed without its
+// devDependencies. The production code is the files reachable from the
+// runtime entrypoints, runtime, or when there are none, the files which
+// aren't tests nor config files.
+func (sc *scanContext) misplacedFindings(runtime map[string]bool) []Finding {
+	declared := sc.declaredLines("package.json")["devDependencies"]
+	var findings []Finding
+	for dependency := range sc.manifest.DevDependencies {
+		if sc.sectionOf(dependency) != "devDependencies" {
+			continue
+		}
+		var production []Location
+		for _, l := range sc.d.usages[dependency] {
+			if !sc.isDevelopmentFile(l.File) && (runtime == nil || runtime[l.File]) {
+				production = append(production, l)
+			}
+		}
+		if len(production) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("%q is declared in devDependencies but imported by the production code", dependency)
+		if runtime != nil {
+			message = fmt.Sprintf("%q is declared in devDependencies but imported by the code reachable from the runtime entrypoints", dependency)
+		}
+		findings = append(findings, sc.newFinding(RuleMisplacedDependency, dependency, "devDependencies", message,
+			append([]Location{{File: "package.json", Line: declared[dependency]}}, production...),
+			fmt.Sprintf("move %q from devDependencies to dependencies, e.g. with --fix-sections", dependency)))
+	}
+	return findings
+}
+
+// runtimeFiles returns the files reachable from the runtime entrypoints of
+// the project: the entrypoints of package.json, the handlers of the
+// Serverless config and the --entry patterns, leaving out the tests and the
+// config files. It returns nil when the project has no such entrypoint, in
+// which case every file which isn't a development file counts as
+// production code.
+func (sc *scanContext) runtimeFiles(patterns []string, files []string) map[string]bool {
+	// The executables run by node are tools of the project, unless they
+	// are the bin of package.json.
+	manifestRoots := sc.manifestEntrypoints()
+	var roots []string
+	for _, root := range sc.entrypoints(patterns, files) {
+		if !sc.isDevelopmentFile(root) && (!sc.isNodeExecutable(root) || slices.Contains(manifestRoots, root)) {
+			if _, err := sc.statProjectFile(root); err == nil {
+				roots = append(roots, root)
+			}
+		}
+	}
+	if len(roots) == 0 {
+		return nil
+	}
+	reachable := sc.followImports(roots).files
+	for file := range reachable {
+		if sc.isDevelopmentFile(file) {
+			delete(reachable, file)
+		}
+	}
+	return reachable
+}
+
+// promotedDependencies returns the devDependencies of the misplaced
+// findings, moved to the dependencies with --fix-sections.
+func promotedDependencies(findings []Finding) []string {
+	var deps []string
+	for _, f := range findings {
+		if f.RuleID == RuleMisplacedDependency {
+			deps = append(deps, f.Package)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// promoteDependencies moves the dependencies from the devDependencies to
+// the dependencies of the content of package.json, keeping its formatting.
+// Every entry is inserted in alphabetical order, and the dependencies are
+// added before the devDependencies when the manifest has none.
+func promoteDependencies(data []byte, deps []string) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	declared := declaredLinesOf(data)
+	var entries, names []string
+	moved := make(map[int]bool)
+	for _, dep := range deps {
+		lineNo := declared["devDependencies"][dep]
+		if _, ok := declared["dependencies"][dep]; ok || lineNo == 0 {
+			continue
+		}
+		moved[lineNo] = true
+		entries = append(entries, strings.TrimSuffix(strings.TrimSpace(lines[lineNo-1]), ","))
+		names = append(names, dep)
+	}
+	if len(entries) == 0 {
+		return data
+	}
+	var kept []string
+	indent := "    "
+	for i, line := range lines {
+		if moved[i+1] {
+			indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			continue
+		}
+		kept = append(kept, line)
+	}
+	for i, entry := range entries {
+		kept = insertDependency(kept, names[i], indent+entry)
+	}
+	return []byte(strings.Join(kept, ""))
+}
+
+// insertDependency inserts the line of the dependency into the
+// dependencies of the lines of package.json, before the first one whose
+// name sorts after it.
+func insertDependency(lines []string, name, entry string) []string {
+	start, dev := -1, -1
+	for i, line := range lines {
+		if m := sectionLineRe.FindStringSubmatch(line); m != nil {
+			switch m[1] {
+			case "dependencies":
+				start = i
+			case "devDependencies":
+				dev = i
+			}
+		}
+	}
+	if start < 0 {
+		if dev < 0 {
+			return lines
+		}
+		header := lines[dev][:len(lines[dev])-len(strings.TrimLeft(lines[dev], " \t"))]
+		section := []string{header + "\"dependencies\": {\n", entry + "\n", header + "},\n"}
+		return slices.Insert(lines, dev, section...)
+	}
+	if body, rest, ok := strings.Cut(lines[start], "{"); ok && strings.HasPrefix(strings.TrimSpace(rest), "}") {
+		// An empty section on a single line, e.g. "dependencies": {},
+		header := body[:len(body)-len(strings.TrimLeft(body, " \t"))]
+		closing := header + strings.TrimSpace(rest) + "\n"
+		return slices.Replace(lines, start, start+1, body+"{\n", entry+"\n", closing)
+	}
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "}") {
+			if prev := strings.TrimRight(lines[i-1], "\r\n"); i-1 > start && !strings.HasSuffix(prev, ",") {
+				lines[i-1] = prev + "," + lines[i-1][len(prev):]
+			}
+			return slices.Insert(lines, i, entry+"\n")
+		}
+		if m := keyLineRe.FindStringSubmatch(lines[i]); m != nil && m[1] > name {
+			return slices.Insert(lines, i, entry+",\n")
+		}
+	}
+	return lines
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsDevelopmentFile(t *testing.T) {
+	sc := newScanContext()
+	tests := map[string]bool{
+		"src/index.js":                  false,
+		"lib/server/app.ts":             false,
+		"src/app.test.js":               true,
+		"src/Button.stories.tsx":        true,
+		"test/helpers.js":               true,
+		"src/__tests__/app.js":          true,
+		"scripts/release.js":            true,
+		"jest.config.js":                true,
+		"packages/ui/src/setupTests.ts": true,
+	}
+	for file, want := range tests {
+		if got := sc.isDevelopmentFile(file); got != want {
+			t.Errorf("isDevelopmentFile(%q) = %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestMisplacedFindings(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"dependencies\": {\n    \"express\": \"^4.18.2\"\n  },\n  \"devDependencies\": {\n    \"chalk\": \"^5.3.0\",\n    \"jest\": \"^29.7.0\"\n  }\n}\n",
+	})
+	sc.manifest = Package{Dependencies: map[string]string{"express": "^4.18.2"}, DevDependencies: map[string]string{"chalk": "^5.3.0", "jest": "^29.7.0"}}
+	sc.d.usages = map[string][]Location{
+		"express": {{File: "src/index.js", Line: 1}},
+		"chalk":   {{File: "src/index.js", Line: 2}, {File: "scripts/release.js", Line: 1}},
+		"jest":    {{File: "src/index.test.js", Line: 1}},
+	}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{} }()
+
+	var got []string
+	for _, f := range sc.misplacedFindings(nil) {
+		for _, l := range f.Locations {
+			got = append(got, f.RuleID+" "+f.Package+" "+l.String())
+		}
+	}
+	want := []string{
+		"misplaced-dependency chalk package.json:6",
+		"misplaced-dependency chalk src/index.js:2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("misplaced findings = %v, want %v", got, want)
+	}
+}
+
+func TestRuntimeMisplacedFindings(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json":      "{\n  \"main\": \"src/index.js\",\n  \"devDependencies\": {\n    \"chalk\": \"^5.3.0\",\n    \"zod\": \"^3.22.0\"\n  }\n}\n",
+		"src/index.js":      "import { log } from \"./log.js\"\n",
+		"src/log.js":        "import chalk from \"chalk\"\n",
+		"src/unused.js":     "import { z } from \"zod\"\n",
+		"src/index.test.js": "import \"./index.js\"\n",
+	})
+	sc.manifest = Package{Main: "src/index.js", DevDependencies: map[string]string{"chalk": "^5.3.0", "zod": "^3.22.0"}}
+	sc.d.usages = map[string][]Location{
+		"chalk": {{File: "src/log.js", Line: 1}},
+		"zod":   {{File: "src/unused.js", Line: 1}},
+	}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{} }()
+
+	runtime := sc.runtimeFiles(nil, []string{"package.json", "src/index.js", "src/log.js", "src/unused.js", "src/index.test.js"})
+	if want := map[string]bool{"src/index.js": true, "src/log.js": true}; !reflect.DeepEqual(runtime, want) {
+		t.Errorf("runtimeFiles() = %v, want %v", runtime, want)
+	}
+	// zod is only imported by a file no entrypoint reaches.
+	findings := sc.misplacedFindings(runtime)
+	if len(findings) != 1 || findings[0].Package != "chalk" {
+		t.Errorf("misplacedFindings() = %+v, want only chalk", findings)
+	}
+}
+
+func TestPromoteDependencies(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{
+			name: "sorted",
+			data: "{\n  \"dependencies\": {\n    \"express\": \"^4.18.2\",\n    \"lodash\": \"^4.17.21\"\n  },\n  \"devDependencies\": {\n    \"jest\": \"^29.7.0\",\n    \"zod\": \"^3.22.0\",\n    \"chalk\": \"^5.3.0\"\n  }\n}\n",
+			want: "{\n  \"dependencies\": {\n    \"chalk\": \"^5.3.0\",\n    \"express\": \"^4.18.2\",\n    \"lodash\": \"^4.17.21\",\n    \"zod\": \"^3.22.0\"\n  },\n  \"devDependencies\": {\n    \"jest\": \"^29.7.0\"\n  }\n}\n",
+		},
+		{
+			name: "no dependencies",
+			data: "{\n  \"name\": \"app\",\n  \"devDependencies\": {\n    \"zod\": \"^3.22.0\"\n  }\n}\n",
+			want: "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"zod\": \"^3.22.0\"\n  },\n  \"devDependencies\": {\n  }\n}\n",
+		},
+		{
+			name: "empty dependencies",
+			data: "{\n  \"dependencies\": {},\n  \"devDependencies\": {\n    \"jest\": \"^29.7.0\",\n    \"zod\": \"^3.22.0\"\n  }\n}\n",
+			want: "{\n  \"dependencies\": {\n    \"zod\": \"^3.22.0\"\n  },\n  \"devDependencies\": {\n    \"jest\": \"^29.7.0\"\n  }\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		got := string(removeTrailingCommas(promoteDependencies([]byte(tt.data), []string{"chalk", "zod"})))
+		if got != tt.want {
+			t.Errorf("%s: promoteDependencies() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
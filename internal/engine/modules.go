@@ -0,0 +1,67 @@
+package engine
+
+import "strings"
+
+// builtinModules maps the modules shipped with Node.js to the major
+// version of Node.js shipping them without a flag, 0 for the ones always
+// shipped. They can be required without being declared in package.json.
+var builtinModules = map[string]int{
+	"assert": 0, "async_hooks": 8, "buffer": 0, "child_process": 0,
+	"cluster": 0, "console": 0, "constants": 0, "crypto": 0,
+	"dgram": 0, "diagnostics_channel": 15, "dns": 0, "domain": 0,
+	"events": 0, "fs": 0, "http": 0, "http2": 10, "https": 0,
+	"inspector": 8, "module": 0, "net": 0, "os": 0, "path": 0,
+	"perf_hooks": 8, "process": 0, "punycode": 0, "querystring": 0,
+	"readline": 0, "repl": 0, "stream": 0, "string_decoder": 0,
+	"sys": 0, "timers": 0, "tls": 0, "trace_events": 10, "tty": 0,
+	"url": 0, "util": 0, "v8": 0, "vm": 0, "wasi": 13,
+	"worker_threads": 12, "zlib": 0,
+}
+
+// prefixedBuiltinModules are the modules of Node.js only available with
+// the "node:" prefix, e.g. "node:test", mapped to the major version
+// shipping them.
+var prefixedBuiltinModules = map[string]int{"test": 18, "sea": 20, "sqlite": 22}
+
+// isBuiltinModule reports whether the package name refers to a builtin
+// module of the runtime of the project, e.g. "fs", "node:fs" or
+// "fs/promises", shipped by the oldest version of Node.js it supports,
+// see readEngines. With Bun, its own modules are builtin too, e.g.
+// "bun:test" or "bun".
+func (sc *scanContext) isBuiltinModule(pkgName string) bool {
+	if sc.bunRuntime && (pkgName == "bun" || strings.HasPrefix(pkgName, "bun:")) {
+		return true
+	}
+	if name, ok := strings.CutPrefix(pkgName, "node:"); ok {
+		since, known := builtinModules[name]
+		if !known {
+			if since, known = prefixedBuiltinModules[name]; !known {
+				// Modules newer than this list.
+				return true
+			}
+		}
+		return sc.minNodeMajor == 0 || since <= sc.minNodeMajor
+	}
+	since, ok := builtinModules[pkgName]
+	return ok && (sc.minNodeMajor == 0 || since <= sc.minNodeMajor)
+}
+
+// isLocalSpecifier reports whether the specifier points to a file
+// of the project rather than to a package, e.g. "./utils" or "/src/app".
+func isLocalSpecifier(specifier string) bool {
+	return strings.HasPrefix(specifier, ".") || strings.HasPrefix(specifier, "/")
+}
+
+// packageName extracts the name of the package from an import specifier.
+//
+// Example:
+//
+//	packageName("lodash/fp")               == "lodash"
+//	packageName("@babel/core/lib/config") == "@babel/core"
+func packageName(specifier string) string {
+	parts := strings.Split(specifier, "/")
+	if strings.HasPrefix(specifier, "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
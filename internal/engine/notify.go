@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+)
+
+// notification is the data of the body posted to the webhook, available
+// to the templates, e.g. {{.Summary.Unused}} or {{json .Project}}.
+type notification struct {
+	Project string       `json:"project"`
+	Summary historyEntry `json:"summary"`
+	Report  *Report      `json:"report"`
+}
+
+// notificationFuncs are the functions available to the templates.
+var notificationFuncs = template.FuncMap{
+	// json encodes a value, e.g. a string into a quoted JSON string.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// notificationBody renders the body posted to the webhook: the
+// notification as JSON, or the template, e.g. the blocks of a Slack message.
+func notificationBody(n notification, templateFile string) ([]byte, error) {
+	if templateFile == "" {
+		return json.Marshal(n)
+	}
+	data, err := os.ReadFile(templateFile)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(templateFile).Funcs(notificationFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, n); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// notifyWebhook posts the body to the webhook.
+func notifyWebhook(url string, body []byte) error {
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// sendNotification notifies the webhook of the results of the run, when
+// it has at least minFindings findings. Failures are reported, but don't
+// fail the run.
+func (sc *scanContext) sendNotification(url, templateFile string, minFindings int, n notification) {
+	if n.Summary.Findings < minFindings {
+		return
+	}
+	body, err := notificationBody(n, templateFile)
+	if err == nil {
+		err = notifyWebhook(url, body)
+	}
+	if err != nil {
+		fmt.Fprintf(sc.logOut, "Failed to notify %s: %v\n", url, err)
+		return
+	}
+	fmt.Fprintf(sc.logOut, "Notified %s\n", url)
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSendNotification(t *testing.T) {
+	sc := newScanContext()
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+	}))
+	defer server.Close()
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+
+	tmpl := filepath.Join(t.TempDir(), "slack.tmpl")
+	if err := os.WriteFile(tmpl, []byte(`{"text": {{json (printf "%s: %d unused, %d missing" .Project .Summary.Unused .Summary.Missing)}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := notification{
+		Project: `api "v2"`,
+		Summary: historyEntry{Unused: 2, Missing: 1, Findings: 3},
+		Report:  &Report{},
+	}
+	sc.sendNotification(server.URL, tmpl, 1, n)
+	sc.sendNotification(server.URL, tmpl, 5, n)
+
+	want := `{"text": "api \"v2\": 2 unused, 1 missing"}`
+	if len(bodies) != 1 || bodies[0] != want {
+		t.Errorf("bodies = %q, want [%q]", bodies, want)
+	}
+}
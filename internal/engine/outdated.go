@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// outdatedDependency is a kept dependency whose declared range is behind
+// the latest version published to the registry.
+type outdatedDependency struct {
+	Package  string `json:"package"`
+	Section  string `json:"section"`
+	Declared string `json:"declared"`
+	Latest   string `json:"latest"`
+	// Behind is the most significant part of the version the declared
+	// range is behind by: "major", "minor" or "patch".
+	Behind string `json:"behind"`
+	// Count is how many versions of that part the range is behind, e.g. 2
+	// for "^2.1.0" when the latest version is 4.0.0.
+	Count int `json:"count"`
+	// InRange is true when the declared range already accepts the latest
+	// version, so only the lockfile has to be updated.
+	InRange bool `json:"inRange"`
+}
+
+func (o outdatedDependency) String() string {
+	s := fmt.Sprintf("%s %s -> %s (%d %s behind)", o.Package, o.Declared, o.Latest, o.Count, o.Behind)
+	if o.InRange {
+		s += ", within the declared range"
+	}
+	return s
+}
+
+// semver is a version made of its major, minor and patch numbers,
+// without its prerelease or build metadata.
+type semver [3]int
+
+// parseSemver parses a version, e.g. "1.2.3" or "v1.2", missing parts being 0.
+func parseSemver(version string) (semver, bool) {
+	var v semver
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i != -1 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// less reports whether the version is lower than the other one.
+func (v semver) less(other semver) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+// rangeBase returns the lowest version accepted by a range of package.json,
+// e.g. "1.2.0" for "^1.2.0", and the operator of that range. Ranges which
+// are not based on a version, e.g. git URLs, "*" or "workspace:", are not.
+func rangeBase(declared string) (semver, string, bool) {
+	r := strings.TrimSpace(declared)
+	if r == "" || strings.Contains(r, ":") || strings.Contains(r, "/") || r == "*" || r == "latest" {
+		return semver{}, "", false
+	}
+	// For unions, the first alternative is the declared baseline.
+	r, _, _ = strings.Cut(r, "||")
+	r = strings.TrimSpace(r)
+	// For hyphen ranges and intersections, the lower bound is the baseline.
+	r, _, _ = strings.Cut(r, " ")
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", "^", "~", ">", "<", "="} {
+		if strings.HasPrefix(r, prefix) {
+			op, r = prefix, strings.TrimPrefix(r, prefix)
+			break
+		}
+	}
+	if op == "<" || op == "<=" {
+		return semver{}, "", false
+	}
+	v, ok := parseSemver(r)
+	return v, op, ok
+}
+
+// accepts reports whether a range with the given base and operator
+// accepts the version, following the rules of npm for "^" and "~".
+func accepts(base semver, op string, v semver) bool {
+	if v.less(base) {
+		return false
+	}
+	switch op {
+	case ">", ">=":
+		return true
+	case "^":
+		switch {
+		case base[0] > 0:
+			return v[0] == base[0]
+		case base[1] > 0:
+			return v[0] == 0 && v[1] == base[1]
+		}
+		return v == base
+	case "~":
+		return v[0] == base[0] && v[1] == base[1]
+	}
+	return v == base
+}
+
+// compareOutdated compares the declared range of a dependency to the latest
+// version, and reports whether the range is behind it.
+func compareOutdated(pkgName, section, declared, latest string) (outdatedDependency, bool) {
+	base, op, ok := rangeBase(declared)
+	if !ok {
+		return outdatedDependency{}, false
+	}
+	v, ok := parseSemver(latest)
+	if !ok {
+		return outdatedDependency{}, false
+	}
+
+	o := outdatedDependency{Package: pkgName, Section: section, Declared: declared, Latest: latest}
+	for i, part := range []string{"major", "minor", "patch"} {
+		if v[i] > base[i] {
+			o.Behind, o.Count = part, v[i]-base[i]
+			break
+		}
+		if v[i] < base[i] {
+			return outdatedDependency{}, false
+		}
+	}
+	if o.Behind == "" {
+		return outdatedDependency{}, false
+	}
+	o.InRange = accepts(base, op, v)
+	return o, true
+}
+
+// outdatedConcurrency is the number of registry requests made at once.
+const outdatedConcurrency = 8
+
+// findOutdated queries the registry for the latest version of the kept
+// dependencies, mapped to their section, and returns the ones whose
+// declared range is behind it, sorted by package.
+func (sc *scanContext) findOutdated(kept map[string]string, ranges map[string]string) []outdatedDependency {
+	var (
+		outdated []outdatedDependency
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, outdatedConcurrency)
+	for pkgName, section := range kept {
+		wg.Add(1)
+		go func(pkgName, section string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			latest, err := sc.fetchLatest(sc.registryName(pkgName))
+			if err != nil {
+				mu.Lock()
+				fmt.Fprintf(sc.logOut, "Failed to fetch the latest version of %q: %v\n", pkgName, err)
+				mu.Unlock()
+				return
+			}
+			if o, ok := compareOutdated(pkgName, section, aliasSpec(ranges[pkgName]), latest.Version); ok {
+				mu.Lock()
+				outdated = append(outdated, o)
+				mu.Unlock()
+			}
+		}(pkgName, section)
+	}
+	wg.Wait()
+
+	sort.Slice(outdated, func(i, j int) bool { return outdated[i].Package < outdated[j].Package })
+	return outdated
+}
+
+// keptDependencies returns the dependencies of package.json which are not
+// reported as unused, mapped to their section, and the declared ranges.
+func (sc *scanContext) keptDependencies(findings []Finding) (kept map[string]string, ranges map[string]string) {
+	unused := make(map[string]bool)
+	for _, f := range findings {
+		if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+			unused[f.Package] = true
+		}
+	}
+	kept, ranges = make(map[string]string), make(map[string]string)
+	for section, deps := range map[string]map[string]string{
+		"dependencies":    sc.manifest.Dependencies,
+		"devDependencies": sc.manifest.DevDependencies,
+	} {
+		for dependency, declared := range deps {
+			if !unused[dependency] {
+				kept[dependency], ranges[dependency] = section, declared
+			}
+		}
+	}
+	return kept, ranges
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareOutdated(t *testing.T) {
+	tests := []struct {
+		declared, latest string
+		want             outdatedDependency
+		ok               bool
+	}{
+		{"^2.1.0", "4.0.0", outdatedDependency{Behind: "major", Count: 2}, true},
+		{"^4.17.1", "4.18.2", outdatedDependency{Behind: "minor", Count: 1, InRange: true}, true},
+		{"~1.2.3", "1.2.9", outdatedDependency{Behind: "patch", Count: 6, InRange: true}, true},
+		{"~1.2.3", "1.3.0", outdatedDependency{Behind: "minor", Count: 1}, true},
+		{"^0.2.0", "0.3.1", outdatedDependency{Behind: "minor", Count: 1}, true},
+		{">=1.0.0", "3.0.0", outdatedDependency{Behind: "major", Count: 2, InRange: true}, true},
+		{"1.x || 2.x", "2.5.0", outdatedDependency{Behind: "major", Count: 1}, true},
+		{"^3.0.0", "3.0.0", outdatedDependency{}, false},
+		{"^3.0.0-beta.1", "2.9.0", outdatedDependency{}, false},
+		{"*", "1.0.0", outdatedDependency{}, false},
+		{"github:user/repo", "1.0.0", outdatedDependency{}, false},
+		{"workspace:^", "1.0.0", outdatedDependency{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareOutdated("pkg", "dependencies", tt.declared, tt.latest)
+		if ok != tt.ok {
+			t.Errorf("compareOutdated(%q, %q) ok = %v, want %v", tt.declared, tt.latest, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		tt.want.Package, tt.want.Section, tt.want.Declared, tt.want.Latest = "pkg", "dependencies", tt.declared, tt.latest
+		if got != tt.want {
+			t.Errorf("compareOutdated(%q, %q) = %+v, want %+v", tt.declared, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestFindOutdated(t *testing.T) {
+	sc := newScanContext()
+	latest := map[string]string{"express": "5.0.1", "@scope/util": "1.4.0", "jest": "29.7.0"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version, ok := latest[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/latest")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"version": %q}`, version)
+	}))
+	defer server.Close()
+	defer func(url string) { sc.registryURL = url }(sc.registryURL)
+	sc.registryURL = server.URL
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+
+	sc.manifest = Package{
+		Dependencies:    map[string]string{"express": "^4.18.2", "@scope/util": "^1.2.0", "lodash": "^4.17.21", "unpublished": "^1.0.0"},
+		DevDependencies: map[string]string{"jest": "^29.7.0"},
+	}
+	defer func() { sc.manifest = Package{} }()
+
+	kept, ranges := sc.keptDependencies([]Finding{{RuleID: RuleUnusedDependency, Package: "lodash"}})
+	got := sc.findOutdated(kept, ranges)
+	want := []outdatedDependency{
+		{Package: "@scope/util", Section: "dependencies", Declared: "^1.2.0", Latest: "1.4.0", Behind: "minor", Count: 2, InRange: true},
+		{Package: "express", Section: "dependencies", Declared: "^4.18.2", Latest: "5.0.1", Behind: "major", Count: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findOutdated() = %+v, want %+v", got, want)
+	}
+}
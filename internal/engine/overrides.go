@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// overrideEntry is an entry of the "overrides" of npm and pnpm, or of the
+// "resolutions" of yarn, in package.json.
+type overrideEntry struct {
+	// Field is the field holding the entry, "overrides" or "resolutions".
+	Field string
+	// Key is the key of the entry, e.g. "lodash@4", "**/lodash" or
+	// "parent/child", and Package the package it overrides or starts
+	// from, e.g. "lodash" or "parent".
+	Key     string
+	Package string
+	// Reference is the dependency whose version the entry reuses, e.g.
+	// "react" for "$react", if any.
+	Reference string
+	// Line and EndLine are the first and last lines of the entry, which
+	// differ for the nested overrides of npm.
+	Line, EndLine int
+}
+
+// overridesStartRe matches the first line of the overrides or the
+// resolutions, which may be nested, e.g. under "pnpm".
+var overridesStartRe = regexp.MustCompile(`^\s*"(overrides|resolutions)"\s*:\s*\{`)
+
+// overrideValueRe matches the value of an entry on the line of its key.
+var overrideValueRe = regexp.MustCompile(`^\s*"[^"]+"\s*:\s*"([^"]*)"`)
+
+// overrideEntries returns the top-level entries of the overrides and of
+// the resolutions of the content of package.json.
+func overrideEntries(data []byte) []overrideEntry {
+	var (
+		entries []overrideEntry
+		field   string
+		depth   int
+		current *overrideEntry
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if field == "" {
+			if m := overridesStartRe.FindStringSubmatch(line); m != nil {
+				field, depth = m[1], braceDepth(line)
+			}
+			continue
+		}
+		if depth == 1 {
+			if m := keyLineRe.FindStringSubmatch(line); m != nil {
+				entries = append(entries, overrideEntry{Field: field, Key: m[1], Package: overriddenPackage(m[1]), Line: lineNo})
+				current = &entries[len(entries)-1]
+				if v := overrideValueRe.FindStringSubmatch(line); v != nil && strings.HasPrefix(v[1], "$") {
+					current.Reference = v[1][1:]
+				}
+			}
+		}
+		depth += braceDepth(line)
+		if current != nil {
+			current.EndLine = lineNo
+			if depth == 1 {
+				current = nil
+			}
+		}
+		if depth <= 0 {
+			field, current = "", nil
+		}
+	}
+	return entries
+}
+
+// braceDepth returns how many more braces the line opens than it closes,
+// outside of its strings.
+func braceDepth(line string) int {
+	depth, inString := 0, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && inString:
+			i++
+		case c == '"':
+			inString = !inString
+		case c == '{' && !inString:
+			depth++
+		case c == '}' && !inString:
+			depth--
+		}
+	}
+	return depth
+}
+
+// overriddenPackage returns the package of the key of an override, e.g.
+// "lodash" for "lodash@^4", "**/lodash" or "lodash/**/minimist", and
+// "@babel/core" for "@babel/core@7".
+func overriddenPackage(key string) string {
+	key = strings.TrimPrefix(key, "**/")
+	name := packageName(key)
+	if at := strings.LastIndex(name, "@"); at > 0 {
+		name = name[:at]
+	}
+	return name
+}
+
+// staleOverrideFindings returns the findings about the entries of the
+// overrides and the resolutions of package.json about the unused
+// dependencies, which are removed, or reusing their version, e.g.
+// "$react", which npm fails to install once they are removed.
+func (sc *scanContext) staleOverrideFindings(findings []Finding) []Finding {
+	removed := make(map[string]bool)
+	for _, f := range findings {
+		if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+			removed[f.Package] = true
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	data, err := sc.readProjectFile("package.json")
+	if err != nil {
+		return nil
+	}
+
+	var stale []Finding
+	for _, e := range overrideEntries(data) {
+		pkgName := e.Package
+		if !removed[pkgName] {
+			if pkgName = e.Reference; !removed[pkgName] {
+				continue
+			}
+		}
+		message := fmt.Sprintf("%s entry %q refers to %q, which is unused", e.Field, e.Key, pkgName)
+		if pkgName == e.Reference {
+			message = fmt.Sprintf("%s entry %q reuses the version of %q, which is unused", e.Field, e.Key, pkgName)
+		}
+		stale = append(stale, sc.newFinding(RuleStaleOverride, pkgName, e.Field, message,
+			[]Location{{File: "package.json", Line: e.Line}},
+			fmt.Sprintf("remove %q from %s, e.g. with --fix-overrides", e.Key, e.Field)))
+	}
+	return stale
+}
+
+// staleOverrides returns the entries of package.json reported as stale
+// overrides.
+func (sc *scanContext) staleOverrides(findings []Finding) []overrideEntry {
+	stale := make(map[int]bool)
+	for _, f := range findings {
+		if f.RuleID == RuleStaleOverride {
+			stale[f.Locations[0].Line] = true
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	data, err := sc.readProjectFile("package.json")
+	if err != nil {
+		return nil
+	}
+	var entries []overrideEntry
+	for _, e := range overrideEntries(data) {
+		if stale[e.Line] {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// staleOverrideLines returns the lines of package.json holding the
+// entries reported as stale overrides.
+func (sc *scanContext) staleOverrideLines(findings []Finding) map[int]bool {
+	lines := make(map[int]bool)
+	for _, e := range sc.staleOverrides(findings) {
+		for l := e.Line; l <= e.EndLine; l++ {
+			lines[l] = true
+		}
+	}
+	return lines
+}
+
+// staleOverrideKeys describes the entries reported as stale overrides,
+// e.g. `overrides "request"`.
+func (sc *scanContext) staleOverrideKeys(findings []Finding) []string {
+	var keys []string
+	for _, e := range sc.staleOverrides(findings) {
+		keys = append(keys, fmt.Sprintf("%s %q", e.Field, e.Key))
+	}
+	return keys
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// overridesManifest is a package.json overriding the versions of its
+// dependencies in every supported form.
+const overridesManifest = `{
+  "dependencies": {
+    "react": "^18.2.0",
+    "left-pad": "^1.3.0"
+  },
+  "overrides": {
+    "left-pad@1": "1.3.0",
+    "react-dom": "$react",
+    "request": {
+      "tough-cookie": "4.1.3"
+    },
+    "semver": "7.5.4"
+  },
+  "resolutions": {
+    "**/left-pad": "1.3.0"
+  }
+}
+`
+
+func TestOverrideEntries(t *testing.T) {
+	want := []overrideEntry{
+		{Field: "overrides", Key: "left-pad@1", Package: "left-pad", Line: 7, EndLine: 7},
+		{Field: "overrides", Key: "react-dom", Package: "react-dom", Reference: "react", Line: 8, EndLine: 8},
+		{Field: "overrides", Key: "request", Package: "request", Line: 9, EndLine: 11},
+		{Field: "overrides", Key: "semver", Package: "semver", Line: 12, EndLine: 12},
+		{Field: "resolutions", Key: "**/left-pad", Package: "left-pad", Line: 15, EndLine: 15},
+	}
+	if got := overrideEntries([]byte(overridesManifest)); !reflect.DeepEqual(got, want) {
+		t.Errorf("overrideEntries() = %+v, want %+v", got, want)
+	}
+}
+
+func TestOverriddenPackage(t *testing.T) {
+	for key, want := range map[string]string{
+		"lodash": "lodash", "lodash@^4": "lodash", "**/lodash": "lodash", "lodash/**/minimist": "lodash",
+		"@babel/core@7": "@babel/core", "@babel/core": "@babel/core",
+	} {
+		if got := overriddenPackage(key); got != want {
+			t.Errorf("overriddenPackage(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestStaleOverrideFindings(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{"package.json": overridesManifest})
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "left-pad"},
+		{RuleID: RuleUnusedDependency, Package: "request"},
+		{RuleID: RuleUnusedDependency, Package: "react"},
+	}
+	var got []string
+	for _, f := range sc.staleOverrideFindings(findings) {
+		got = append(got, f.Package+" "+f.Locations[0].String()+" "+f.Message)
+	}
+	want := []string{
+		`left-pad package.json:7 overrides entry "left-pad@1" refers to "left-pad", which is unused`,
+		`react package.json:8 overrides entry "react-dom" reuses the version of "react", which is unused`,
+		`request package.json:9 overrides entry "request" refers to "request", which is unused`,
+		`left-pad package.json:15 resolutions entry "**/left-pad" refers to "left-pad", which is unused`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("staleOverrideFindings() = %q, want %q", got, want)
+	}
+}
+
+func TestFixOverrides(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"dependencies\": {\n    \"react\": \"^18.2.0\",\n    \"request\": \"^2.88.2\"\n  },\n  \"overrides\": {\n    \"request\": {\n      \"tough-cookie\": \"4.1.3\"\n    },\n    \"semver\": \"7.5.4\"\n  }\n}\n",
+		"index.js":     "const React = require('react');\n",
+	})
+	out, err := exec.Command(deposePath, "--fix-overrides", "--no-history").CombinedOutput()
+	if err != nil {
+		t.Fatalf("depose --fix-overrides: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), `[stale-override] overrides entry "request" refers to "request", which is unused`) {
+		t.Errorf("stale override was not reported:\n%s", out)
+	}
+	data, err := os.ReadFile("package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"dependencies\": {\n    \"react\": \"^18.2.0\"\n  },\n  \"overrides\": {\n    \"semver\": \"7.5.4\"\n  }\n}\n"; string(data) != want {
+		t.Errorf("package.json = %q, want %q", data, want)
+	}
+}
+
+func TestCreateNewPackageJsonKeepsOverrides(t *testing.T) {
+	got := string(removeTrailingCommas(createNewPackageJson([]string{"request", "left-pad"}, []byte(overridesManifest))))
+	want := strings.Replace(overridesManifest, "    \"left-pad\": \"^1.3.0\"\n", "", 1)
+	want = strings.Replace(want, "\"react\": \"^18.2.0\",\n", "\"react\": \"^18.2.0\"\n", 1)
+	if got != want {
+		t.Errorf("createNewPackageJson() = %q, want %q", got, want)
+	}
+}
+
+func TestFixMessageOverrides(t *testing.T) {
+	_, body := fixMessage([]string{"request"}, []string{`overrides "request"`})
+	want := "depose found these dependencies unused, and removed them:\n\n- request\n\nalong with their overrides, which became dead configuration:\n\n- overrides \"request\"\n"
+	if body != want {
+		t.Errorf("fixMessage() body = %q, want %q", body, want)
+	}
+}
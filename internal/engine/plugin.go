@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pluginPrefix is the prefix of the name of the executables on PATH
+// which are run as plugins.
+//
+// Plugins let teams detect the packages used by their own frameworks or
+// file formats, or report their own findings, without forking depose.
+const pluginPrefix = "depose-plugin-"
+
+// pluginRequest is written as JSON to the standard input of every plugin.
+type pluginRequest struct {
+	// Language is the language of the scanned project, e.g. "node".
+	Language string `json:"language"`
+	// Files are the files of the project which are not excluded.
+	Files []pluginFile `json:"files"`
+}
+
+// pluginFile is a file of the project, with its path relative to the project root.
+type pluginFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// pluginResponse is read as JSON from the standard output of every plugin.
+type pluginResponse struct {
+	// Specifiers are the packages the plugin found, which are
+	// handled like the ones found by the extractors of depose.
+	Specifiers []pluginSpecifier `json:"specifiers"`
+	// Rules declares the custom rules of the findings of the plugin.
+	Rules []Rule `json:"rules"`
+	// Findings are reported along with the findings of depose.
+	Findings []Finding `json:"findings"`
+}
+
+// pluginSpecifier is a package found by a plugin in a file of the project.
+type pluginSpecifier struct {
+	File string `json:"file"`
+	Path string `json:"path"`
+	Line int    `json:"line,omitempty"`
+}
+
+// findPlugins returns the paths of the plugins found on PATH, sorted by name.
+// When several directories provide a plugin with the same name,
+// the first one wins, like for any other command.
+func findPlugins() []string {
+	found := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, pluginPrefix) || entry.IsDir() {
+				continue
+			}
+			if _, ok := found[name]; ok {
+				continue
+			}
+			if path, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+				found[name] = path
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	plugins := make([]string, 0, len(names))
+	for _, name := range names {
+		plugins = append(plugins, found[name])
+	}
+	return plugins
+}
+
+// runPlugins runs every plugin on the files, marks the packages they found
+// as found, and returns their findings.
+//
+// A failing plugin is reported as a warning, without failing the scan.
+func (sc *scanContext) runPlugins(plugins []string, files []string) []Finding {
+	if len(plugins) == 0 {
+		return nil
+	}
+	req := pluginRequest{Language: sc.lang.name, Files: []pluginFile{}}
+	for _, file := range files {
+		data, err := sc.readProjectFile(file)
+		if err != nil {
+			fmt.Fprintf(sc.logOut, "Warning: %v\n", err)
+			continue
+		}
+		req.Files = append(req.Files, pluginFile{Path: filepath.ToSlash(file), Content: string(data)})
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		fmt.Fprintf(sc.logOut, "Warning: %v\n", err)
+		return nil
+	}
+
+	var findings []Finding
+	for _, plugin := range plugins {
+		fmt.Fprintf(sc.logOut, "Running plugin: %s\n", filepath.Base(plugin))
+		resp, err := sc.runPlugin(plugin, input)
+		if err != nil {
+			fmt.Fprintf(sc.logOut, "Warning: plugin %s failed: %v\n", filepath.Base(plugin), err)
+			continue
+		}
+		findings = append(findings, sc.applyPluginResponse(filepath.Base(plugin), resp)...)
+	}
+	return findings
+}
+
+// runPlugin runs the plugin in the project root with the request as its
+// input, and decodes its response.
+func (sc *scanContext) runPlugin(plugin string, input []byte) (pluginResponse, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(sc.ctx, plugin)
+	cmd.Dir = sc.dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	var resp pluginResponse
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return resp, fmt.Errorf("%v: %s", err, msg)
+		}
+		return resp, err
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return resp, fmt.Errorf("invalid response: %v", err)
+	}
+	return resp, nil
+}
+
+// applyPluginResponse registers the rules of the plugin, marks the packages it
+// found as found, and returns its valid findings.
+//
+// Findings without a rule ID are skipped. Findings without a severity
+// get the default severity of their rule.
+func (sc *scanContext) applyPluginResponse(plugin string, resp pluginResponse) []Finding {
+	for _, rule := range resp.Rules {
+		if _, ok := sc.ruleByID(rule.ID); ok || rule.ID == "" {
+			continue
+		}
+		if rule.DefaultSeverity == "" {
+			rule.DefaultSeverity = SeverityWarning
+		}
+		sc.rules = append(sc.rules, rule)
+	}
+
+	for _, s := range resp.Specifiers {
+		sc.markModuleAsFound(s.Path, Location{File: s.File, Line: s.Line})
+	}
+
+	var findings []Finding
+	for _, f := range resp.Findings {
+		if f.RuleID == "" {
+			fmt.Fprintf(sc.logOut, "Warning: plugin %s reported a finding without rule ID\n", plugin)
+			continue
+		}
+		if f.Severity == "" {
+			f.Severity = SeverityWarning
+			if rule, ok := sc.ruleByID(f.RuleID); ok {
+				f.Severity = rule.DefaultSeverity
+			}
+		}
+		f.Suppression = ""
+		findings = append(findings, f)
+	}
+	return findings
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// writePlugin writes an executable plugin script to the directory, which saves
+// its request next to itself and answers with the response.
+func writePlugin(t *testing.T, dir, name, response string) string {
+	t.Helper()
+	script := "#!/bin/sh\ncat > \"$0.request.json\"\ncat <<'EOF'\n" + response + "\nEOF\n"
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts are shell scripts")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	b := writePlugin(t, first, "depose-plugin-b", "{}")
+	a := writePlugin(t, second, "depose-plugin-a", "{}")
+	writePlugin(t, second, "depose-plugin-b", "{}")
+	writePlugin(t, second, "other-tool", "{}")
+	if err := os.WriteFile(filepath.Join(second, "depose-plugin-not-executable"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+
+	if got, want := findPlugins(), []string{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findPlugins() = %v, want %v", got, want)
+	}
+}
+
+func TestRunPlugins(t *testing.T) {
+	sc := newScanContext()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts are shell scripts")
+	}
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.WriteFile("app.config", []byte("framework: acme-ui\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plugin := writePlugin(t, t.TempDir(), "depose-plugin-acme", `{
+  "specifiers": [{"file": "app.config", "path": "acme-ui/button", "line": 1}],
+  "rules": [{"id": "acme-legacy-config", "description": "Legacy ACME config"}],
+  "findings": [
+    {"ruleId": "acme-legacy-config", "package": "acme-ui", "message": "legacy config",
+     "locations": [{"file": "app.config", "line": 1}]},
+    {"message": "no rule"}
+  ]
+}`)
+	failing := writePlugin(t, t.TempDir(), "depose-plugin-broken", "not json")
+
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"acme-ui": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func(original []Rule) { sc.lang, sc.d.mp, sc.d.usages, sc.rules = nil, nil, nil, original }(sc.rules)
+
+	findings := sc.runPlugins([]string{failing, plugin}, []string{"app.config"})
+
+	var req pluginRequest
+	data, err := os.ReadFile(plugin + ".request.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	wantReq := pluginRequest{Language: "node", Files: []pluginFile{{Path: "app.config", Content: "framework: acme-ui\n"}}}
+	if !reflect.DeepEqual(req, wantReq) {
+		t.Errorf("request = %+v, want %+v", req, wantReq)
+	}
+
+	if !sc.d.mp["acme-ui"] {
+		t.Errorf("acme-ui was not marked as used")
+	}
+	if rule, ok := sc.ruleByID("acme-legacy-config"); !ok || rule.DefaultSeverity != SeverityWarning {
+		t.Errorf("ruleByID(acme-legacy-config) = %v, %v", rule, ok)
+	}
+	want := []Finding{{
+		RuleID:    "acme-legacy-config",
+		Severity:  SeverityWarning,
+		Package:   "acme-ui",
+		Message:   "legacy config",
+		Locations: []Location{{File: "app.config", Line: 1}},
+	}}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("runPlugins() = %+v, want %+v", findings, want)
+	}
+}
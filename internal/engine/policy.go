@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// Policy lists the dependencies an organization bans or requires in its
+// projects. It is enforced with --check.
+type Policy struct {
+	// Banned lists the packages which must not be declared at all, used or
+	// not. Patterns are matched with path.Match, e.g. "@deprecated/*".
+	Banned []string `json:"banned"`
+	// Required lists the packages which must be declared.
+	Required []string `json:"required"`
+}
+
+// policyFindings reports the declared dependencies banned by the policy,
+// and the dependencies it requires which are not declared.
+func (sc *scanContext) policyFindings(policy Policy) []Finding {
+	declared := sc.lang.declaredLines()
+	var findings []Finding
+	for dependency := range sc.d.mp {
+		for _, pattern := range policy.Banned {
+			if ok, _ := path.Match(pattern, dependency); !ok {
+				continue
+			}
+			section := sc.lang.sectionOf(dependency)
+			findings = append(findings, sc.newFinding(RuleBannedDependency, dependency, section,
+				fmt.Sprintf("%q is declared in %s but banned by the policy", dependency, section),
+				[]Location{{File: sc.manifestFile, Line: declared[section][dependency]}},
+				sc.lang.removeFix(dependency, section)))
+			break
+		}
+	}
+	for _, pkgName := range policy.Required {
+		if _, ok := sc.d.mp[pkgName]; ok {
+			continue
+		}
+		findings = append(findings, sc.newFinding(RuleRequiredDependency, pkgName, "",
+			fmt.Sprintf("%q is required by the policy but not declared in %s", pkgName, sc.manifestFile),
+			[]Location{{File: sc.manifestFile}}, sc.lang.addFix(pkgName)))
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Package < findings[j].Package })
+	return findings
+}
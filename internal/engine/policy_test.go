@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolicyFindings(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{
+  "dependencies": {
+    "moment": "^2.29.4",
+    "@deprecated/ui": "^1.0.0",
+    "express": "^4.18.2"
+  }
+}`,
+	})
+	sc.lang = sc.nodeLanguage
+	sc.manifestFile = "package.json"
+	sc.loadManifest()
+	sc.d.mp = map[string]bool{"moment": true, "@deprecated/ui": false, "express": true}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.manifestFile = "" }()
+
+	findings := sc.policyFindings(Policy{
+		Banned:   []string{"moment", "@deprecated/*", "request"},
+		Required: []string{"express", "helmet"},
+	})
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.RuleID+" "+f.Package+" "+f.Locations[0].String())
+	}
+	want := []string{
+		"banned-dependency @deprecated/ui package.json:4",
+		"required-dependency helmet package.json",
+		"banned-dependency moment package.json:3",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("policyFindings() = %v, want %v", got, want)
+	}
+}
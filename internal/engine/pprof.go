@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Files of the profiles written with --pprof-dir, which are read by
+// "go tool pprof".
+const (
+	cpuProfileFile  = "cpu.pprof"
+	heapProfileFile = "heap.pprof"
+)
+
+// startProfiling starts the CPU profile of the run in the directory, which
+// is created if needed. stopProfiling stops it, and writes the heap
+// profile of the end of the run next to it.
+func (sc *scanContext) startProfiling(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	cpu, err := os.Create(filepath.Join(dir, cpuProfileFile))
+	if err != nil {
+		return err
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return err
+	}
+	sc.stopProfiling = func() {
+		sc.stopProfiling = func() {}
+		pprof.StopCPUProfile()
+		cpu.Close()
+		if err := writeHeapProfile(filepath.Join(dir, heapProfileFile)); err != nil {
+			fmt.Fprintf(sc.logOut, "Failed to write the heap profile: %v\n", err)
+			return
+		}
+		fmt.Fprintf(sc.logOut, "Wrote the CPU and heap profiles of the run to %s\n", dir)
+	}
+	return nil
+}
+
+// writeHeapProfile writes the profile of the live objects of the heap to
+// the path, after a garbage collection.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,26 @@
+package engine
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartProfiling(t *testing.T) {
+	sc := newScanContext()
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	dir := filepath.Join(t.TempDir(), "profiles")
+
+	if err := sc.startProfiling(dir); err != nil {
+		t.Fatal(err)
+	}
+	sc.stopProfiling()
+	sc.stopProfiling() // stopping twice is a no-op
+	for _, name := range []string{cpuProfileFile, heapProfileFile} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() == 0 {
+			t.Errorf("profile %s wasn't written: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// prCommentMarker identifies the comment of depose among the comments of
+// a pull request, so it is updated instead of posting a new one.
+const prCommentMarker = "<!-- depose-pr-comment -->"
+
+// gitlabAPIURL is the URL of the API of GitLab. CI_API_V4_URL, set by
+// GitLab CI, overrides it.
+var gitlabAPIURL = "https://gitlab.com/api/v4"
+
+// pullRequest identifies a pull request of GitHub, or a merge request of
+// GitLab.
+type pullRequest struct {
+	platform string // "github" or "gitlab"
+	repo     string // e.g. "owner/repo" or "group/project"
+	number   string
+}
+
+// pullRequestRe matches the identifiers of pull requests given to --pr,
+// e.g. "owner/repo#12" on GitHub or "group/project!12" on GitLab.
+var pullRequestRe = regexp.MustCompile(`^([^#!:\s]+/[^#!:\s]+)([#!])(\d+)$`)
+
+// githubPullRefRe matches GITHUB_REF in the workflows triggered by pull
+// requests, e.g. refs/pull/123/merge.
+var githubPullRefRe = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// parsePullRequest parses the identifier of a pull request. When it is
+// empty, the pull request is read from the environment of GitHub Actions
+// or GitLab CI.
+func parsePullRequest(id string) (pullRequest, error) {
+	if id != "" {
+		m := pullRequestRe.FindStringSubmatch(id)
+		if m == nil {
+			return pullRequest{}, fmt.Errorf("invalid pull request %q, expected owner/repo#123 or group/project!123", id)
+		}
+		if m[2] == "!" {
+			return pullRequest{"gitlab", m[1], m[3]}, nil
+		}
+		return pullRequest{"github", m[1], m[3]}, nil
+	}
+	if iid := os.Getenv("CI_MERGE_REQUEST_IID"); iid != "" {
+		return pullRequest{"gitlab", os.Getenv("CI_PROJECT_PATH"), iid}, nil
+	}
+	if m := githubPullRefRe.FindStringSubmatch(os.Getenv("GITHUB_REF")); m != nil {
+		return pullRequest{"github", os.Getenv("GITHUB_REPOSITORY"), m[1]}, nil
+	}
+	return pullRequest{}, fmt.Errorf("no pull request given with --pr, nor found in the environment of the CI")
+}
+
+// reportPRComment posts the findings as a comment of the pull request given
+// with --pr, replacing the comment of the previous runs. The comment is
+// written to w too. Without findings, no comment is posted, but the
+// previous one is updated if any, so it doesn't go stale.
+func (sc *scanContext) reportPRComment(w io.Writer, r *Report) error {
+	pr, err := parsePullRequest(sc.prID)
+	if err != nil {
+		return err
+	}
+	body := sc.prCommentBody(r)
+	if _, err := io.WriteString(w, body); err != nil {
+		return err
+	}
+
+	switch pr.platform {
+	case "github":
+		return upsertGitHubComment(pr, body, len(r.Findings) > 0)
+	default:
+		return upsertGitLabNote(pr, body, len(r.Findings) > 0)
+	}
+}
+
+// prCommentBody renders the findings as the Markdown of a comment.
+func (sc *scanContext) prCommentBody(r *Report) string {
+	var sb strings.Builder
+	sb.WriteString(prCommentMarker + "\n### depose\n\n")
+	scope := "This pull request"
+	if sc.since != "" {
+		scope = fmt.Sprintf("Since `%s`, this pull request", sc.since)
+	}
+	if len(r.Findings) == 0 {
+		fmt.Fprintf(&sb, "%s introduces no dependency findings. :tada:\n", scope)
+	} else {
+		fmt.Fprintf(&sb, "%s introduces %d dependency finding(s):\n\n", scope, len(r.Findings))
+		sb.WriteString("| Rule | Package | Location | Fix |\n| --- | --- | --- | --- |\n")
+		for _, f := range r.Findings {
+			location := ""
+			if len(f.Locations) > 0 {
+				location = f.Locations[0].String()
+			}
+			fmt.Fprintf(&sb, "| `%s` | `%s` | %s | %s |\n", f.RuleID, f.Package, location, strings.ReplaceAll(f.SuggestedFix, "|", `\|`))
+		}
+	}
+	if len(r.ScriptOnly) > 0 {
+		names := make([]string, len(r.ScriptOnly))
+		for i, u := range r.ScriptOnly {
+			names[i] = "`" + u.Package + "`"
+		}
+		fmt.Fprintf(&sb, "\nScript-only, kept because the scripts of package.json name them: %s.\n", strings.Join(names, ", "))
+	}
+	return sb.String()
+}
+
+// upsertGitHubComment updates the comment of depose on the pull request,
+// or posts it if there is none and post is true.
+func upsertGitHubComment(pr pullRequest, body string, post bool) error {
+	api := githubAPIURL
+	if env := os.Getenv("GITHUB_API_URL"); env != "" {
+		api = env
+	}
+	api = strings.TrimSuffix(api, "/") + "/repos/" + pr.repo + "/issues"
+	header := http.Header{"Authorization": {"Bearer " + githubToken()}, "Accept": {"application/vnd.github+json"}}
+
+	var comments []struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	if err := callAPI(http.MethodGet, api+"/"+pr.number+"/comments?per_page=100", header, nil, &comments); err != nil {
+		return err
+	}
+	payload := map[string]string{"body": body}
+	for _, c := range comments {
+		if strings.Contains(c.Body, prCommentMarker) {
+			return callAPI(http.MethodPatch, fmt.Sprintf("%s/comments/%d", api, c.ID), header, payload, nil)
+		}
+	}
+	if !post {
+		return nil
+	}
+	return callAPI(http.MethodPost, api+"/"+pr.number+"/comments", header, payload, nil)
+}
+
+// upsertGitLabNote updates the note of depose on the merge request, or
+// posts it if there is none and post is true. The token is read from
+// GITLAB_TOKEN.
+func upsertGitLabNote(pr pullRequest, body string, post bool) error {
+	api := gitlabAPIURL
+	if env := os.Getenv("CI_API_V4_URL"); env != "" {
+		api = env
+	}
+	api = fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes", strings.TrimSuffix(api, "/"), url.PathEscape(pr.repo), pr.number)
+	header := http.Header{"PRIVATE-TOKEN": {os.Getenv("GITLAB_TOKEN")}}
+
+	var notes []struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	if err := callAPI(http.MethodGet, api+"?per_page=100", header, nil, &notes); err != nil {
+		return err
+	}
+	payload := map[string]string{"body": body}
+	for _, n := range notes {
+		if strings.Contains(n.Body, prCommentMarker) {
+			return callAPI(http.MethodPut, fmt.Sprintf("%s/%d", api, n.ID), header, payload, nil)
+		}
+	}
+	if !post {
+		return nil
+	}
+	return callAPI(http.MethodPost, api, header, payload, nil)
+}
+
+// callAPI sends the payload, if any, as JSON to the URL of a REST API,
+// and decodes the response into out, unless it is nil.
+func callAPI(method, url string, header http.Header, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
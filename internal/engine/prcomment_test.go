@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParsePullRequest(t *testing.T) {
+	t.Setenv("CI_MERGE_REQUEST_IID", "")
+	t.Setenv("GITHUB_REF", "")
+	for id, want := range map[string]pullRequest{
+		"acme/app#12":           {"github", "acme/app", "12"},
+		"group/sub/project!7":   {"gitlab", "group/sub/project", "7"},
+		"":                      {},
+		"acme#12":               {},
+		"acme/app#twelve":       {},
+		"https://x/acme/app#12": {},
+	} {
+		got, err := parsePullRequest(id)
+		if got != want || (err == nil) != (want.platform != "") {
+			t.Errorf("parsePullRequest(%q) = %+v, %v, want %+v", id, got, err, want)
+		}
+	}
+
+	t.Setenv("GITHUB_REF", "refs/pull/42/merge")
+	t.Setenv("GITHUB_REPOSITORY", "acme/app")
+	if got, err := parsePullRequest(""); err != nil || got != (pullRequest{"github", "acme/app", "42"}) {
+		t.Errorf("parsePullRequest on GitHub Actions = %+v, %v", got, err)
+	}
+	t.Setenv("CI_MERGE_REQUEST_IID", "9")
+	t.Setenv("CI_PROJECT_PATH", "group/project")
+	if got, err := parsePullRequest(""); err != nil || got != (pullRequest{"gitlab", "group/project", "9"}) {
+		t.Errorf("parsePullRequest on GitLab CI = %+v, %v", got, err)
+	}
+}
+
+func TestPRCommentBody(t *testing.T) {
+	sc := newScanContext()
+	sc.since = "origin/main"
+	defer func() { sc.since = "" }()
+
+	body := sc.prCommentBody(&Report{})
+	if !strings.HasPrefix(body, prCommentMarker) || !strings.Contains(body, "Since `origin/main`, this pull request introduces no dependency findings") {
+		t.Errorf("body without findings = %q", body)
+	}
+	body = sc.prCommentBody(&Report{Findings: []Finding{{
+		RuleID: RuleUnusedDependency, Package: "lodash", SuggestedFix: "npm uninstall lodash || true",
+		Locations: []Location{{File: "package.json", Line: 4}},
+	}}})
+	if !strings.Contains(body, "introduces 1 dependency finding(s)") ||
+		!strings.Contains(body, "| `unused-dependency` | `lodash` | package.json:4 | npm uninstall lodash \\|\\| true |") {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestReportPRComment(t *testing.T) {
+	sc := newScanContext()
+	report := &Report{Findings: []Finding{{RuleID: RuleUnusedDependency, Package: "lodash"}}}
+	var requests []string
+	var posted map[string]string
+	handler := func(existing string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `[{"id": 1, "body": "LGTM"}`+existing+`]`)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &posted)
+			io.WriteString(w, `{}`)
+		}
+	}
+
+	t.Run("github", func(t *testing.T) {
+		requests, posted = nil, nil
+		server := httptest.NewServer(handler(""))
+		defer server.Close()
+		t.Setenv("GITHUB_API_URL", server.URL)
+		sc.prID = "acme/app#12"
+		defer func() { sc.prID = "" }()
+
+		var sb strings.Builder
+		if err := sc.reportPRComment(&sb, report); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"GET /repos/acme/app/issues/12/comments", "POST /repos/acme/app/issues/12/comments"}
+		if strings.Join(requests, ",") != strings.Join(want, ",") {
+			t.Errorf("requests = %v, want %v", requests, want)
+		}
+		if posted["body"] != sb.String() || !strings.Contains(sb.String(), "`lodash`") {
+			t.Errorf("posted %q, written %q", posted["body"], sb.String())
+		}
+	})
+
+	t.Run("gitlab", func(t *testing.T) {
+		requests, posted = nil, nil
+		server := httptest.NewServer(handler(`, {"id": 5, "body": "` + prCommentMarker + `"}`))
+		defer server.Close()
+		t.Setenv("CI_API_V4_URL", server.URL)
+		sc.prID = "group/project!3"
+		defer func() { sc.prID = "" }()
+
+		if err := sc.reportPRComment(io.Discard, report); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"GET /projects/group/project/merge_requests/3/notes", "PUT /projects/group/project/merge_requests/3/notes/5"}
+		if strings.Join(requests, ",") != strings.Join(want, ",") {
+			t.Errorf("requests = %v, want %v", requests, want)
+		}
+	})
+
+	t.Run("no findings", func(t *testing.T) {
+		requests = nil
+		server := httptest.NewServer(handler(""))
+		defer server.Close()
+		t.Setenv("GITHUB_API_URL", server.URL)
+		sc.prID = "acme/app#12"
+		defer func() { sc.prID = "" }()
+
+		if err := sc.reportPRComment(io.Discard, &Report{}); err != nil {
+			t.Fatal(err)
+		}
+		if len(requests) != 1 {
+			t.Errorf("requests = %v, want no comment posted", requests)
+		}
+	})
+}
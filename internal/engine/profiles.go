@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// exclusionProfile bundles the files and directories skipped
+// when scanning a kind of Node.js project.
+type exclusionProfile struct {
+	// extends is the profile whose exclusions are included.
+	extends string
+	// paths are relative to the project root, with forward slashes.
+	paths []string
+	// dirs are directory names, skipped at any depth.
+	dirs []string
+}
+
+// baseProfile is always applied: the manifests, the files written by
+// depose, and the directories which never contain the sources.
+var baseProfile = exclusionProfile{
+	paths: []string{
+		"package.json", "package-lock.json", "oldpackage.json",
+		".gitignore", ".env", "README.md", baselineFile,
+	},
+	dirs: []string{"node_modules", ".git", ".depose"},
+}
+
+// profiles are the exclusion profiles selected with --profile.
+var profiles = map[string]exclusionProfile{
+	"node": {
+		dirs: []string{"dist", "build", "coverage", ".nyc_output", ".cache"},
+	},
+	"next": {
+		extends: "node",
+		paths:   []string{"out"},
+		dirs:    []string{".next", ".vercel"},
+	},
+	"react-native": {
+		extends: "node",
+		paths:   []string{"android/app/build", "android/.gradle", "ios/Pods"},
+		dirs:    []string{".expo", ".expo-shared"},
+	},
+	"electron": {
+		extends: "node",
+		paths:   []string{"out", "release"},
+		dirs:    []string{"dist_electron"},
+	},
+	"firebase": {
+		extends: "node",
+		dirs:    []string{".firebase"},
+	},
+	"serverless": {
+		extends: "node",
+		dirs:    []string{".serverless", ".esbuild", ".webpack"},
+	},
+	"monorepo": {
+		extends: "node",
+		dirs:    []string{".turbo", ".nx", ".yarn"},
+	},
+}
+
+// defaultProfile is the profile used when --profile is not given.
+const defaultProfile = "node"
+
+// profileExclusions returns the paths and the directory names excluded by
+// the base profile and the given profiles, e.g. "next,monorepo".
+func profileExclusions(names string) (paths map[string]int, dirs map[string]bool, err error) {
+	paths, dirs = make(map[string]int), make(map[string]bool)
+	add := func(p exclusionProfile) {
+		for _, path := range p.paths {
+			paths[path] = 0
+		}
+		for _, dir := range p.dirs {
+			dirs[dir] = true
+		}
+	}
+	add(baseProfile)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		for name != "" {
+			p, ok := profiles[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("unknown profile %q, available profiles: %s", name, strings.Join(profileNames(), ", "))
+			}
+			add(p)
+			name = p.extends
+		}
+	}
+	return paths, dirs, nil
+}
+
+// profileNames returns the names of the profiles, sorted.
+func profileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
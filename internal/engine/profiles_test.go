@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestProfileExclusions(t *testing.T) {
+	paths, dirs, err := profileExclusions("react-native, monorepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"package.json", baselineFile, "ios/Pods", "android/app/build"} {
+		if _, ok := paths[path]; !ok {
+			t.Errorf("path %q is not excluded", path)
+		}
+	}
+	for _, dir := range []string{"node_modules", "dist", ".expo", ".turbo"} {
+		if !dirs[dir] {
+			t.Errorf("directory %q is not excluded", dir)
+		}
+	}
+	if dirs[".next"] {
+		t.Errorf("directory .next of the next profile is excluded")
+	}
+	for _, path := range []string{"main.go", "depose"} {
+		if _, ok := paths[path]; ok {
+			t.Errorf("project specific path %q is excluded", path)
+		}
+	}
+
+	if _, _, err := profileExclusions("rails"); err == nil {
+		t.Errorf("profileExclusions(rails) did not fail")
+	}
+}
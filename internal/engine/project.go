@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// ProjectContext is the state of the scan of a single project, e.g. a
+// workspace of a monorepo: its manifest, its config and its results.
+//
+// Every ProjectContext is scanned by Scan, with its own scanContext, so
+// projects are scanned concurrently without sharing the dependency map,
+// nor the diagnostics of the run.
+type ProjectContext struct {
+	// Dir is the directory of the project, relative to the root.
+	Dir      string
+	Manifest Package
+	// Config is the config of depose in the manifest of the project.
+	Config Config
+	// Report holds the results of the scan, and Err why it failed, if it did.
+	Report *Report
+	Err    error
+}
+
+// newProjectContext returns the context of the project in the directory,
+// whose manifest is data.
+func newProjectContext(dir string, data []byte) (*ProjectContext, error) {
+	c := &ProjectContext{Dir: dir}
+	if err := json.Unmarshal(data, &c.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/package.json: %v", dir, err)
+	}
+	c.Config = c.Manifest.Depose
+	return c, nil
+}
+
+// scan scans the project of the root directory with the flags in args,
+// recording its results or its error.
+func (c *ProjectContext) scan(ctx context.Context, root string, args []string) {
+	c.Report, c.Err = Scan(ctx, filepath.Join(root, filepath.FromSlash(c.Dir)), args)
+}
+
+// scanProjects scans the projects of the root directory concurrently, at
+// most jobs at a time. The scans which fail don't stop the others: their
+// error is recorded in their context.
+func scanProjects(ctx context.Context, root string, projects []*ProjectContext, args []string, jobs int) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobs)
+	for _, c := range projects {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			c.scan(ctx, root, args)
+		}()
+	}
+	wg.Wait()
+}
+
+// projectScanArgs returns the flags of the scans of the projects nested in
+// the project, e.g. its workspaces: dry runs without history, which report
+// everything, in the mode of the scan.
+func (sc *scanContext) projectScanArgs() []string {
+	args := []string{"--dry-run", "--no-history", "--no-ascend", "--verbose"}
+	if sc.strict {
+		args = append(args, "--strict")
+	}
+	if sc.profileNamesFlag != defaultProfile {
+		args = append(args, "--profile", sc.profileNamesFlag)
+	}
+	if sc.noPlugins {
+		args = append(args, "--no-plugins")
+	}
+	return args
+}
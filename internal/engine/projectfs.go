@@ -0,0 +1,210 @@
+package engine
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// setProjectFS scans the file system instead of the working directory.
+func (sc *scanContext) setProjectFS(fsys fs.FS) {
+	sc.projectFS = fsys
+	sc.installedPackages.mu.Lock()
+	sc.installedPackages.mp = make(map[string]*installedPackage)
+	sc.installedPackages.mu.Unlock()
+}
+
+// projectOnDisk reports whether the scanned project is its directory on disk.
+func (sc *scanContext) projectOnDisk() bool {
+	return sc.projectFS == os.DirFS(sc.dir)
+}
+
+// fsPath converts a path relative to the project root, e.g. collected
+// while walking the project or joined with filepath, to a path of projectFS.
+func fsPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+// readProjectFile reads the file of the project.
+func (sc *scanContext) readProjectFile(name string) ([]byte, error) {
+	return fs.ReadFile(sc.projectFS, fsPath(name))
+}
+
+// fileHead returns the start of the file of the project, e.g. to read its
+// shebang line, or nil if it can't be read.
+func (sc *scanContext) fileHead(name string) []byte {
+	f, err := sc.openProjectFile(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	head := make([]byte, 256)
+	n, _ := io.ReadFull(f, head)
+	return head[:n]
+}
+
+// statProjectFile returns the info of the file of the project.
+func (sc *scanContext) statProjectFile(name string) (fs.FileInfo, error) {
+	return fs.Stat(sc.projectFS, fsPath(name))
+}
+
+// openProjectFile opens the file of the project.
+func (sc *scanContext) openProjectFile(name string) (fs.File, error) {
+	return sc.projectFS.Open(fsPath(name))
+}
+
+// walkProject collects the files of the project, see scanDir.
+func (sc *scanContext) walkProject() error {
+	return fs.WalkDir(sc.projectFS, ".", sc.scanDir)
+}
+
+// memFS is a read-only file system held in memory, e.g. the files of a
+// tarball. Directories are implied by the paths of the files.
+type memFS struct {
+	files map[string][]byte
+	dirs  map[string][]fs.DirEntry
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string][]byte), dirs: map[string][]fs.DirEntry{".": nil}}
+}
+
+// add adds the file, and the directories containing it.
+func (m *memFS) add(name string, data []byte) {
+	if _, ok := m.files[name]; !ok {
+		m.addEntry(name, false, len(data))
+	}
+	m.files[name] = data
+}
+
+// addEntry adds the entry of the file or directory to its parent.
+func (m *memFS) addEntry(name string, isDir bool, size int) {
+	dir := path.Dir(name)
+	if _, ok := m.dirs[dir]; !ok {
+		m.addEntry(dir, true, 0)
+		m.dirs[dir] = nil
+	}
+	m.dirs[dir] = append(m.dirs[dir], fs.FileInfoToDirEntry(memInfo{name: path.Base(name), size: size, dir: isDir}))
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if data, ok := m.files[name]; ok {
+		return &memFile{Reader: bytes.NewReader(data), info: memInfo{name: path.Base(name), size: len(data)}}, nil
+	}
+	if entries, ok := m.dirs[name]; ok {
+		sorted := append([]fs.DirEntry(nil), entries...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+		return &memDir{info: memInfo{name: path.Base(name), dir: true}, entries: sorted}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// memInfo is the info of a file or directory of a memFS.
+type memInfo struct {
+	name string
+	size int
+	dir  bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(i.size) }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// memFile is an open file of a memFS.
+type memFile struct {
+	*bytes.Reader
+	info memInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an open directory of a memFS.
+type memDir struct {
+	info    memInfo
+	entries []fs.DirEntry
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 || n >= len(d.entries) {
+		entries := d.entries
+		d.entries = nil
+		if n > 0 && len(entries) == 0 {
+			return nil, io.EOF
+		}
+		return entries, nil
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// readTarFS reads the regular files of the tarball, gzipped or not, into
+// memory. Like projectRoot, the single top-level directory of tarballs
+// wrapping the project in one is their root.
+func readTarFS(r io.Reader) (fs.FS, error) {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	m := newMemFS()
+	tr := tar.NewReader(src)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if hdr.Typeflag != tar.TypeReg || !fs.ValidPath(name) || name == "." {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		m.add(name, data)
+	}
+
+	if root := m.dirs["."]; len(root) == 1 && root[0].IsDir() {
+		return fs.Sub(m, root[0].Name())
+	}
+	return m, nil
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFS(t *testing.T) {
+	m := newMemFS()
+	m.add("package.json", []byte("{}"))
+	m.add("src/index.js", []byte("require(\"express\")"))
+	m.add("src/lib/util.js", nil)
+	if err := fstest.TestFS(m, "package.json", "src/index.js", "src/lib/util.js"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReadTarFS(t *testing.T) {
+	var tarball bytes.Buffer
+	tw := tar.NewWriter(&tarball)
+	for _, name := range []string{"repo-main/package.json", "repo-main/src/index.js", "../evil.js"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: 2, Typeflag: tar.TypeReg})
+		tw.Write([]byte("{}"))
+	}
+	tw.Close()
+
+	fsys, err := readTarFS(&tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			got = append(got, path)
+		}
+		return err
+	})
+	if want := []string{"package.json", "src/index.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestScanProjectFS(t *testing.T) {
+	sc := newScanContext()
+	sc.setProjectFS(fstest.MapFS{
+		"package.json":             {Data: []byte(`{"dependencies": {"express": "^4.18.2", "lodash": "^4.17.21"}}`)},
+		"src/server.js":            {Data: []byte("const express = require(\"express\");\n")},
+		"node_modules/lodash/x.js": {Data: []byte("require(\"lodash\")")},
+	})
+	sc.lang, sc.strict = sc.nodeLanguage, true
+	defer func() {
+		sc.setProjectFS(os.DirFS("."))
+		sc.lang, sc.strict = nil, false
+		sc.d = Dependency{}
+		sc.manifest = Package{}
+		sc.files = nil
+	}()
+
+	sc.scanProject()
+	if want := map[string]bool{"express": true, "lodash": false}; !reflect.DeepEqual(sc.d.mp, want) {
+		t.Errorf("dependencies = %v, want %v", sc.d.mp, want)
+	}
+	if want := []string{"src/server.js"}; !reflect.DeepEqual(sc.files, want) {
+		t.Errorf("files = %v, want %v", sc.files, want)
+	}
+}
+
+func TestExtractPackagesLogOrder(t *testing.T) {
+	sc := newScanContext()
+	fsys := fstest.MapFS{}
+	var files []string
+	var want bytes.Buffer
+	for i := 0; i < 50; i++ {
+		file := fmt.Sprintf("src/%02d.js", i)
+		fsys[file] = &fstest.MapFile{Data: []byte(fmt.Sprintf("require(\"pkg-%02d\")", i))}
+		files = append(files, file)
+		fmt.Fprintf(&want, "Reading file: %s\nFound a package: pkg-%02d\n", file, i)
+	}
+	sc.setProjectFS(fsys)
+	sc.lang, sc.strict = sc.nodeLanguage, true
+	sc.d.mp = make(map[string]bool)
+	sc.d.usages = make(map[string][]Location)
+	sc.d.ignoredLines = make(map[Location][]string)
+	var got bytes.Buffer
+	defer func(w io.Writer) {
+		sc.logOut = w
+		sc.setProjectFS(os.DirFS("."))
+		sc.lang, sc.strict = nil, false
+		sc.d = Dependency{}
+	}(sc.logOut)
+	sc.logOut = &got
+
+	sc.extractPackages(files)
+	if got.String() != want.String() {
+		t.Errorf("log = %s, want %s", got.String(), want.String())
+	}
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// projectTrait is a characteristic of Node.js projects, e.g. TypeScript or
+// Next.js, detected from their files and their manifest, which selects an
+// exclusion profile, unless --no-auto-detect or --profile is given.
+type projectTrait struct {
+	name string
+	// profile is the exclusion profile of the projects having the trait,
+	// if any.
+	profile string
+	// files are the files of the projects having the trait, at their root.
+	files []string
+	// packages are the packages declared by the projects having the trait.
+	packages []string
+	// detect reports whether the project has the trait, in addition to
+	// its files and packages.
+	detect func(pkg Package) bool
+}
+
+// projectTraits are the traits which are detected, in the order they are
+// printed.
+var projectTraits = []projectTrait{
+	{name: "TypeScript", files: []string{"tsconfig.json"}, packages: []string{"typescript"}},
+	{name: "React", packages: []string{"react"}},
+	{name: "Next.js", profile: "next", files: []string{"next.config.js", "next.config.mjs", "next.config.ts"}, packages: []string{"next"}},
+	{name: "React Native", profile: "react-native", files: []string{"metro.config.js"}, detect: isReactNativeProject},
+	{name: "Electron", profile: "electron", files: []string{"forge.config.js", "electron-builder.yml", "electron-builder.json"}, packages: []string{"electron"}},
+	{name: "Firebase", profile: "firebase", files: []string{"firebase.json"}, packages: []string{"firebase-functions"}},
+	{name: "Serverless", profile: "serverless", files: serverlessConfigFiles, packages: []string{"serverless"}},
+	{name: "monorepo", profile: "monorepo", files: []string{"pnpm-workspace.yaml", "lerna.json", "turbo.json", "nx.json"}, detect: func(pkg Package) bool {
+		return len(pkg.Workspaces) > 0
+	}},
+}
+
+// hasTrait reports whether the project, whose manifest is pkg, has the trait.
+func (sc *scanContext) hasTrait(t projectTrait, pkg Package) bool {
+	for _, name := range t.packages {
+		if _, ok := pkg.Dependencies[name]; ok {
+			return true
+		}
+		if _, ok := pkg.DevDependencies[name]; ok {
+			return true
+		}
+	}
+	for _, file := range t.files {
+		if _, err := sc.statProjectFile(file); err == nil {
+			return true
+		}
+	}
+	return t.detect != nil && t.detect(pkg)
+}
+
+// detectProjectTraits returns the traits of the project, whose manifest is
+// pkg, along with the exclusion profiles they select.
+func (sc *scanContext) detectProjectTraits(pkg Package) (traits []projectTrait, profiles []string) {
+	for _, t := range projectTraits {
+		if sc.hasTrait(t, pkg) {
+			traits = append(traits, t)
+			if t.profile != "" {
+				profiles = append(profiles, t.profile)
+			}
+		}
+	}
+	return traits, profiles
+}
+
+// autoDetectProject detects the traits of the project, and adds the
+// exclusions of the profiles they select to the ones of the default
+// profile, printing them. TypeScript projects keep the @types packages of
+// the packages they use, see markTypesPackages.
+func (sc *scanContext) autoDetectProject(pkg Package) {
+	traits, profiles := sc.detectProjectTraits(pkg)
+	if len(traits) == 0 {
+		return
+	}
+	names := make([]string, len(traits))
+	for i, t := range traits {
+		names[i] = t.name
+		sc.typesChecks = sc.typesChecks || t.name == "TypeScript"
+	}
+	if len(profiles) == 0 {
+		fmt.Fprintf(sc.logOut, "Detected a %s project\n", strings.Join(names, ", "))
+		return
+	}
+	paths, dirs, err := profileExclusions(strings.Join(profiles, ","))
+	if err != nil {
+		return
+	}
+	maps.Copy(sc.filesToExclude, paths)
+	maps.Copy(sc.dirsToExclude, dirs)
+	fmt.Fprintf(sc.logOut, "Detected a %s project, using the %s profile(s); run with --no-auto-detect or --profile to change them\n",
+		strings.Join(names, ", "), strings.Join(profiles, ", "))
+}
+
+// typesPackagePrefix is the scope of the packages of DefinitelyTyped,
+// holding the types of the packages which don't ship their own.
+const typesPackagePrefix = "@types/"
+
+// typedPackage returns the package whose types the @types package holds,
+// e.g. "@babel/core" for "@types/babel__core", and whether it is one.
+func typedPackage(dependency string) (string, bool) {
+	name, ok := strings.CutPrefix(dependency, typesPackagePrefix)
+	if !ok || name == "" {
+		return "", false
+	}
+	if scope, pkgName, scoped := strings.Cut(name, "__"); scoped {
+		return "@" + scope + "/" + pkgName, true
+	}
+	return name, true
+}
+
+// markTypesPackages marks the declared @types packages as used in lenient
+// mode, when the package whose types they hold is used, e.g. @types/express
+// along with express, or is the runtime, i.e. @types/node.
+func (sc *scanContext) markTypesPackages() {
+	sc.d.mu.Lock()
+	defer sc.d.mu.Unlock()
+	for dependency, used := range sc.d.mp {
+		typed, ok := typedPackage(dependency)
+		if used || !ok {
+			continue
+		}
+		if typed == "node" || sc.d.mp[typed] || len(sc.d.usages[typed]) > 0 {
+			loc := Location{File: sc.manifestFile}
+			if len(sc.d.usages[typed]) > 0 {
+				loc = sc.d.usages[typed][0]
+			}
+			sc.markAsUsed(dependency, EvidenceTypes, loc)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestDetectProjectTraits(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{"tsconfig.json": "{}", "turbo.json": "{}"})
+	pkg := Package{Dependencies: map[string]string{"next": "14.0.0", "react": "18.2.0"}}
+
+	traits, profiles := sc.detectProjectTraits(pkg)
+	var names []string
+	for _, trait := range traits {
+		names = append(names, trait.name)
+	}
+	if want := []string{"TypeScript", "React", "Next.js", "monorepo"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("traits = %v, want %v", names, want)
+	}
+	if want := []string{"next", "monorepo"}; !reflect.DeepEqual(profiles, want) {
+		t.Errorf("profiles = %v, want %v", profiles, want)
+	}
+
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	defer func() {
+		sc.filesToExclude, sc.dirsToExclude, _ = profileExclusions(defaultProfile)
+		sc.typesChecks = false
+	}()
+	sc.autoDetectProject(pkg)
+	if !sc.dirsToExclude[".next"] || !sc.dirsToExclude[".turbo"] || !sc.dirsToExclude["dist"] || !sc.typesChecks {
+		t.Errorf("autoDetectProject() excluded %v, typesChecks = %v", sc.dirsToExclude, sc.typesChecks)
+	}
+}
+
+func TestMarkTypesPackages(t *testing.T) {
+	sc := newScanContext()
+	sc.d.mp = map[string]bool{
+		"express": true, "@types/express": false, "@types/node": false,
+		"@types/babel__core": false, "@types/lodash": false,
+	}
+	sc.d.usages = map[string][]Location{"@babel/core": {{File: "babel.config.js", Line: 1}}}
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markTypesPackages()
+	want := map[string]bool{
+		"express": true, "@types/express": true, "@types/node": true,
+		"@types/babel__core": true, "@types/lodash": false,
+	}
+	if !reflect.DeepEqual(sc.d.mp, want) {
+		t.Errorf("d.mp = %v, want %v", sc.d.mp, want)
+	}
+	if got := sc.d.evidence["@types/express"]; got != EvidenceTypes {
+		t.Errorf("evidence of @types/express = %q, want %q", got, EvidenceTypes)
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// pruneCommand implements "depose prune", which generates a manifest keeping
+// only the dependencies imported by the entrypoints, following the local
+// import graph, e.g. to install slim production images:
+//
+//	depose prune --for production --entry src/server.ts --out package.prod.json
+func (sc *scanContext) pruneCommand(fs *flag.FlagSet) func(args []string) {
+	target := fs.String("for", "production", "environment the manifest is generated for: production")
+	out := fs.String("out", "", "path of the generated manifest, stdout when empty")
+	var entries stringList
+	fs.Var(&entries, "entry", "entrypoint of the application, can be repeated (default: the \"main\" of package.json, or index.js)")
+	return func(args []string) {
+		if *target != "production" {
+			logFatalf("Unknown target %q, only production is supported", *target)
+		}
+		sc.logOut = os.Stderr
+		sc.lang = sc.nodeLanguage
+
+		data := sc.loadManifest()
+
+		if len(entries) == 0 {
+			entry := sc.manifest.Main
+			if entry == "" {
+				entry = "index.js"
+			}
+			entries = stringList{entry}
+		}
+		for _, entry := range entries {
+			if _, err := os.Stat(entry); err != nil {
+				logFatalf("Entrypoint %s: %v", entry, err)
+			}
+		}
+
+		graph := sc.followImports(entries)
+		fmt.Fprintf(sc.logOut, "Followed the imports of %d file(s)\n", len(graph.files))
+
+		remove := map[string][]string{}
+		for dependency := range sc.manifest.Dependencies {
+			if _, ok := graph.packages[dependency]; !ok {
+				remove["dependencies"] = append(remove["dependencies"], dependency)
+			}
+		}
+		for dependency := range sc.manifest.DevDependencies {
+			remove["devDependencies"] = append(remove["devDependencies"], dependency)
+			if locations, ok := graph.packages[dependency]; ok {
+				fmt.Fprintf(sc.logOut, "Warning: %q is imported at %s, but only declared in devDependencies\n", dependency, locations[0])
+			}
+		}
+		var missing []string
+		for pkgName := range graph.packages {
+			_, dep := sc.manifest.Dependencies[pkgName]
+			_, devDep := sc.manifest.DevDependencies[pkgName]
+			if !dep && !devDep && pkgName != sc.manifest.Name {
+				missing = append(missing, pkgName)
+			}
+		}
+		sort.Strings(missing)
+		for _, pkgName := range missing {
+			fmt.Fprintf(sc.logOut, "Warning: %q is imported at %s, but not declared in package.json\n", pkgName, graph.packages[pkgName][0])
+		}
+
+		pruned := removeTrailingCommas(removeDeclaredLines(data, remove))
+		if *out == "" {
+			if _, err := os.Stdout.Write(pruned); err != nil {
+				logFatal(err)
+			}
+			return
+		}
+		if err := os.WriteFile(*out, pruned, 0o644); err != nil {
+			logFatal(err)
+		}
+		fmt.Fprintf(sc.logOut, "The %s manifest has been written to %s.\n", *target, *out)
+	}
+}
+
+// removeDeclaredLines removes from the manifest the lines declaring the
+// dependencies, grouped by section, leaving every other line untouched.
+func removeDeclaredLines(data []byte, remove map[string][]string) []byte {
+	lines := declaredLinesOf(data)
+	skip := make(map[int]bool)
+	for section, dependencies := range remove {
+		for _, dependency := range dependencies {
+			if line, ok := lines[section][dependency]; ok {
+				skip[line] = true
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for i, line := range splitLines(data) {
+		if !skip[i+1] {
+			sb.WriteString(line + "\n")
+		}
+	}
+	return []byte(sb.String())
+}
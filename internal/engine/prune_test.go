@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRunPrune(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{
+  "name": "api",
+  "imports": {
+    "#config": "./src/config.js"
+  },
+  "scripts": {
+    "start": "node dist/server.js"
+  },
+  "dependencies": {
+    "express": "^4.18.2",
+    "pg": "^8.11.0",
+    "lodash": "^4.17.21",
+    "dotenv": "^16.0.0"
+  },
+  "devDependencies": {
+    "jest": "^29.7.0"
+  }
+}
+`,
+		"src/server.ts":   "import express from \"express\";\nimport { db } from \"./db.js\";\nimport config from \"#config\";\nimport fs from \"node:fs\";\n",
+		"src/db.ts":       "const { Pool } = require(\"pg\");\n",
+		"src/config.js":   "require(\"dotenv/config\");\n",
+		"scripts/seed.js": "const _ = require(\"lodash\");\n",
+	})
+	defer func(out io.Writer, l *language, m Package) { sc.logOut, sc.lang, sc.manifest = out, l, m }(sc.logOut, sc.lang, sc.manifest)
+
+	sc.runCommand("prune", []string{"--for", "production", "--entry", "src/server.ts", "--out", "package.prod.json"})
+
+	data, err := os.ReadFile("package.prod.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pruned Package
+	if err := json.Unmarshal(data, &pruned); err != nil {
+		t.Fatalf("the pruned manifest is not valid JSON: %v\n%s", err, data)
+	}
+	wantDeps := map[string]string{"express": "^4.18.2", "pg": "^8.11.0", "dotenv": "^16.0.0"}
+	if !reflect.DeepEqual(pruned.Dependencies, wantDeps) {
+		t.Errorf("dependencies = %v, want %v", pruned.Dependencies, wantDeps)
+	}
+	if len(pruned.DevDependencies) != 0 {
+		t.Errorf("devDependencies = %v, want none", pruned.DevDependencies)
+	}
+	if pruned.Scripts["start"] != "node dist/server.js" {
+		t.Errorf("scripts were not kept: %v", pruned.Scripts)
+	}
+}
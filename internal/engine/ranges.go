@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Styles of the version ranges of the dependencies.
+const (
+	RangeCaret    = "caret"    // ^1.2.3
+	RangeTilde    = "tilde"    // ~1.2.3
+	RangeExact    = "exact"    // 1.2.3
+	RangeWildcard = "wildcard" // *, x or an empty range
+	RangeTag      = "tag"      // latest, next
+	RangeGit      = "git"      // git URLs and hosted repositories, e.g. github:user/repo
+	RangeURL      = "url"      // tarball URLs
+	RangeComplex  = "complex"  // >=1.2.3 <2, 1.x, 1.2.3 - 2 or 1 || 2
+	RangeLocal    = "local"    // file:, link:, workspace: and portal:, and npm: aliases
+)
+
+// rangeFixStyles are the styles accepted by --fix-ranges.
+var rangeFixStyles = []string{RangeCaret, RangeExact}
+
+// rangeDescriptions describe the styles in the messages of the findings.
+var rangeDescriptions = map[string]string{
+	RangeCaret:    "a caret range",
+	RangeTilde:    "a tilde range",
+	RangeExact:    "an exact version",
+	RangeWildcard: "a wildcard",
+	RangeTag:      "a dist-tag",
+	RangeGit:      "a git URL",
+	RangeURL:      "a tarball URL",
+	RangeComplex:  "a complex range",
+}
+
+// rangePlurals describe the dominant style of a manifest.
+var rangePlurals = map[string]string{
+	RangeCaret: "caret ranges",
+	RangeTilde: "tilde ranges",
+	RangeExact: "exact versions",
+}
+
+var (
+	// versionRe matches a version, possibly partial, e.g. "1.2.3", "1.2"
+	// or "1.0.0-beta.1".
+	versionRe = regexp.MustCompile(`^v?(\d+)(?:\.(\d+)(?:\.(\d+)([-+][\w.+-]*)?)?)?$`)
+	// hostedRepoRe matches the shorthand of a GitHub repository, e.g.
+	// "user/repo#v1.0.0".
+	hostedRepoRe = regexp.MustCompile(`^[\w.-]+/[\w.-]+(#.*)?$`)
+	// distTagRe matches a dist-tag, e.g. "latest" or "next".
+	distTagRe = regexp.MustCompile(`^[a-z][\w.-]*$`)
+)
+
+// rangeStyle returns the style of the version range of a dependency.
+func rangeStyle(versionRange string) string {
+	r := strings.TrimSpace(versionRange)
+	switch {
+	case r == "" || r == "*" || r == "x" || r == "X":
+		return RangeWildcard
+	case strings.HasPrefix(r, "file:"), strings.HasPrefix(r, "link:"), strings.HasPrefix(r, "workspace:"),
+		strings.HasPrefix(r, "portal:"), strings.HasPrefix(r, "npm:"):
+		return RangeLocal
+	case strings.HasPrefix(r, "git"), strings.HasPrefix(r, "github:"), strings.HasPrefix(r, "gitlab:"),
+		strings.HasPrefix(r, "bitbucket:"), hostedRepoRe.MatchString(r):
+		return RangeGit
+	case strings.HasPrefix(r, "http://"), strings.HasPrefix(r, "https://"):
+		return RangeURL
+	case strings.HasPrefix(r, "^") && versionRe.MatchString(r[1:]):
+		return RangeCaret
+	case strings.HasPrefix(r, "~") && versionRe.MatchString(strings.TrimPrefix(r[1:], ">")):
+		return RangeTilde
+	case isExactVersion(strings.TrimPrefix(r, "=")):
+		return RangeExact
+	case distTagRe.MatchString(r):
+		return RangeTag
+	}
+	return RangeComplex
+}
+
+// isExactVersion reports whether the version is complete, e.g. "1.2.3"
+// but not "1.2".
+func isExactVersion(version string) bool {
+	m := versionRe.FindStringSubmatch(version)
+	return m != nil && m[3] != ""
+}
+
+// dominantRangeStyle returns the most common style among the caret, tilde
+// and exact ranges of the dependencies, preferring caret ranges, then
+// exact versions, on a tie. It returns "" when there are none.
+func dominantRangeStyle(ranges map[string]string) string {
+	counts := make(map[string]int)
+	for _, r := range ranges {
+		counts[rangeStyle(r)]++
+	}
+	dominant := ""
+	for _, style := range []string{RangeCaret, RangeExact, RangeTilde} {
+		if counts[style] > counts[dominant] {
+			dominant = style
+		}
+	}
+	return dominant
+}
+
+// normalizedRange returns the range of the dependency in the style, e.g.
+// "^1.2.0" for "~1.2" in the caret style. Exact versions pin the version
+// installed in node_modules, if any, or else the lower bound of the range.
+// It returns false when the range can't be converted, e.g. a git URL.
+func (sc *scanContext) normalizedRange(dependency, versionRange, style string) (string, bool) {
+	r := strings.TrimSpace(versionRange)
+	switch rangeStyle(r) {
+	case RangeCaret, RangeTilde, RangeExact:
+	default:
+		return "", false
+	}
+	m := versionRe.FindStringSubmatch(strings.TrimLeft(r, "^~>=v"))
+	version := m[1] + "." + orZero(m[2]) + "." + orZero(m[3]) + m[4]
+	if style == RangeExact {
+		if installed := sc.readInstalledPackage(filepath.Join("node_modules", filepath.FromSlash(dependency))); installed != nil && isExactVersion(installed.Version) {
+			version = installed.Version
+		}
+		return version, true
+	}
+	return "^" + version, true
+}
+
+// orZero returns the part of a version, or "0" when it is missing.
+func orZero(part string) string {
+	if part == "" {
+		return "0"
+	}
+	return part
+}
+
+// rangeFindings reports the dependencies whose ranges differ from the
+// style, or from the dominant style of their section of the manifest when
+// it is "", e.g. a tilde range among caret ranges, a wildcard or a git URL.
+// Local packages and aliases are left out.
+func (sc *scanContext) rangeFindings(style string) []Finding {
+	declared := sc.declaredLines("package.json")
+	var findings []Finding
+	for section, deps := range map[string]map[string]string{
+		"dependencies":    sc.manifest.Dependencies,
+		"devDependencies": sc.manifest.DevDependencies,
+	} {
+		target := style
+		if target == "" {
+			target = dominantRangeStyle(deps)
+		}
+		for dependency, versionRange := range deps {
+			current := rangeStyle(versionRange)
+			if current == target || current == RangeLocal || target == "" && current == RangeComplex {
+				continue
+			}
+			message := fmt.Sprintf("%q uses %s (%s) in %s", dependency, rangeDescriptions[current], versionRange, section)
+			switch {
+			case style != "":
+				message += ", not " + rangeDescriptions[style]
+			case target != "":
+				message += fmt.Sprintf(", while most of them use %s", rangePlurals[target])
+			}
+			fix := "pin it to a version range"
+			if normalized, ok := sc.normalizedRange(dependency, versionRange, target); ok {
+				fix = fmt.Sprintf("use %q, e.g. with --fix-ranges %s", normalized, target)
+			}
+			findings = append(findings, sc.newFinding(RuleInconsistentRange, dependency, section, message,
+				[]Location{{File: "package.json", Line: declared[section][dependency]}}, fix))
+		}
+	}
+	return findings
+}
+
+// rangeFixes returns the new ranges of the dependencies reported by the
+// findings whose ranges can be converted to the style.
+func (sc *scanContext) rangeFixes(findings []Finding, style string) map[string]map[string]string {
+	fixes := make(map[string]map[string]string)
+	for _, f := range findings {
+		if f.RuleID != RuleInconsistentRange {
+			continue
+		}
+		deps := sc.manifest.Dependencies
+		if f.Section == "devDependencies" {
+			deps = sc.manifest.DevDependencies
+		}
+		if normalized, ok := sc.normalizedRange(f.Package, deps[f.Package], style); ok {
+			if fixes[f.Section] == nil {
+				fixes[f.Section] = make(map[string]string)
+			}
+			fixes[f.Section][f.Package] = normalized
+		}
+	}
+	return fixes
+}
+
+// rewriteRanges replaces the ranges of the dependencies of each section
+// of the content of package.json, keeping its formatting.
+func rewriteRanges(data []byte, fixes map[string]map[string]string) []byte {
+	if len(fixes) == 0 {
+		return data
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	declared := declaredLinesOf(data)
+	sections := make([]string, 0, len(fixes))
+	for section := range fixes {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		for dependency, newRange := range fixes[section] {
+			lineNo := declared[section][dependency]
+			if lineNo == 0 {
+				continue
+			}
+			key := fmt.Sprintf("%q", dependency)
+			i := strings.Index(lines[lineNo-1], key)
+			if i < 0 {
+				continue
+			}
+			prefix, rest := lines[lineNo-1][:i+len(key)], lines[lineNo-1][i+len(key):]
+			colon := strings.Index(rest, ":")
+			start := strings.Index(rest, `"`)
+			if colon < 0 || start < colon {
+				continue
+			}
+			end := strings.Index(rest[start+1:], `"`)
+			if end < 0 {
+				continue
+			}
+			lines[lineNo-1] = prefix + rest[:start] + fmt.Sprintf("%q", newRange) + rest[start+end+2:]
+		}
+	}
+	return []byte(strings.Join(lines, ""))
+}
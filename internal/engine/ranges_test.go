@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRangeStyle(t *testing.T) {
+	tests := map[string]string{
+		"^1.2.3":                          RangeCaret,
+		"^1":                              RangeCaret,
+		"~1.2.3":                          RangeTilde,
+		"~>1.2":                           RangeTilde,
+		"1.2.3":                           RangeExact,
+		"=1.2.3-beta.1":                   RangeExact,
+		"1.2":                             RangeComplex,
+		"*":                               RangeWildcard,
+		"":                                RangeWildcard,
+		"latest":                          RangeTag,
+		"git+https://github.com/a/b.git":  RangeGit,
+		"github:user/repo#v1.0.0":         RangeGit,
+		"user/repo":                       RangeGit,
+		"https://example.com/pkg-1.0.tgz": RangeURL,
+		">=1.2.3 <2":                      RangeComplex,
+		"1.x":                             RangeComplex,
+		"1 || 2":                          RangeComplex,
+		"workspace:*":                     RangeLocal,
+		"file:../lib":                     RangeLocal,
+		"npm:string-width@^4.2.0":         RangeLocal,
+	}
+	for r, want := range tests {
+		if got := rangeStyle(r); got != want {
+			t.Errorf("rangeStyle(%q) = %q, want %q", r, got, want)
+		}
+	}
+}
+
+func TestNormalizedRange(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{"node_modules/lodash/package.json": `{"version": "4.17.21"}`})
+	tests := []struct {
+		dependency, versionRange, style, want string
+		ok                                    bool
+	}{
+		{"lodash", "~4.17.0", RangeCaret, "^4.17.0", true},
+		{"lodash", "^4.17.0", RangeExact, "4.17.21", true},
+		{"chalk", "^5", RangeExact, "5.0.0", true},
+		{"chalk", "5.3.0", RangeCaret, "^5.3.0", true},
+		{"left-pad", "github:user/left-pad", RangeCaret, "", false},
+		{"left-pad", "*", RangeExact, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := sc.normalizedRange(tt.dependency, tt.versionRange, tt.style)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizedRange(%q, %q, %q) = %q, %v, want %q, %v", tt.dependency, tt.versionRange, tt.style, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRangeFindings(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"dependencies\": {\n    \"express\": \"^4.18.2\",\n    \"lodash\": \"~4.17.21\",\n    \"react\": \"^18.2.0\",\n    \"left-pad\": \"github:user/left-pad\",\n    \"ui\": \"workspace:*\"\n  },\n  \"devDependencies\": {\n    \"jest\": \"29.7.0\",\n    \"eslint\": \"*\"\n  }\n}\n",
+	})
+	defer func(m Package) { sc.manifest = m }(sc.manifest)
+	sc.manifest = Package{
+		Dependencies:    map[string]string{"express": "^4.18.2", "lodash": "~4.17.21", "react": "^18.2.0", "left-pad": "github:user/left-pad", "ui": "workspace:*"},
+		DevDependencies: map[string]string{"jest": "29.7.0", "eslint": "*"},
+	}
+
+	messages := func(findings []Finding) []string {
+		var got []string
+		for _, f := range findings {
+			got = append(got, f.Locations[0].String()+" "+f.Message+": "+f.SuggestedFix)
+		}
+		sort.Strings(got)
+		return got
+	}
+	want := []string{
+		`package.json:11 "eslint" uses a wildcard (*) in devDependencies, while most of them use exact versions: pin it to a version range`,
+		`package.json:4 "lodash" uses a tilde range (~4.17.21) in dependencies, while most of them use caret ranges: use "^4.17.21", e.g. with --fix-ranges caret`,
+		`package.json:6 "left-pad" uses a git URL (github:user/left-pad) in dependencies, while most of them use caret ranges: pin it to a version range`,
+	}
+	if got := messages(sc.rangeFindings("")); !reflect.DeepEqual(got, want) {
+		t.Errorf("rangeFindings() = %q, want %q", got, want)
+	}
+
+	findings := sc.rangeFindings(RangeCaret)
+	wantFixes := map[string]map[string]string{
+		"dependencies":    {"lodash": "^4.17.21"},
+		"devDependencies": {"jest": "^29.7.0"},
+	}
+	if got := sc.rangeFixes(findings, RangeCaret); !reflect.DeepEqual(got, wantFixes) {
+		t.Errorf("rangeFixes() = %v, want %v", got, wantFixes)
+	}
+}
+
+func TestRewriteRanges(t *testing.T) {
+	data := "{\n  \"dependencies\": {\n    \"lodash\": \"~4.17.21\",\n    \"react\":\"^18.2.0\"\n  },\n  \"devDependencies\": {\n    \"lodash\": \"4.17.21\"\n  }\n}\n"
+	got := string(rewriteRanges([]byte(data), map[string]map[string]string{
+		"dependencies":    {"lodash": "4.17.21", "react": "18.2.0"},
+		"devDependencies": {"lodash": "^4.17.21"},
+	}))
+	want := "{\n  \"dependencies\": {\n    \"lodash\": \"4.17.21\",\n    \"react\":\"18.2.0\"\n  },\n  \"devDependencies\": {\n    \"lodash\": \"^4.17.21\"\n  }\n}\n"
+	if got != want {
+		t.Errorf("rewriteRanges() = %q, want %q", got, want)
+	}
+}
+
+func TestFixRanges(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"dependencies\": {\n    \"express\": \"^4.18.2\",\n    \"lodash\": \"~4.17.21\",\n    \"left-pad\": \"^1.3.0\"\n  }\n}\n",
+		"index.js":     "require('express');\nrequire('lodash');\n",
+	})
+	out, err := exec.Command(deposePath, "--fix-ranges", "exact", "--no-history").CombinedOutput()
+	if err != nil {
+		t.Fatalf("depose --fix-ranges exact: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile("package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"dependencies\": {\n    \"express\": \"4.18.2\",\n    \"lodash\": \"4.17.21\"\n  }\n}\n"; string(data) != want {
+		t.Errorf("package.json = %q, want %q\n%s", data, want, out)
+	}
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+)
+
+// isReactNativeProject reports whether the project is a React Native or
+// an Expo app, whose native modules are linked without being imported.
+func isReactNativeProject(pkg Package) bool {
+	for _, name := range []string{"react-native", "expo"} {
+		if _, ok := pkg.Dependencies[name]; ok {
+			return true
+		}
+		if _, ok := pkg.DevDependencies[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nativeModuleMarkers are the files of the installed packages which the
+// autolinking of React Native or Expo links into the native app.
+var nativeModuleMarkers = []string{
+	"react-native.config.js", "expo-module.config.json",
+	"android/build.gradle", "android/build.gradle.kts", "*.podspec", "ios/*.podspec",
+}
+
+// isNativeModule reports whether the installed package ships native code.
+func (sc *scanContext) isNativeModule(pkgName string) bool {
+	dir := filepath.Join("node_modules", filepath.FromSlash(pkgName))
+	for _, marker := range nativeModuleMarkers {
+		if matches, _ := fs.Glob(sc.projectFS, fsPath(filepath.Join(dir, filepath.FromSlash(marker)))); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// markNativeModules marks the declared dependencies shipping native code
+// as used, since autolinking links them into the app even when no source
+// file imports them, e.g. react-native-screens for react-navigation.
+func (sc *scanContext) markNativeModules() {
+	for dependency := range sc.d.mp {
+		if sc.isNativeModule(dependency) {
+			sc.markAsUsed(dependency, EvidenceNative, Location{File: path.Join("node_modules", dependency)})
+		}
+	}
+}
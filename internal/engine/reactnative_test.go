@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"io"
+	"testing"
+)
+
+func TestReactNativeProject(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{
+  "dependencies": {
+    "react-native": "0.74.0",
+    "react-native-screens": "^3.31.0",
+    "react-native-svg": "^15.2.0",
+    "expo-camera": "~15.0.0",
+    "expo-font": "~12.0.0",
+    "lodash": "^4.17.21"
+  }
+}`,
+		"node_modules/react-native-screens/package.json":      `{}`,
+		"node_modules/react-native-screens/RNScreens.podspec": "",
+		"node_modules/react-native-svg/package.json":          `{}`,
+		"node_modules/react-native-svg/android/build.gradle":  "",
+		"node_modules/expo-font/package.json":                 `{}`,
+		"node_modules/expo-font/expo-module.config.json":      `{}`,
+		"node_modules/lodash/package.json":                    `{}`,
+		"app.json":                                            `{"expo": {"plugins": [["expo-camera", {"cameraPermission": "Allow"}]]}}`,
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.files = nil }()
+
+	sc.scanProject()
+	for dependency, want := range map[string]Evidence{
+		"react-native-screens": EvidenceNative, "react-native-svg": EvidenceNative,
+		"expo-font": EvidenceNative, "expo-camera": EvidenceConfig,
+	} {
+		if got := sc.d.evidence[dependency]; got != want {
+			t.Errorf("%s kept on %q evidence, want %q", dependency, got, want)
+		}
+	}
+	if sc.d.mp["lodash"] {
+		t.Error("lodash, which ships no native code, was kept")
+	}
+}
+
+func TestReactNativeConfigs(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"react-native-vector-icons": false, "crypto-browserify": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings("metro.config.js", []byte("module.exports = {\n  resolver: {\n    extraNodeModules: { crypto: require.resolve('crypto-browserify') },\n  },\n};\n"))
+	sc.markConfigStrings("react-native.config.js", []byte("module.exports = {\n  dependencies: {\n    'react-native-vector-icons': { platforms: { ios: null } },\n  },\n};\n"))
+	for dependency := range sc.d.mp {
+		if !sc.d.mp[dependency] {
+			t.Errorf("%s was not marked as used", dependency)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpClient is the HTTP client used to query the registry and GitHub.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// registryPackage is the part of the manifest of a published version
+// of a package used by depose.
+type registryPackage struct {
+	Name     string          `json:"name"`
+	Version  string          `json:"version"`
+	License  json.RawMessage `json:"license"`
+	Licenses json.RawMessage `json:"licenses"`
+}
+
+// fetchLatest fetches the manifest of the latest version of the package
+// published to the registry.
+func (sc *scanContext) fetchLatest(pkgName string) (registryPackage, error) {
+	var pkg registryPackage
+	url := strings.TrimSuffix(sc.registryURL, "/") + "/" + pkgName + "/latest"
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return pkg, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return pkg, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return pkg, fmt.Errorf("GET %s: %v", url, err)
+	}
+	return pkg, nil
+}
+
+// fetchPublishTimes fetches when every version of the package was
+// published to the registry, from the "time" of its document, which also
+// holds when the package was "created" and last "modified".
+func (sc *scanContext) fetchPublishTimes(pkgName string) (map[string]time.Time, error) {
+	var doc struct {
+		Time map[string]time.Time `json:"time"`
+	}
+	url := strings.TrimSuffix(sc.registryURL, "/") + "/" + pkgName
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("GET %s: %v", url, err)
+	}
+	return doc.Time, nil
+}
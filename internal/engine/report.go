@@ -0,0 +1,423 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Report is the result of a run of depose, rendered by the reporters.
+type Report struct {
+	Findings []Finding `json:"findings"`
+	// Suppressed holds the findings suppressed by the config or by
+	// ignore comments. It is only populated in verbose mode.
+	Suppressed []Finding `json:"suppressed,omitempty"`
+	// Usage maps every package found in the project to the
+	// locations where it is used. It is only populated in verbose mode.
+	Usage map[string][]Location `json:"usage,omitempty"`
+	// Verdicts tells why every used dependency is considered used, and
+	// in which mode that evidence counts. It is only populated in verbose mode.
+	Verdicts []Verdict `json:"verdicts,omitempty"`
+	// Explanations tells how the verdict of every declared dependency was
+	// reached. It is only populated with --explain.
+	Explanations []Explanation `json:"explanations,omitempty"`
+	// Outdated lists the kept dependencies which are behind their latest
+	// version. It is only populated with --outdated.
+	Outdated []outdatedDependency `json:"outdated,omitempty"`
+	// Alternatives suggests packages to replace the deprecated or
+	// heavyweight dependencies which are kept.
+	Alternatives []suggestion `json:"alternatives,omitempty"`
+	// LastPublish lists when the kept dependencies were last published,
+	// flagging the unmaintained ones. It is only populated with
+	// --last-publish.
+	LastPublish []publishStatus `json:"lastPublish,omitempty"`
+	// BundleSizes lists the sizes of the kept runtime dependencies, the
+	// heaviest first. It is only populated with --bundle-size.
+	BundleSizes []bundleSize `json:"bundleSizes,omitempty"`
+	// Groups holds the findings grouped by --group-by, e.g. by their
+	// owners, read from CODEOWNERS.
+	Groups []findingGroup `json:"groups,omitempty"`
+	// CLIOnly lists the declared dependencies which are never imported,
+	// but whose executables are run by Makefiles, Dockerfiles or shell
+	// scripts, along with the commands running them.
+	CLIOnly []CLIUsage `json:"cliOnly,omitempty"`
+	// ScriptOnly lists the declared dependencies which are kept only
+	// because the scripts of package.json name them. They are reported
+	// as unused instead with --treat-script-only-as-unused.
+	ScriptOnly []ScriptOnlyUsage `json:"scriptOnly,omitempty"`
+	// Skipped lists the directories and files left out of the scan by
+	// --max-depth and --max-files, whose findings may be missing.
+	Skipped []skippedPath `json:"skipped,omitempty"`
+	// Diagnostics lists the non-fatal errors of the run, e.g. the files
+	// which were skipped.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Status is the state of a dependency of the project.
+type Status string
+
+const (
+	// StatusUsed is a declared dependency which is used.
+	StatusUsed Status = "used"
+	// StatusUnused is a declared dependency which nothing uses.
+	StatusUnused Status = "unused"
+	// StatusMissing is a package which is used, but not declared.
+	StatusMissing Status = "missing"
+	// StatusPhantom is a package which is used and installed,
+	// but only as a transitive dependency.
+	StatusPhantom Status = "phantom"
+)
+
+// DependencyStatus tells whether a package is used, and why.
+type DependencyStatus struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Section is the section of the manifest declaring the dependency,
+	// when it is known, e.g. "devDependencies".
+	Section string `json:"section,omitempty"`
+	// Evidence is the kind of evidence a used dependency is kept on,
+	// and Mode the mode it counts in.
+	Evidence Evidence `json:"evidence,omitempty"`
+	Mode     string   `json:"mode,omitempty"`
+	// Suppressed reports whether the finding about the dependency
+	// was suppressed by the config or by ignore comments.
+	Suppressed bool       `json:"suppressed,omitempty"`
+	Locations  []Location `json:"locations,omitempty"`
+}
+
+// ruleStatuses maps the rules of the findings about a dependency
+// to the status of the dependency.
+var ruleStatuses = map[string]Status{
+	RuleUnusedDependency:    StatusUnused,
+	RuleUnusedDevDependency: StatusUnused,
+	RuleMissingDependency:   StatusMissing,
+	RulePhantomDependency:   StatusPhantom,
+}
+
+// Dependencies lists the declared dependencies and the used packages of
+// the project, sorted by name, from the verdicts and the findings of the
+// report. The used ones are only known in verbose mode.
+func (r *Report) Dependencies() []DependencyStatus {
+	deps := []DependencyStatus{}
+	for _, v := range r.Verdicts {
+		deps = append(deps, DependencyStatus{
+			Name: v.Package, Status: StatusUsed, Evidence: v.Evidence, Mode: v.Mode, Locations: r.Usage[v.Package],
+		})
+	}
+	add := func(f Finding, suppressed bool) {
+		status, ok := ruleStatuses[f.RuleID]
+		if !ok {
+			return
+		}
+		deps = append(deps, DependencyStatus{
+			Name: f.Package, Status: status, Section: f.Section, Suppressed: suppressed, Locations: f.Locations,
+		})
+	}
+	for _, f := range r.Findings {
+		add(f, false)
+	}
+	for _, f := range r.Suppressed {
+		add(f, true)
+	}
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+// Reporter renders the report to the given writer.
+type Reporter func(w io.Writer, r *Report) error
+
+// textReportTemplate is the Go template of the text report, which
+// --format-template replaces, e.g. to change its wording or language.
+const textReportTemplate = `
+{{- if not .Findings}}No findings.
+{{end}}
+{{- if .Groups}}
+	{{- range .Groups}}{{.Name}} ({{len .Findings}} finding(s)):
+{{range .Findings}}{{template "finding" .}}{{end}}
+	{{- end}}
+{{- else}}
+	{{- range .Findings}}{{template "finding" .}}{{end}}
+{{- end}}
+{{- with .Findings}}{{len .}} finding(s)
+{{end}}
+{{- with .Suppressed}}Suppressed findings:
+{{range .}}{{template "finding" .}}    suppressed by: {{.Suppression}}
+{{end}}{{end}}
+{{- with .Usage}}Usage:
+{{range $name, $locations := .}}  {{$name}}
+{{range $locations}}    at {{.}}{{if .SideEffect}} (side-effect usage){{end}}{{if .LowConfidence}} (low confidence){{end}}
+{{end}}{{end}}{{end}}
+{{- with .Verdicts}}Used dependencies:
+{{range .}}  {{.Package}} ({{.Mode}}: {{.Evidence}})
+{{end}}{{end}}
+{{- with .Explanations}}Explanations:
+{{range .}}  {{.Package}}
+    declared in {{.Section}} at {{.Declared}}
+{{range .Evidence}}    {{.Detector}} evidence at {{.Location}} ({{.Confidence}} confidence)
+{{else}}    no evidence
+{{end}}    verdict: {{.Verdict}}
+{{end}}{{end}}
+{{- with .CLIOnly}}CLI-only usage:
+{{range .}}  {{.Package}} ({{.References}} reference(s){{if .DockerfileOnly}}, Dockerfile only{{end}})
+{{range .Locations}}    at {{.}}
+{{end}}{{end}}{{end}}
+{{- with .ScriptOnly}}Script-only usage:
+{{range .}}  {{.Package}} (script-only)
+{{range .Locations}}    at {{.}}
+{{end}}{{end}}{{end}}
+{{- with .Skipped}}Skipped by the limits of the scan:
+{{range .}}  {{.Path}} ({{.Reason}})
+{{end}}{{end}}
+{{- with .Outdated}}Outdated dependencies:
+{{range .}}  {{.}}
+{{end}}{{end}}
+{{- with .Alternatives}}Suggested alternatives:
+{{range .}}  {{.}}
+{{end}}{{end}}
+{{- with .LastPublish}}Last published:
+{{range .}}  {{.}}
+{{end}}{{end}}
+{{- with .BundleSizes}}Bundle size:
+{{range .}}  {{.}}
+{{end}}{{end}}
+{{- define "finding"}}{{.Severity}} [{{.RuleID}}] {{.Message}}
+{{range .Locations}}    at {{.}}
+{{end}}
+	{{- with .LastReference}}    history: {{.}}
+{{end}}
+	{{- range .ManagedBy}}    managed by: {{.}}
+{{end}}
+	{{- with .SuggestedFix}}    fix: {{.}}
+{{end}}
+{{- end}}`
+
+// reportText prints a human readable summary of the findings, rendered
+// with the template of --format-template, if any, or textReportTemplate.
+func (sc *scanContext) reportText(w io.Writer, r *Report) error {
+	tmpl, err := parseReportTemplate(sc.formatTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, r)
+}
+
+// parseReportTemplate parses the template of the text report read from
+// the file, or textReportTemplate when it is empty. The functions of the
+// templates of the notifications are available, e.g. json.
+func parseReportTemplate(file string) (*template.Template, error) {
+	text := textReportTemplate
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("report").Funcs(notificationFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// reportJSON writes the report as a JSON document.
+func reportJSON(w io.Writer, r *Report) error {
+	out := *r
+	if out.Findings == nil {
+		out.Findings = []Finding{}
+	}
+	if out.Diagnostics == nil {
+		out.Diagnostics = []Diagnostic{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// reportGitHub writes the findings as GitHub Actions workflow commands,
+// which are shown as annotations on the pull request. The script-only
+// dependencies are annotated as notices.
+//
+// Suppressed findings are never annotated.
+func reportGitHub(w io.Writer, r *Report) error {
+	for _, f := range r.Findings {
+		level := "warning"
+		switch f.Severity {
+		case SeverityError:
+			level = "error"
+		case SeverityNote:
+			level = "notice"
+		}
+		props := "title=" + escapeWorkflowProperty(f.RuleID)
+		if len(f.Locations) > 0 {
+			props = fmt.Sprintf("file=%s,line=%d,%s", escapeWorkflowProperty(f.Locations[0].File), f.Locations[0].Line, props)
+		}
+		message := f.Message
+		if len(f.Owners) > 0 {
+			message += " (owners: " + strings.Join(f.Owners, " ") + ")"
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", level, props, escapeWorkflowData(message)); err != nil {
+			return err
+		}
+	}
+	for _, u := range r.ScriptOnly {
+		props := "title=script-only"
+		if len(u.Locations) > 0 {
+			props = fmt.Sprintf("file=%s,line=%d,%s", escapeWorkflowProperty(u.Locations[0].File), u.Locations[0].Line, props)
+		}
+		if _, err := fmt.Fprintf(w, "::notice %s::%s\n", props, escapeWorkflowData(u.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeWorkflowData escapes the characters which have
+// a special meaning in GitHub workflow commands.
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeWorkflowProperty escapes the characters which have a special
+// meaning in the property values of GitHub workflow commands.
+func escapeWorkflowProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// The types below describe the subset of SARIF 2.1.0 used by depose.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool           `json:"tool"`
+	Results    []sarifResult       `json:"results"`
+	Properties *sarifRunProperties `json:"properties,omitempty"`
+}
+
+// sarifRunProperties is the property bag of a run.
+type sarifRunProperties struct {
+	ScriptOnly []string `json:"scriptOnly,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations,omitempty"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+	Properties   *sarifProperties   `json:"properties,omitempty"`
+}
+
+// sarifProperties is the property bag of a result.
+type sarifProperties struct {
+	Owners []string `json:"owners,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// reportSARIF writes the findings as a SARIF log,
+// which can be uploaded to code scanning services.
+//
+// Suppressed findings are included as results with suppressions,
+// so code scanning services show them as dismissed. The script-only
+// dependencies are listed in the properties of the run.
+func (sc *scanContext) reportSARIF(w io.Writer, r *Report) error {
+	driver := sarifDriver{
+		Name:           "depose",
+		InformationURI: "https://github.com/CoderParth/depose",
+	}
+	for _, rule := range sc.rules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: rule.ID, ShortDescription: sarifMessage{Text: rule.Description}})
+	}
+
+	results := []sarifResult{}
+	for _, f := range append(append([]Finding{}, r.Findings...), r.Suppressed...) {
+		result := sarifResult{
+			RuleID:  f.RuleID,
+			Level:   string(f.Severity),
+			Message: sarifMessage{Text: f.Message},
+		}
+		for _, l := range f.Locations {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: l.File},
+			}}
+			if l.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: l.Line}
+			}
+			result.Locations = append(result.Locations, loc)
+		}
+		if len(f.Owners) > 0 {
+			result.Properties = &sarifProperties{Owners: f.Owners}
+		}
+		if f.Suppression != "" {
+			kind := "external"
+			if strings.Contains(f.Suppression, ignoreDirective) {
+				kind = "inSource"
+			}
+			result.Suppressions = []sarifSuppression{{Kind: kind, Justification: f.Suppression}}
+		}
+		results = append(results, result)
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: results}
+	for _, u := range r.ScriptOnly {
+		if run.Properties == nil {
+			run.Properties = &sarifRunProperties{}
+		}
+		run.Properties.ScriptOnly = append(run.Properties.ScriptOnly, u.Package)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
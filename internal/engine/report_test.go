@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testFindings = []Finding{
+	{
+		RuleID:       RuleUnusedDependency,
+		Severity:     SeverityWarning,
+		Package:      "pg",
+		Section:      "dependencies",
+		Message:      `"pg" is declared in dependencies but never used`,
+		Locations:    []Location{{File: "package.json", Line: 17}},
+		SuggestedFix: `remove "pg" from dependencies`,
+	},
+	{
+		RuleID:    RuleMissingDependency,
+		Severity:  SeverityError,
+		Package:   "zod",
+		Message:   `"zod" is used but not declared in package.json`,
+		Locations: []Location{{File: "src/app.js", Line: 3}},
+	},
+}
+
+func TestReportGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	if err := reportGitHub(&buf, &Report{Findings: testFindings}); err != nil {
+		t.Fatalf("reportGitHub failed: %v", err)
+	}
+
+	want := "::warning file=package.json,line=17,title=unused-dependency::\"pg\" is declared in dependencies but never used\n" +
+		"::error file=src/app.js,line=3,title=missing-dependency::\"zod\" is used but not declared in package.json\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestReportText(t *testing.T) {
+	sc := newScanContext()
+	var buf bytes.Buffer
+	if err := sc.reportText(&buf, &Report{Findings: testFindings}); err != nil {
+		t.Fatalf("reportText failed: %v", err)
+	}
+	want := "warning [unused-dependency] \"pg\" is declared in dependencies but never used\n" +
+		"    at package.json:17\n" +
+		"    fix: remove \"pg\" from dependencies\n" +
+		"error [missing-dependency] \"zod\" is used but not declared in package.json\n" +
+		"    at src/app.js:3\n" +
+		"2 finding(s)\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestReportTextFormatTemplate(t *testing.T) {
+	sc := newScanContext()
+	file := filepath.Join(t.TempDir(), "report.tmpl")
+	tmpl := `{{range .Findings}}{{.Package}} : {{if eq .RuleID "unused-dependency"}}inutilisé{{else}}manquant{{end}}
+{{end}}{{len .Findings}} problème(s)
+`
+	if err := os.WriteFile(file, []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sc.formatTemplate = file
+	defer func() { sc.formatTemplate = "" }()
+
+	var buf bytes.Buffer
+	if err := sc.reportText(&buf, &Report{Findings: testFindings}); err != nil {
+		t.Fatalf("reportText failed: %v", err)
+	}
+	if want := "pg : inutilisé\nzod : manquant\n2 problème(s)\n"; buf.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if err := os.WriteFile(file, []byte("{{.Findings"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.reportText(&buf, &Report{}); err == nil {
+		t.Error("reportText accepted an invalid template")
+	}
+}
+
+func TestReportGitHubEscapesProperties(t *testing.T) {
+	var buf bytes.Buffer
+	r := &Report{Findings: []Finding{{
+		RuleID:    RuleMissingDependency,
+		Severity:  SeverityError,
+		Message:   "100% missing",
+		Locations: []Location{{File: "src/a,b:c.js", Line: 1}},
+	}}}
+	if err := reportGitHub(&buf, r); err != nil {
+		t.Fatalf("reportGitHub failed: %v", err)
+	}
+
+	want := "::error file=src/a%2Cb%3Ac.js,line=1,title=missing-dependency::100%25 missing\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestReportSARIF(t *testing.T) {
+	sc := newScanContext()
+	var buf bytes.Buffer
+	if err := sc.reportSARIF(&buf, &Report{Findings: testFindings}); err != nil {
+		t.Fatalf("reportSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to unmarshal SARIF output: %v", err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[1].RuleID != RuleMissingDependency || results[1].Level != "error" {
+		t.Fatalf("unexpected result: %+v", results[1])
+	}
+	if region := results[1].Locations[0].PhysicalLocation.Region; region == nil || region.StartLine != 3 {
+		t.Fatalf("unexpected region: %+v", region)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	tests := map[string]string{
+		"express":                "express",
+		"lodash/fp":              "lodash",
+		"@babel/core":            "@babel/core",
+		"@babel/core/lib/config": "@babel/core",
+	}
+	for specifier, want := range tests {
+		if got := packageName(specifier); got != want {
+			t.Errorf("packageName(%q) = %q, want %q", specifier, got, want)
+		}
+	}
+}
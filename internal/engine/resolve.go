@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// installedPackage is the part of the package.json of an installed
+// package used to resolve specifiers, to estimate its bundle size, and to
+// find the real name of the git and tarball dependencies.
+type installedPackage struct {
+	Exports json.RawMessage `json:"exports"`
+	Name    string          `json:"name"`
+	Version string          `json:"version"`
+	Main    string          `json:"main"`
+	Module  string          `json:"module"`
+}
+
+// readInstalledPackage reads the package.json of the package installed in dir.
+func (sc *scanContext) readInstalledPackage(dir string) *installedPackage {
+	key := dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		key = abs
+	}
+	sc.installedPackages.mu.Lock()
+	defer sc.installedPackages.mu.Unlock()
+
+	if pkg, ok := sc.installedPackages.mp[key]; ok {
+		return pkg
+	}
+	var pkg *installedPackage
+	if data, err := sc.readProjectFile(filepath.Join(dir, "package.json")); err == nil {
+		pkg = &installedPackage{}
+		if json.Unmarshal(data, pkg) != nil {
+			pkg = &installedPackage{}
+		}
+	}
+	sc.installedPackages.mp[key] = pkg
+	return pkg
+}
+
+// resolvePackage looks for the package in the node_modules directories of
+// the directory of the file and of its parents, up to the project root,
+// like Node.js does. It returns the directory the package is installed in.
+func (sc *scanContext) resolvePackage(file, pkgName string) (string, *installedPackage) {
+	dir := filepath.Dir(file)
+	for {
+		pkgDir := filepath.Join(dir, "node_modules", filepath.FromSlash(pkgName))
+		if pkg := sc.readInstalledPackage(pkgDir); pkg != nil {
+			return pkgDir, pkg
+		}
+		if dir == "." || dir == string(filepath.Separator) || dir == filepath.Dir(dir) {
+			return "", nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// isUnresolvableSpecifier reports whether the specifier, found in the file,
+// refers to an installed package which does not provide it, e.g. a subpath
+// which is not part of the "exports" of the package, or a file missing from
+// a package without exports.
+//
+// Specifiers of packages which are not installed are not reported,
+// since they are already reported as missing.
+func (sc *scanContext) isUnresolvableSpecifier(specifier, file string) bool {
+	pkgName := packageName(specifier)
+	pkgDir, pkg := sc.resolvePackage(file, pkgName)
+	if pkg == nil {
+		return false
+	}
+	subpath := "." + strings.TrimPrefix(specifier, pkgName)
+
+	if len(pkg.Exports) > 0 && string(pkg.Exports) != "null" {
+		var exports any
+		if err := json.Unmarshal(pkg.Exports, &exports); err != nil {
+			return false
+		}
+		return !isExported(exports, subpath)
+	}
+	return subpath != "." && !sc.fileExists(filepath.Join(pkgDir, filepath.FromSlash(subpath)))
+}
+
+// isExported reports whether the subpath, e.g. "./fp", is exported by the
+// "exports" field of a package.json, following the resolution algorithm of
+// Node.js. Every condition is accepted, since depose does not know in which
+// environment the file runs.
+func isExported(exports any, subpath string) bool {
+	subpaths, ok := exports.(map[string]any)
+	if !ok || !hasSubpathKeys(subpaths) {
+		// The exports are the target of the main entry point, "." only.
+		return subpath == "." && hasTarget(exports)
+	}
+
+	if target, ok := subpaths[subpath]; ok && !strings.Contains(subpath, "*") {
+		return hasTarget(target)
+	}
+	// Subpath patterns, e.g. "./features/*.js", the most specific one winning.
+	bestKey := ""
+	for key := range subpaths {
+		prefix, suffix, ok := strings.Cut(key, "*")
+		if !ok || strings.Contains(suffix, "*") {
+			continue
+		}
+		if len(subpath) >= len(key) && strings.HasPrefix(subpath, prefix) && strings.HasSuffix(subpath, suffix) &&
+			patternKeyLess(bestKey, key) {
+			bestKey = key
+		}
+	}
+	if bestKey != "" {
+		return hasTarget(subpaths[bestKey])
+	}
+	// Legacy folder mappings, e.g. "./lib/".
+	for key, target := range subpaths {
+		if strings.HasSuffix(key, "/") && strings.HasPrefix(subpath, key) {
+			return hasTarget(target)
+		}
+	}
+	return false
+}
+
+// packageImportsTargets returns the targets which the "#" specifier maps to
+// in the "imports" field of package.json, under every condition, e.g.
+// "undici" and "./src/fetch.js" for "#fetch" with the following field:
+//
+//	"imports": {
+//	  "#fetch": {"node": "undici", "default": "./src/fetch.js"}
+//	}
+//
+// Specifiers not starting with "#" are not internal.
+func (sc *scanContext) packageImportsTargets(specifier string) ([]string, bool) {
+	if !strings.HasPrefix(specifier, "#") {
+		return nil, false
+	}
+
+	key, match := specifier, ""
+	if _, ok := sc.manifest.Imports[specifier]; !ok || strings.Contains(specifier, "*") {
+		key = ""
+		for k := range sc.manifest.Imports {
+			prefix, suffix, ok := strings.Cut(k, "*")
+			if ok && !strings.Contains(suffix, "*") && len(specifier) >= len(k) &&
+				strings.HasPrefix(specifier, prefix) && strings.HasSuffix(specifier, suffix) && patternKeyLess(key, k) {
+				key, match = k, specifier[len(prefix):len(specifier)-len(suffix)]
+			}
+		}
+		if key == "" {
+			return nil, true
+		}
+	}
+
+	var targets []string
+	for _, target := range targetStrings(sc.manifest.Imports[key]) {
+		if strings.HasPrefix(target, "#") {
+			// Targets can't be internal specifiers themselves.
+			continue
+		}
+		targets = append(targets, strings.ReplaceAll(target, "*", match))
+	}
+	sort.Strings(targets)
+	return targets, true
+}
+
+// targetStrings returns the strings of an export or import target,
+// under every condition.
+func targetStrings(target any) []string {
+	switch t := target.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var result []string
+		for _, item := range t {
+			result = append(result, targetStrings(item)...)
+		}
+		return result
+	case map[string]any:
+		// Conditions are visited in alphabetical order, for stable results.
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var result []string
+		for _, key := range keys {
+			result = append(result, targetStrings(t[key])...)
+		}
+		return result
+	}
+	return nil
+}
+
+// hasSubpathKeys reports whether the keys of the exports object are subpaths,
+// rather than conditions.
+func hasSubpathKeys(exports map[string]any) bool {
+	for key := range exports {
+		return strings.HasPrefix(key, ".")
+	}
+	return false
+}
+
+// patternKeyLess orders the subpath patterns matching a specifier,
+// like PATTERN_KEY_COMPARE of Node.js: the longer prefix wins,
+// then the longer pattern.
+func patternKeyLess(a, b string) bool {
+	if a == "" {
+		return true
+	}
+	prefixA, _, _ := strings.Cut(a, "*")
+	prefixB, _, _ := strings.Cut(b, "*")
+	if len(prefixA) != len(prefixB) {
+		return len(prefixA) < len(prefixB)
+	}
+	return len(a) < len(b)
+}
+
+// hasTarget reports whether an export target resolves to a file under any
+// condition. A null target explicitly hides the subpath.
+func hasTarget(target any) bool {
+	switch t := target.(type) {
+	case string:
+		return strings.HasPrefix(t, "./")
+	case []any:
+		for _, item := range t {
+			if hasTarget(item) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range t {
+			if hasTarget(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fileExists reports whether the path resolves to a file, trying the
+// extensions and index files Node.js tries for CommonJS modules.
+func (sc *scanContext) fileExists(p string) bool {
+	candidates := []string{p}
+	for _, ext := range []string{".js", ".json", ".node", ".cjs", ".mjs", ".d.ts"} {
+		candidates = append(candidates, p+ext, filepath.Join(p, "index"+ext))
+	}
+	candidates = append(candidates, filepath.Join(p, "package.json"))
+	for _, c := range candidates {
+		if info, err := sc.statProjectFile(c); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
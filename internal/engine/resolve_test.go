@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles writes the files, relative to the current directory.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIsUnresolvableSpecifier(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"node_modules/modern/package.json": `{"exports": {
+			".": {"import": "./index.mjs", "require": "./index.cjs"},
+			"./fp": {"import": "./fp.mjs", "require": null},
+			"./features/*.js": "./src/*.js",
+			"./features/private/*": null,
+			"./legacy/": "./legacy/",
+			"./package.json": "./package.json"
+		}}`,
+		"node_modules/sugar/package.json":          `{"exports": "./index.js"}`,
+		"node_modules/old/package.json":            `{"main": "lib/index.js"}`,
+		"node_modules/old/lib/util.js":             ``,
+		"node_modules/@scope/ui/package.json":      `{"exports": {"./button": "./button.js"}}`,
+		"packages/a/node_modules/old/package.json": `{"exports": {"./only": "./only.js"}}`,
+	})
+
+	tests := []struct {
+		specifier string
+		file      string
+		want      bool
+	}{
+		{"modern", "src/index.js", false},
+		{"modern/fp", "src/index.js", false},
+		{"modern/features/x.js", "src/index.js", false},
+		{"modern/features/private/x", "src/index.js", true},
+		{"modern/legacy/a.js", "src/index.js", false},
+		{"modern/internal", "src/index.js", true},
+		{"sugar", "index.js", false},
+		{"sugar/lib/x", "index.js", true},
+		{"old", "index.js", false},
+		{"old/lib/util", "index.js", false},
+		{"old/lib/missing", "index.js", true},
+		{"@scope/ui", "index.js", true},
+		{"@scope/ui/button", "index.js", false},
+		// The nested node_modules of the workspace wins over the root one.
+		{"old/lib/util", "packages/a/src/index.js", true},
+		{"old/only", "packages/a/src/index.js", false},
+		{"not-installed/anything", "index.js", false},
+	}
+	for _, tt := range tests {
+		if got := sc.isUnresolvableSpecifier(tt.specifier, tt.file); got != tt.want {
+			t.Errorf("isUnresolvableSpecifier(%q, %q) = %v, want %v", tt.specifier, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestPackageImportsTargets(t *testing.T) {
+	sc := newScanContext()
+	sc.manifest = Package{Imports: map[string]any{
+		"#fetch":       map[string]any{"node": "undici", "default": "./src/fetch.js"},
+		"#db":          "./src/db.js",
+		"#utils/*":     "./src/utils/*.js",
+		"#lodash/*":    "lodash/*",
+		"#internal/*":  nil,
+		"#self":        "#db",
+		"#polyfills/*": []any{"core-js/stable/*", "./polyfills/*.js"},
+	}}
+	defer func() { sc.manifest = Package{} }()
+
+	tests := []struct {
+		specifier string
+		want      []string
+		ok        bool
+	}{
+		{"lodash", nil, false},
+		{"#fetch", []string{"./src/fetch.js", "undici"}, true},
+		{"#db", []string{"./src/db.js"}, true},
+		{"#utils/date", []string{"./src/utils/date.js"}, true},
+		{"#lodash/fp", []string{"lodash/fp"}, true},
+		{"#polyfills/array", []string{"./polyfills/array.js", "core-js/stable/array"}, true},
+		{"#internal/x", nil, true},
+		{"#self", nil, true},
+		{"#unknown", nil, true},
+	}
+	for _, tt := range tests {
+		got, ok := sc.packageImportsTargets(tt.specifier)
+		if !reflect.DeepEqual(got, tt.want) || ok != tt.ok {
+			t.Errorf("packageImportsTargets(%q) = %v, %v, want %v, %v", tt.specifier, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,665 @@
+package engine
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// configResolver expands the shorthand package names used by the config
+// files of a tool, e.g. "airbnb" in .eslintrc stands for "eslint-config-airbnb".
+// Config files whose names are not recognized by isConfigFile, e.g.
+// .storybook/main.js, are read as config files when a resolver matches them.
+type configResolver struct {
+	// matches reports whether the file is a config file of the tool.
+	matches func(file string) bool
+	// expand returns the package names a string of the config may stand for.
+	// It is nil for the tools which only use full package names.
+	expand func(name string) []string
+	// names returns the package names found on a line of the config
+	// besides its strings, e.g. unquoted keys. It may be nil.
+	names func(line string) []string
+	// isConfigPackage reports whether the package is a shareable config,
+	// whose own config is followed, e.g. the plugins required by
+	// eslint-config-airbnb are used by the projects extending it.
+	// It is nil for the tools whose configs are not followed.
+	isConfigPackage func(pkgName string) bool
+	// tools are the packages reading the config files, which are used
+	// even when the files don't name them, e.g. prisma by schema.prisma.
+	// It may be nil.
+	tools []string
+}
+
+// resolversFor returns the resolvers of the config file.
+func (sc *scanContext) resolversFor(file string) []configResolver {
+	var resolvers []configResolver
+	for _, r := range sc.configResolvers {
+		if r.matches(file) {
+			resolvers = append(resolvers, r)
+		}
+	}
+	return resolvers
+}
+
+// yamlValueRe matches the unquoted values of YAML, e.g. "extends: airbnb"
+// or "- prettier", which config files commonly use for package names.
+var yamlValueRe = regexp.MustCompile(`^\s*(?:-\s+|[\w-]+:\s+)([@\w][^\s#"',\[\]{}]*)\s*$`)
+
+// configNames returns the strings of a line of a config file which may be
+// package names: its quoted strings, the unquoted values of YAML, and the
+// names found by the resolvers of the file.
+func configNames(line string, yaml bool, resolvers []configResolver) []string {
+	var names []string
+	for _, m := range configStringRe.FindAllStringSubmatch(line, -1) {
+		names = append(names, m[1])
+	}
+	if yaml {
+		if m := yamlValueRe.FindStringSubmatch(line); m != nil {
+			names = append(names, m[1])
+		}
+	}
+	for _, r := range resolvers {
+		if r.names != nil {
+			names = append(names, r.names(line)...)
+		}
+	}
+	return names
+}
+
+// isYAMLConfig reports whether the config file may be written in YAML,
+// e.g. .eslintrc.yml or an .eslintrc without extension.
+func isYAMLConfig(file string) bool {
+	switch filepath.Ext(file) {
+	case ".yml", ".yaml":
+		return true
+	case "":
+		return strings.HasPrefix(filepath.Base(file), ".")
+	}
+	return false
+}
+
+// expandConfigName returns the package names a string of a config file
+// stands for: the string itself, and its expansions by the resolvers.
+func expandConfigName(name string, resolvers []configResolver) []string {
+	names := []string{name}
+	for _, r := range resolvers {
+		if r.expand != nil {
+			names = append(names, r.expand(name)...)
+		}
+	}
+	return names
+}
+
+// followConfigPackage marks the declared dependencies referenced by the
+// config of an installed shareable config package as used, following the
+// configs it extends in turn. They are recorded at the location of the
+// project config extending the package.
+//
+// The mutex of "d" must not be held by the caller.
+func (sc *scanContext) followConfigPackage(pkgName string, r configResolver, loc Location, visited map[string]bool) {
+	if r.isConfigPackage == nil || visited[pkgName] || !r.isConfigPackage(pkgName) {
+		return
+	}
+	visited[pkgName] = true
+
+	data, ok := sc.readPackageEntry(pkgName)
+	if !ok {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		for _, name := range configNames(line, false, []configResolver{r}) {
+			for _, candidate := range expandConfigName(name, []configResolver{r}) {
+				dependency, ok := sc.lang.normalize(candidate)
+				if !ok {
+					continue
+				}
+				sc.d.mu.Lock()
+				if _, declared := sc.d.mp[dependency]; declared {
+					sc.markAsUsed(dependency, EvidenceConfig, loc)
+					sc.d.usages[dependency] = append(sc.d.usages[dependency], loc)
+				}
+				sc.d.mu.Unlock()
+				sc.followConfigPackage(dependency, r, loc, visited)
+			}
+		}
+	}
+}
+
+// readPackageEntry reads the entry file of a package installed in the
+// node_modules of the project, i.e. its "main", or index.js.
+func (sc *scanContext) readPackageEntry(pkgName string) ([]byte, bool) {
+	dir := filepath.Join("node_modules", filepath.FromSlash(pkgName))
+	var pkg struct {
+		Main string `json:"main"`
+	}
+	if data, err := sc.readProjectFile(filepath.Join(dir, "package.json")); err != nil || json.Unmarshal(data, &pkg) != nil {
+		return nil, false
+	}
+	main := pkg.Main
+	if main == "" {
+		main = "index.js"
+	}
+	entry, ok := sc.resolveLocalFile(filepath.Join(dir, "package.json"), "./"+strings.TrimPrefix(main, "./"))
+	if !ok {
+		return nil, false
+	}
+	data, err := sc.readProjectFile(entry)
+	return data, err == nil
+}
+
+// eslintResolver resolves the names of the shareable configs and plugins
+// of ESLint, following its naming conventions:
+// https://eslint.org/docs/latest/use/configure/configuration-files-deprecated
+var eslintResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return strings.HasPrefix(name, ".eslintrc") || strings.HasPrefix(name, "eslint.config.")
+	},
+	expand: eslintNames,
+	isConfigPackage: func(pkgName string) bool {
+		name := pkgName[strings.LastIndex(pkgName, "/")+1:]
+		return strings.HasPrefix(name, "eslint-config")
+	},
+}
+
+// eslintNames returns the packages a name of an ESLint config may stand for:
+// the shareable config of "extends", e.g. "airbnb" for eslint-config-airbnb,
+// the plugin of "plugins", e.g. "import" for eslint-plugin-import, and the
+// plugin providing a config, e.g. "plugin:react/recommended" for
+// eslint-plugin-react.
+func eslintNames(name string) []string {
+	if strings.HasPrefix(name, "eslint:") || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(name, "plugin:"); ok {
+		// The name of the config follows the last slash, e.g. "plugin:@scope/name/recommended".
+		if slash := strings.LastIndex(rest, "/"); slash != -1 {
+			rest = rest[:slash]
+		}
+		return []string{prefixedName(rest, "eslint-plugin")}
+	}
+	if rest := name[strings.LastIndex(name, "/")+1:]; strings.HasPrefix(rest, "eslint-config") || strings.HasPrefix(rest, "eslint-plugin") {
+		// Full names are matched as they are.
+		return nil
+	}
+	return []string{prefixedName(name, "eslint-config"), prefixedName(name, "eslint-plugin")}
+}
+
+// prefixedName expands a short package name with the prefix of the packages
+// of a tool, e.g. "airbnb" to "eslint-config-airbnb", "@scope" to
+// "@scope/eslint-config" and "@scope/name" to "@scope/eslint-config-name".
+// Names already starting with the prefix are left as they are.
+func prefixedName(name, prefix string) string {
+	scope, rest := "", name
+	if strings.HasPrefix(name, "@") {
+		var ok bool
+		if scope, rest, ok = strings.Cut(name, "/"); !ok {
+			return path.Join(name, prefix)
+		}
+		scope += "/"
+	}
+	if strings.HasPrefix(rest, prefix) {
+		return name
+	}
+	return scope + prefix + "-" + rest
+}
+
+// babelResolver resolves the names of the presets and plugins of Babel,
+// following its name normalization:
+// https://babeljs.io/docs/options#name-normalization
+var babelResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return strings.HasPrefix(name, ".babelrc") || strings.HasPrefix(name, "babel.config.")
+	},
+	expand: babelNames,
+}
+
+// babelNames returns the packages a name of a Babel config may stand for,
+// either as a preset or as a plugin, e.g. "@babel/env" for
+// @babel/preset-env, "react-app" for babel-preset-react-app, or
+// "module:metro-react-native-babel-preset" for the package named as is.
+func babelNames(name string) []string {
+	if module, ok := strings.CutPrefix(name, "module:"); ok {
+		return []string{module}
+	}
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") {
+		return nil
+	}
+	rest := name[strings.LastIndex(name, "/")+1:]
+	if strings.HasPrefix(rest, "babel-preset") || strings.HasPrefix(rest, "babel-plugin") ||
+		strings.HasPrefix(name, "@babel/preset-") || strings.HasPrefix(name, "@babel/plugin-") {
+		// Full names are matched as they are.
+		return nil
+	}
+	var names []string
+	for _, kind := range []string{"preset", "plugin"} {
+		if rest, ok := strings.CutPrefix(name, "@babel/"); ok {
+			// Packages of the @babel scope drop the "babel-" of the prefix.
+			names = append(names, "@babel/"+kind+"-"+rest)
+		} else {
+			names = append(names, prefixedName(name, "babel-"+kind))
+		}
+	}
+	return names
+}
+
+// postcssResolver finds the plugins of PostCSS configs, which are either
+// listed by name, required, or the keys of the "plugins" object, e.g.
+// plugins: { tailwindcss: {}, autoprefixer: {} }.
+//
+// Tailwind configs need no resolver: their plugins are required, and
+// the plugins of Tailwind 4, loaded with @plugin, are found in stylesheets.
+var postcssResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return strings.HasPrefix(name, ".postcssrc") || strings.HasPrefix(name, "postcss.config.")
+	},
+	names: objectKeys,
+}
+
+// objectKeyRe matches the unquoted keys of JavaScript objects and of YAML
+// mappings, which may be package names, e.g. "autoprefixer: {}".
+var objectKeyRe = regexp.MustCompile(`(?:^|[{,])\s*(@?[A-Za-z_$][\w$./-]*)\s*:`)
+
+// objectKeys returns the unquoted keys of the objects of the line.
+func objectKeys(line string) []string {
+	var keys []string
+	for _, m := range objectKeyRe.FindAllStringSubmatch(line, -1) {
+		keys = append(keys, m[1])
+	}
+	return keys
+}
+
+// storybookResolver matches the main config of Storybook, whose addons
+// and framework are packages named by strings, e.g.
+// addons: ['@storybook/addon-essentials'].
+//
+// The configs of Cypress and Playwright need no resolver: their plugins
+// are required, and the reporters of Playwright are quoted strings of
+// playwright.config.ts, read like any other config file.
+var storybookResolver = configResolver{
+	matches: func(file string) bool {
+		return filepath.Base(filepath.Dir(file)) == ".storybook" && strings.HasPrefix(filepath.Base(file), "main.")
+	},
+}
+
+// electronBuilderResolver matches the config files of electron-builder,
+// e.g. electron-builder.yml or electron-builder.js, whose hooks and
+// targets name packages. The configs of Electron Forge, forge.config.js,
+// are read like any other config file.
+var electronBuilderResolver = configResolver{
+	matches: func(file string) bool {
+		return strings.HasPrefix(filepath.Base(file), "electron-builder.")
+	},
+}
+
+// graphqlCodegenResolver resolves the names of the plugins and presets
+// of GraphQL Code Generator, used by its config file, e.g. codegen.yml:
+// https://the-guild.dev/graphql/codegen/docs/config-reference/codegen-config
+var graphqlCodegenResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return strings.HasPrefix(name, "codegen.") || strings.HasPrefix(name, ".codegen.")
+	},
+	expand: graphqlCodegenNames,
+	tools:  []string{"@graphql-codegen/cli"},
+}
+
+// graphqlCodegenNames returns the packages a name of a codegen config may
+// stand for, following the lookup of the plugins and presets, e.g.
+// "typescript" for @graphql-codegen/typescript, or "client" for
+// @graphql-codegen/client-preset.
+func graphqlCodegenNames(name string) []string {
+	if strings.HasPrefix(name, "@") || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") || strings.Contains(name, "/") {
+		return nil
+	}
+	return []string{
+		"@graphql-codegen/" + name, "@graphql-codegen/" + name + "-preset", "@graphql-codegen/" + name + "-plugin",
+		"graphql-codegen-" + name, "graphql-codegen-" + name + "-plugin",
+	}
+}
+
+// prismaProviderRe matches the providers of the generators of Prisma
+// schemas, which may be commands, e.g. provider = "npx zod-prisma-types".
+var prismaProviderRe = regexp.MustCompile(`^\s*provider\s*=\s*"([^"]+)"`)
+
+// prismaResolver finds the generators of Prisma schemas, e.g.
+// schema.prisma, or the files of a schema split in a prisma/schema
+// directory. The prisma CLI generates the client from them:
+// https://www.prisma.io/docs/orm/prisma-schema/overview/generators
+var prismaResolver = configResolver{
+	matches: func(file string) bool {
+		return filepath.Ext(file) == ".prisma"
+	},
+	expand: func(name string) []string {
+		if name == "prisma-client-js" || name == "prisma-client" {
+			return []string{"@prisma/client"}
+		}
+		return nil
+	},
+	names: func(line string) []string {
+		m := prismaProviderRe.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		// The package run by the command, e.g. "npx prisma-erd-generator".
+		words := strings.Fields(m[1])
+		return []string{words[len(words)-1]}
+	},
+	tools: []string{"prisma"},
+}
+
+// typeormDrivers maps the database types of TypeORM to the packages of
+// their drivers, which TypeORM loads itself:
+// https://typeorm.io/data-source-options
+var typeormDrivers = map[string][]string{
+	"postgres":       {"pg"},
+	"cockroachdb":    {"pg"},
+	"mysql":          {"mysql", "mysql2"},
+	"mariadb":        {"mysql", "mysql2"},
+	"sqlite":         {"sqlite3"},
+	"better-sqlite3": {"better-sqlite3"},
+	"mssql":          {"mssql"},
+	"oracle":         {"oracledb"},
+	"mongodb":        {"mongodb"},
+	"sqljs":          {"sql.js"},
+	"spanner":        {"@google-cloud/spanner"},
+}
+
+// typeormResolver resolves the database types of the config files of
+// TypeORM, ormconfig.json or ormconfig.yml, to the packages of their
+// drivers, e.g. "postgres" to pg.
+var typeormResolver = configResolver{
+	matches: func(file string) bool {
+		return strings.HasPrefix(filepath.Base(file), "ormconfig.")
+	},
+	expand: func(name string) []string { return typeormDrivers[name] },
+	tools:  []string{"typeorm"},
+}
+
+// drizzleResolver matches the config files of Drizzle Kit, which
+// generates the migrations of Drizzle ORM, e.g. drizzle.config.ts.
+var drizzleResolver = configResolver{
+	matches: func(file string) bool {
+		return strings.HasPrefix(filepath.Base(file), "drizzle.config.")
+	},
+	tools: []string{"drizzle-kit"},
+}
+
+// quotedStringRe matches the quoted strings of a line, including the ones
+// with spaces, e.g. the commands of lint-staged.
+var quotedStringRe = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+// lintStagedNames returns the packages run by the commands of the line,
+// either quoted or the unquoted values of YAML, e.g. "- eslint --fix".
+func (sc *scanContext) lintStagedNames(line string) []string {
+	var commands []string
+	for _, m := range quotedStringRe.FindAllStringSubmatch(line, -1) {
+		commands = append(commands, m[1]+m[2])
+	}
+	if len(commands) == 0 {
+		trimmed := strings.TrimSpace(line)
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+			commands = append(commands, item)
+		} else if _, value, ok := strings.Cut(trimmed, ": "); ok {
+			commands = append(commands, value)
+		}
+	}
+	var names []string
+	for _, command := range commands {
+		for _, ref := range scriptReferences(command, sc.bins) {
+			names = append(names, ref.Package)
+		}
+	}
+	return names
+}
+
+// commitlintResolver resolves the names of the shareable configs and
+// plugins of commitlint configs, e.g. commitlint.config.js or
+// .commitlintrc.json, and follows the configs they extend:
+// https://commitlint.js.org/reference/configuration.html
+var commitlintResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return strings.HasPrefix(name, ".commitlintrc") || strings.HasPrefix(name, "commitlint.config.")
+	},
+	expand: commitlintNames,
+	isConfigPackage: func(pkgName string) bool {
+		return strings.Contains(pkgName, "commitlint-config") || strings.HasPrefix(pkgName, "@commitlint/config-")
+	},
+	tools: []string{"@commitlint/cli"},
+}
+
+// commitlintNames returns the packages a name of a commitlint config may
+// stand for, following its resolution of the configs it extends, e.g.
+// "conventional" for commitlint-config-conventional and "@scope" for
+// @scope/commitlint-config, and of its plugins.
+func commitlintNames(name string) []string {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") || strings.Contains(name, "commitlint-") {
+		return nil
+	}
+	if strings.HasPrefix(name, "@") {
+		if strings.Contains(name, "/") {
+			// Scoped names are matched as they are.
+			return nil
+		}
+		return []string{name + "/commitlint-config", name + "/commitlint-plugin"}
+	}
+	return []string{"commitlint-config-" + name, "commitlint-plugin-" + name}
+}
+
+// browserslistExtendsRe matches the shareable configs extended by a
+// browserslist config, e.g. "extends browserslist-config-acme".
+var browserslistExtendsRe = regexp.MustCompile(`^\s*["']?extends\s+([@\w][^\s"',]*)`)
+
+// browserslistResolver marks the packages reading the target browsers of
+// the project as used when they are configured, in .browserslistrc, a
+// browserslist file, or the "browserslist" key of package.json, since
+// they are never imported: https://github.com/browserslist/browserslist
+var browserslistResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return name == ".browserslistrc" || name == "browserslist"
+	},
+	names: func(line string) []string {
+		if m := browserslistExtendsRe.FindStringSubmatch(line); m != nil {
+			return []string{m[1]}
+		}
+		return nil
+	},
+	tools: []string{"browserslist", "caniuse-lite", "update-browserslist-db", "autoprefixer", "postcss-preset-env"},
+}
+
+// testRunnerOptionRe matches the options of the configs of Jest and Vitest
+// whose value names a package by a shorthand, e.g. testEnvironment: "jsdom"
+// for jest-environment-jsdom, capturing the option and its value.
+var testRunnerOptionRe = regexp.MustCompile(`["']?\b(testEnvironment|testRunner|runner|environment|provider)["']?\s*:\s*["']([^"']+)["']`)
+
+// jestResolver finds the packages of the configs of Jest, e.g.
+// jest.config.js or the "jest" key of package.json: the environments and
+// runners named by shorthands, and the packages of the setupFiles,
+// globalSetup and snapshotSerializers given by their path in
+// node_modules, e.g. "<rootDir>/node_modules/jest-canvas-mock/lib/index.js".
+// The ones given by their name, e.g. "@testing-library/jest-dom", are
+// quoted strings, read like in any other config file:
+// https://jestjs.io/docs/configuration
+var jestResolver = configResolver{
+	matches: func(file string) bool {
+		return strings.HasPrefix(filepath.Base(file), "jest.config.")
+	},
+	names: func(line string) []string {
+		names := installedPackageNames(line)
+		m := testRunnerOptionRe.FindStringSubmatch(line)
+		if m == nil || isPathName(m[2]) {
+			return names
+		}
+		switch m[1] {
+		case "testEnvironment":
+			return append(names, prefixedName(m[2], "jest-environment"))
+		case "runner":
+			return append(names, prefixedName(m[2], "jest-runner"))
+		case "testRunner":
+			return append(names, prefixedName(m[2], "jest"))
+		}
+		return names
+	},
+}
+
+// vitestEnvironments maps the environments of Vitest to the packages they
+// need, besides the ones named like them, e.g. "jsdom".
+var vitestEnvironments = map[string]string{"edge-runtime": "@edge-runtime/vm"}
+
+// vitestResolver finds the packages of the configs of Vitest, e.g.
+// vitest.config.ts, or the "test" of vite.config.ts: the packages of the
+// environments and of the coverage providers, e.g. provider: "v8" for
+// @vitest/coverage-v8. Like for Jest, the setupFiles and globalSetup
+// given by name are quoted strings:
+// https://vitest.dev/config/
+var vitestResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return strings.HasPrefix(name, "vitest.config.") || strings.HasPrefix(name, "vitest.workspace.") ||
+			strings.HasPrefix(name, "vite.config.")
+	},
+	names: func(line string) []string {
+		names := installedPackageNames(line)
+		m := testRunnerOptionRe.FindStringSubmatch(line)
+		if m == nil || isPathName(m[2]) {
+			return names
+		}
+		switch {
+		case m[1] == "environment" && vitestEnvironments[m[2]] != "":
+			return append(names, vitestEnvironments[m[2]])
+		case m[1] == "provider" && (m[2] == "v8" || m[2] == "istanbul"):
+			return append(names, "@vitest/coverage-"+m[2])
+		}
+		return names
+	},
+}
+
+// installedPackageNames returns the packages of the paths of the line in
+// node_modules, e.g. jest-canvas-mock for
+// "<rootDir>/node_modules/jest-canvas-mock/lib/index.js".
+func installedPackageNames(line string) []string {
+	var names []string
+	for _, m := range configStringRe.FindAllStringSubmatch(line, -1) {
+		if pkg := installedPackageRe.FindStringSubmatch(m[1]); pkg != nil && strings.Contains(m[1], "node_modules/") {
+			names = append(names, pkg[1])
+		}
+	}
+	return names
+}
+
+// isPathName reports whether the value of a config names a file rather
+// than a package, e.g. "./env.js" or "<rootDir>/env.js".
+func isPathName(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") || strings.HasPrefix(name, "<rootDir>")
+}
+
+// nxResolver finds the packages of the executors, generators and plugins
+// of Nx, named in nx.json, in the project.json of its projects, or in the
+// workspace.json of the older workspaces, e.g. "@nx/webpack:webpack" for
+// the webpack executor of @nx/webpack:
+// https://nx.dev/reference/nx-json
+var nxResolver = configResolver{
+	matches: func(file string) bool {
+		switch filepath.Base(file) {
+		case "nx.json", "project.json", "workspace.json":
+			return true
+		}
+		return false
+	},
+	expand: nxNames,
+	tools:  []string{"nx"},
+}
+
+// nxNames returns the package of an executor or a generator of Nx, named
+// by the package and its name, e.g. "@nx/jest:jest" or "@nrwl/react:app".
+func nxNames(name string) []string {
+	pkg, executor, ok := strings.Cut(name, ":")
+	if !ok || pkg == "" || isPathName(pkg) || strings.HasPrefix(executor, "//") {
+		return nil
+	}
+	return []string{pkg}
+}
+
+// turboResolver matches the pipelines of Turborepo, turbo.json, whose
+// tasks run the scripts of the workspaces with turbo.
+var turboResolver = configResolver{
+	matches: func(file string) bool {
+		return filepath.Base(file) == "turbo.json"
+	},
+	tools: []string{"turbo"},
+}
+
+// lernaUseNxRe matches the option of lerna.json running the tasks with Nx.
+var lernaUseNxRe = regexp.MustCompile(`"useNx"\s*:\s*true`)
+
+// lernaResolver matches the config of Lerna, lerna.json, which keeps nx
+// when Lerna runs the tasks with it.
+var lernaResolver = configResolver{
+	matches: func(file string) bool {
+		return filepath.Base(file) == "lerna.json"
+	},
+	names: func(line string) []string {
+		if lernaUseNxRe.MatchString(line) {
+			return []string{"nx"}
+		}
+		return nil
+	},
+	tools: []string{"lerna"},
+}
+
+// changesetsResolver matches the config of Changesets,
+// .changeset/config.json, which keeps @changesets/cli, and names the
+// packages writing the changelogs, e.g. "@changesets/changelog-github":
+// https://github.com/changesets/changesets/blob/main/docs/config-file-options.md
+var changesetsResolver = configResolver{
+	matches: func(file string) bool {
+		return filepath.Base(filepath.Dir(file)) == ".changeset" && filepath.Base(file) == "config.json"
+	},
+	tools: []string{"@changesets/cli"},
+}
+
+// conventionalPresetRe matches the presets of the conventional changelog,
+// e.g. preset: "conventionalcommits", used by the release tools.
+var conventionalPresetRe = regexp.MustCompile(`["']?preset["']?\s*:\s*["']([\w-]+)["']`)
+
+// conventionalPresetNames returns the package of the preset of the
+// conventional changelog of the line, e.g. conventional-changelog-angular
+// for preset: "angular".
+func conventionalPresetNames(line string) []string {
+	if m := conventionalPresetRe.FindStringSubmatch(line); m != nil {
+		return []string{prefixedName(m[1], "conventional-changelog")}
+	}
+	return nil
+}
+
+// semanticReleaseResolver matches the configs of semantic-release,
+// release.config.js, .releaserc or the "release" key of package.json,
+// whose plugins and shareable configs are named in full, e.g.
+// "@semantic-release/npm":
+// https://semantic-release.gitbook.io/semantic-release/usage/configuration
+var semanticReleaseResolver = configResolver{
+	matches: func(file string) bool {
+		name := filepath.Base(file)
+		return strings.HasPrefix(name, ".releaserc") || strings.HasPrefix(name, "release.config.")
+	},
+	names: conventionalPresetNames,
+	tools: []string{"semantic-release"},
+}
+
+// releaseItResolver matches the configs of release-it, .release-it.json or
+// the "release-it" key of package.json, whose plugins are the keys of the
+// "plugins" object, e.g. "@release-it/conventional-changelog":
+// https://github.com/release-it/release-it
+var releaseItResolver = configResolver{
+	matches: func(file string) bool {
+		return strings.HasPrefix(filepath.Base(file), ".release-it.")
+	},
+	names: conventionalPresetNames,
+	tools: []string{"release-it"},
+}
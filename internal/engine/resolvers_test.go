@@ -0,0 +1,455 @@
+package engine
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+func TestESLintNames(t *testing.T) {
+	tests := map[string][]string{
+		"airbnb":                      {"eslint-config-airbnb", "eslint-plugin-airbnb"},
+		"eslint-config-prettier":      nil,
+		"@company/eslint-plugin":      nil,
+		"@company":                    {"@company/eslint-config", "@company/eslint-plugin"},
+		"@company/strict":             {"@company/eslint-config-strict", "@company/eslint-plugin-strict"},
+		"plugin:react/recommended":    {"eslint-plugin-react"},
+		"plugin:@typescript-eslint/x": {"@typescript-eslint/eslint-plugin"},
+		"plugin:@scope/name/strict":   {"@scope/eslint-plugin-name"},
+		"eslint:recommended":          nil,
+		"./local-config.js":           nil,
+	}
+	for name, want := range tests {
+		if got := eslintNames(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("eslintNames(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestMarkConfigStringsESLint(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		// eslint-config-airbnb extends eslint-config-airbnb-base,
+		// which requires the import plugin.
+		"node_modules/eslint-config-airbnb/package.json":      `{"main": "index.js"}`,
+		"node_modules/eslint-config-airbnb/index.js":          "module.exports = { extends: ['airbnb-base', './rules/react'] };\n",
+		"node_modules/eslint-config-airbnb-base/package.json": `{}`,
+		"node_modules/eslint-config-airbnb-base/index.js":     "module.exports = { plugins: ['import'] };\n",
+	})
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{
+		"eslint-config-airbnb": false, "eslint-plugin-import": false, "eslint-plugin-react": false,
+		"@typescript-eslint/eslint-plugin": false, "eslint-config-prettier": false, "eslint-plugin-vue": false,
+	}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings(".eslintrc.json", []byte(`{
+  "extends": ["airbnb", "plugin:react/recommended", "plugin:@typescript-eslint/recommended"],
+  "plugins": ["@typescript-eslint"]
+}`))
+	sc.markConfigStrings(".eslintrc.yml", []byte("extends:\n  - prettier\n"))
+
+	for dependency, want := range map[string]bool{
+		"eslint-config-airbnb": true, "eslint-plugin-import": true, "eslint-plugin-react": true,
+		"@typescript-eslint/eslint-plugin": true, "eslint-config-prettier": true, "eslint-plugin-vue": false,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+	if got, want := sc.d.usages["eslint-plugin-import"], []Location{{File: ".eslintrc.json", Line: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of eslint-plugin-import = %v, want %v", got, want)
+	}
+	if got, want := sc.d.usages["eslint-config-prettier"], []Location{{File: ".eslintrc.yml", Line: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of eslint-config-prettier = %v, want %v", got, want)
+	}
+}
+
+func TestKeyLocation(t *testing.T) {
+	data := []byte(`{
+  "devDependencies": {
+    "prettier": "^3.0.0"
+  },
+  "prettier": "@company/prettier-config"
+}`)
+	if got := keyLocation(data, "prettier")(1); got != (Location{File: "package.json", Line: 5}) {
+		t.Errorf("keyLocation(prettier)(1) = %v, want package.json:5", got)
+	}
+	if got := keyLocation(data, "eslintConfig")(1); got != (Location{File: "package.json"}) {
+		t.Errorf("keyLocation(eslintConfig)(1) = %v, want package.json", got)
+	}
+}
+
+func TestBabelNames(t *testing.T) {
+	tests := map[string][]string{
+		"@babel/env":                             {"@babel/preset-env", "@babel/plugin-env"},
+		"@babel/preset-env":                      nil,
+		"@babel/plugin-transform-runtime":        nil,
+		"react-app":                              {"babel-preset-react-app", "babel-plugin-react-app"},
+		"babel-plugin-macros":                    nil,
+		"@company":                               {"@company/babel-preset", "@company/babel-plugin"},
+		"@company/internal":                      {"@company/babel-preset-internal", "@company/babel-plugin-internal"},
+		"module:metro-react-native-babel-preset": {"metro-react-native-babel-preset"},
+		"./plugins/local":                        nil,
+	}
+	for name, want := range tests {
+		if got := babelNames(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("babelNames(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestMarkConfigStringsBabel(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"@babel/preset-env": false, "babel-preset-react-app": false, "babel-plugin-lodash": false, "babel-plugin-macros": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings("babel.config.js", []byte("module.exports = {\n  presets: ['@babel/env', ['react-app', { flow: false }]],\n  plugins: ['lodash'],\n};\n"))
+	for dependency, want := range map[string]bool{
+		"@babel/preset-env": true, "babel-preset-react-app": true, "babel-plugin-lodash": true, "babel-plugin-macros": false,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+}
+
+func TestMarkConfigStringsPostCSS(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"autoprefixer": false, "postcss-preset-env": false, "tailwindcss": false, "@fullhuman/postcss-purgecss": false, "cssnano": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings("postcss.config.js", []byte("module.exports = {\n  plugins: {\n    tailwindcss: {},\n    autoprefixer: { flexbox: 'no-2009' },\n    'postcss-preset-env': {},\n  },\n};\n"))
+	sc.markConfigStrings(".postcssrc.yml", []byte("plugins:\n  @fullhuman/postcss-purgecss: {}\n"))
+	for dependency, want := range map[string]bool{
+		"autoprefixer": true, "postcss-preset-env": true, "tailwindcss": true, "@fullhuman/postcss-purgecss": true, "cssnano": false,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+	if got, want := sc.d.usages["autoprefixer"], []Location{{File: "postcss.config.js", Line: 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of autoprefixer = %v, want %v", got, want)
+	}
+}
+
+func TestMarkConfigStringsTestTools(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{
+		"@storybook/addon-essentials": false, "@storybook/react-vite": false, "@storybook/addon-links": false,
+		"allure-playwright": false, "vite": false, "@playwright/test": false,
+	}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings(".storybook/main.ts", []byte("const config = {\n  addons: ['@storybook/addon-essentials/preset'],\n  framework: { name: '@storybook/react-vite' },\n};\nexport default config;\n"))
+	sc.markConfigStrings("playwright.config.ts", []byte("export default defineConfig({\n  reporter: [['list'], ['allure-playwright', { outputFolder: 'out' }]],\n});\n"))
+	sc.markConfigStrings("cypress.config.ts", []byte("export default defineConfig({\n  component: { devServer: { framework: 'react', bundler: 'vite' } },\n});\n"))
+	for dependency, want := range map[string]bool{
+		"@storybook/addon-essentials": true, "@storybook/react-vite": true, "@storybook/addon-links": false,
+		"allure-playwright": true, "vite": true, "@playwright/test": false,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+}
+
+func TestBundlerConfigs(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"vite.config.mts":  "import { defineConfig } from 'vite';\nimport react from '@vitejs/plugin-react';\n\nexport default defineConfig({\n  plugins: [react()],\n  optimizeDeps: { include: ['lodash-es'] },\n});\n",
+		"rollup.config.ts": "import type { RollupOptions } from 'rollup';\nimport resolve from '@rollup/plugin-node-resolve';\n\nconst config: RollupOptions = { plugins: [resolve()] };\nexport default config;\n",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"vite": false, "@vitejs/plugin-react": false, "lodash-es": false, "rollup": false, "@rollup/plugin-node-resolve": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.d.ignoredLines = make(map[Location][]string)
+	defer func() { sc.d = Dependency{} }()
+
+	for _, file := range []string{"vite.config.mts", "rollup.config.ts"} {
+		extractor, ok := extract.For("node", file)
+		if !ok {
+			t.Fatalf("no extractor registered for %s", file)
+		}
+		sc.readFileAndExtractPackages(sc.logOut, file, extractor)
+	}
+	for dependency, want := range map[string]Evidence{
+		"vite": EvidenceImport, "@vitejs/plugin-react": EvidenceImport, "lodash-es": EvidenceConfig,
+		"rollup": EvidenceImport, "@rollup/plugin-node-resolve": EvidenceImport,
+	} {
+		if !sc.d.mp[dependency] || sc.d.evidence[dependency] != want {
+			t.Errorf("%s was not kept on %s evidence", dependency, want)
+		}
+	}
+}
+
+func TestDesktopPackagerConfigs(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{
+  "build": {
+    "appId": "com.example.app",
+    "win": {"target": "squirrel"},
+    "afterSign": "electron-builder-notarize"
+  },
+  "config": {
+    "forge": {"makers": [{"name": "@electron-forge/maker-squirrel"}]}
+  },
+  "devDependencies": {
+    "electron-builder-notarize": "^1.5.0",
+    "@electron-forge/maker-squirrel": "^7.0.0",
+    "@electron-forge/maker-dmg": "^7.0.0",
+    "electron-builder-squirrel-windows": "^24.0.0"
+  }
+}`,
+		"electron-builder.js": "module.exports = {\n  win: { target: 'squirrel' },\n  squirrelWindows: { customSquirrelVendorDir: 'electron-builder-squirrel-windows' },\n};\n",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.files = nil }()
+
+	sc.scanProject()
+	for dependency, want := range map[string]bool{
+		"electron-builder-notarize": true, "@electron-forge/maker-squirrel": true,
+		"electron-builder-squirrel-windows": true, "@electron-forge/maker-dmg": false,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+	if got, want := sc.d.usages["electron-builder-notarize"], []Location{{File: "package.json", Line: 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of electron-builder-notarize = %v, want %v", got, want)
+	}
+}
+
+func TestMarkConfigStringsGenerators(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{
+		"@graphql-codegen/cli": false, "@graphql-codegen/typescript": false, "@graphql-codegen/client-preset": false,
+		"@graphql-codegen/typescript-operations": false, "prisma": false, "@prisma/client": false,
+		"prisma-erd-generator": false, "typeorm": false, "pg": false, "mysql2": false, "drizzle-kit": false,
+	}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings("codegen.yml", []byte("schema: http://localhost:4000/graphql\ngenerates:\n  src/gql/:\n    preset: client\n  src/types.ts:\n    plugins:\n      - typescript\n"))
+	sc.markConfigStrings("prisma/schema.prisma", []byte("generator client {\n  provider = \"prisma-client-js\"\n}\n\ngenerator erd {\n  provider = \"npx prisma-erd-generator\"\n}\n"))
+	sc.markConfigStrings("ormconfig.json", []byte("{\n  \"type\": \"postgres\",\n  \"host\": \"localhost\"\n}\n"))
+	sc.markConfigStrings("drizzle.config.json", []byte("{\n  \"dialect\": \"postgresql\"\n}\n"))
+	for dependency, want := range map[string]bool{
+		"@graphql-codegen/cli": true, "@graphql-codegen/typescript": true, "@graphql-codegen/client-preset": true,
+		"@graphql-codegen/typescript-operations": false, "prisma": true, "@prisma/client": true,
+		"prisma-erd-generator": true, "typeorm": true, "pg": true, "mysql2": false, "drizzle-kit": true,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+	if got, want := sc.d.usages["prisma-erd-generator"], []Location{{File: "prisma/schema.prisma", Line: 6}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of prisma-erd-generator = %v, want %v", got, want)
+	}
+	if got, want := sc.d.usages["prisma"], []Location{{File: "prisma/schema.prisma", Line: 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of prisma = %v, want %v", got, want)
+	}
+}
+
+func TestMarkConfigStringsCommitTools(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.bins = map[string]string{"tsc": "typescript"}
+	sc.d.mp = map[string]bool{
+		"lint-staged": false, "eslint": false, "prettier": false, "typescript": false, "stylelint": false,
+		"@commitlint/cli": false, "@commitlint/config-conventional": false, "commitlint-plugin-function-rules": false,
+		"@acme/commitlint-config": false,
+	}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{}; sc.bins = nil }()
+
+	sc.markConfigStrings(".lintstagedrc.json", []byte("{\n  \"*.{js,ts}\": [\"eslint --fix\", \"prettier --write\"],\n  \"*.ts\": \"tsc -p . --noEmit\"\n}\n"))
+	sc.markConfigStrings("commitlint.config.js", []byte("module.exports = {\n  extends: ['@commitlint/config-conventional', '@acme'],\n  plugins: ['function-rules'],\n};\n"))
+	for dependency, want := range map[string]bool{
+		"lint-staged": true, "eslint": true, "prettier": true, "typescript": true, "stylelint": false,
+		"@commitlint/cli": true, "@commitlint/config-conventional": true, "commitlint-plugin-function-rules": true,
+		"@acme/commitlint-config": true,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+
+	sc.d.mp["stylelint"] = false
+	sc.markConfigStrings(".lintstagedrc.yml", []byte("'*.css':\n  - stylelint --fix\n"))
+	if !sc.d.mp["stylelint"] {
+		t.Errorf("stylelint run by .lintstagedrc.yml is not used")
+	}
+}
+
+func TestBrowserslistConfigs(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{
+		"browserslist": false, "caniuse-lite": false, "autoprefixer": false, "postcss-preset-env": false,
+		"browserslist-config-acme": false, "sass": false,
+	}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings(".browserslistrc", []byte("# Browsers of the app\nextends browserslist-config-acme\n> 0.5%\nlast 2 versions\nnot dead\n"))
+	for dependency, want := range map[string]bool{
+		"browserslist": true, "caniuse-lite": true, "autoprefixer": true, "postcss-preset-env": true,
+		"browserslist-config-acme": true, "sass": false,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+	if got := sc.d.usages["caniuse-lite"]; !reflect.DeepEqual(got, []Location{{File: ".browserslistrc", Line: 1}}) {
+		t.Errorf("caniuse-lite used at %v, want .browserslistrc:1", got)
+	}
+}
+
+func TestBrowserslistKey(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"browserslist\": [\"defaults\", \"not IE 11\"],\n  \"devDependencies\": {\n    \"autoprefixer\": \"^10.0.0\",\n    \"caniuse-lite\": \"^1.0.0\"\n  }\n}\n",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	sc.d = Dependency{mp: make(map[string]bool), usages: make(map[string][]Location)}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.bins = nil }()
+
+	sc.readPackages()
+	for _, dependency := range []string{"autoprefixer", "caniuse-lite"} {
+		if !sc.d.mp[dependency] || sc.d.evidence[dependency] != EvidenceConfig {
+			t.Errorf("%s was not kept on config evidence", dependency)
+		}
+		if got := sc.d.usages[dependency]; !reflect.DeepEqual(got, []Location{{File: "package.json", Line: 2}}) {
+			t.Errorf("%s used at %v, want package.json:2", dependency, got)
+		}
+	}
+}
+
+func TestTestRunnerConfigs(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"jest\": {\n    \"runner\": \"eslint\",\n    \"testRunner\": \"jasmine2\"\n  },\n  \"devDependencies\": {\n    \"jest-runner-eslint\": \"^2.0.0\",\n    \"jest-jasmine2\": \"^29.0.0\"\n  }\n}\n",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	sc.d = Dependency{mp: make(map[string]bool), usages: make(map[string][]Location)}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.bins = nil }()
+
+	sc.readPackages()
+	sc.d.mp["jest-environment-jsdom"], sc.d.mp["jest-canvas-mock"], sc.d.mp["enzyme-to-json"] = false, false, false
+	sc.d.mp["jest-environment-node"], sc.d.mp["@vitest/coverage-v8"], sc.d.mp["@edge-runtime/vm"] = false, false, false
+	sc.markConfigStrings("jest.config.js", []byte(`module.exports = {
+  testEnvironment: "jsdom",
+  moduleFileExtensions: ["js", "node"],
+  setupFiles: ["<rootDir>/node_modules/jest-canvas-mock/lib/index.js", "./jest.setup.js"],
+  snapshotSerializers: ["enzyme-to-json/serializer"],
+};
+`))
+	sc.markConfigStrings("vite.config.ts", []byte("export default defineConfig({\n  test: {\n    environment: 'edge-runtime',\n    coverage: { provider: 'v8' },\n  },\n});\n"))
+
+	for dependency, want := range map[string]bool{
+		"jest-runner-eslint": true, "jest-jasmine2": true, "jest-environment-jsdom": true, "jest-canvas-mock": true,
+		"enzyme-to-json": true, "jest-environment-node": false, "@vitest/coverage-v8": true, "@edge-runtime/vm": true,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+	if got, want := sc.d.usages["jest-runner-eslint"], []Location{{File: "package.json", Line: 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of jest-runner-eslint = %v, want %v", got, want)
+	}
+	if got, want := sc.d.usages["jest-environment-jsdom"], []Location{{File: "jest.config.js", Line: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of jest-environment-jsdom = %v, want %v", got, want)
+	}
+}
+
+func TestMonorepoToolConfigs(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{
+		"nx": false, "@nx/webpack": false, "@nx/jest": false, "@nx/eslint": false, "@nx/vite": false,
+		"@nrwl/react": false, "nx-cloud": false, "turbo": false, "lerna": false, "@nx/next": false,
+	}
+	sc.d.usages = make(map[string][]Location)
+	defer func() { sc.d = Dependency{} }()
+
+	sc.markConfigStrings("nx.json", []byte(`{
+  "targetDefaults": {"build": {"executor": "@nx/webpack:webpack", "dependsOn": ["^build"]}},
+  "plugins": ["@nx/eslint/plugin", {"plugin": "@nx/vite/plugin"}],
+  "generators": {"@nrwl/react:application": {"style": "css"}},
+  "tasksRunnerOptions": {"default": {"runner": "nx-cloud", "options": {"url": "https://cloud.nx.app"}}}
+}`))
+	sc.markConfigStrings("apps/web/project.json", []byte(`{"targets": {"test": {"executor": "@nx/jest:jest"}}}`))
+	sc.markConfigStrings("turbo.json", []byte(`{"tasks": {"build": {"dependsOn": ["^build"]}}}`))
+	for dependency, want := range map[string]bool{
+		"nx": true, "@nx/webpack": true, "@nx/jest": true, "@nx/eslint": true, "@nx/vite": true,
+		"@nrwl/react": true, "nx-cloud": true, "turbo": true, "lerna": false, "@nx/next": false,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+
+	sc.d.mp["nx"] = false
+	sc.markConfigStrings("lerna.json", []byte("{\n  \"version\": \"independent\",\n  \"useNx\": true\n}\n"))
+	if !sc.d.mp["lerna"] || !sc.d.mp["nx"] {
+		t.Errorf("lerna.json kept lerna = %v, nx = %v", sc.d.mp["lerna"], sc.d.mp["nx"])
+	}
+}
+
+func TestReleaseToolConfigs(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"release\": {\n    \"plugins\": [\"@semantic-release/commit-analyzer\", \"@semantic-release/npm\"]\n  },\n  \"devDependencies\": {\n    \"semantic-release\": \"^23.0.0\",\n    \"@semantic-release/commit-analyzer\": \"^12.0.0\",\n    \"@semantic-release/npm\": \"^12.0.0\",\n    \"@semantic-release/github\": \"^10.0.0\"\n  }\n}\n",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	sc.d = Dependency{mp: make(map[string]bool), usages: make(map[string][]Location)}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.bins = nil }()
+
+	sc.readPackages()
+	for _, dependency := range []string{"@changesets/cli", "@changesets/changelog-github", "conventional-changelog-conventionalcommits", "release-it", "@release-it/conventional-changelog"} {
+		sc.d.mp[dependency] = false
+	}
+	sc.markConfigStrings(".changeset/config.json", []byte(`{"changelog": ["@changesets/changelog-github", {"repo": "acme/app"}], "access": "public"}`))
+	sc.markConfigStrings(".release-it.json", []byte(`{"plugins": {"@release-it/conventional-changelog": {"preset": "conventionalcommits"}}}`))
+
+	for dependency, want := range map[string]bool{
+		"semantic-release": true, "@semantic-release/commit-analyzer": true, "@semantic-release/npm": true,
+		"@semantic-release/github": false, "@changesets/cli": true, "@changesets/changelog-github": true,
+		"release-it": true, "@release-it/conventional-changelog": true, "conventional-changelog-conventionalcommits": true,
+	} {
+		if sc.d.mp[dependency] != want {
+			t.Errorf("%s used = %v, want %v", dependency, sc.d.mp[dependency], want)
+		}
+	}
+	if got, want := sc.d.usages["semantic-release"], []Location{{File: "package.json", Line: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usages of semantic-release = %v, want %v", got, want)
+	}
+}
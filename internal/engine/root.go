@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// findProjectRoot returns the nearest directory holding a manifest of
+// the language, starting from dir and walking upward, like git does to
+// find .git. The walk stops at the root of the git repository, if any,
+// so a manifest outside of it is never picked. It returns false when no
+// manifest is found.
+func findProjectRoot(l *language, dir string) (string, bool) {
+	for {
+		for _, file := range l.manifestFiles {
+			if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+				return dir, true
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// absFlagPaths makes the paths given as flags absolute, before changing
+// the working directory, since they are relative to the directory depose
+// runs from.
+func (sc *scanContext) absFlagPaths() {
+	for _, path := range []*string{&sc.outPath, &sc.metricsTextfile, &sc.notifyTemplate, &sc.botPRBody, &sc.expectFile, &sc.formatTemplate} {
+		if *path != "" {
+			*path, _ = filepath.Abs(*path)
+		}
+	}
+	for i, path := range sc.extraManifests {
+		sc.extraManifests[i], _ = filepath.Abs(path)
+	}
+}
+
+// enterProjectRoot changes the working directory to the root of the
+// project, when depose runs from one of its subdirectories. Without a
+// manifest upward, the working directory is kept, so reading the
+// manifest reports the error. Projects which are not on disk are kept too.
+func (sc *scanContext) enterProjectRoot(l *language) {
+	wd, err := os.Getwd()
+	if err != nil || !sc.projectOnDisk() {
+		return
+	}
+	root, ok := findProjectRoot(l, wd)
+	if !ok || root == wd {
+		return
+	}
+	if err := os.Chdir(root); err != nil {
+		fatal(err)
+	}
+	fmt.Fprintf(sc.logOut, "No manifest in %s, scanning the project at %s\n", wd, root)
+}
+
+// ManifestFiles returns the names of the manifests of the language, e.g.
+// go.mod for go, the first one being the one expected, and false when
+// depose doesn't support the language.
+func ManifestFiles(langName string) ([]string, bool) {
+	l, ok := newScanContext().languages[langName]
+	if !ok {
+		return nil, false
+	}
+	return l.manifestFiles, true
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	sc := newScanContext()
+	root := t.TempDir()
+	for _, dir := range []string{"app/src/components", "repo/.git", "repo/lib"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"package.json", "app/package.json"} {
+		if err := os.WriteFile(filepath.Join(root, file), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir    string
+		want   string
+		wantOK bool
+	}{
+		{"app/src/components", "app", true},
+		{"app", "app", true},
+		{".", ".", true},
+		// The manifest above the git repository is not picked.
+		{"repo/lib", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := findProjectRoot(sc.nodeLanguage, filepath.Join(root, tt.dir))
+		if ok != tt.wantOK || ok && got != filepath.Join(root, tt.want) {
+			t.Errorf("findProjectRoot(%q) = %q, %v, want %q, %v", tt.dir, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"flag"
+	"io"
+	"os"
+)
+
+// scanCommand implements "depose scan", which scans a project without
+// changing it, and writes the report as JSON. With --from-tar, the
+// project is read from a tarball, e.g. streamed by a build system which
+// doesn't check the project out on the machine running the scan:
+//
+//	git archive HEAD | depose scan --from-tar -
+//
+// The flags of the main command are accepted too, e.g. --strict.
+func (sc *scanContext) scanCommand(fs *flag.FlagSet) func(args []string) {
+	sc.defineFlags(fs)
+	fs.Set("reporter", "json")
+	fs.Set("dry-run", "true")
+	fs.Set("no-history", "true")
+	fromTar := fs.String("from-tar", "", "read the project from the tarball at the path, gzipped or not, or from stdin with -")
+	return func(args []string) {
+		if *fromTar == "" {
+			sc.run()
+			return
+		}
+		var src io.Reader = os.Stdin
+		if *fromTar != "-" {
+			f, err := os.Open(*fromTar)
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+			src = f
+		}
+		// The tarball is scanned in memory, so package.json can only be
+		// written elsewhere.
+		fsys, err := readTarFS(src)
+		if err != nil {
+			fatal(err)
+		}
+		sc.setProjectFS(fsys)
+		sc.noAscend = true
+		if !sc.toStdout && sc.outPath == "" {
+			sc.dryRun = true
+		}
+		sc.run()
+	}
+}
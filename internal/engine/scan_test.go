@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+)
+
+func TestScanFromTar(t *testing.T) {
+	var tarball bytes.Buffer
+	tw := tar.NewWriter(&tarball)
+	for name, content := range map[string]string{
+		"package.json": `{"dependencies": {"express": "^4.18.2", "lodash": "^4.17.21"}}`,
+		"server.js":    "const express = require(\"express\");\n",
+	} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+
+	cmd := exec.Command(buildDepose(t), "scan", "--from-tar", "-", "--strict")
+	cmd.Dir = t.TempDir()
+	cmd.Stdin = &tarball
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Report
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid report %s: %v", out, err)
+	}
+	if len(r.Findings) != 1 || r.Findings[0].Package != "lodash" {
+		t.Errorf("findings = %+v, want lodash unused", r.Findings)
+	}
+}
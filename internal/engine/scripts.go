@@ -0,0 +1,393 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// markCommandReferences marks the packages referenced by the command line
+// as found. Preloaded modules are imports found on the location, while
+// executables are only considered as usage in lenient mode.
+func (sc *scanContext) markCommandReferences(command string, loc Location) {
+	for _, ref := range scriptReferences(command, sc.bins) {
+		if ref.Evidence == EvidenceImport {
+			sc.markModuleAsFound(ref.Package, loc)
+			continue
+		}
+		if sc.strict {
+			continue
+		}
+		sc.d.mu.Lock()
+		if _, ok := sc.d.mp[ref.Package]; ok {
+			sc.markScriptUsage(ref.Package, loc)
+		}
+		sc.d.mu.Unlock()
+	}
+}
+
+// markScriptUsage marks the declared dependency as used because the script
+// on the location names it, and records the location.
+//
+// The mutex of "d" must be held by the caller.
+func (sc *scanContext) markScriptUsage(dependency string, loc Location) {
+	sc.markAsUsed(dependency, EvidenceScript, loc)
+	if sc.d.scriptUsages == nil {
+		sc.d.scriptUsages = make(map[string][]Location)
+	}
+	sc.d.scriptUsages[dependency] = append(sc.d.scriptUsages[dependency], loc)
+}
+
+// ScriptOnlyUsage is a declared dependency which is only kept because the
+// scripts of package.json name it, i.e. without any other evidence.
+type ScriptOnlyUsage struct {
+	Package   string     `json:"package"`
+	Section   string     `json:"section"`
+	Locations []Location `json:"locations"`
+}
+
+func (u ScriptOnlyUsage) String() string {
+	return fmt.Sprintf("%q is script-only: only the scripts of package.json name it", u.Package)
+}
+
+// scriptOnlyUsages returns the dependencies kept only because the scripts
+// name them, sorted by name, along with the lines of the scripts.
+func (sc *scanContext) scriptOnlyUsages() []ScriptOnlyUsage {
+	var usages []ScriptOnlyUsage
+	for dependency, used := range sc.d.mp {
+		if !used || sc.d.evidence[dependency] != EvidenceScript {
+			continue
+		}
+		locations := append([]Location(nil), sc.d.scriptUsages[dependency]...)
+		sortLocations(locations)
+		usages = append(usages, ScriptOnlyUsage{Package: dependency, Section: sc.lang.sectionOf(dependency), Locations: slices.Compact(locations)})
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Package < usages[j].Package })
+	return usages
+}
+
+// markScriptOnlyAsUnused marks the dependencies kept only because the
+// scripts name them as unused, for --treat-script-only-as-unused. Their
+// evidence is left, so their findings tell why they were kept before.
+func (sc *scanContext) markScriptOnlyAsUnused() {
+	for dependency, used := range sc.d.mp {
+		if used && sc.d.evidence[dependency] == EvidenceScript {
+			sc.d.mp[dependency] = false
+		}
+	}
+}
+
+// scriptReference is a package referenced by a command of a script.
+type scriptReference struct {
+	Package string
+	// Evidence is EvidenceImport for the modules preloaded by node,
+	// e.g. with -r, and EvidenceScript for the executables being run.
+	Evidence Evidence
+}
+
+// preloadFlags are the flags of node and of its wrappers, e.g. ts-node,
+// whose value is a module loaded before running the script.
+var preloadFlags = map[string]bool{
+	"-r": true, "--require": true, "--loader": true,
+	"--experimental-loader": true, "--import": true,
+}
+
+// packageRunners are the commands running the executable of a package
+// given as their first argument, e.g. "npx rimraf dist" or "yarn jest".
+var packageRunners = map[string]bool{
+	"npx": true, "bunx": true, "pnpx": true,
+	"pnpm dlx": true, "yarn dlx": true, "npm exec": true,
+	"yarn": true, "yarn exec": true, "yarn run": true,
+	"pnpm": true, "pnpm exec": true, "pnpm run": true,
+}
+
+// scriptReferences parses the command line of a script, and returns the
+// packages it references: the executables being run, including through
+// npx, and the modules preloaded with -r, --require, --loader or --import.
+//
+// Executables are mapped to their package with the "bin" field of the
+// installed packages, e.g. "tsc" is provided by "typescript".
+func scriptReferences(script string, bins map[string]string) []scriptReference {
+	var refs []scriptReference
+	for _, command := range splitCommands(shellWords(script)) {
+		// Skip the environment variables, e.g. "NODE_ENV=test jest".
+		for len(command) > 0 && strings.Contains(command[0], "=") && !strings.HasPrefix(command[0], "-") {
+			command = command[1:]
+		}
+		if len(command) == 0 {
+			continue
+		}
+
+		program := command[0]
+		args := command[1:]
+		if len(args) > 0 && packageRunners[program+" "+args[0]] {
+			program, args = program+" "+args[0], args[1:]
+		}
+		if packageRunners[program] {
+			args = skipRunnerFlags(args, &refs)
+			if len(args) == 0 {
+				continue
+			}
+			program, args = stripVersion(args[0]), args[1:]
+		}
+		pkgName, ok := bins[program]
+		if !ok && !strings.ContainsAny(program, "/.") {
+			pkgName, ok = program, true
+		}
+		if ok {
+			refs = append(refs, scriptReference{Package: pkgName, Evidence: EvidenceScript})
+		}
+		if pkgName == "rollup" {
+			refs = append(refs, rollupPlugins(args)...)
+		}
+
+		for i := 0; i < len(args); i++ {
+			flag, value, hasValue := strings.Cut(args[i], "=")
+			if !preloadFlags[flag] {
+				continue
+			}
+			if !hasValue {
+				if i+1 == len(args) {
+					break
+				}
+				i++
+				value = args[i]
+			}
+			if !isLocalSpecifier(value) {
+				refs = append(refs, scriptReference{Package: packageName(value), Evidence: EvidenceImport})
+			}
+		}
+	}
+	return refs
+}
+
+// packageManagers are the commands whose first argument is a subcommand,
+// e.g. "npm run build", rather than a package, and whose run subcommands
+// take the name of a script.
+var packageManagers = map[string]bool{"npm": true, "yarn": true, "pnpm": true, "bun": true}
+
+// runSubcommands are the subcommands of the package managers running a
+// script of package.json, e.g. "npm run build".
+var runSubcommands = map[string]bool{"run": true, "run-script": true, "rum": true, "urn": true}
+
+// scriptWordReferences returns the declared dependencies named by the
+// words of the script, which are kept in lenient mode even when no command
+// runs them, e.g. "mocha --reporter mocha-junit-reporter". The words are
+// matched as a whole, along with the values of their options, e.g.
+// "--plugin=prettier-plugin-svelte", the packages of their paths, e.g.
+// "node_modules/jest/bin/jest.js" or "dotenv/config", and the executables
+// of the installed packages, so "npm run build" doesn't keep a dependency
+// named "run".
+func scriptWordReferences(script string, declared map[string]bool, bins map[string]string) []scriptReference {
+	found := make(map[string]bool)
+	var refs []scriptReference
+	add := func(pkgName string) {
+		if _, ok := declared[pkgName]; ok && !found[pkgName] {
+			found[pkgName] = true
+			refs = append(refs, scriptReference{Package: pkgName, Evidence: EvidenceScript})
+		}
+	}
+	for _, command := range splitCommands(shellWords(script)) {
+		if len(command) > 2 && packageManagers[command[0]] && runSubcommands[command[1]] {
+			// e.g. "yarn run build", whose script isn't a package.
+			command = command[3:]
+		} else if len(command) > 1 && packageManagers[command[0]] {
+			// e.g. "npm test", whose subcommand isn't a package.
+			command = command[2:]
+		}
+		for _, word := range command {
+			for _, field := range strings.FieldsFunc(word, func(r rune) bool { return r == '=' || r == ',' || unicode.IsSpace(r) }) {
+				if i := strings.LastIndex(field, "node_modules/"); i >= 0 {
+					field = field[i+len("node_modules/"):]
+					if bin, ok := strings.CutPrefix(field, ".bin/"); ok {
+						add(bins[bin])
+						continue
+					}
+				} else if isLocalSpecifier(field) {
+					continue
+				}
+				add(field)
+				add(packageName(field))
+				add(stripVersion(field))
+				if pkgName, ok := bins[field]; ok {
+					add(pkgName)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// rollupPlugins returns the plugins given to the rollup CLI with -p or
+// --plugin, whose short names stand for either the official plugin or a
+// community one, e.g. "node-resolve" for @rollup/plugin-node-resolve or
+// rollup-plugin-node-resolve:
+// https://rollupjs.org/command-line-interface/#p-plugin-plugin-p-plugin-plugin
+func rollupPlugins(args []string) []scriptReference {
+	var refs []scriptReference
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if flag != "-p" && flag != "--plugin" {
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				break
+			}
+			i++
+			value = args[i]
+		}
+		// The options of the plugin follow an equal sign, e.g. "terser={...}".
+		value, _, _ = strings.Cut(value, "=")
+		if value == "" || isLocalSpecifier(value) {
+			continue
+		}
+		if strings.HasPrefix(value, "@") || strings.HasPrefix(value, "rollup-plugin-") {
+			refs = append(refs, scriptReference{Package: packageName(value), Evidence: EvidenceScript})
+			continue
+		}
+		refs = append(refs,
+			scriptReference{Package: "@rollup/plugin-" + value, Evidence: EvidenceScript},
+			scriptReference{Package: "rollup-plugin-" + value, Evidence: EvidenceScript})
+	}
+	return refs
+}
+
+// skipRunnerFlags skips the flags of a package runner, recording the
+// packages given with -p or --package, and returns the remaining arguments.
+func skipRunnerFlags(args []string, refs *[]scriptReference) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		flag, value, hasValue := strings.Cut(args[0], "=")
+		args = args[1:]
+		if flag != "-p" && flag != "--package" {
+			continue
+		}
+		if !hasValue && len(args) > 0 {
+			value, args = args[0], args[1:]
+		}
+		if value != "" {
+			*refs = append(*refs, scriptReference{Package: stripVersion(value), Evidence: EvidenceScript})
+		}
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	return args
+}
+
+// stripVersion removes the version from a package given to npx,
+// e.g. "@scope/pkg@1.2.3" becomes "@scope/pkg".
+func stripVersion(pkg string) string {
+	if idx := strings.LastIndex(pkg, "@"); idx > 0 {
+		return pkg[:idx]
+	}
+	return pkg
+}
+
+// shellWords splits a command line into words, honoring quotes and
+// keeping the control operators, e.g. "&&", as separate words.
+func shellWords(line string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case r == '&' || r == '|' || r == ';' || r == '(' || r == ')':
+			flush()
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == r && r != ';' && r != '(' && r != ')' {
+				op += string(r)
+				i++
+			}
+			words = append(words, op)
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	flush()
+	return words
+}
+
+// splitCommands splits the words of a command line into its commands.
+func splitCommands(words []string) [][]string {
+	var commands [][]string
+	var command []string
+	for _, w := range words {
+		switch w {
+		case "&&", "||", "|", "&", ";", "(", ")":
+			if len(command) > 0 {
+				commands = append(commands, command)
+			}
+			command = nil
+		default:
+			command = append(command, w)
+		}
+	}
+	if len(command) > 0 {
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// installedBins maps the executables of the installed dependencies
+// to the package providing them, e.g. "tsc" to "typescript".
+func (sc *scanContext) installedBins(dependencies map[string]bool) map[string]string {
+	bins := make(map[string]string)
+	for dependency := range dependencies {
+		data, err := sc.readProjectFile(filepath.Join("node_modules", filepath.FromSlash(dependency), "package.json"))
+		if err != nil {
+			continue
+		}
+		var pkg struct {
+			Name string          `json:"name"`
+			Bin  json.RawMessage `json:"bin"`
+		}
+		if json.Unmarshal(data, &pkg) != nil {
+			continue
+		}
+
+		var single string
+		var multiple map[string]string
+		switch {
+		case json.Unmarshal(pkg.Bin, &single) == nil:
+			// A single executable is named after the package, without its scope.
+			name := pkg.Name
+			if name == "" {
+				name = dependency
+			}
+			bins[name[strings.LastIndex(name, "/")+1:]] = dependency
+		case json.Unmarshal(pkg.Bin, &multiple) == nil:
+			for bin := range multiple {
+				bins[bin] = dependency
+			}
+		}
+	}
+	return bins
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScriptReferences(t *testing.T) {
+	bins := map[string]string{"tsc": "typescript", "ts-node": "ts-node"}
+	tests := []struct {
+		script string
+		want   []scriptReference
+	}{
+		{"node -r dotenv/config server.js", []scriptReference{
+			{"node", EvidenceScript}, {"dotenv", EvidenceImport},
+		}},
+		{"npx rimraf dist && tsc -p .", []scriptReference{
+			{"rimraf", EvidenceScript}, {"typescript", EvidenceScript},
+		}},
+		{"NODE_ENV=test ts-node --require=tsconfig-paths/register scripts/seed.ts", []scriptReference{
+			{"ts-node", EvidenceScript}, {"tsconfig-paths", EvidenceImport},
+		}},
+		{"node --loader @esbuild-kit/esm-loader --import ./setup.js src/index.ts", []scriptReference{
+			{"node", EvidenceScript}, {"@esbuild-kit/esm-loader", EvidenceImport},
+		}},
+		{"npx --yes -p @commitlint/cli@17 commitlint --edit", []scriptReference{
+			{"@commitlint/cli", EvidenceScript}, {"commitlint", EvidenceScript},
+		}},
+		{"pnpm dlx create-vite@latest app", []scriptReference{
+			{"create-vite", EvidenceScript},
+		}},
+		{"rollup -c -p node-resolve --plugin=terser={compress:false} -p @rollup/plugin-json -p ./local.js", []scriptReference{
+			{"rollup", EvidenceScript},
+			{"@rollup/plugin-node-resolve", EvidenceScript}, {"rollup-plugin-node-resolve", EvidenceScript},
+			{"@rollup/plugin-terser", EvidenceScript}, {"rollup-plugin-terser", EvidenceScript},
+			{"@rollup/plugin-json", EvidenceScript},
+		}},
+		{`echo "npx fake" ; ./bin/run.sh | prettier --write .`, []scriptReference{
+			{"echo", EvidenceScript}, {"prettier", EvidenceScript},
+		}},
+	}
+	for _, tt := range tests {
+		if got := scriptReferences(tt.script, bins); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scriptReferences(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestScriptWordReferences(t *testing.T) {
+	declared := map[string]bool{
+		"run": false, "build": false, "mocha": false, "mocha-junit-reporter": false,
+		"prettier-plugin-svelte": false, "jest": false, "typescript": false, "dotenv": false,
+	}
+	bins := map[string]string{"tsc": "typescript"}
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{"npm run build", nil},
+		{"yarn run build && pnpm test", nil},
+		{"mocha --reporter mocha-junit-reporter", []string{"mocha", "mocha-junit-reporter"}},
+		{"prettier --plugin=prettier-plugin-svelte .", []string{"prettier-plugin-svelte"}},
+		{"node node_modules/jest/bin/jest.js && node_modules/.bin/tsc", []string{"jest", "typescript"}},
+		{"node -r dotenv/config ./build/index.js", []string{"dotenv"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, ref := range scriptWordReferences(tt.script, declared, bins) {
+			got = append(got, ref.Package)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scriptWordReferences(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestScriptOnlyUsages(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.bins = map[string]string{"tsc": "typescript"}
+	sc.d.mp = map[string]bool{"typescript": false, "rimraf": false, "eslint": false, "express": false}
+	sc.d.usages = make(map[string][]Location)
+	defer func() {
+		sc.lang, sc.bins = nil, nil
+		sc.d.mp, sc.d.usages, sc.d.evidence, sc.d.scriptUsages = nil, nil, nil, nil
+	}()
+
+	loc := Location{File: "package.json", Line: 3}
+	sc.markCommandReferences("rimraf dist && tsc && eslint .", loc)
+	sc.d.mu.Lock()
+	sc.markScriptUsage("rimraf", loc)
+	sc.d.mu.Unlock()
+	sc.markConfigStrings("ci.yml", []byte(`run: "eslint"`))
+	sc.markModuleAsFound("typescript", Location{File: "build.js", Line: 1})
+
+	want := []ScriptOnlyUsage{{Package: "rimraf", Section: "devDependencies", Locations: []Location{loc}}}
+	if got := sc.scriptOnlyUsages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("scriptOnlyUsages() = %v, want %v", got, want)
+	}
+
+	sc.markScriptOnlyAsUnused()
+	if sc.d.mp["rimraf"] || !sc.d.mp["typescript"] || !sc.d.mp["eslint"] {
+		t.Errorf("markScriptOnlyAsUnused() left d.mp = %v", sc.d.mp)
+	}
+	for _, f := range sc.buildFindings("app") {
+		if f.Package == "rimraf" && !strings.Contains(f.Message, "only named by the scripts") {
+			t.Errorf("message of the script-only dependency = %q", f.Message)
+		}
+	}
+}
+
+func TestReportScriptOnly(t *testing.T) {
+	sc := newScanContext()
+	r := &Report{ScriptOnly: []ScriptOnlyUsage{{Package: "rimraf", Section: "devDependencies", Locations: []Location{{File: "package.json", Line: 3}}}}}
+	var text, github bytes.Buffer
+	if err := sc.reportText(&text, r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "Script-only usage:\n  rimraf (script-only)\n    at package.json:3\n") {
+		t.Errorf("reportText() = %q", text.String())
+	}
+	if err := reportGitHub(&github, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "::notice file=package.json,line=3,title=script-only::\"rimraf\" is script-only"; !strings.HasPrefix(github.String(), want) {
+		t.Errorf("reportGitHub() = %q, want prefix %q", github.String(), want)
+	}
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// severityOff turns a rule off in the "rules" of the config: its findings
+// are dropped, as if they were suppressed.
+const severityOff Severity = "off"
+
+// scriptOnlyRule is the name, in the "rules" of the config, of the
+// dependencies only named by the scripts of package.json. Turning it on
+// reports them as unused, like --treat-script-only-as-unused, with the
+// severity it is set to.
+const scriptOnlyRule = "script-only"
+
+// severityNames maps the severities accepted by the "rules" of the config,
+// including the ones of ESLint, to the severities.
+var severityNames = map[string]Severity{
+	"error": SeverityError, "warn": SeverityWarning, "warning": SeverityWarning,
+	"note": SeverityNote, "info": SeverityNote, "off": severityOff,
+}
+
+// parseRuleSeverities parses the "rules" of the config, mapping rule IDs,
+// or the kinds of findings of --fail-on, e.g. "phantom", to severities:
+//
+//	"rules": {"unused-dev-dependency": "warn", "phantom": "error", "script-only": "off"}
+//
+// The policy rules always fail the check, so they can't be configured.
+func (sc *scanContext) parseRuleSeverities(config map[string]string) (map[string]Severity, error) {
+	severities := make(map[string]Severity)
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	// The rule IDs override the kinds they belong to, whatever the order.
+	sort.Slice(names, func(i, j int) bool {
+		_, iKind := findingKinds[names[i]]
+		_, jKind := findingKinds[names[j]]
+		return iKind && !jKind || iKind == jKind && names[i] < names[j]
+	})
+	for _, name := range names {
+		severity, ok := severityNames[strings.ToLower(config[name])]
+		if !ok {
+			return nil, fmt.Errorf("unknown severity %q for the rule %q, available severities: error, warn, note, off", config[name], name)
+		}
+		_, isRule := sc.ruleByID(name)
+		switch {
+		case policyRules[name]:
+			return nil, fmt.Errorf("the severity of the policy rule %q can't be configured", name)
+		case isRule || name == scriptOnlyRule:
+			severities[name] = severity
+		case findingKinds[name] != nil:
+			for _, rule := range findingKinds[name] {
+				severities[rule] = severity
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule %q in the config", name)
+		}
+	}
+	return severities, nil
+}
+
+// severityOf returns the severity the config sets for the finding, if it
+// sets one. The severity of script-only dependencies reported as unused
+// is the one of scriptOnlyRule, when set.
+func (sc *scanContext) severityOf(f Finding, severities map[string]Severity) (Severity, bool) {
+	if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+		if severity, ok := severities[scriptOnlyRule]; ok && sc.d.evidence[f.Package] == EvidenceScript {
+			return severity, true
+		}
+	}
+	severity, ok := severities[f.RuleID]
+	return severity, ok
+}
+
+// applyRuleSeverities sets the severity of the findings of the rules which
+// the config sets, and drops the findings of the rules it turns off.
+func (sc *scanContext) applyRuleSeverities(findings []Finding, severities map[string]Severity) []Finding {
+	if len(severities) == 0 {
+		return findings
+	}
+	for i, f := range findings {
+		if severity, ok := sc.severityOf(f, severities); ok {
+			findings[i].Severity = severity
+		}
+	}
+	return slices.DeleteFunc(findings, func(f Finding) bool {
+		return f.Severity == severityOff
+	})
+}
+
+// failingFindings returns the findings which may fail the check: the ones
+// of the rules which the config doesn't lower to a warning or a note.
+func (sc *scanContext) failingFindings(findings []Finding, severities map[string]Severity) []Finding {
+	var failing []Finding
+	for _, f := range findings {
+		if severity, ok := sc.severityOf(f, severities); !ok || severity == SeverityError {
+			failing = append(failing, f)
+		}
+	}
+	return failing
+}
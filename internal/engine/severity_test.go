@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRuleSeverities(t *testing.T) {
+	sc := newScanContext()
+	got, err := sc.parseRuleSeverities(map[string]string{
+		"unused":                "off",
+		"unused-dev-dependency": "Warn",
+		"phantom":               "error",
+		"script-only":           "note",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Severity{
+		RuleUnusedDependency:    severityOff,
+		RuleUnusedDevDependency: SeverityWarning,
+		RulePhantomDependency:   SeverityError,
+		scriptOnlyRule:          SeverityNote,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRuleSeverities() = %v, want %v", got, want)
+	}
+
+	for _, config := range []map[string]string{
+		{"unused": "fatal"},
+		{"unknown-rule": "warn"},
+		{RuleBannedDependency: "off"},
+	} {
+		if _, err := sc.parseRuleSeverities(config); err == nil {
+			t.Errorf("parseRuleSeverities(%v) accepted an invalid config", config)
+		}
+	}
+}
+
+func TestApplyRuleSeverities(t *testing.T) {
+	sc := newScanContext()
+	sc.d.evidence = map[string]Evidence{"nodemon": EvidenceScript}
+	defer func() { sc.d.evidence = nil }()
+	severities := map[string]Severity{
+		RuleUnusedDependency:  severityOff,
+		RulePhantomDependency: SeverityError,
+		RuleMissingDependency: SeverityWarning,
+		scriptOnlyRule:        SeverityNote,
+	}
+	findings := sc.applyRuleSeverities([]Finding{
+		{RuleID: RuleUnusedDependency, Package: "lodash", Severity: SeverityWarning},
+		{RuleID: RuleUnusedDevDependency, Package: "nodemon", Severity: SeverityWarning},
+		{RuleID: RuleUnusedDevDependency, Package: "jest", Severity: SeverityWarning},
+		{RuleID: RulePhantomDependency, Package: "debug", Severity: SeverityNote},
+		{RuleID: RuleMissingDependency, Package: "axios", Severity: SeverityError},
+	}, severities)
+
+	want := []Finding{
+		{RuleID: RuleUnusedDevDependency, Package: "nodemon", Severity: SeverityNote},
+		{RuleID: RuleUnusedDevDependency, Package: "jest", Severity: SeverityWarning},
+		{RuleID: RulePhantomDependency, Package: "debug", Severity: SeverityError},
+		{RuleID: RuleMissingDependency, Package: "axios", Severity: SeverityWarning},
+	}
+	if !reflect.DeepEqual(findings, want) {
+		t.Fatalf("applyRuleSeverities() = %+v, want %+v", findings, want)
+	}
+
+	// The rules lowered by the config never fail the check, unlike the
+	// ones it doesn't set.
+	var packages []string
+	for _, f := range sc.failingFindings(findings, severities) {
+		packages = append(packages, f.Package)
+	}
+	if want := []string{"jest", "debug"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("failingFindings() = %q, want %q", packages, want)
+	}
+
+	groups := groupBySeverity(findings)
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	if want := []string{"error", "warning", "note"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("groupBySeverity() = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,230 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sinceSkippedFlags are the flags which are not passed to the scan of the
+// revision of --since, since they change its output or have side effects.
+var sinceSkippedFlags = map[string]bool{
+	"since": true, "reporter": true, "check": true, "update-baseline": true,
+	"stdout": true, "out": true, "dry-run": true, "verbose": true,
+	"notify-webhook": true, "notify-template": true, "notify-min-findings": true,
+	"timings": true, "metrics-textfile": true, "metrics-pushgateway": true,
+	"graph": true, "graph-lockfile": true, "bot-pr-body": true, "update-bots": true,
+	"outdated": true, "last-referenced": true, "group-by": true, "pr": true,
+	"no-ascend": true, "no-history": true, "from-tar": true, "output": true,
+	"verify": true, "filter": true, "expect": true, "update": true, "format-template": true,
+	"last-publish": true, "unmaintained-after": true, "bundle-size": true, "bundle-size-api": true, "explain": true, "pprof-dir": true,
+	"manifest": true,
+}
+
+// findingsAt returns the findings of the project at the git revision. The
+// tree of the project at the revision is scanned in memory by "depose
+// scan", with the flags set for the run, as if depose ran on that commit.
+func (sc *scanContext) findingsAt(rev string) ([]Finding, error) {
+	prefix, err := runGit("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"scan", "--from-tar", "-"}
+	if sc.definedFlags != nil {
+		sc.definedFlags.Visit(func(f *flag.Flag) {
+			if !sinceSkippedFlags[f.Name] {
+				args = append(args, "--"+f.Name+"="+f.Value.String())
+			}
+		})
+	}
+
+	archive := exec.Command("git", "archive", "--format=tar", rev+":"+prefix)
+	scan := exec.Command(exe, args...)
+	var stderr bytes.Buffer
+	archive.Stderr = &stderr
+	if scan.Stdin, err = archive.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	scan.Stderr = &stderr
+	if err := archive.Start(); err != nil {
+		return nil, err
+	}
+	out, scanErr := scan.Output()
+	if err := archive.Wait(); err != nil {
+		return nil, fmt.Errorf("git archive %s: %v: %s", rev, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	if scanErr != nil {
+		return nil, fmt.Errorf("%v: %s", scanErr, lastLines(stderr.Bytes(), 3))
+	}
+
+	var report Report
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, err
+	}
+	return report.Findings, nil
+}
+
+// lastLines returns the last n lines of the output, e.g. to explain why a
+// command failed without its progress messages.
+func lastLines(out []byte, n int) []byte {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// applySince splits the findings into the ones introduced since the
+// revision, and the ones already found at the revision, which are marked
+// as suppressed. Findings are matched like the entries of the baseline.
+func applySince(findings, base []Finding, rev string) (introduced, existing []Finding) {
+	known := make(map[baselineEntry]bool)
+	for _, f := range base {
+		known[baselineEntry{f.RuleID, f.Package, f.Section}] = true
+	}
+	for _, f := range findings {
+		if known[baselineEntry{f.RuleID, f.Package, f.Section}] {
+			f.Suppression = "already found at " + rev
+			existing = append(existing, f)
+			continue
+		}
+		introduced = append(introduced, f)
+	}
+	return introduced, existing
+}
+
+// hunkHeaderRe matches the header of a hunk of a unified diff, capturing
+// the first line and the number of lines of the new file, e.g. "12" and
+// "3" in "@@ -10,2 +12,3 @@". The number is 1 when omitted.
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// changedLines are the lines added since a git revision, by file.
+type changedLines struct {
+	lines map[string]map[int]bool
+	// untracked are the new files git doesn't track yet, whose lines are
+	// all added.
+	untracked map[string]bool
+}
+
+// contains reports whether the location is on a line added since the
+// revision.
+func (c changedLines) contains(loc Location) bool {
+	return c.untracked[loc.File] || c.lines[loc.File][loc.Line]
+}
+
+// parseAddedLines returns the lines added by a unified diff without
+// context, by file, the new paths of the files relative to the project.
+func parseAddedLines(diff string) map[string]map[int]bool {
+	added := make(map[string]map[int]bool)
+	var file string
+	for _, line := range strings.Split(diff, "\n") {
+		if name, ok := strings.CutPrefix(line, "+++ "); ok {
+			if unquoted, err := strconv.Unquote(name); err == nil {
+				name = unquoted
+			}
+			file = ""
+			if name, ok = strings.CutPrefix(name, "b/"); ok {
+				file = name
+			}
+			continue
+		}
+		m := hunkHeaderRe.FindStringSubmatch(line)
+		if m == nil || file == "" {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		for l := start; l < start+count; l++ {
+			if added[file] == nil {
+				added[file] = make(map[int]bool)
+			}
+			added[file][l] = true
+		}
+	}
+	return added
+}
+
+// linesAddedSince returns the lines of the project added since the git
+// revision, in the working tree, along with the untracked files.
+func linesAddedSince(rev string) (changedLines, error) {
+	diff, err := runGit("diff", "--unified=0", "--no-color", "--no-ext-diff", "--relative", rev, "--")
+	if err != nil {
+		return changedLines{}, err
+	}
+	others, err := runGit("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return changedLines{}, err
+	}
+	c := changedLines{lines: parseAddedLines(diff), untracked: make(map[string]bool)}
+	for _, file := range strings.Split(others, "\n") {
+		if file != "" {
+			c.untracked[file] = true
+		}
+	}
+	return c, nil
+}
+
+// applyNewUsages reports the undeclared packages used by the lines added
+// since the revision, with only the locations of those lines, e.g. "the
+// changes since origin/main introduce usage of "zod" but don't add it to
+// package.json". This is more precise than the missing dependencies of the
+// whole project: a missing or phantom dependency already found at the
+// revision is reported again when the changes add usages of it.
+func (sc *scanContext) applyNewUsages(introduced, existing []Finding, added changedLines, rev string) (reported, accepted []Finding) {
+	for _, f := range introduced {
+		if locations := addedUsages(f, added); len(locations) > 0 {
+			f = sc.newUsageFinding(f, locations, rev)
+		}
+		reported = append(reported, f)
+	}
+	for _, f := range existing {
+		if locations := addedUsages(f, added); len(locations) > 0 {
+			f = sc.newUsageFinding(f, locations, rev)
+			f.Suppression = ""
+			reported = append(reported, f)
+			continue
+		}
+		accepted = append(accepted, f)
+	}
+	return reported, accepted
+}
+
+// addedUsages returns the locations of the missing or phantom dependency
+// on the lines added since the revision.
+func addedUsages(f Finding, added changedLines) []Location {
+	if f.RuleID != RuleMissingDependency && f.RuleID != RulePhantomDependency {
+		return nil
+	}
+	var locations []Location
+	for _, loc := range f.Locations {
+		if added.contains(loc) {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// newUsageFinding returns the finding of the missing or phantom dependency
+// for its usages added since the revision.
+func (sc *scanContext) newUsageFinding(f Finding, locations []Location, rev string) Finding {
+	f.Locations = locations
+	if f.RuleID == RulePhantomDependency {
+		f.Message = fmt.Sprintf("the changes since %s introduce usage of %q, only installed as a transitive dependency, but don't add it to %s", rev, f.Package, sc.manifestFile)
+	} else {
+		f.Message = fmt.Sprintf("the changes since %s introduce usage of %q but don't add it to %s", rev, f.Package, sc.manifestFile)
+	}
+	return f
+}
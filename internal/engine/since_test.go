@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestApplySince(t *testing.T) {
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "moment", Section: "dependencies"},
+		{RuleID: RuleUnusedDependency, Package: "lodash", Section: "dependencies"},
+		{RuleID: RuleMissingDependency, Package: "moment"},
+	}
+	base := []Finding{{RuleID: RuleUnusedDependency, Package: "moment", Section: "dependencies"}}
+	introduced, existing := applySince(findings, base, "origin/main")
+	if len(introduced) != 2 || introduced[0].Package != "lodash" || introduced[1].RuleID != RuleMissingDependency {
+		t.Errorf("introduced = %+v", introduced)
+	}
+	if len(existing) != 1 || existing[0].Package != "moment" || existing[0].Suppression != "already found at origin/main" {
+		t.Errorf("existing = %+v", existing)
+	}
+}
+
+func TestSinceFlag(t *testing.T) {
+	deposePath := buildDepose(t)
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeFiles(t, map[string]string{
+		"package.json": `{"name": "app", "dependencies": {"express": "^4.18.2", "moment": "^2.29.4"}}`,
+		"index.js":     "const express = require(\"express\");\n",
+	})
+	env := append(os.Environ(), "GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com")
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"add", "-A"}, {"commit", "-q", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	// The change adds lodash, which is unused too.
+	writeFiles(t, map[string]string{
+		"package.json": `{"name": "app", "dependencies": {"express": "^4.18.2", "lodash": "^4.17.21", "moment": "^2.29.4"}}`,
+	})
+
+	out, err := exec.Command(deposePath, "--since", "HEAD", "--reporter", "json", "--dry-run", "--no-history").Output()
+	if err != nil {
+		t.Fatalf("depose --since: %v\n%s", err, out)
+	}
+	var report Report
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, out)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Package != "lodash" {
+		t.Errorf("findings = %+v, want only lodash", report.Findings)
+	}
+}
+
+func TestParseAddedLines(t *testing.T) {
+	diff := `diff --git a/src/app.js b/src/app.js
+index 1111111..2222222 100644
+--- a/src/app.js
++++ b/src/app.js
+@@ -3,0 +4,2 @@ import express from "express"
++import zod from "zod"
++import axios from "axios"
+@@ -10 +12 @@ app.listen()
+-app.listen(3000)
++app.listen(port)
+diff --git a/old.js b/old.js
+deleted file mode 100644
+--- a/old.js
++++ /dev/null
+@@ -1 +0,0 @@
+-require("moment")
+`
+	want := map[string]map[int]bool{"src/app.js": {4: true, 5: true, 12: true}}
+	if got := parseAddedLines(diff); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAddedLines() = %v, want %v", got, want)
+	}
+}
+
+func TestApplyNewUsages(t *testing.T) {
+	sc := newScanContext()
+	sc.manifestFile = "package.json"
+	added := changedLines{
+		lines:     map[string]map[int]bool{"src/app.js": {4: true}},
+		untracked: map[string]bool{"src/new.js": true},
+	}
+	introduced := []Finding{
+		{RuleID: RuleMissingDependency, Package: "axios", Message: "axios", Locations: []Location{{File: "src/app.js", Line: 4}}},
+		{RuleID: RuleUnusedDependency, Package: "lodash", Section: "dependencies"},
+	}
+	existing := []Finding{
+		{RuleID: RuleMissingDependency, Package: "zod", Suppression: "already found at origin/main",
+			Locations: []Location{{File: "src/app.js", Line: 1}, {File: "src/new.js", Line: 2}}},
+		{RuleID: RuleMissingDependency, Package: "yup", Suppression: "already found at origin/main",
+			Locations: []Location{{File: "src/app.js", Line: 2}}},
+	}
+	reported, accepted := sc.applyNewUsages(introduced, existing, added, "origin/main")
+
+	want := []Finding{
+		{RuleID: RuleMissingDependency, Package: "axios", Locations: []Location{{File: "src/app.js", Line: 4}},
+			Message: `the changes since origin/main introduce usage of "axios" but don't add it to package.json`},
+		{RuleID: RuleUnusedDependency, Package: "lodash", Section: "dependencies"},
+		{RuleID: RuleMissingDependency, Package: "zod", Locations: []Location{{File: "src/new.js", Line: 2}},
+			Message: `the changes since origin/main introduce usage of "zod" but don't add it to package.json`},
+	}
+	if !reflect.DeepEqual(reported, want) {
+		t.Errorf("reported = %+v, want %+v", reported, want)
+	}
+	if len(accepted) != 1 || accepted[0].Package != "yup" {
+		t.Errorf("accepted = %+v, want only yup", accepted)
+	}
+}
+
+func TestSinceNewUsages(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{"name": "app", "dependencies": {"express": "^4.18.2"}}`,
+		"index.js":     "const express = require(\"express\");\nconst yup = require(\"yup\");\n",
+	})
+	env := append(os.Environ(), "GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com")
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"add", "-A"}, {"commit", "-q", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	// yup was already missing, but the change uses it in a new file.
+	writeFiles(t, map[string]string{
+		"index.js":   "const express = require(\"express\");\nconst yup = require(\"yup\");\nconst zod = require(\"zod\");\n",
+		"src/new.js": "import * as yup from \"yup\";\n",
+	})
+
+	out, err := exec.Command(deposePath, "--since", "HEAD", "--reporter", "json", "--dry-run", "--no-history").Output()
+	if err != nil {
+		t.Fatalf("depose --since: %v\n%s", err, out)
+	}
+	var report Report
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, out)
+	}
+	got := make(map[string][]Location)
+	for _, f := range report.Findings {
+		got[f.Package] = f.Locations
+	}
+	want := map[string][]Location{
+		"yup": {{File: "src/new.js", Line: 1}},
+		"zod": {{File: "index.js", Line: 3}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %+v, want the usages of yup and zod added by the change", report.Findings)
+	}
+}
@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// The classifications of the packages referenced by a file, against the
+// manifest of the project.
+const (
+	classDeclared = "declared"
+	classPhantom  = "phantom"
+	classMissing  = "missing"
+	classBuiltin  = "builtin"
+	classSelf     = "self"
+)
+
+// fileReference is a package referenced by a file, with the lines it is
+// referenced on.
+type fileReference struct {
+	Package string `json:"package"`
+	// Classification is one of declared, phantom (only installed as a
+	// transitive dependency), missing, builtin or self (the project
+	// itself).
+	Classification string `json:"classification"`
+	// Section is the section of the manifest the package is declared in.
+	Section string `json:"section,omitempty"`
+	Lines   []int  `json:"lines"`
+}
+
+// fileReport is the output of "depose file" with --json.
+type fileReport struct {
+	File     string          `json:"file"`
+	Manifest string          `json:"manifest"`
+	Packages []fileReference `json:"packages"`
+}
+
+// fileReferences returns the packages referenced by the file, which has
+// been scanned, classified against the manifest of the project, by name.
+func (sc *scanContext) fileReferences(file, projectName string) []fileReference {
+	var refs []fileReference
+	for pkgName, locations := range sc.d.usages {
+		var lines []int
+		for _, loc := range locations {
+			if loc.File == file && !slices.Contains(lines, loc.Line) {
+				lines = append(lines, loc.Line)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		sort.Ints(lines)
+		ref := fileReference{Package: pkgName, Lines: lines}
+		switch _, declared := sc.d.mp[pkgName]; {
+		case declared:
+			ref.Classification, ref.Section = classDeclared, sc.lang.sectionOf(pkgName)
+		case pkgName == projectName:
+			ref.Classification = classSelf
+		case sc.lang.isBuiltin(pkgName):
+			ref.Classification = classBuiltin
+		case sc.lang.isInstalled(pkgName):
+			ref.Classification = classPhantom
+		default:
+			ref.Classification = classMissing
+		}
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Package < refs[j].Package })
+	return refs
+}
+
+// writeFileReferences prints the packages referenced by the file as a
+// table, e.g. "express  declared (dependencies)  1, 14".
+func writeFileReferences(w io.Writer, file string, refs []fileReference) error {
+	if len(refs) == 0 {
+		_, err := fmt.Fprintf(w, "%s references no package\n", file)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tCLASSIFICATION\tLINES")
+	for _, ref := range refs {
+		class := ref.Classification
+		if ref.Section != "" {
+			class += " (" + ref.Section + ")"
+		}
+		lines := make([]string, len(ref.Lines))
+		for i, line := range ref.Lines {
+			lines[i] = fmt.Sprint(line)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", ref.Package, class, strings.Join(lines, ", "))
+	}
+	return tw.Flush()
+}
+
+// projectRelPath returns the path of the file relative to the project
+// root, the current directory, with forward slashes. The path was made
+// absolute before depose entered the project root.
+func projectRelPath(abs string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not in the project at %s", abs, wd)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// fileCommand implements "depose file", which reports the packages
+// referenced by a single file, with their lines and their classification
+// against the manifest, for quick spot checks and editor integrations:
+//
+//	depose file --json src/server.ts
+func (sc *scanContext) fileCommand(fs *flag.FlagSet) func(args []string) {
+	asJSON := fs.Bool("json", false, "print the packages as JSON")
+	fs.BoolVar(&sc.strict, "strict", false, "only count imports as usage, not package names found in scripts and config files")
+	fs.StringVar(&sc.langName, "lang", "node", "language of the project: node, go or python")
+	return func(args []string) {
+		var ok bool
+		if sc.lang, ok = sc.languages[sc.langName]; !ok {
+			logFatalf("Unknown language %q", sc.langName)
+		}
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "usage: depose file [flags] <path>")
+			fs.PrintDefaults()
+			exit(2)
+		}
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			logFatal(err)
+		}
+		sc.logOut = io.Discard
+		sc.d.mp = make(map[string]bool)
+		sc.d.usages = make(map[string][]Location)
+		sc.d.ignoredLines = make(map[Location][]string)
+		sc.d.unresolved = make(map[string][]Location)
+		if !sc.noAscend {
+			sc.enterProjectRoot(sc.lang)
+		}
+		sc.manifestFile = sc.findManifest(sc.lang)
+		projectName := sc.lang.readManifest(sc.manifestFile)
+
+		file, err := projectRelPath(abs)
+		if err != nil {
+			logFatal(err)
+		}
+		if _, err := sc.statProjectFile(file); err != nil {
+			fatal(classifyError(file, err, false))
+		}
+		extractor, ok := sc.extractorFor(file)
+		if !ok {
+			logFatalf("%s is not a file depose scans for %s projects", file, sc.lang.name)
+		}
+		sc.readFileAndExtractPackages(io.Discard, file, extractor)
+
+		refs := sc.fileReferences(file, projectName)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if refs == nil {
+				refs = []fileReference{}
+			}
+			if err := enc.Encode(fileReport{File: file, Manifest: sc.manifestFile, Packages: refs}); err != nil {
+				logFatal(err)
+			}
+			return
+		}
+		if err := writeFileReferences(os.Stdout, file, refs); err != nil {
+			logFatal(err)
+		}
+	}
+}
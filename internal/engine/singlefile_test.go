@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/CoderParth/depose/extract"
+)
+
+func TestFileReferences(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json":                    "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"express\": \"^4.0.0\"\n  }\n}\n",
+		"node_modules/debug/package.json": `{"name": "debug"}`,
+		"src/app.js": `import express from "express"
+import fs from "node:fs"
+const debug = require("debug")
+import axios from "axios"
+import { handler } from "app/handlers"
+express.static(fs)
+`,
+		"src/other.js": `import lodash from "lodash"`,
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	sc.d = Dependency{mp: make(map[string]bool), usages: make(map[string][]Location), ignoredLines: make(map[Location][]string), unresolved: make(map[string][]Location)}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.bins = nil }()
+
+	projectName := sc.lang.readManifest("package.json")
+	for _, file := range []string{"src/app.js", "src/other.js"} {
+		extractor, _ := extract.For(sc.lang.name, file)
+		sc.readFileAndExtractPackages(io.Discard, file, extractor)
+	}
+
+	refs := sc.fileReferences("src/app.js", projectName)
+	want := []fileReference{
+		{Package: "app", Classification: classSelf, Lines: []int{5}},
+		{Package: "axios", Classification: classMissing, Lines: []int{4}},
+		{Package: "debug", Classification: classPhantom, Lines: []int{3}},
+		{Package: "express", Classification: classDeclared, Section: "dependencies", Lines: []int{1}},
+		{Package: "node:fs", Classification: classBuiltin, Lines: []int{2}},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Fatalf("fileReferences() = %+v, want %+v", refs, want)
+	}
+
+	var buf bytes.Buffer
+	if err := writeFileReferences(&buf, "src/app.js", refs[3:4]); err != nil {
+		t.Fatal(err)
+	}
+	if want := "PACKAGE  CLASSIFICATION           LINES\nexpress  declared (dependencies)  1\n"; buf.String() != want {
+		t.Errorf("writeFileReferences() = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// toolConfig lists the config files read by a tool, which are left
+// behind once the tool is removed. Patterns are matched against the base
+// names of the files with path.Match.
+type toolConfig struct {
+	// tools are the packages of the tool; the files are stale once every
+	// declared one is removed, e.g. either @babel/core or babel-core.
+	tools    []string
+	patterns []string
+}
+
+// toolConfigs are the config files of the tools commonly removed from
+// projects.
+var toolConfigs = []toolConfig{
+	{[]string{"tslint"}, []string{"tslint.json", "tslint.yaml"}},
+	{[]string{"eslint"}, []string{".eslintrc", ".eslintrc.*", "eslint.config.*", ".eslintignore"}},
+	{[]string{"@babel/core", "babel-core"}, []string{".babelrc", ".babelrc.*", "babel.config.*"}},
+	{[]string{"prettier"}, []string{".prettierrc", ".prettierrc.*", "prettier.config.*", ".prettierignore"}},
+	{[]string{"stylelint"}, []string{".stylelintrc", ".stylelintrc.*", "stylelint.config.*"}},
+	{[]string{"jest"}, []string{"jest.config.*"}},
+	{[]string{"mocha"}, []string{".mocharc", ".mocharc.*"}},
+	{[]string{"nyc"}, []string{".nycrc", ".nycrc.*"}},
+	{[]string{"karma"}, []string{"karma.conf.*"}},
+	{[]string{"nodemon"}, []string{"nodemon.json"}},
+	{[]string{"husky"}, []string{".huskyrc", ".huskyrc.*"}},
+	{[]string{"lint-staged"}, []string{".lintstagedrc", ".lintstagedrc.*", "lint-staged.config.*"}},
+	{[]string{"@commitlint/cli"}, []string{".commitlintrc", ".commitlintrc.*", "commitlint.config.*"}},
+	{[]string{"webpack", "webpack-cli"}, []string{"webpack.config.*"}},
+	{[]string{"rollup"}, []string{"rollup.config.*"}},
+	{[]string{"vite"}, []string{"vite.config.*"}},
+	{[]string{"vitest"}, []string{"vitest.config.*"}},
+	{[]string{"tailwindcss"}, []string{"tailwind.config.*"}},
+	{[]string{"cypress"}, []string{"cypress.config.*", "cypress.json"}},
+	{[]string{"@playwright/test"}, []string{"playwright.config.*"}},
+}
+
+// staleFindings returns the findings about the scripts of package.json
+// running the executables of the unused dependencies, which are removed,
+// and about the config files of the tools which are all removed.
+func (sc *scanContext) staleFindings(findings []Finding) []Finding {
+	removed := make(map[string]bool)
+	for _, f := range findings {
+		if f.RuleID == RuleUnusedDependency || f.RuleID == RuleUnusedDevDependency {
+			removed[f.Package] = true
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	var stale []Finding
+	scriptLines := sc.declaredLines("package.json")["scripts"]
+	for name, script := range sc.manifest.Scripts {
+		for _, ref := range scriptReferences(script, sc.bins) {
+			if ref.Evidence == EvidenceScript && removed[ref.Package] {
+				stale = append(stale, sc.newFinding(RuleStaleScript, ref.Package, "scripts",
+					fmt.Sprintf("script %q runs %q, which is unused", name, ref.Package),
+					[]Location{{File: "package.json", Line: scriptLines[name]}},
+					fmt.Sprintf("remove the %q script", name)))
+				break
+			}
+		}
+	}
+
+	for _, tc := range toolConfigs {
+		tool, ok := sc.removedTool(tc.tools, removed)
+		if !ok {
+			continue
+		}
+		for _, file := range sc.files {
+			file = filepath.ToSlash(file)
+			if matchesAnyPattern(path.Base(file), tc.patterns) {
+				stale = append(stale, sc.newFinding(RuleStaleConfig, tool, "",
+					fmt.Sprintf("%s configures %q, which is unused", file, tool),
+					[]Location{{File: file}}, "delete "+file))
+			}
+		}
+	}
+	return stale
+}
+
+// removedTool returns the removed package of the tool, when none of its
+// packages is kept.
+func (sc *scanContext) removedTool(tools []string, removed map[string]bool) (string, bool) {
+	tool := ""
+	for _, t := range tools {
+		if removed[t] {
+			tool = t
+		} else if _, declared := sc.d.mp[t]; declared {
+			return "", false
+		}
+	}
+	return tool, tool != ""
+}
+
+// matchesAnyPattern reports whether the name matches one of the patterns.
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// staleScriptLines returns the lines of package.json declaring the
+// scripts reported as stale.
+func staleScriptLines(findings []Finding) map[int]bool {
+	lines := make(map[int]bool)
+	for _, f := range findings {
+		if f.RuleID == RuleStaleScript && f.Locations[0].Line > 0 {
+			lines[f.Locations[0].Line] = true
+		}
+	}
+	return lines
+}
+
+// removeLines removes the lines, numbered from 1, from the content of
+// package.json. The trailing commas left behind are removed with the
+// ones of the dependencies, by removeTrailingCommas.
+func removeLines(data []byte, drop map[int]bool) []byte {
+	if len(drop) == 0 {
+		return data
+	}
+	var kept strings.Builder
+	for i, line := range strings.SplitAfter(string(data), "\n") {
+		if !drop[i+1] {
+			kept.WriteString(line)
+		}
+	}
+	return []byte(kept.String())
+}
+
+// deleteStaleConfigs deletes the config files reported as stale, and
+// returns them.
+func (sc *scanContext) deleteStaleConfigs(findings []Finding) ([]string, error) {
+	var deleted []string
+	for _, f := range findings {
+		if f.RuleID != RuleStaleConfig {
+			continue
+		}
+		file := f.Locations[0].File
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+		fmt.Fprintf(sc.logOut, "Deleted %s, which configures %s.\n", file, f.Package)
+		deleted = append(deleted, file)
+	}
+	return deleted, nil
+}
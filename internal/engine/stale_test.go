@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStaleFindings(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"scripts\": {\n    \"lint\": \"tslint -p .\",\n    \"build\": \"babel src -d lib\",\n    \"test\": \"jest\"\n  }\n}\n",
+	})
+	sc.manifest = Package{Scripts: map[string]string{"lint": "tslint -p .", "build": "babel src -d lib", "test": "jest"}}
+	sc.d.mp = map[string]bool{"tslint": false, "@babel/core": false, "babel-core": true, "jest": true}
+	sc.files = []string{"tslint.json", ".babelrc", "src/.eslintrc.json", "jest.config.js"}
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.files = nil }()
+
+	findings := []Finding{
+		{RuleID: RuleUnusedDevDependency, Package: "tslint"},
+		{RuleID: RuleUnusedDevDependency, Package: "@babel/core"},
+		{RuleID: RuleMissingDependency, Package: "jest"},
+	}
+	var got []string
+	for _, f := range sc.staleFindings(findings) {
+		got = append(got, f.RuleID+" "+f.Package+" "+f.Locations[0].String()+" "+f.SuggestedFix)
+	}
+	// babel-core keeps .babelrc, and the build script runs babel, not @babel/core.
+	want := []string{
+		`stale-script tslint package.json:3 remove the "lint" script`,
+		"stale-config tslint tslint.json delete tslint.json",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stale findings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRemoveLines(t *testing.T) {
+	data := "{\n  \"scripts\": {\n    \"build\": \"tsc\",\n    \"lint\": \"tslint -p .\"\n  }\n}\n"
+	got := string(removeTrailingCommas(removeLines([]byte(data), map[int]bool{4: true})))
+	if want := "{\n  \"scripts\": {\n    \"build\": \"tsc\"\n  }\n}\n"; got != want {
+		t.Errorf("removeLines() = %q, want %q", got, want)
+	}
+}
+
+func TestFixScripts(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": "{\n  \"scripts\": {\n    \"start\": \"node index.js\",\n    \"lint\": \"tslint -p .\"\n  },\n  \"devDependencies\": {\n    \"tslint\": \"^6.1.3\"\n  }\n}\n",
+		"index.js":     "console.log('hello');\n",
+		"tslint.json":  "{\"extends\": \"tslint:recommended\"}\n",
+	})
+	out, err := exec.Command(deposePath, "--strict", "--fix-scripts", "--no-history").CombinedOutput()
+	if err != nil {
+		t.Fatalf("depose --fix-scripts: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile("package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"scripts\": {\n    \"start\": \"node index.js\"\n  },\n  \"devDependencies\": {\n  }\n}\n"; string(data) != want {
+		t.Errorf("package.json = %q, want %q", data, want)
+	}
+	if _, err := os.Stat("tslint.json"); !os.IsNotExist(err) {
+		t.Errorf("tslint.json was not deleted: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,232 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+// userConfigDir returns the directory of the configuration of the user,
+// where the stats are stored. It is replaced by the tests.
+var userConfigDir = os.UserConfigDir
+
+// usageStats is the local aggregate of the runs of depose, across the
+// projects of the user. It is never transmitted anywhere.
+type usageStats struct {
+	// Since is the time of the first recorded run.
+	Since time.Time `json:"since"`
+	// Projects are the stats of every project scanned, keyed by a hash of
+	// its directory, so that the file doesn't hold the paths of the user.
+	Projects map[string]*projectStats `json:"projects"`
+}
+
+// projectStats are the stats of the runs in a project.
+type projectStats struct {
+	Runs    int       `json:"runs"`
+	LastRun time.Time `json:"lastRun"`
+	// Removed is the number of dependencies removed from the manifest,
+	// and BytesSaved the size of their directories in node_modules.
+	Removed    int   `json:"removed"`
+	BytesSaved int64 `json:"bytesSaved"`
+}
+
+// statsPath returns the path of the stats file, in the configuration
+// directory of the user, e.g. ~/.config/depose/stats.json on Linux.
+func statsPath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "depose", "stats.json"), nil
+}
+
+// projectKey returns the key of the project in the directory in the
+// stats: a short hash of its absolute path.
+func projectKey(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	sum := sha256.Sum256([]byte(dir))
+	return hex.EncodeToString(sum[:8])
+}
+
+// readStats reads the stats file. A missing file is treated as empty stats.
+func readStats(path string) (*usageStats, error) {
+	stats := &usageStats{Projects: make(map[string]*projectStats)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, err
+	}
+	if stats.Projects == nil {
+		stats.Projects = make(map[string]*projectStats)
+	}
+	return stats, nil
+}
+
+// recordStats updates the stats of the project of the directory in the
+// stats file, e.g. to count a run.
+func recordStats(path, dir string, update func(*projectStats)) error {
+	stats, err := readStats(path)
+	if err != nil {
+		return err
+	}
+	key := projectKey(dir)
+	p := stats.Projects[key]
+	if p == nil {
+		p = &projectStats{}
+		stats.Projects[key] = p
+	}
+	update(p)
+	if stats.Since.IsZero() {
+		stats.Since = p.LastRun
+	}
+
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// countRun counts a run of the project at the time in its stats.
+func countRun(now time.Time) func(*projectStats) {
+	return func(p *projectStats) {
+		p.Runs++
+		p.LastRun = now.UTC()
+	}
+}
+
+// countRemoval counts the dependencies removed from the manifest of the
+// project, which saved the bytes, in its stats.
+func countRemoval(removed int, bytesSaved int64) func(*projectStats) {
+	return func(p *projectStats) {
+		p.Removed += removed
+		p.BytesSaved += bytesSaved
+	}
+}
+
+// installedSize returns the size of the installed directories of the
+// dependencies in node_modules of the project, i.e. the disk space freed by removing
+// them. The dependencies which aren't installed count for nothing.
+func (sc *scanContext) installedSize(deps []string) int64 {
+	var size int64
+	for _, dep := range deps {
+		fs.WalkDir(sc.projectFS, path.Join("node_modules", dep), func(_ string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.Type().IsRegular() {
+				if info, err := entry.Info(); err == nil {
+					size += info.Size()
+				}
+			}
+			return nil
+		})
+	}
+	return size
+}
+
+// statsTotals are the stats summed over the projects.
+type statsTotals struct {
+	Since      time.Time `json:"since"`
+	Runs       int       `json:"runs"`
+	Projects   int       `json:"projects"`
+	Removed    int       `json:"removed"`
+	BytesSaved int64     `json:"bytesSaved"`
+}
+
+// totals sums the stats of the projects.
+func (s *usageStats) totals() statsTotals {
+	t := statsTotals{Since: s.Since, Projects: len(s.Projects)}
+	for _, p := range s.Projects {
+		t.Runs += p.Runs
+		t.Removed += p.Removed
+		t.BytesSaved += p.BytesSaved
+	}
+	return t
+}
+
+// writeStats prints the totals of the stats as a table.
+func writeStats(w io.Writer, t statsTotals) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Since:\t%s\n", t.Since.Local().Format("2006-01-02"))
+	fmt.Fprintf(tw, "Runs:\t%d\n", t.Runs)
+	fmt.Fprintf(tw, "Projects scanned:\t%d\n", t.Projects)
+	fmt.Fprintf(tw, "Dependencies removed:\t%d\n", t.Removed)
+	fmt.Fprintf(tw, "Disk space saved:\t%s\n", FormatBytes(uint64(t.BytesSaved)))
+	return tw.Flush()
+}
+
+// statsCommand implements "depose stats", which prints the aggregate of
+// the past runs of depose on the machine, across projects. The stats are
+// local to the user, and never transmitted.
+//
+//	depose stats --json
+func statsCommand(fs *flag.FlagSet) func(args []string) {
+	asJSON := fs.Bool("json", false, "print the stats as JSON")
+	reset := fs.Bool("reset", false, "delete the recorded stats")
+	return func(args []string) {
+		path, err := statsPath()
+		if err != nil {
+			logFatalf("Failed to locate the stats: %v", err)
+		}
+		if *reset {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logFatal(err)
+			}
+			fmt.Fprintf(os.Stderr, "Deleted %s\n", path)
+			return
+		}
+		stats, err := readStats(path)
+		if err != nil {
+			logFatalf("Failed to read %s: %v", path, err)
+		}
+		if len(stats.Projects) == 0 {
+			fmt.Fprintf(os.Stderr, "No runs recorded in %s yet\n", path)
+			return
+		}
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats.totals()); err != nil {
+				logFatal(err)
+			}
+			return
+		}
+		if err := writeStats(os.Stdout, stats.totals()); err != nil {
+			logFatal(err)
+		}
+	}
+}
+
+// updateStats updates the stats of the project, reporting a failure as a
+// diagnostic, since the stats are only informative.
+func (sc *scanContext) updateStats(update func(*projectStats)) {
+	path, err := statsPath()
+	if err == nil {
+		err = recordStats(path, ".", update)
+	}
+	if err != nil {
+		fmt.Fprintf(sc.logOut, "Failed to record the run into the stats: %v\n", err)
+		sc.recordDiagnostic(path, err)
+	}
+}
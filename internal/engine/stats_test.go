@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// The runs of the tests in this process are not recorded into the stats
+// of the user. The binaries they run are kept off them by buildDepose.
+func init() {
+	dir := filepath.Join(os.TempDir(), "depose-test-config")
+	userConfigDir = func() (string, error) { return dir, nil }
+}
+
+func TestRecordStats(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"node_modules/left-pad/package.json":  `{"name": "left-pad"}`,
+		"node_modules/left-pad/index.js":      "module.exports = 1\n",
+		"node_modules/@types/node/index.d.ts": "export {}\n",
+	})
+	path := filepath.Join(t.TempDir(), "depose", "stats.json")
+	first := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	size := sc.installedSize([]string{"left-pad", "@types/node", "not-installed"})
+	if want := int64(len(`{"name": "left-pad"}`) + len("module.exports = 1\n") + len("export {}\n")); size != want {
+		t.Errorf("installedSize() = %d, want %d", size, want)
+	}
+	for _, update := range []func(*projectStats){countRun(first), countRemoval(3, size), countRun(first.Add(time.Hour))} {
+		if err := recordStats(path, ".", update); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recordStats(path, "..", countRun(first.Add(2*time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := readStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := stats.totals()
+	want := statsTotals{Since: first, Runs: 3, Projects: 2, Removed: 3, BytesSaved: size}
+	if got != want {
+		t.Errorf("totals() = %+v, want %+v", got, want)
+	}
+	data, _ := os.ReadFile(path)
+	if wd, _ := os.Getwd(); bytes.Contains(data, []byte(filepath.Base(wd))) {
+		t.Errorf("the stats hold the path of the project:\n%s", data)
+	}
+
+	var buf bytes.Buffer
+	if err := writeStats(&buf, got); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Projects scanned:      2", "Dependencies removed:  3"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("writeStats() = %q, want a line %q", buf.String(), line)
+		}
+	}
+}
+
+func TestInstalledSizeFromTar(t *testing.T) {
+	sc := newScanContext()
+	sc.setProjectFS(fstest.MapFS{
+		"package.json":                    {Data: []byte(`{"name": "app"}`)},
+		"node_modules/chalk/package.json": {Data: []byte(`{"name": "chalk"}`)},
+		"node_modules/chalk/index.js":     {Data: []byte("export default {}\n")},
+	})
+	defer sc.setProjectFS(os.DirFS("."))
+
+	if got, want := sc.installedSize([]string{"chalk"}), int64(len(`{"name": "chalk"}`)+len("export default {}\n")); got != want {
+		t.Errorf("installedSize() = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ignoreDirective is the comment which suppresses findings
+// reported on the line following it.
+//
+// Example:
+//
+//	// depose-ignore next-line
+//	const plugin = require("undeclared-plugin");
+//
+// Rule IDs can be listed after the directive to only suppress those rules:
+//
+//	// depose-ignore next-line phantom-dependency
+const ignoreDirective = "depose-ignore next-line"
+
+// Config represents the "depose" key of the package.json file,
+// which is used to configure depose per project.
+type Config struct {
+	// Ignore lists packages whose findings are suppressed.
+	Ignore []string `json:"ignore"`
+	// DenyLicenses lists the licenses which "depose licenses" flags,
+	// as SPDX identifiers, e.g. "GPL-3.0-only".
+	DenyLicenses []string `json:"denyLicenses"`
+	// Policy lists the banned and required dependencies, enforced with --check.
+	Policy Policy `json:"policy"`
+	// Check sets the budget of findings tolerated with --check.
+	Check Thresholds `json:"check"`
+	// Alternatives maps packages to the alternatives suggested for them,
+	// e.g. {"moment": "dayjs"}, in addition to the built-in ones, which an
+	// empty alternative disables.
+	Alternatives map[string]string `json:"alternatives"`
+	// Clean sets what "depose clean" keeps under .depose/.
+	Clean Retention `json:"clean"`
+	// Rules sets the severity of the rules, or of the kinds of findings,
+	// to error, warn, note or off, e.g. {"phantom": "error"}.
+	Rules map[string]string `json:"rules"`
+}
+
+// parseIgnoreDirective reports whether the line contains a valid ignore
+// directive, and returns the rule IDs it is restricted to, if any.
+//
+// Unknown rule IDs are returned as an error. When rule IDs are listed but
+// none of them is known, the directive is invalid, so that a misspelled
+// rule never turns into a directive suppressing every rule.
+func (sc *scanContext) parseIgnoreDirective(line string) ([]string, bool, error) {
+	idx := strings.Index(line, ignoreDirective)
+	if idx == -1 {
+		return nil, false, nil
+	}
+	rest, _, _ := strings.Cut(line[idx+len(ignoreDirective):], "\n")
+	rest = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "*/"))
+
+	var ruleIDs, unknown []string
+	for _, field := range strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if _, ok := sc.ruleByID(field); ok {
+			ruleIDs = append(ruleIDs, field)
+		} else {
+			unknown = append(unknown, field)
+		}
+	}
+
+	var err error
+	if len(unknown) > 0 {
+		err = fmt.Errorf("unknown rule ID(s) in %q comment: %s", ignoreDirective, strings.Join(unknown, ", "))
+	}
+	if len(ruleIDs) == 0 && len(unknown) > 0 {
+		return nil, false, err
+	}
+	return ruleIDs, true, err
+}
+
+// recordIgnoreDirectives records the lines of the file following an ignore
+// directive, so the findings reported on them can be suppressed.
+//
+// Directives are comments in every language, so they are looked for in the
+// raw content of the file, regardless of the extractor handling it.
+// Invalid directives are reported to w.
+func (sc *scanContext) recordIgnoreDirectives(w io.Writer, file string, data []byte) {
+	var ignoreNext bool
+	var ignoredRules []string
+	for lineNo, currLine := range strings.Split(string(data), "\n") {
+		loc := Location{File: file, Line: lineNo + 1}
+		if ignoreNext {
+			sc.markLineAsIgnored(loc, ignoredRules)
+		}
+		var err error
+		ignoredRules, ignoreNext, err = sc.parseIgnoreDirective(currLine)
+		if err != nil {
+			fmt.Fprintf(w, "Warning: %s: %v\n", loc, err)
+		}
+	}
+}
+
+// markLineAsIgnored records that findings on the given location
+// are suppressed for the given rules, or for all rules when none are given.
+func (sc *scanContext) markLineAsIgnored(loc Location, ruleIDs []string) {
+	sc.d.mu.Lock()
+	sc.d.ignoredLines[loc] = ruleIDs
+	sc.d.mu.Unlock()
+}
+
+// isLineIgnored reports whether findings of the rule are suppressed on the location.
+func (sc *scanContext) isLineIgnored(loc Location, ruleID string) bool {
+	ruleIDs, ok := sc.d.ignoredLines[Location{File: loc.File, Line: loc.Line}]
+	if !ok {
+		return false
+	}
+	if len(ruleIDs) == 0 {
+		return true
+	}
+	for _, id := range ruleIDs {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+// applySuppressions splits the findings into the ones which should be reported,
+// and the ones suppressed either by the "ignore" list of the config, or by
+// ignore directives on every line the finding was found on. Unused local
+// packages, e.g. "workspace:*", are suppressed too, unless --include-local.
+//
+// The reason of the suppression is stored in the suppressed findings.
+func (sc *scanContext) applySuppressions(findings []Finding, config Config) (kept, suppressed []Finding) {
+	ignored := make(map[string]bool, len(config.Ignore))
+	for _, pkgName := range config.Ignore {
+		ignored[pkgName] = true
+	}
+
+	for _, f := range findings {
+		switch {
+		case ignored[f.Package]:
+			f.Suppression = `listed in "depose.ignore" of package.json`
+			suppressed = append(suppressed, f)
+		case sc.allLinesIgnored(f):
+			f.Suppression = fmt.Sprintf("%q comment", ignoreDirective)
+			suppressed = append(suppressed, f)
+		case !sc.includeLocal && sc.isUnusedLocal(f):
+			f.Suppression = "local package, reported with --include-local"
+			suppressed = append(suppressed, f)
+		default:
+			kept = append(kept, f)
+		}
+	}
+	return kept, suppressed
+}
+
+// allLinesIgnored reports whether every location of the finding
+// is preceded by an ignore directive.
+func (sc *scanContext) allLinesIgnored(f Finding) bool {
+	if len(f.Locations) == 0 {
+		return false
+	}
+	for _, l := range f.Locations {
+		if !sc.isLineIgnored(l, f.RuleID) {
+			return false
+		}
+	}
+	return true
+}
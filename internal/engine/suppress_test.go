@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIgnoreDirective(t *testing.T) {
+	sc := newScanContext()
+	tests := []struct {
+		line    string
+		rules   []string
+		ignored bool
+		invalid bool
+	}{
+		{`const a = require("a");`, nil, false, false},
+		{`// depose-ignore next-line`, nil, true, false},
+		{`/* depose-ignore next-line missing-dependency */`, []string{RuleMissingDependency}, true, false},
+		{`// depose-ignore next-line phantom-dependency, not-a-rule`, []string{RulePhantomDependency}, true, true},
+		// A misspelled rule must not suppress every rule.
+		{`// depose-ignore next-line phantom-dependancy`, nil, false, true},
+	}
+	for _, tt := range tests {
+		rules, ignored, err := sc.parseIgnoreDirective(tt.line)
+		if ignored != tt.ignored || !reflect.DeepEqual(rules, tt.rules) || (err != nil) != tt.invalid {
+			t.Errorf("parseIgnoreDirective(%q) = %v, %v, %v, want %v, %v, invalid rules: %v",
+				tt.line, rules, ignored, err, tt.rules, tt.ignored, tt.invalid)
+		}
+	}
+}
+
+func TestApplySuppressions(t *testing.T) {
+	sc := newScanContext()
+	sc.d.ignoredLines = map[Location][]string{
+		{File: "a.js", Line: 2}: nil,
+		{File: "b.js", Line: 5}: {RulePhantomDependency},
+	}
+	defer func() { sc.d.ignoredLines = nil }()
+
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "pg"},
+		{RuleID: RuleMissingDependency, Package: "zod", Locations: []Location{{File: "a.js", Line: 2}}},
+		{RuleID: RuleMissingDependency, Package: "yup", Locations: []Location{{File: "b.js", Line: 5}}},
+		{RuleID: RuleMissingDependency, Package: "joi", Locations: []Location{{File: "a.js", Line: 2}, {File: "c.js", Line: 1}}},
+	}
+	kept, suppressed := sc.applySuppressions(findings, Config{Ignore: []string{"pg"}})
+
+	var keptPkgs, suppressedPkgs []string
+	for _, f := range kept {
+		keptPkgs = append(keptPkgs, f.Package)
+	}
+	for _, f := range suppressed {
+		suppressedPkgs = append(suppressedPkgs, f.Package)
+	}
+	if want := []string{"yup", "joi"}; !reflect.DeepEqual(keptPkgs, want) {
+		t.Errorf("kept = %v, want %v", keptPkgs, want)
+	}
+	if want := []string{"pg", "zod"}; !reflect.DeepEqual(suppressedPkgs, want) {
+		t.Errorf("suppressed = %v, want %v", suppressedPkgs, want)
+	}
+}
+
+func TestApplySuppressionsLocalPackages(t *testing.T) {
+	sc := newScanContext()
+	sc.manifest = Package{
+		Dependencies:    map[string]string{"@app/ui": "workspace:*", "lodash": "^4.17.21"},
+		DevDependencies: map[string]string{"fixtures": "file:../fixtures"},
+	}
+	defer func() { sc.manifest = Package{}; sc.includeLocal = false }()
+
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "@app/ui"},
+		{RuleID: RuleUnusedDependency, Package: "lodash"},
+		{RuleID: RuleUnusedDevDependency, Package: "fixtures"},
+	}
+	kept, suppressed := sc.applySuppressions(findings, Config{})
+	if len(kept) != 1 || kept[0].Package != "lodash" || len(suppressed) != 2 {
+		t.Errorf("kept = %+v, suppressed = %+v, want only lodash kept", kept, suppressed)
+	}
+
+	sc.includeLocal = true
+	if kept, _ := sc.applySuppressions(findings, Config{}); len(kept) != 3 {
+		t.Errorf("kept = %+v with --include-local, want every finding", kept)
+	}
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Thresholds tolerate a budget of findings in check mode, instead of
+// failing on any of them. They are set in the "check" key of the config,
+// and overridden by the flags of the same name.
+type Thresholds struct {
+	// FailOn lists the kinds of findings failing the check, e.g.
+	// ["unused", "missing"]. Every kind fails it when empty.
+	FailOn []string `json:"failOn"`
+	// MaxUnused and MaxMissing are the numbers of unused and missing
+	// dependencies tolerated. Nil means none is.
+	MaxUnused  *int `json:"maxUnused"`
+	MaxMissing *int `json:"maxMissing"`
+}
+
+// findingKinds maps the kinds of findings accepted by --fail-on
+// to the rules reporting them.
+var findingKinds = map[string][]string{
+	"unused":     {RuleUnusedDependency, RuleUnusedDevDependency},
+	"missing":    {RuleMissingDependency},
+	"phantom":    {RulePhantomDependency},
+	"unresolved": {RuleUnresolvedImport},
+	"orphaned":   {RuleOrphanedFile},
+	"dynamic":    {RuleDynamicImport},
+	"misplaced":  {RuleMisplacedDependency},
+	"ranges":     {RuleInconsistentRange},
+}
+
+// policyRules always fail the check, whatever the thresholds.
+var policyRules = map[string]bool{RuleBannedDependency: true, RuleRequiredDependency: true}
+
+// findingKindNames returns the kinds of findings, sorted.
+func findingKindNames() []string {
+	names := make([]string, 0, len(findingKinds))
+	for name := range findingKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkFailures returns why the findings fail the check, if they do:
+// the findings of a kind failing it beyond its budget, and the policy
+// violations. The findings of the kinds not listed by FailOn, and the
+// rules of plugins when FailOn is set, never fail it.
+func checkFailures(findings []Finding, t Thresholds) ([]string, error) {
+	failing := make(map[string]bool)
+	for _, kind := range t.FailOn {
+		rules, ok := findingKinds[strings.TrimSpace(kind)]
+		if !ok {
+			return nil, fmt.Errorf("unknown kind of finding %q, available kinds: %s", kind, strings.Join(findingKindNames(), ", "))
+		}
+		for _, rule := range rules {
+			failing[rule] = true
+		}
+	}
+
+	counts := make(map[string]int)
+	policy := 0
+	for _, f := range findings {
+		switch {
+		case policyRules[f.RuleID]:
+			policy++
+		case len(failing) == 0 || failing[f.RuleID]:
+			counts[f.RuleID]++
+		}
+	}
+
+	var failures []string
+	if policy > 0 {
+		failures = append(failures, fmt.Sprintf("%d policy violation(s)", policy))
+	}
+	budgets := []struct {
+		kind  string
+		limit *int
+	}{{"unused", t.MaxUnused}, {"missing", t.MaxMissing}}
+	budgeted := make(map[string]bool)
+	for _, b := range budgets {
+		if b.limit == nil {
+			continue
+		}
+		count := 0
+		for _, rule := range findingKinds[b.kind] {
+			count += counts[rule]
+			budgeted[rule] = true
+		}
+		if count > *b.limit {
+			failures = append(failures, fmt.Sprintf("%d %s finding(s), more than the %d tolerated", count, b.kind, *b.limit))
+		}
+	}
+	rules := make([]string, 0, len(counts))
+	for rule := range counts {
+		if !budgeted[rule] {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		failures = append(failures, fmt.Sprintf("%d %s finding(s)", counts[rule], rule))
+	}
+	return failures, nil
+}
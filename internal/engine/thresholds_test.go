@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckFailures(t *testing.T) {
+	findings := []Finding{
+		{RuleID: RuleUnusedDependency, Package: "lodash"},
+		{RuleID: RuleUnusedDevDependency, Package: "jest"},
+		{RuleID: RuleMissingDependency, Package: "axios"},
+		{RuleID: RulePhantomDependency, Package: "debug"},
+	}
+	one, two := 1, 2
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		want       []string
+	}{
+		{"no thresholds", Thresholds{}, []string{"1 missing-dependency finding(s)", "1 phantom-dependency finding(s)", "1 unused-dependency finding(s)", "1 unused-dev-dependency finding(s)"}},
+		{"within budgets", Thresholds{FailOn: []string{"unused", "missing"}, MaxUnused: &two, MaxMissing: &one}, nil},
+		{"over budget", Thresholds{FailOn: []string{"unused"}, MaxUnused: &one}, []string{"2 unused finding(s), more than the 1 tolerated"}},
+		{"phantom only", Thresholds{FailOn: []string{"phantom"}}, []string{"1 phantom-dependency finding(s)"}},
+	}
+	for _, tt := range tests {
+		got, err := checkFailures(findings, tt.thresholds)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: checkFailures() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	policy := append(findings, Finding{RuleID: RuleBannedDependency, Package: "moment"})
+	got, _ := checkFailures(policy, Thresholds{FailOn: []string{"missing"}, MaxMissing: &one})
+	if want := []string{"1 policy violation(s)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("checkFailures() = %q, want %q", got, want)
+	}
+	if _, err := checkFailures(nil, Thresholds{FailOn: []string{"unknown"}}); err == nil {
+		t.Error("checkFailures() accepted an unknown kind of finding")
+	}
+}
@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampleInterval is how often the goroutines and the memory of the run
+// are sampled to record their peak.
+const sampleInterval = 10 * time.Millisecond
+
+// phaseTiming is the duration of a phase of the run.
+type phaseTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// runMetrics measures the performance of a run: the files scanned, the
+// bytes read, the duration of its phases, and the peak of its goroutines
+// and memory. A nil *runMetrics measures nothing.
+type runMetrics struct {
+	start        time.Time
+	filesScanned atomic.Int64
+	bytesRead    atomic.Int64
+
+	mu             sync.Mutex
+	phases         []phaseTiming
+	phaseStart     time.Time
+	total          time.Duration
+	peakGoroutines int
+	peakMemory     uint64
+	stop, done     chan struct{}
+}
+
+// startMetrics starts measuring the run, sampling its goroutines and
+// memory in the background until finish is called.
+func startMetrics() *runMetrics {
+	m := &runMetrics{start: time.Now(), stop: make(chan struct{}), done: make(chan struct{})}
+	m.sample()
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(sampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.sample()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+	return m
+}
+
+// sample records the current goroutines and heap, if they are the peak.
+func (m *runMetrics) sample() {
+	if m == nil {
+		return
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	goroutines := runtime.NumGoroutine()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.peakGoroutines = max(m.peakGoroutines, goroutines)
+	m.peakMemory = max(m.peakMemory, ms.HeapAlloc)
+}
+
+// beginPhase ends the current phase of the run, if any, and begins the
+// named one.
+func (m *runMetrics) beginPhase(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endPhase()
+	m.phases = append(m.phases, phaseTiming{name: name})
+	m.phaseStart = time.Now()
+}
+
+// endPhase records the duration of the current phase. m.mu must be held.
+func (m *runMetrics) endPhase() {
+	if n := len(m.phases); n > 0 && m.phases[n-1].duration == 0 {
+		m.phases[n-1].duration = time.Since(m.phaseStart)
+	}
+}
+
+// recordFile counts a file scanned, of size bytes.
+func (m *runMetrics) recordFile(size int) {
+	if m == nil {
+		return
+	}
+	m.filesScanned.Add(1)
+	m.bytesRead.Add(int64(size))
+}
+
+// finish ends the current phase and stops the sampling.
+func (m *runMetrics) finish() {
+	close(m.stop)
+	<-m.done
+	m.sample()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endPhase()
+	m.total = time.Since(m.start)
+}
+
+// writeTimings writes the metrics of the run, as printed by --timings.
+func (m *runMetrics) writeTimings(w io.Writer) {
+	fmt.Fprintln(w, "Timings:")
+	fmt.Fprintf(w, "  files scanned: %d (%s)\n", m.filesScanned.Load(), FormatBytes(uint64(m.bytesRead.Load())))
+	for _, p := range m.phases {
+		fmt.Fprintf(w, "  %s: %s\n", p.name, p.duration.Round(time.Microsecond))
+	}
+	fmt.Fprintf(w, "  total: %s\n", m.total.Round(time.Microsecond))
+	fmt.Fprintf(w, "  peak goroutines: %d\n", m.peakGoroutines)
+	fmt.Fprintf(w, "  peak memory: %s\n", FormatBytes(m.peakMemory))
+}
+
+// writePrometheus writes the metrics of the run in the text format of
+// Prometheus, labelled with the name of the project unless it is empty.
+func (m *runMetrics) writePrometheus(w io.Writer, project string, findings int) {
+	var labels []string
+	if project != "" {
+		labels = append(labels, "project="+strconv.Quote(project))
+	}
+	gauge := func(name, help string, value any, extra ...string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		fmt.Fprintf(w, "%s%s %v\n", name, promLabels(append(labels, extra...)), value)
+	}
+	gauge("depose_files_scanned", "Files scanned by the last run.", m.filesScanned.Load())
+	gauge("depose_bytes_read", "Bytes read by the last run.", m.bytesRead.Load())
+	gauge("depose_findings", "Findings reported by the last run.", findings)
+	gauge("depose_duration_seconds", "Duration of the last run.", m.total.Seconds())
+	fmt.Fprintln(w, "# HELP depose_phase_duration_seconds Duration of the phases of the last run.")
+	fmt.Fprintln(w, "# TYPE depose_phase_duration_seconds gauge")
+	for _, p := range m.phases {
+		fmt.Fprintf(w, "depose_phase_duration_seconds%s %v\n", promLabels(append(labels, "phase="+strconv.Quote(p.name))), p.duration.Seconds())
+	}
+	gauge("depose_peak_goroutines", "Peak number of goroutines of the last run.", m.peakGoroutines)
+	gauge("depose_peak_memory_bytes", "Peak heap of the last run.", m.peakMemory)
+	gauge("depose_last_run_timestamp_seconds", "Time the last run finished.", m.start.Add(m.total).Unix())
+}
+
+// promLabels formats the labels of a Prometheus sample, e.g. {phase="walk"}.
+func promLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// writeMetricsTextfile writes the metrics to path for the textfile
+// collector of the node exporter. The file is replaced atomically, so
+// the collector never reads a partial file.
+func writeMetricsTextfile(path string, m *runMetrics, project string, findings int) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	// The node exporter usually runs as another user.
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	m.writePrometheus(tmp, project, findings)
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// pushMetrics pushes the metrics to a Prometheus pushgateway, replacing
+// the metrics previously pushed for the project.
+func pushMetrics(gateway string, m *runMetrics, project string, findings int) error {
+	url := strings.TrimSuffix(gateway, "/") + "/metrics/job/depose"
+	if project != "" {
+		// Names like "@scope/name" can't be path segments, so they are encoded.
+		url += "/project@base64/" + base64.RawURLEncoding.EncodeToString([]byte(project))
+	}
+	var body bytes.Buffer
+	m.writePrometheus(&body, "", findings)
+	req, err := http.NewRequest(http.MethodPut, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// emitMetrics finishes measuring the run, and writes its metrics to the
+// outputs selected by the flags, and its profiles with --pprof-dir.
+// Failures are reported, but don't fail the run.
+func (sc *scanContext) emitMetrics(project string, findings int) {
+	sc.stopProfiling()
+	if sc.metrics == nil {
+		return
+	}
+	sc.metrics.finish()
+	if sc.timings {
+		sc.metrics.writeTimings(sc.logOut)
+	}
+	if sc.metricsTextfile != "" {
+		if err := writeMetricsTextfile(sc.metricsTextfile, sc.metrics, project, findings); err != nil {
+			fmt.Fprintf(sc.logOut, "Failed to write the metrics to %s: %v\n", sc.metricsTextfile, err)
+		}
+	}
+	if sc.metricsPushgateway != "" {
+		if err := pushMetrics(sc.metricsPushgateway, sc.metrics, project, findings); err != nil {
+			fmt.Fprintf(sc.logOut, "Failed to push the metrics to %s: %v\n", sc.metricsPushgateway, err)
+		}
+	}
+	sc.metrics = nil
+}
+
+// FormatBytes formats a size in bytes with a binary unit, e.g. 1.5 MiB.
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunMetrics(t *testing.T) {
+	m := startMetrics()
+	m.beginPhase("walk")
+	m.recordFile(100)
+	m.recordFile(28)
+	m.beginPhase("parse")
+	m.finish()
+
+	if got := m.filesScanned.Load(); got != 2 {
+		t.Errorf("files scanned = %d, want 2", got)
+	}
+	if len(m.phases) != 2 || m.phases[0].name != "walk" || m.phases[1].duration == 0 {
+		t.Errorf("phases = %+v, want walk and parse, both ended", m.phases)
+	}
+	if m.peakGoroutines == 0 || m.peakMemory == 0 {
+		t.Errorf("peaks = %d goroutines, %d bytes, want samples", m.peakGoroutines, m.peakMemory)
+	}
+
+	var out bytes.Buffer
+	m.writePrometheus(&out, "api", 3)
+	for _, want := range []string{
+		"# TYPE depose_files_scanned gauge\ndepose_files_scanned{project=\"api\"} 2\n",
+		"depose_bytes_read{project=\"api\"} 128\n",
+		"depose_findings{project=\"api\"} 3\n",
+		"depose_phase_duration_seconds{project=\"api\",phase=\"walk\"} ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("writePrometheus() = %s, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+	}))
+	defer server.Close()
+
+	m := startMetrics()
+	m.finish()
+	if err := pushMetrics(server.URL+"/", m, "@acme/api", 0); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/metrics/job/depose/project@base64/QGFjbWUvYXBp"; method != http.MethodPut || path != want {
+		t.Errorf("request = %s %s, want PUT %s", method, path, want)
+	}
+	// The project is a grouping label, so it is not repeated in the body.
+	if strings.Contains(body, "project=") || !strings.Contains(body, "depose_findings 0\n") {
+		t.Errorf("body = %s, want the metrics without the project label", body)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB"}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
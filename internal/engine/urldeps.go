@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hosts of the raw files of the git repositories, from which the
+// manifests of the git dependencies are fetched.
+var (
+	rawGitHubURL = "https://raw.githubusercontent.com"
+	rawGitLabURL = "https://gitlab.com"
+)
+
+var (
+	// hostedGitRe matches the git repositories hosted on GitHub or GitLab,
+	// as shorthands, e.g. "github:user/repo#v1.0.0" or "user/repo", or as
+	// URLs, e.g. "git+https://github.com/user/repo.git#v1.0.0".
+	hostedGitRe = regexp.MustCompile(`^(?:(github|gitlab):|git(?:\+(?:https?|ssh))?://(?:git@)?(github|gitlab)\.com[:/]|https?://(github|gitlab)\.com/)?([\w.-]+)/([\w.-]+?)(?:\.git)?(?:#(.*))?$`)
+	// tarballRe matches the URLs of tarballs, e.g. the ones of a registry.
+	tarballRe = regexp.MustCompile(`^https?://.*\.(?:tgz|tar\.gz)(?:\?.*)?$`)
+)
+
+// resolveURLDependencies returns the real names of the dependencies
+// declared with git or tarball URLs, mapped to their declared names, when
+// they differ. The real names are read from the installed copies, or with
+// fetch, from the manifests of the repositories or from the tarballs.
+func (sc *scanContext) resolveURLDependencies(dependencies map[string]string, fetch bool) map[string]string {
+	names := make(map[string]string)
+	declared := make([]string, 0, len(dependencies))
+	for dependency := range dependencies {
+		declared = append(declared, dependency)
+	}
+	sort.Strings(declared)
+	for _, dependency := range declared {
+		versionRange := dependencies[dependency]
+		if style := rangeStyle(versionRange); style != RangeGit && style != RangeURL {
+			continue
+		}
+		name := ""
+		if installed := sc.readInstalledPackage(filepath.Join("node_modules", filepath.FromSlash(dependency))); installed != nil {
+			name = installed.Name
+		} else if fetch {
+			var err error
+			if name, err = fetchURLPackageName(versionRange); err != nil {
+				fmt.Fprintf(sc.logOut, "Failed to find the name of %q: %v\n", dependency, err)
+				continue
+			}
+		}
+		if name != "" && name != dependency {
+			fmt.Fprintf(sc.logOut, "%q is declared with %s, and named %q by its manifest.\n", dependency, versionRange, name)
+			names[name] = dependency
+		}
+	}
+	return names
+}
+
+// fetchURLPackageName returns the name of the package of a git or
+// tarball URL, from the manifest of its repository, or of the tarball.
+func fetchURLPackageName(versionRange string) (string, error) {
+	if tarballRe.MatchString(versionRange) {
+		return fetchTarballName(versionRange)
+	}
+	manifestURL, ok := hostedManifestURL(versionRange)
+	if !ok {
+		return "", fmt.Errorf("unsupported git host")
+	}
+	var pkg struct {
+		Name string `json:"name"`
+	}
+	if err := callAPI(http.MethodGet, manifestURL, nil, nil, &pkg); err != nil {
+		return "", err
+	}
+	return pkg.Name, nil
+}
+
+// hostedManifestURL returns the URL of the raw package.json of a git
+// repository hosted on GitHub or GitLab, at the ref of the URL, or at
+// the head of its default branch.
+func hostedManifestURL(versionRange string) (string, bool) {
+	m := hostedGitRe.FindStringSubmatch(versionRange)
+	if m == nil {
+		return "", false
+	}
+	host := m[1] + m[2] + m[3]
+	user, repo, ref := m[4], m[5], m[6]
+	if ref == "" || strings.HasPrefix(ref, "semver:") {
+		ref = "HEAD"
+	}
+	if host == "gitlab" {
+		return fmt.Sprintf("%s/%s/%s/-/raw/%s/package.json", rawGitLabURL, user, repo, ref), true
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/package.json", rawGitHubURL, user, repo, ref), true
+}
+
+// fetchTarballName downloads the tarball, and returns the name of its
+// package, read from the package.json at its root, e.g. package/package.json.
+func fetchTarballName(url string) (string, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("GET %s: %v", url, err)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("GET %s: no package.json in the tarball", url)
+		}
+		if err != nil {
+			return "", fmt.Errorf("GET %s: %v", url, err)
+		}
+		if dir, file := path.Split(header.Name); file == "package.json" && strings.Count(dir, "/") == 1 {
+			var pkg struct {
+				Name string `json:"name"`
+			}
+			if err := json.NewDecoder(tr).Decode(&pkg); err != nil {
+				return "", fmt.Errorf("GET %s: %s: %v", url, header.Name, err)
+			}
+			return pkg.Name, nil
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHostedManifestURL(t *testing.T) {
+	tests := map[string]string{
+		"user/repo":               "https://raw.githubusercontent.com/user/repo/HEAD/package.json",
+		"github:user/repo#v1.2.0": "https://raw.githubusercontent.com/user/repo/v1.2.0/package.json",
+		"git+https://github.com/user/repo.git#main": "https://raw.githubusercontent.com/user/repo/main/package.json",
+		"git+ssh://git@github.com/user/repo.git":    "https://raw.githubusercontent.com/user/repo/HEAD/package.json",
+		"github:user/repo#semver:^1.0.0":            "https://raw.githubusercontent.com/user/repo/HEAD/package.json",
+		"gitlab:group/repo#v2":                      "https://gitlab.com/group/repo/-/raw/v2/package.json",
+		"git+https://gitlab.com/group/repo.git":     "https://gitlab.com/group/repo/-/raw/HEAD/package.json",
+	}
+	for versionRange, want := range tests {
+		if got, ok := hostedManifestURL(versionRange); !ok || got != want {
+			t.Errorf("hostedManifestURL(%q) = %q, %v, want %q", versionRange, got, ok, want)
+		}
+	}
+	if got, ok := hostedManifestURL("git+https://example.com/repo.git"); ok {
+		t.Errorf("hostedManifestURL() of an unknown host = %q", got)
+	}
+}
+
+// tarball returns a gzipped tarball holding the files.
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResolveURLDependencies(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"node_modules/ui/package.json": `{"name": "@acme/ui", "version": "1.0.0"}`,
+	})
+	archive := tarball(t, map[string]string{
+		"package/index.js":                  "module.exports = {};\n",
+		"package/node_modules/package.json": `{"name": "nested"}`,
+		"package/package.json":              `{"name": "left-pad-fork"}`,
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/chart/v3/package.json":
+			fmt.Fprint(w, `{"name": "chart.js"}`)
+		case "/pad-1.0.0.tgz":
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { rawGitHubURL = url }(rawGitHubURL)
+	rawGitHubURL = server.URL
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+
+	dependencies := map[string]string{
+		"ui":      "git+https://github.com/acme/ui.git",
+		"chart":   "github:user/chart#v3",
+		"pad":     server.URL + "/pad-1.0.0.tgz",
+		"same":    "github:user/same",
+		"express": "^4.18.2",
+	}
+	if got, want := sc.resolveURLDependencies(dependencies, false), map[string]string{"@acme/ui": "ui"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveURLDependencies() offline = %v, want %v", got, want)
+	}
+	want := map[string]string{"@acme/ui": "ui", "chart.js": "chart", "left-pad-fork": "pad"}
+	if got := sc.resolveURLDependencies(dependencies, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveURLDependencies() = %v, want %v", got, want)
+	}
+}
+
+func TestMarkModuleAsFoundURLDependency(t *testing.T) {
+	sc := newScanContext()
+	sc.lang = sc.nodeLanguage
+	sc.d.mp = map[string]bool{"ui": false}
+	sc.d.usages = make(map[string][]Location)
+	sc.urlDependencyNames = map[string]string{"@acme/ui": "ui"}
+	defer func() { sc.lang, sc.d, sc.urlDependencyNames = nil, Dependency{}, nil }()
+
+	sc.markModuleAsFound("@acme/ui/button", Location{File: "src/app.js", Line: 1})
+	if !sc.d.mp["ui"] || len(sc.d.usages["@acme/ui"]) > 0 {
+		t.Errorf("the import of @acme/ui did not mark ui as used: %v, %v", sc.d.mp, sc.d.usages)
+	}
+}
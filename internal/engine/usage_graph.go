@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// graphWriters maps the formats accepted by the --graph flag
+// to the functions rendering the usage graph.
+var graphWriters = map[string]func(w io.Writer, g usageGraph) error{
+	"dot":     writeDOT,
+	"mermaid": writeMermaid,
+}
+
+// usageGraph is the graph of the files of the project to the packages they
+// use, and optionally of the packages to the packages they depend on.
+type usageGraph struct {
+	// edges maps every node to the nodes it points to.
+	edges map[string]map[string]bool
+	// packages are the nodes which are packages, rather than files.
+	packages map[string]bool
+}
+
+func (g usageGraph) addEdge(from, to string) {
+	if g.edges[from] == nil {
+		g.edges[from] = make(map[string]bool)
+	}
+	g.edges[from][to] = true
+}
+
+// sortedNodes returns the files of the graph, then its packages,
+// both in alphabetical order.
+func (g usageGraph) sortedNodes() []string {
+	seen := make(map[string]bool)
+	for from, targets := range g.edges {
+		seen[from] = true
+		for to := range targets {
+			seen[to] = true
+		}
+	}
+	nodes := make([]string, 0, len(seen))
+	for node := range seen {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if g.packages[nodes[i]] != g.packages[nodes[j]] {
+			return !g.packages[nodes[i]]
+		}
+		return nodes[i] < nodes[j]
+	})
+	return nodes
+}
+
+// sortedTargets returns the nodes the node points to, in alphabetical order.
+func (g usageGraph) sortedTargets(node string) []string {
+	targets := make([]string, 0, len(g.edges[node]))
+	for to := range g.edges[node] {
+		targets = append(targets, to)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// buildUsageGraph builds the graph of the files to the packages they use,
+// from the locations where every package was found. When lockDeps is not
+// nil, the packages are linked to their own dependencies, transitively.
+func buildUsageGraph(usages map[string][]Location, lockDeps map[string][]string) usageGraph {
+	g := usageGraph{edges: make(map[string]map[string]bool), packages: make(map[string]bool)}
+	var queue []string
+	for pkgName, locations := range usages {
+		for _, loc := range locations {
+			g.addEdge(filepath.ToSlash(loc.File), pkgName)
+		}
+		g.packages[pkgName] = true
+		queue = append(queue, pkgName)
+	}
+
+	visited := make(map[string]bool)
+	for len(queue) > 0 {
+		pkgName := queue[0]
+		queue = queue[1:]
+		if visited[pkgName] {
+			continue
+		}
+		visited[pkgName] = true
+		for _, dep := range lockDeps[pkgName] {
+			g.addEdge(pkgName, dep)
+			g.packages[dep] = true
+			queue = append(queue, dep)
+		}
+	}
+	return g
+}
+
+// readLockfileDependencies reads the dependencies of every installed package
+// from package-lock.json, with the "packages" of lockfile versions 2 and 3,
+// or the "dependencies" of version 1.
+func (sc *scanContext) readLockfileDependencies(path string) (map[string][]string, error) {
+	data, err := sc.readProjectFile(path)
+	if err != nil {
+		return nil, err
+	}
+	type lockEntry struct {
+		Dependencies map[string]string `json:"dependencies"`
+		Requires     map[string]string `json:"requires"`
+	}
+	var lock struct {
+		Packages     map[string]lockEntry       `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	deps := make(map[string][]string)
+	add := func(pkgName string, requires map[string]string) {
+		for dep := range requires {
+			deps[pkgName] = append(deps[pkgName], dep)
+		}
+	}
+	if len(lock.Packages) > 0 {
+		for key, entry := range lock.Packages {
+			// Keys are paths, e.g. "node_modules/a/node_modules/b".
+			idx := strings.LastIndex(key, "node_modules/")
+			if idx == -1 {
+				continue
+			}
+			add(key[idx+len("node_modules/"):], entry.Dependencies)
+		}
+	} else {
+		for pkgName, raw := range lock.Dependencies {
+			var entry lockEntry
+			if json.Unmarshal(raw, &entry) == nil {
+				add(pkgName, entry.Requires)
+			}
+		}
+	}
+	for pkgName := range deps {
+		sort.Strings(deps[pkgName])
+	}
+	return deps, nil
+}
+
+// writeDOT renders the usage graph in the DOT language of Graphviz,
+// with the packages drawn as boxes.
+func writeDOT(w io.Writer, g usageGraph) error {
+	fmt.Fprintln(w, "digraph depose {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	for _, node := range g.sortedNodes() {
+		if g.packages[node] {
+			fmt.Fprintf(w, "  %q [shape=box];\n", node)
+		}
+	}
+	for _, node := range g.sortedNodes() {
+		for _, to := range g.sortedTargets(node) {
+			fmt.Fprintf(w, "  %q -> %q;\n", node, to)
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// writeMermaid renders the usage graph as a Mermaid flowchart. Nodes are
+// given generated ids, since paths and scoped package names are not valid ids.
+func writeMermaid(w io.Writer, g usageGraph) error {
+	fmt.Fprintln(w, "flowchart LR")
+	ids := make(map[string]string)
+	for i, node := range g.sortedNodes() {
+		ids[node] = fmt.Sprintf("n%d", i)
+		label := strings.ReplaceAll(node, `"`, "#quot;")
+		if g.packages[node] {
+			fmt.Fprintf(w, "  %s[\"%s\"]\n", ids[node], label)
+		} else {
+			fmt.Fprintf(w, "  %s(\"%s\")\n", ids[node], label)
+		}
+	}
+	var err error
+	for _, node := range g.sortedNodes() {
+		for _, to := range g.sortedTargets(node) {
+			_, err = fmt.Fprintf(w, "  %s --> %s\n", ids[node], ids[to])
+		}
+	}
+	return err
+}
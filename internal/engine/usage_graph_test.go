@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUsageGraph(t *testing.T) {
+	usages := map[string][]Location{
+		"express":     {{File: "src/app.js", Line: 1}, {File: "src/app.js", Line: 9}},
+		"@scope/util": {{File: "src/app.js", Line: 2}, {File: "test/app.test.js", Line: 1}},
+	}
+	lockDeps := map[string][]string{"express": {"body-parser"}, "body-parser": {"bytes"}, "unused": {"left-pad"}}
+	g := buildUsageGraph(usages, lockDeps)
+
+	var dot strings.Builder
+	if err := writeDOT(&dot, g); err != nil {
+		t.Fatal(err)
+	}
+	wantDOT := `digraph depose {
+  rankdir=LR;
+  "@scope/util" [shape=box];
+  "body-parser" [shape=box];
+  "bytes" [shape=box];
+  "express" [shape=box];
+  "src/app.js" -> "@scope/util";
+  "src/app.js" -> "express";
+  "test/app.test.js" -> "@scope/util";
+  "body-parser" -> "bytes";
+  "express" -> "body-parser";
+}
+`
+	if dot.String() != wantDOT {
+		t.Errorf("writeDOT() =\n%s\nwant\n%s", dot.String(), wantDOT)
+	}
+
+	var mermaid strings.Builder
+	if err := writeMermaid(&mermaid, buildUsageGraph(usages, nil)); err != nil {
+		t.Fatal(err)
+	}
+	wantMermaid := `flowchart LR
+  n0("src/app.js")
+  n1("test/app.test.js")
+  n2["@scope/util"]
+  n3["express"]
+  n0 --> n2
+  n0 --> n3
+  n1 --> n2
+`
+	if mermaid.String() != wantMermaid {
+		t.Errorf("writeMermaid() =\n%s\nwant\n%s", mermaid.String(), wantMermaid)
+	}
+}
+
+func TestReadLockfileDependencies(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"v3/package-lock.json": `{"lockfileVersion": 3, "packages": {
+			"": {"dependencies": {"express": "^4.0.0"}},
+			"node_modules/express": {"dependencies": {"debug": "2.6.9", "body-parser": "1.20.1"}},
+			"node_modules/express/node_modules/debug": {"dependencies": {"ms": "2.0.0"}}
+		}}`,
+		"v1/package-lock.json": `{"lockfileVersion": 1, "dependencies": {
+			"express": {"version": "4.18.2", "requires": {"debug": "2.6.9"}},
+			"debug": {"version": "2.6.9"}
+		}}`,
+	})
+
+	got, err := sc.readLockfileDependencies("v3/package-lock.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"express": {"body-parser", "debug"}, "debug": {"ms"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readLockfileDependencies(v3) = %v, want %v", got, want)
+	}
+
+	got, err = sc.readLockfileDependencies("v1/package-lock.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"express": {"debug"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readLockfileDependencies(v1) = %v, want %v", got, want)
+	}
+}
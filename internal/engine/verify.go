@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// runVerifyCommand runs the verification command given with --verify in
+// the shell, writing its output to logOut, and reports whether it passed.
+func (sc *scanContext) runVerifyCommand(command string) bool {
+	cmd := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	}
+	cmd.Stdout, cmd.Stderr = sc.logOut, sc.logOut
+	return cmd.Run() == nil
+}
+
+// trialRemoval returns the function reporting whether removing a subset of
+// the dependencies from package.json, whose original content is given,
+// fails the verification command. It writes package.json for each subset,
+// and records the first error writing it in *err, after which every subset
+// passes, so the search ends.
+func (sc *scanContext) trialRemoval(command string, original []byte, err *error) func(subset []string) bool {
+	return func(subset []string) bool {
+		if *err != nil {
+			return false
+		}
+		fmt.Fprintf(sc.logOut, "Trying the removal of %s\n", strings.Join(subset, ", "))
+		if *err = os.WriteFile("package.json", removeTrailingCommas(createNewPackageJson(subset, original)), 0o644); *err != nil {
+			return false
+		}
+		return !sc.runVerifyCommand(command)
+	}
+}
+
+// breakingRemovals returns the groups of removals which make the
+// verification fail, given the set of removals failing it and whether
+// removing a subset of them fails it, by bisecting the set. A group holds
+// a single removal, or several ones which only fail together.
+func breakingRemovals(set []string, fails func(subset []string) bool) [][]string {
+	if len(set) == 1 {
+		return [][]string{set}
+	}
+	half := len(set) / 2
+	var groups [][]string
+	for _, subset := range [][]string{set[:half], set[half:]} {
+		if fails(subset) {
+			groups = append(groups, breakingRemovals(subset, fails)...)
+		}
+	}
+	if len(groups) == 0 {
+		// Both halves pass on their own, so the failure needs removals
+		// of both.
+		return [][]string{set}
+	}
+	return groups
+}
+
+// describeRemovals describes the groups of removals of breakingRemovals,
+// e.g. "moment, and dotenv with lodash together".
+func describeRemovals(groups [][]string) string {
+	described := make([]string, len(groups))
+	for i, group := range groups {
+		described[i] = strings.Join(group, " with ")
+		if len(group) > 1 {
+			described[i] += " together"
+		}
+	}
+	if len(described) == 1 {
+		return described[0]
+	}
+	return strings.Join(described[:len(described)-1], ", ") + ", and " + described[len(described)-1]
+}
+
+// verifyRemoval runs the verification command once the dependencies have
+// been removed from package.json, whose original content is given. If it
+// fails, the removals causing the failure are searched by bisection,
+// running the command with subsets of the removals, and package.json is
+// restored from its original content, with its backup deleted.
+func (sc *scanContext) verifyRemoval(command string, original []byte, removed []string) error {
+	fmt.Fprintf(sc.logOut, "Verifying the removal with: %s\n", command)
+	if sc.runVerifyCommand(command) {
+		fmt.Fprintln(sc.logOut, "Verification passed.")
+		return nil
+	}
+
+	fmt.Fprintf(sc.logOut, "Verification failed, bisecting the %d removed dependencies...\n", len(removed))
+	var writeErr error
+	culprits := breakingRemovals(removed, sc.trialRemoval(command, original, &writeErr))
+	if err := os.WriteFile("package.json", original, 0o644); err != nil {
+		return fmt.Errorf("verification failed, and package.json could not be restored: %v", err)
+	}
+	if err := os.Remove("oldpackage.json"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("verification failed, and oldpackage.json could not be deleted: %v", err)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("verification failed, and package.json was restored, but the bisection failed: %v", writeErr)
+	}
+	return fmt.Errorf("verification failed, package.json has been restored: the removal of %s likely caused the failure", describeRemovals(culprits))
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBreakingRemovals(t *testing.T) {
+	removed := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name   string
+		broken func(subset []string) bool
+		want   [][]string
+	}{
+		{"single culprit", func(subset []string) bool { return contains(subset, "d") }, [][]string{{"d"}}},
+		{"first culprit", func(subset []string) bool { return contains(subset, "a") }, [][]string{{"a"}}},
+		{"two culprits", func(subset []string) bool { return contains(subset, "a") || contains(subset, "e") }, [][]string{{"a"}, {"e"}}},
+		{"interaction", func(subset []string) bool { return contains(subset, "b") && contains(subset, "e") }, [][]string{removed}},
+	}
+	for _, tt := range tests {
+		if got := breakingRemovals(removed, tt.broken); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: breakingRemovals() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeRemovals(t *testing.T) {
+	tests := []struct {
+		groups [][]string
+		want   string
+	}{
+		{[][]string{{"moment"}}, "moment"},
+		{[][]string{{"dotenv", "lodash"}}, "dotenv with lodash together"},
+		{[][]string{{"a"}, {"b"}, {"c", "d"}}, "a, b, and c with d together"},
+	}
+	for _, tt := range tests {
+		if got := describeRemovals(tt.groups); got != tt.want {
+			t.Errorf("describeRemovals(%v) = %q, want %q", tt.groups, got, tt.want)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestVerifyFlag(t *testing.T) {
+	deposePath := buildDepose(t)
+	chdir(t, t.TempDir())
+	original := "{\n  \"dependencies\": {\n    \"dotenv\": \"^16.0.0\",\n    \"express\": \"^4.18.2\",\n    \"lodash\": \"^4.17.21\",\n    \"moment\": \"^2.29.4\"\n  }\n}\n"
+	writeFiles(t, map[string]string{"package.json": original, "index.js": "require(\"express\");\n"})
+
+	// The verification needs moment, which is unused by the code.
+	out, err := exec.Command(deposePath, "--no-history", "--verify", "grep -q moment package.json").CombinedOutput()
+	if err == nil {
+		t.Fatalf("depose --verify passed:\n%s", out)
+	}
+	if !strings.Contains(string(out), "the removal of moment likely caused the failure") {
+		t.Errorf("output does not blame moment:\n%s", out)
+	}
+	if data, _ := os.ReadFile("package.json"); string(data) != original {
+		t.Errorf("package.json was not restored:\n%s", data)
+	}
+	if _, err := os.Stat("oldpackage.json"); !os.IsNotExist(err) {
+		t.Errorf("oldpackage.json was not deleted: %v", err)
+	}
+
+	out, err = exec.Command(deposePath, "--no-history", "--verify", "grep -q express package.json").CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "Verification passed.") {
+		t.Fatalf("depose --verify failed: %v\n%s", err, out)
+	}
+	if data, _ := os.ReadFile("package.json"); strings.Contains(string(data), "moment") {
+		t.Errorf("moment was not removed:\n%s", data)
+	}
+}
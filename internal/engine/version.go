@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// releasesURL is the GitHub API endpoint of the latest release of depose.
+var releasesURL = "https://api.github.com/repos/CoderParth/depose/releases/latest"
+
+// currentVersion returns the version of the running binary, from the
+// ldflags of the release, or from the build info of "go install".
+func (sc *scanContext) currentVersion() string {
+	if sc.version != "" {
+		return sc.version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// versionCommand implements "depose version", which prints the version of
+// depose, along with the commit and the Go version it was built with.
+func (sc *scanContext) versionCommand(fs *flag.FlagSet) func(args []string) {
+	return func(args []string) {
+		fmt.Printf("depose %s", sc.currentVersion())
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" && len(setting.Value) >= 7 {
+					fmt.Printf(" (%s)", setting.Value[:7])
+				}
+			}
+		}
+		fmt.Printf(" %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	}
+}
+
+// release is the part of a GitHub release used to upgrade depose.
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// fetchLatestRelease fetches the latest release of depose from GitHub.
+func fetchLatestRelease() (release, error) {
+	var rel release
+	resp, err := httpClient.Get(releasesURL)
+	if err != nil {
+		return rel, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return rel, fmt.Errorf("GET %s: %s", releasesURL, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return rel, fmt.Errorf("GET %s: %v", releasesURL, err)
+	}
+	return rel, nil
+}
+
+// assetFor returns the URL and the name of the asset of the release built
+// for the platform, e.g. "depose_linux_amd64.tar.gz".
+func (rel release) assetFor(goos, goarch string) (url, name string, ok bool) {
+	platform := goos + "_" + goarch
+	for _, asset := range rel.Assets {
+		if strings.Contains(strings.ToLower(asset.Name), platform) && !strings.HasSuffix(asset.Name, ".sha256") {
+			return asset.URL, asset.Name, true
+		}
+	}
+	return "", "", false
+}
+
+// isNewer reports whether the tag of a release is a newer version
+// than the current one. Development builds are always upgraded.
+func isNewer(tag, current string) bool {
+	latest, ok := parseSemver(tag)
+	if !ok {
+		return false
+	}
+	v, ok := parseSemver(current)
+	return !ok || v.less(latest)
+}
+
+// extractBinary returns the depose binary from a release asset, which is
+// either a .tar.gz or .zip archive containing it, or the binary itself.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	isBinary := func(path string) bool {
+		base := filepath.Base(path)
+		return base == "depose" || base == "depose.exe"
+	}
+	switch {
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if hdr.Typeflag == tar.TypeReg && isBinary(hdr.Name) {
+				return io.ReadAll(tr)
+			}
+		}
+	case strings.HasSuffix(name, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if isBinary(f.Name) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+	default:
+		return data, nil
+	}
+	return nil, errors.New("no depose binary in " + name)
+}
+
+// replaceExecutable atomically replaces the binary at path with the new
+// one, by writing it next to it and renaming it over the old one.
+func replaceExecutable(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".depose-upgrade-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// upgradeCommand implements "depose upgrade", which replaces the running binary
+// with the one of the latest GitHub release built for the platform.
+//
+//	depose upgrade --check
+func (sc *scanContext) upgradeCommand(fs *flag.FlagSet) func(args []string) {
+	checkOnly := fs.Bool("check", false, "only tell whether a newer version is available")
+	return func(args []string) {
+		rel, err := fetchLatestRelease()
+		if err != nil {
+			logFatalf("Failed to fetch the latest release: %v", err)
+		}
+		current := sc.currentVersion()
+		if !isNewer(rel.TagName, current) {
+			fmt.Printf("depose %s is up to date\n", current)
+			return
+		}
+		if *checkOnly {
+			fmt.Printf("depose %s is available, run \"depose upgrade\" to install it (current: %s)\n", rel.TagName, current)
+			return
+		}
+
+		url, name, ok := rel.assetFor(runtime.GOOS, runtime.GOARCH)
+		if !ok {
+			logFatalf("The release %s has no binary for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+		}
+		resp, err := httpClient.Get(url)
+		if err != nil {
+			logFatalf("Failed to download %s: %v", name, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			logFatalf("Failed to download %s: %s", name, resp.Status)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			logFatalf("Failed to download %s: %v", name, err)
+		}
+		binary, err := extractBinary(name, data)
+		if err != nil {
+			logFatal(err)
+		}
+
+		path, err := os.Executable()
+		if err == nil {
+			path, err = filepath.EvalSymlinks(path)
+		}
+		if err != nil {
+			logFatalf("Failed to locate the depose binary: %v", err)
+		}
+		if err := replaceExecutable(path, binary); err != nil {
+			logFatalf("Failed to replace %s: %v", path, err)
+		}
+		fmt.Printf("Upgraded depose from %s to %s\n", current, rel.TagName)
+	}
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		tag, current string
+		want         bool
+	}{
+		{"v1.3.0", "v1.2.9", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.3.0", false},
+		{"v1.2.0", "(devel)", true},
+		{"nightly", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := isNewer(tt.tag, tt.current); got != tt.want {
+			t.Errorf("isNewer(%q, %q) = %v, want %v", tt.tag, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestUpgradeAsset(t *testing.T) {
+	var rel release
+	for _, name := range []string{"checksums.txt", "depose_darwin_arm64.tar.gz", "depose_linux_amd64.tar.gz.sha256", "depose_linux_amd64.tar.gz"} {
+		rel.Assets = append(rel.Assets, struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		}{name, "https://example.com/" + name})
+	}
+	if _, name, ok := rel.assetFor("linux", "amd64"); !ok || name != "depose_linux_amd64.tar.gz" {
+		t.Errorf("assetFor(linux, amd64) = %q, %v", name, ok)
+	}
+	if _, _, ok := rel.assetFor("windows", "amd64"); ok {
+		t.Errorf("assetFor(windows, amd64) found an asset")
+	}
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"README.md": "readme", "depose": "binary"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	binary, err := extractBinary("depose_linux_amd64.tar.gz", archive.Bytes())
+	if err != nil || string(binary) != "binary" {
+		t.Fatalf("extractBinary() = %q, %v", binary, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "depose")
+	if err := os.WriteFile(path, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceExecutable(path, binary); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "binary" || info.Mode().Perm() != 0o755 {
+		t.Errorf("replaceExecutable() left %q with mode %v", data, info.Mode())
+	}
+}
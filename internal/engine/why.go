@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// scanProject reads the manifest of the project, and looks
+// for the usages of its dependencies in every file.
+func (sc *scanContext) scanProject() {
+	sc.d.mp = make(map[string]bool)
+	sc.d.usages = make(map[string][]Location)
+	sc.d.ignoredLines = make(map[Location][]string)
+	sc.d.unresolved = make(map[string][]Location)
+
+	if !sc.noAscend {
+		sc.enterProjectRoot(sc.lang)
+	}
+	sc.manifestFile = sc.findManifest(sc.lang)
+	sc.lang.readManifest(sc.manifestFile)
+	if err := sc.walkProject(); err != nil {
+		fmt.Fprintf(sc.logOut, "Error scanning the directory %v:\n", err)
+	}
+	sc.extractPackages(sc.files)
+}
+
+// declaredDependencies returns the dependencies declared in the manifest,
+// in alphabetical order.
+func (sc *scanContext) declaredDependencies() []string {
+	var dependencies []string
+	for dependency := range sc.d.mp {
+		dependencies = append(dependencies, dependency)
+	}
+	sort.Strings(dependencies)
+	return dependencies
+}
+
+// explain writes why the package is kept or removed by depose:
+// the evidence it is used on, and the locations where it is found.
+func (sc *scanContext) explain(w io.Writer, pkgName string) {
+	used, declared := sc.d.mp[pkgName]
+	locations := sc.d.usages[pkgName]
+	switch {
+	case declared && used:
+		evidence := sc.d.evidence[pkgName]
+		fmt.Fprintf(w, "%s is declared in %s of %s, and kept: %s evidence (%s)\n",
+			pkgName, sc.lang.sectionOf(pkgName), sc.manifestFile, evidence, evidence.Mode())
+	case declared:
+		fmt.Fprintf(w, "%s is declared in %s of %s, but nothing uses it, so it would be removed\n",
+			pkgName, sc.lang.sectionOf(pkgName), sc.manifestFile)
+	case len(locations) > 0:
+		fmt.Fprintf(w, "%s is not declared in %s, but it is used\n", pkgName, sc.manifestFile)
+	default:
+		fmt.Fprintf(w, "%s is neither declared in %s nor used\n", pkgName, sc.manifestFile)
+	}
+	for _, loc := range locations {
+		switch {
+		case loc.SideEffect:
+			fmt.Fprintf(w, "  used at %s (side-effect usage)\n", loc)
+		case loc.LowConfidence:
+			fmt.Fprintf(w, "  used at %s (low confidence)\n", loc)
+		default:
+			fmt.Fprintf(w, "  used at %s\n", loc)
+		}
+	}
+}
+
+// whyCommand implements "depose why", which explains why a dependency
+// is kept or removed, with the locations where it is used:
+//
+//	depose why express
+func (sc *scanContext) whyCommand(fs *flag.FlagSet) func(args []string) {
+	list := fs.Bool("list", false, "list the declared dependencies, e.g. for the shell completion")
+	fs.BoolVar(&sc.strict, "strict", false, "only count imports as usage, not package names found in scripts and config files")
+	fs.StringVar(&sc.langName, "lang", "node", "language of the project: node, go or python")
+	return func(args []string) {
+		var ok bool
+		if sc.lang, ok = sc.languages[sc.langName]; !ok {
+			logFatalf("Unknown language %q", sc.langName)
+		}
+		sc.logOut = io.Discard
+
+		if *list {
+			sc.d.mp = make(map[string]bool)
+			sc.manifestFile = sc.findManifest(sc.lang)
+			sc.lang.readManifest(sc.manifestFile)
+			for _, dependency := range sc.declaredDependencies() {
+				fmt.Println(dependency)
+			}
+			return
+		}
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "usage: depose why [flags] <dependency>")
+			fs.PrintDefaults()
+			exit(2)
+		}
+		sc.logOut = os.Stderr
+		sc.scanProject()
+		sc.explain(os.Stdout, args[0])
+	}
+}
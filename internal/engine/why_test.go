@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"package.json": `{
+  "scripts": {"lint": "eslint ."},
+  "dependencies": {"express": "^4.18.2", "lodash": "^4.17.21"},
+  "devDependencies": {"eslint": "^8.0.0"}
+}`,
+		"src/app.js": "const express = require(\"express\");\nconst chalk = require(\"chalk\");\n",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+	sc.lang = sc.nodeLanguage
+	defer func() { sc.d = Dependency{}; sc.manifest = Package{}; sc.files = nil }()
+
+	sc.scanProject()
+	if got, want := sc.declaredDependencies(), []string{"eslint", "express", "lodash"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("declaredDependencies() = %v, want %v", got, want)
+	}
+
+	tests := map[string]string{
+		"express": "express is declared in dependencies of package.json, and kept: import evidence (strict)\n  used at src/app.js:1\n",
+		"eslint":  "eslint is declared in devDependencies of package.json, and kept: script evidence (lenient)\n",
+		"lodash":  "lodash is declared in dependencies of package.json, but nothing uses it, so it would be removed\n",
+		"chalk":   "chalk is not declared in package.json, but it is used\n  used at src/app.js:2\n",
+		"react":   "react is neither declared in package.json nor used\n",
+	}
+	for pkgName, want := range tests {
+		var sb strings.Builder
+		sc.explain(&sb, pkgName)
+		if sb.String() != want {
+			t.Errorf("explain(%q) =\n%s\nwant\n%s", pkgName, sb.String(), want)
+		}
+	}
+}
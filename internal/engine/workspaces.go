@@ -0,0 +1,432 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// workspace is a package of a monorepo.
+type workspace struct {
+	// Dir is the directory of the workspace, relative to the root.
+	Dir     string `json:"dir"`
+	Name    string `json:"name"`
+	project *ProjectContext
+	// Unused lists the dependencies of the workspace nothing uses.
+	Unused []DependencyStatus `json:"unused"`
+	// Error is why the scan of the workspace failed, if it did, and
+	// Diagnostics are the non-fatal errors of its scan.
+	Error       string       `json:"error,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// Owners are the owners of the package.json of the workspace, read
+	// from CODEOWNERS. It is only populated with --group-by owner.
+	Owners []string `json:"owners,omitempty"`
+}
+
+// versionUsage is a range of a dependency and the workspaces declaring it.
+type versionUsage struct {
+	Range      string   `json:"range"`
+	Workspaces []string `json:"workspaces"`
+}
+
+// sharedDependency is a dependency declared by several workspaces.
+type sharedDependency struct {
+	Package  string         `json:"package"`
+	Versions []versionUsage `json:"versions"`
+}
+
+// workspaceReport is the root-level report of a monorepo.
+type workspaceReport struct {
+	Workspaces []*workspace `json:"workspaces"`
+	// Mismatched lists the dependencies declared with different ranges.
+	Mismatched []sharedDependency `json:"mismatched,omitempty"`
+	// Hoistable lists the dependencies declared with the same range by
+	// several workspaces, but not by the root, which could declare them once.
+	Hoistable []sharedDependency `json:"hoistable,omitempty"`
+}
+
+// workspacePatterns returns the patterns of the workspaces of the monorepo,
+// read from the "workspaces" of package.json, either a list or an object
+// with "packages" like Yarn's, from pnpm-workspace.yaml, from the
+// "packages" of lerna.json, or from the layout of the projects of Nx.
+func workspacePatterns(rootData []byte) []string {
+	var doc struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal(rootData, &doc) == nil && len(doc.Workspaces) > 0 {
+		var patterns []string
+		if json.Unmarshal(doc.Workspaces, &patterns) == nil {
+			return patterns
+		}
+		var yarn struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(doc.Workspaces, &yarn) == nil {
+			return yarn.Packages
+		}
+	}
+	if patterns := pnpmWorkspacePatterns(); len(patterns) > 0 {
+		return patterns
+	}
+	if patterns := lernaPackagePatterns(); len(patterns) > 0 {
+		return patterns
+	}
+	return nxProjectPatterns()
+}
+
+// pnpmWorkspacePatterns returns the "packages" of pnpm-workspace.yaml.
+func pnpmWorkspacePatterns() []string {
+	data, err := os.ReadFile("pnpm-workspace.yaml")
+	if err != nil {
+		return nil
+	}
+	var patterns []string
+	inPackages := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-"):
+			inPackages = strings.HasPrefix(trimmed, "packages:")
+		case inPackages && strings.HasPrefix(trimmed, "-"):
+			patterns = append(patterns, strings.Trim(strings.TrimSpace(trimmed[1:]), `"'`))
+		}
+	}
+	return patterns
+}
+
+// lernaPackagePatterns returns the "packages" of lerna.json, which Lerna
+// uses when the package manager declares no workspaces.
+func lernaPackagePatterns() []string {
+	data, err := os.ReadFile("lerna.json")
+	if err != nil {
+		return nil
+	}
+	var lerna struct {
+		Packages []string `json:"packages"`
+	}
+	json.Unmarshal(data, &lerna)
+	return lerna.Packages
+}
+
+// nxProjectPatterns returns the directories of the projects of an Nx
+// workspace, read from the "workspaceLayout" of nx.json, apps/ and libs/
+// by default. Only the projects having their own package.json are
+// workspaces: the other ones use the dependencies of the root.
+func nxProjectPatterns() []string {
+	data, err := os.ReadFile("nx.json")
+	if err != nil {
+		return nil
+	}
+	var nx struct {
+		WorkspaceLayout struct {
+			AppsDir string `json:"appsDir"`
+			LibsDir string `json:"libsDir"`
+		} `json:"workspaceLayout"`
+	}
+	json.Unmarshal(data, &nx)
+	apps, libs := cmp.Or(nx.WorkspaceLayout.AppsDir, "apps"), cmp.Or(nx.WorkspaceLayout.LibsDir, "libs")
+	patterns := []string{strings.TrimSuffix(apps, "/") + "/*"}
+	if libs != apps {
+		patterns = append(patterns, strings.TrimSuffix(libs, "/")+"/*")
+	}
+	return patterns
+}
+
+// findWorkspaces returns the workspaces matching the patterns, sorted by
+// directory. Patterns starting with "!" exclude the directories they match.
+func findWorkspaces(patterns []string) ([]*workspace, error) {
+	excluded := make(map[string]bool)
+	var dirs []string
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		// "**" is matched one level deep, like "*", which covers the usual layouts.
+		pattern = strings.ReplaceAll(strings.TrimPrefix(pattern, "!"), "**", "*")
+		matches, err := filepath.Glob(filepath.FromSlash(strings.TrimSuffix(pattern, "/")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %v", pattern, err)
+		}
+		for _, dir := range matches {
+			if negated {
+				excluded[filepath.ToSlash(dir)] = true
+			} else {
+				dirs = append(dirs, filepath.ToSlash(dir))
+			}
+		}
+	}
+	sort.Strings(dirs)
+
+	var workspaces []*workspace
+	for i, dir := range dirs {
+		if excluded[dir] || i > 0 && dirs[i-1] == dir {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(filepath.FromSlash(dir), "package.json"))
+		if err != nil {
+			continue
+		}
+		project, err := newProjectContext(dir, data)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, &workspace{Dir: dir, Name: project.Manifest.Name, project: project})
+	}
+	return workspaces, nil
+}
+
+// sharedDependencies groups the dependencies declared by several
+// workspaces into the ones declared with mismatched ranges, and the
+// ones which could be hoisted to the root, which doesn't declare them.
+// Local packages, e.g. "workspace:*", are left out.
+func sharedDependencies(root Package, workspaces []*workspace) (mismatched, hoistable []sharedDependency) {
+	ranges := make(map[string]map[string][]string)
+	for _, w := range workspaces {
+		for _, deps := range []map[string]string{w.project.Manifest.Dependencies, w.project.Manifest.DevDependencies} {
+			for dependency, r := range deps {
+				if isLocalRange(r) {
+					continue
+				}
+				if ranges[dependency] == nil {
+					ranges[dependency] = make(map[string][]string)
+				}
+				ranges[dependency][r] = append(ranges[dependency][r], w.Dir)
+			}
+		}
+	}
+
+	for dependency, byRange := range ranges {
+		shared := sharedDependency{Package: dependency}
+		count := 0
+		for r, dirs := range byRange {
+			sort.Strings(dirs)
+			shared.Versions = append(shared.Versions, versionUsage{Range: r, Workspaces: dirs})
+			count += len(dirs)
+		}
+		sort.Slice(shared.Versions, func(i, j int) bool { return shared.Versions[i].Range < shared.Versions[j].Range })
+		_, inRoot := root.Dependencies[dependency]
+		if _, ok := root.DevDependencies[dependency]; ok {
+			inRoot = true
+		}
+		switch {
+		case len(shared.Versions) > 1:
+			mismatched = append(mismatched, shared)
+		case count > 1 && !inRoot:
+			hoistable = append(hoistable, shared)
+		}
+	}
+	sort.Slice(mismatched, func(i, j int) bool { return mismatched[i].Package < mismatched[j].Package })
+	sort.Slice(hoistable, func(i, j int) bool { return hoistable[i].Package < hoistable[j].Package })
+	return mismatched, hoistable
+}
+
+// highestRange returns the range of the shared dependency with the highest
+// base version, which the other ranges are aligned to.
+func highestRange(shared sharedDependency) (string, bool) {
+	best, found := "", false
+	var bestBase semver
+	for _, v := range shared.Versions {
+		base, _, ok := rangeBase(v.Range)
+		if !ok {
+			// Ranges which are not based on a version can't be compared.
+			return "", false
+		}
+		if !found || bestBase.less(base) {
+			best, bestBase, found = v.Range, base, true
+		}
+	}
+	return best, found
+}
+
+// alignVersions rewrites the package.json of the workspaces declaring a
+// mismatched dependency with a lower range, to declare the highest one.
+func (sc *scanContext) alignVersions(mismatched []sharedDependency) error {
+	for _, shared := range mismatched {
+		target, ok := highestRange(shared)
+		if !ok {
+			fmt.Fprintf(sc.logOut, "Skipping %s: its ranges can't be compared\n", shared.Package)
+			continue
+		}
+		for _, v := range shared.Versions {
+			if v.Range == target {
+				continue
+			}
+			for _, dir := range v.Workspaces {
+				if err := rewriteRange(filepath.Join(filepath.FromSlash(dir), "package.json"), shared.Package, v.Range, target); err != nil {
+					return err
+				}
+				fmt.Fprintf(sc.logOut, "%s: %s %s -> %s\n", dir, shared.Package, v.Range, target)
+			}
+		}
+	}
+	return nil
+}
+
+// rewriteRange replaces the range of the dependency in the manifest,
+// keeping the rest of the file as it is.
+func rewriteRange(file, dependency, from, to string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	re := regexp.MustCompile(`("` + regexp.QuoteMeta(dependency) + `"\s*:\s*")` + regexp.QuoteMeta(from) + `"`)
+	return os.WriteFile(file, re.ReplaceAll(data, []byte("${1}"+strings.ReplaceAll(to, "$", "$$")+`"`)), 0o644)
+}
+
+// writeWorkspaceReport prints the unused dependencies of every workspace,
+// grouped by owner when byOwner is set, followed by the dependencies
+// shared by several of them.
+func writeWorkspaceReport(w io.Writer, r *workspaceReport, byOwner bool) {
+	writeWorkspace := func(ws *workspace, indent string) {
+		name := ws.Dir
+		if ws.Name != "" {
+			name += " (" + ws.Name + ")"
+		}
+		fmt.Fprintf(w, "%sWorkspace %s:\n", indent, name)
+		if ws.Error != "" {
+			fmt.Fprintf(w, "%s  failed: %s\n", indent, ws.Error)
+			return
+		}
+		if len(ws.Unused) == 0 {
+			fmt.Fprintf(w, "%s  No unused dependencies.\n", indent)
+		}
+		for _, dep := range ws.Unused {
+			fmt.Fprintf(w, "%s  unused: %s (%s)\n", indent, dep.Name, dep.Section)
+		}
+		for _, diag := range ws.Diagnostics {
+			fmt.Fprintf(w, "%s  skipped %s: %s\n", indent, diag.File, diag.Message)
+		}
+	}
+	if byOwner {
+		owners, byOwner := []string{}, make(map[string][]*workspace)
+		for _, ws := range r.Workspaces {
+			wsOwners := ws.Owners
+			if len(wsOwners) == 0 {
+				wsOwners = []string{unowned}
+			}
+			for _, owner := range wsOwners {
+				if byOwner[owner] == nil {
+					owners = append(owners, owner)
+				}
+				byOwner[owner] = append(byOwner[owner], ws)
+			}
+		}
+		sort.Slice(owners, func(i, j int) bool { return ownerLess(owners[i], owners[j]) })
+		for _, owner := range owners {
+			fmt.Fprintf(w, "%s:\n", owner)
+			for _, ws := range byOwner[owner] {
+				writeWorkspace(ws, "  ")
+			}
+		}
+	} else {
+		for _, ws := range r.Workspaces {
+			writeWorkspace(ws, "")
+		}
+	}
+	writeShared := func(title string, deps []sharedDependency) {
+		if len(deps) == 0 {
+			return
+		}
+		fmt.Fprintln(w, title)
+		for _, shared := range deps {
+			var versions []string
+			for _, v := range shared.Versions {
+				versions = append(versions, fmt.Sprintf("%s in %s", v.Range, strings.Join(v.Workspaces, ", ")))
+			}
+			fmt.Fprintf(w, "  %s: %s\n", shared.Package, strings.Join(versions, "; "))
+		}
+	}
+	writeShared("Mismatched versions:", r.Mismatched)
+	writeShared("Could be hoisted to the root:", r.Hoistable)
+}
+
+// workspacesCommand implements "depose workspaces", which scans every
+// workspace of a monorepo, and reports the dependencies they share:
+//
+//	depose workspaces --fix-versions
+func (sc *scanContext) workspacesCommand(fs *flag.FlagSet) func(args []string) {
+	fixVersions := fs.Bool("fix-versions", false, "align the mismatched ranges of the workspaces to the highest one")
+	asJSON := fs.Bool("json", false, "write the report as JSON")
+	fs.BoolVar(&sc.strict, "strict", false, "only count imports as usage, not package names found in scripts and config files")
+	fs.StringVar(&sc.groupBy, "group-by", "", "group the workspaces of the report: owner, read from CODEOWNERS")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "number of workspaces scanned concurrently")
+	return func(args []string) {
+		sc.logOut = os.Stderr
+		sc.lang = sc.nodeLanguage
+		rootData := sc.loadManifest()
+		if sc.groupBy != "" && sc.groupBy != "owner" {
+			logFatalf("Unknown grouping %q", sc.groupBy)
+		}
+
+		workspaces, err := findWorkspaces(workspacePatterns(rootData))
+		if err != nil {
+			logFatal(err)
+		}
+		if len(workspaces) == 0 {
+			logFatal("No workspaces found in package.json, pnpm-workspace.yaml, lerna.json nor nx.json")
+		}
+		projects := make([]*ProjectContext, len(workspaces))
+		for i, ws := range workspaces {
+			projects[i] = ws.project
+		}
+		fmt.Fprintf(sc.logOut, "Scanning %d workspaces\n", len(workspaces))
+		scanProjects(sc.ctx, sc.dir, projects, sc.projectScanArgs(), *jobs)
+		failed := 0
+		for _, ws := range workspaces {
+			ws.Unused = []DependencyStatus{}
+			if ws.project.Err != nil {
+				ws.Error = ws.project.Err.Error()
+				failed++
+				continue
+			}
+			for _, dep := range ws.project.Report.Dependencies() {
+				if dep.Status == StatusUnused && !dep.Suppressed {
+					ws.Unused = append(ws.Unused, dep)
+				}
+			}
+			ws.Diagnostics = ws.project.Report.Diagnostics
+		}
+
+		if sc.groupBy == "owner" {
+			owners, err := sc.readCodeowners()
+			if err != nil {
+				logFatalf("Failed to read CODEOWNERS: %v", err)
+			}
+			for _, ws := range workspaces {
+				ws.Owners = ownersOf(owners, ws.Dir+"/package.json")
+			}
+		}
+
+		r := &workspaceReport{Workspaces: workspaces}
+		r.Mismatched, r.Hoistable = sharedDependencies(sc.manifest, workspaces)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(r); err != nil {
+				logFatal(err)
+			}
+		} else {
+			writeWorkspaceReport(os.Stdout, r, sc.groupBy == "owner")
+		}
+
+		if *fixVersions {
+			if err := sc.alignVersions(r.Mismatched); err != nil {
+				logFatal(err)
+			}
+		}
+		if failed > 0 {
+			logFatalf("Failed to scan %d of the %d workspaces", failed, len(workspaces))
+		}
+	}
+}
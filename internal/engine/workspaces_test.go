@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkspacePatterns(t *testing.T) {
+	chdir(t, t.TempDir())
+	if got, want := workspacePatterns([]byte(`{"workspaces": ["packages/*", "apps/*"]}`)), []string{"packages/*", "apps/*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("npm workspaces = %v, want %v", got, want)
+	}
+	if got, want := workspacePatterns([]byte(`{"workspaces": {"packages": ["packages/*"], "nohoist": ["**/react-native"]}}`)), []string{"packages/*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("yarn workspaces = %v, want %v", got, want)
+	}
+	writeFiles(t, map[string]string{
+		"pnpm-workspace.yaml": "packages:\n  # every package\n  - 'packages/*'\n  - \"!packages/legacy\"\ncatalog:\n  - react\n",
+	})
+	if got, want := workspacePatterns([]byte(`{}`)), []string{"packages/*", "!packages/legacy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pnpm workspaces = %v, want %v", got, want)
+	}
+
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{"lerna.json": `{"packages": ["modules/*"], "useNx": true}`, "nx.json": `{}`})
+	if got, want := workspacePatterns([]byte(`{}`)), []string{"modules/*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lerna packages = %v, want %v", got, want)
+	}
+	if err := os.Remove("lerna.json"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := workspacePatterns([]byte(`{}`)), []string{"apps/*", "libs/*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nx projects = %v, want %v", got, want)
+	}
+	writeFiles(t, map[string]string{"nx.json": `{"workspaceLayout": {"appsDir": "packages", "libsDir": "packages"}}`})
+	if got, want := workspacePatterns([]byte(`{}`)), []string{"packages/*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nx projects = %v, want %v", got, want)
+	}
+}
+
+func TestSharedDependencies(t *testing.T) {
+	sc := newScanContext()
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"packages/api/package.json": `{
+  "name": "@app/api",
+  "dependencies": {"react": "^17.0.2", "zod": "^3.22.0", "@app/ui": "workspace:*"},
+  "devDependencies": {"typescript": "^5.4.0"}
+}`,
+		"packages/web/package.json": `{
+  "name": "@app/web",
+  "dependencies": {"react": "^18.2.0", "zod": "^3.22.0", "@app/ui": "workspace:*"},
+  "devDependencies": {"typescript": "^5.4.0"}
+}`,
+		"packages/legacy/package.json": `{"dependencies": {"react": "^15.0.0"}}`,
+		"packages/docs/README.md":      "no manifest",
+	})
+	defer func(w io.Writer) { sc.logOut = w }(sc.logOut)
+	sc.logOut = io.Discard
+
+	workspaces, err := findWorkspaces([]string{"packages/*", "!packages/legacy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dirs []string
+	for _, w := range workspaces {
+		dirs = append(dirs, w.Dir+" "+w.Name)
+	}
+	if want := []string{"packages/api @app/api", "packages/web @app/web"}; !reflect.DeepEqual(dirs, want) {
+		t.Fatalf("workspaces = %v, want %v", dirs, want)
+	}
+
+	root := Package{DevDependencies: map[string]string{"typescript": "^5.4.0"}}
+	mismatched, hoistable := sharedDependencies(root, workspaces)
+	wantMismatched := []sharedDependency{{Package: "react", Versions: []versionUsage{
+		{Range: "^17.0.2", Workspaces: []string{"packages/api"}},
+		{Range: "^18.2.0", Workspaces: []string{"packages/web"}},
+	}}}
+	if !reflect.DeepEqual(mismatched, wantMismatched) {
+		t.Errorf("mismatched = %+v, want %+v", mismatched, wantMismatched)
+	}
+	wantHoistable := []sharedDependency{{Package: "zod", Versions: []versionUsage{
+		{Range: "^3.22.0", Workspaces: []string{"packages/api", "packages/web"}},
+	}}}
+	if !reflect.DeepEqual(hoistable, wantHoistable) {
+		t.Errorf("hoistable = %+v, want %+v", hoistable, wantHoistable)
+	}
+
+	if err := sc.alignVersions(mismatched); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("packages/api/package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"react": "^18.2.0", "zod"`) {
+		t.Errorf("react was not aligned to ^18.2.0:\n%s", data)
+	}
+}
+
+func TestScanProjects(t *testing.T) {
+	chdir(t, t.TempDir())
+	writeFiles(t, map[string]string{
+		"packages/api/package.json": `{"name": "@app/api", "dependencies": {"express": "^4.18.2", "moment": "^2.29.4"}}`,
+		"packages/api/index.js":     "require(\"express\");\n",
+		"packages/web/package.json": `{"name": "@app/web", "dependencies": {"react": "^18.2.0"}}`,
+		"packages/web/index.js":     "import React from \"react\";\n",
+		"packages/docs/README.md":   "no manifest",
+	})
+	var projects []*ProjectContext
+	for _, dir := range []string{"packages/api", "packages/web"} {
+		data, err := os.ReadFile(dir + "/package.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		project, err := newProjectContext(dir, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		projects = append(projects, project)
+	}
+	projects = append(projects, &ProjectContext{Dir: "packages/docs"})
+
+	scanProjects(context.Background(), ".", projects, newScanContext().projectScanArgs(), 2)
+	for _, c := range projects[:2] {
+		if c.Err != nil {
+			t.Fatalf("scan of %s failed: %v", c.Dir, c.Err)
+		}
+	}
+	var unused []string
+	for _, dep := range projects[0].Report.Dependencies() {
+		if dep.Status == StatusUnused {
+			unused = append(unused, dep.Name)
+		}
+	}
+	if !reflect.DeepEqual(unused, []string{"moment"}) {
+		t.Errorf("unused dependencies of packages/api = %v, want [moment]", unused)
+	}
+	for _, dep := range projects[1].Report.Dependencies() {
+		if dep.Status != StatusUsed {
+			t.Errorf("packages/web: %s is %s, want used", dep.Name, dep.Status)
+		}
+	}
+	// The failure of a project doesn't stop the scans of the others.
+	if !errors.Is(projects[2].Err, ErrNoManifest) {
+		t.Errorf("scan of packages/docs: %v, want %v", projects[2].Err, ErrNoManifest)
+	}
+}
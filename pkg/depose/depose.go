@@ -0,0 +1,212 @@
+// Package depose exposes the results of depose to Go programs, e.g. bots
+// or dashboards, as typed values instead of the text printed by the CLI.
+//
+// The types of this package are stable: fields are only ever added, and
+// their JSON names never change.
+//
+// Scan runs the scan of the CLI in the process, with its own state, so the
+// results are the ones the CLI of the same version reports, and several
+// projects are scanned concurrently.
+package depose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CoderParth/depose/internal/engine"
+)
+
+// Location points to a line in a file, relative to the project root.
+type Location struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+	// SideEffect is true when the package is loaded there for its side
+	// effects only, e.g. import "core-js/stable".
+	SideEffect bool `json:"sideEffect,omitempty"`
+	// LowConfidence is true when the package is only used there by a code
+	// block of a Markdown document, scanned with --include-markdown.
+	LowConfidence bool `json:"lowConfidence,omitempty"`
+}
+
+// Finding is a single problem detected in the project, identified by the
+// stable ID of its rule, e.g. "unused-dependency".
+type Finding struct {
+	RuleID       string     `json:"ruleId"`
+	Severity     string     `json:"severity"`
+	Package      string     `json:"package"`
+	Section      string     `json:"section,omitempty"`
+	Message      string     `json:"message"`
+	Locations    []Location `json:"locations,omitempty"`
+	SuggestedFix string     `json:"suggestedFix,omitempty"`
+	// Suppression describes why the finding was suppressed, if it was.
+	Suppression string `json:"suppression,omitempty"`
+}
+
+// Status is the state of a dependency of the project.
+type Status string
+
+const (
+	// StatusUsed is a declared dependency which is used.
+	StatusUsed Status = "used"
+	// StatusUnused is a declared dependency which nothing uses.
+	StatusUnused Status = "unused"
+	// StatusMissing is a package which is used, but not declared.
+	StatusMissing Status = "missing"
+	// StatusPhantom is a package which is used and installed,
+	// but only as a transitive dependency.
+	StatusPhantom Status = "phantom"
+)
+
+// DependencyStatus tells whether a package is used, and why.
+type DependencyStatus struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Section is the section of the manifest declaring the dependency,
+	// when it is known, e.g. "devDependencies".
+	Section string `json:"section,omitempty"`
+	// Evidence is the kind of evidence a used dependency is kept on,
+	// e.g. "import" or "config", and Mode the mode it counts in.
+	Evidence string `json:"evidence,omitempty"`
+	Mode     string `json:"mode,omitempty"`
+	// Suppressed reports whether the finding about the dependency
+	// was suppressed by the config or by ignore comments.
+	Suppressed bool       `json:"suppressed,omitempty"`
+	Locations  []Location `json:"locations,omitempty"`
+}
+
+// Report is the result of a scan.
+type Report struct {
+	Findings   []Finding `json:"findings"`
+	Suppressed []Finding `json:"suppressed,omitempty"`
+	// Dependencies lists the declared dependencies and the used
+	// packages of the project, sorted by name.
+	Dependencies []DependencyStatus `json:"dependencies"`
+	// Diagnostics lists the non-fatal errors of the scan, e.g. the files
+	// which were skipped.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Diagnostic is a non-fatal error met during the scan, identified by
+// the code of the error in the catalog of depose, e.g. "permission",
+// or "error" for the others.
+type Diagnostic struct {
+	Code    string `json:"code"`
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// The errors of Scan when the scan stops with an error of the catalog of
+// depose. They are matched with errors.Is.
+var (
+	ErrNoManifest          = engine.ErrNoManifest
+	ErrManifestParse       = engine.ErrManifestParse
+	ErrPermission          = engine.ErrPermission
+	ErrScannerTokenTooLong = engine.ErrScannerTokenTooLong
+)
+
+// Options configure a scan. The zero value scans a Node.js project
+// in lenient mode.
+type Options struct {
+	// Executable is ignored: the scans run in the process.
+	//
+	// Deprecated: Scan no longer runs the depose executable.
+	Executable string
+	// Strict only counts imports as usage, like --strict.
+	Strict bool
+	// Language is the language of the project, like --lang.
+	Language string
+	// Profile selects the exclusion profiles, like --profile.
+	Profile string
+	// NoPlugins doesn't run the depose-plugin-* executables found in the
+	// PATH, like --no-plugins, e.g. to scan untrusted projects.
+	NoPlugins bool
+}
+
+// args returns the flags of the scan: a dry run which leaves the project
+// untouched and reports everything. The scan never ascends to the project
+// of a parent directory when the directory has no manifest, so it never
+// reads files outside of the directory.
+func (o Options) args() []string {
+	args := []string{"--dry-run", "--no-history", "--no-ascend", "--verbose"}
+	if o.Strict {
+		args = append(args, "--strict")
+	}
+	if o.Language != "" {
+		args = append(args, "--lang", o.Language)
+	}
+	if o.Profile != "" {
+		args = append(args, "--profile", o.Profile)
+	}
+	if o.NoPlugins {
+		args = append(args, "--no-plugins")
+	}
+	return args
+}
+
+// Scan scans the project in the directory, leaving it untouched.
+func Scan(ctx context.Context, dir string, opts Options) (*Report, error) {
+	r, err := engine.Scan(ctx, dir, opts.args())
+	if err != nil {
+		return nil, fmt.Errorf("depose failed: %w", err)
+	}
+	return newReport(r), nil
+}
+
+// newReport converts the report of the scan to the types of the package.
+func newReport(r *engine.Report) *Report {
+	report := &Report{
+		Findings:     append([]Finding{}, newFindings(r.Findings)...),
+		Suppressed:   newFindings(r.Suppressed),
+		Dependencies: []DependencyStatus{},
+		Diagnostics:  []Diagnostic{},
+	}
+	for _, dep := range r.Dependencies() {
+		report.Dependencies = append(report.Dependencies, DependencyStatus{
+			Name:       dep.Name,
+			Status:     Status(dep.Status),
+			Section:    dep.Section,
+			Evidence:   string(dep.Evidence),
+			Mode:       dep.Mode,
+			Suppressed: dep.Suppressed,
+			Locations:  newLocations(dep.Locations),
+		})
+	}
+	for _, diag := range r.Diagnostics {
+		report.Diagnostics = append(report.Diagnostics, Diagnostic(diag))
+	}
+	return report
+}
+
+// newFindings converts the findings of the scan.
+func newFindings(findings []engine.Finding) []Finding {
+	if findings == nil {
+		return nil
+	}
+	out := make([]Finding, len(findings))
+	for i, f := range findings {
+		out[i] = Finding{
+			RuleID:       f.RuleID,
+			Severity:     string(f.Severity),
+			Package:      f.Package,
+			Section:      f.Section,
+			Message:      f.Message,
+			Locations:    newLocations(f.Locations),
+			SuggestedFix: f.SuggestedFix,
+			Suppression:  f.Suppression,
+		}
+	}
+	return out
+}
+
+// newLocations converts the locations of the findings and the usages.
+func newLocations(locations []engine.Location) []Location {
+	if locations == nil {
+		return nil
+	}
+	out := make([]Location, len(locations))
+	for i, loc := range locations {
+		out[i] = Location(loc)
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package depose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"package.json": `{
+  "dependencies": {"express": "^4.18.2", "lodash": "^4.17.21"},
+  "devDependencies": {"eslint": "^8.0.0"}
+}`,
+		".eslintrc.json": `{"extends": "eslint:recommended"}`,
+		"server.js":      "const express = require(\"express\");\nconst chalk = require(\"chalk\");\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Scan(context.Background(), dir, Options{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DependencyStatus{
+		{Name: "chalk", Status: StatusMissing, Locations: []Location{{File: "server.js", Line: 2}}},
+		{Name: "eslint", Status: StatusUnused, Section: "devDependencies", Locations: []Location{{File: "package.json"}}},
+		{Name: "express", Status: StatusUsed, Evidence: "import", Mode: "strict", Locations: []Location{{File: "server.js", Line: 1}}},
+		{Name: "lodash", Status: StatusUnused, Section: "dependencies", Locations: []Location{{File: "package.json"}}},
+	}
+	if !reflect.DeepEqual(r.Dependencies, want) {
+		t.Errorf("Dependencies = %+v, want %+v", r.Dependencies, want)
+	}
+	if len(r.Findings) != 3 {
+		t.Errorf("got %d findings, want 3: %+v", len(r.Findings), r.Findings)
+	}
+
+	after, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("Scan changed package.json")
+	}
+	if _, err := Scan(context.Background(), t.TempDir(), Options{}); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("Scan() of a project without manifest = %v, want ErrNoManifest", err)
+	}
+	// A directory without manifest is never scanned as the project of its
+	// parent directory.
+	sub := filepath.Join(dir, "src")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Scan(context.Background(), sub, Options{}); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("Scan() of a subdirectory without manifest = %v, want ErrNoManifest", err)
+	}
+}
+
+func TestScanConcurrent(t *testing.T) {
+	dirs := make([]string, 8)
+	for i := range dirs {
+		dirs[i] = t.TempDir()
+		manifest := fmt.Sprintf(`{"dependencies": {"used-%d": "^1.0.0", "unused-%d": "^1.0.0"}}`, i, i)
+		source := fmt.Sprintf("const used = require(\"used-%d\");\n", i)
+		for name, content := range map[string]string{"package.json": manifest, "index.js": source} {
+			if err := os.WriteFile(filepath.Join(dirs[i], name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The scans share no state, so each reports its own dependencies only.
+	reports := make([]*Report, len(dirs))
+	errs := make([]error, len(dirs))
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i], errs[i] = Scan(context.Background(), dir, Options{NoPlugins: true})
+		}()
+	}
+	wg.Wait()
+	for i, r := range reports {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		want := []DependencyStatus{
+			{Name: fmt.Sprintf("unused-%d", i), Status: StatusUnused, Section: "dependencies", Locations: []Location{{File: "package.json"}}},
+			{Name: fmt.Sprintf("used-%d", i), Status: StatusUsed, Evidence: "import", Mode: "strict", Locations: []Location{{File: "index.js", Line: 1}}},
+		}
+		if !reflect.DeepEqual(r.Dependencies, want) {
+			t.Errorf("Dependencies of project %d = %+v, want %+v", i, r.Dependencies, want)
+		}
+	}
+}
+
+func TestScanCanceled(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies": {"lodash": "^4.17.21"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Scan(ctx, dir, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Scan() with a canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestOptionsArgs(t *testing.T) {
+	got := Options{Strict: true, NoPlugins: true}.args()
+	want := []string{"--dry-run", "--no-history", "--no-ascend", "--verbose", "--strict", "--no-plugins"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args() = %q, want %q", got, want)
+	}
+}
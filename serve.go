@@ -0,0 +1,313 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CoderParth/depose/internal/engine"
+	"github.com/CoderParth/depose/pkg/depose"
+)
+
+// maxUploadSize is the largest tarball accepted by POST /scan.
+const maxUploadSize = 100 << 20
+
+// maxExtractedSize and maxTarEntries limit the size of the files of an
+// uploaded tarball once extracted, and their number, since a small gzipped
+// tarball can expand to gigabytes.
+var (
+	maxExtractedSize int64 = 1 << 30
+	maxTarEntries          = 100_000
+)
+
+// scanServer serves the scans of projects, either found under its root
+// directory or uploaded as tarballs. Every scan has its own state in
+// pkg/depose, so scans run concurrently.
+type scanServer struct {
+	// root is the directory the paths of the requests are relative to.
+	root string
+
+	// mu guards the counters of the scans, exported by GET /metrics.
+	mu           sync.Mutex
+	scans        int
+	failedScans  int
+	scanDuration time.Duration
+}
+
+// scanRequest is the JSON body of POST /scan scanning a project on disk.
+type scanRequest struct {
+	Path string `json:"path"`
+}
+
+func (s *scanServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scan", s.handleScan)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// handleScan implements POST /scan. The body is either a JSON document
+// naming the path of the project, relative to the root of the server, or
+// the project as a gzipped tarball. The options of the scan are given as
+// query parameters, e.g. ?strict=true&lang=go&profile=next.
+func (s *scanServer) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+	opts, err := scanOptions(r)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	// The plugins of the server never run on the projects of the requests.
+	opts.NoPlugins = true
+
+	var dir string
+	switch mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); strings.TrimSpace(mediaType) {
+	case "application/json":
+		var req scanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+			return
+		}
+		if dir, err = s.projectDir(req.Path); err != nil {
+			httpError(w, http.StatusBadRequest, err)
+			return
+		}
+	case "application/gzip", "application/x-gzip", "application/x-tar":
+		tmp, err := os.MkdirTemp("", "depose-scan-")
+		if err != nil {
+			httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer os.RemoveAll(tmp)
+		if err := extractTarball(http.MaxBytesReader(w, r.Body, maxUploadSize), tmp); err != nil {
+			httpError(w, http.StatusBadRequest, fmt.Errorf("invalid tarball: %v", err))
+			return
+		}
+		dir = projectRoot(tmp)
+	default:
+		httpError(w, http.StatusUnsupportedMediaType, errors.New("the body must be JSON or a gzipped tarball"))
+		return
+	}
+	if manifest, ok := hasManifest(dir, opts.Language); !ok {
+		httpError(w, http.StatusBadRequest, fmt.Errorf("no %s in the project", manifest))
+		return
+	}
+
+	start := time.Now()
+	report, err := depose.Scan(r.Context(), dir, opts)
+	s.recordScan(time.Since(start), err)
+	if err != nil {
+		httpError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
+
+// recordScan counts a scan, which took d, in the metrics of the server.
+func (s *scanServer) recordScan(d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scans++
+	if err != nil {
+		s.failedScans++
+	}
+	s.scanDuration += d
+}
+
+// handleMetrics implements GET /metrics, which exports the counters of
+// the scans and the usage of the server in the text format of Prometheus.
+func (s *scanServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	scans, failed, duration := s.scans, s.failedScans, s.scanDuration
+	s.mu.Unlock()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metric := func(name, kind, help string, value any) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	metric("depose_scans_total", "counter", "Scans run by the server.", scans)
+	metric("depose_scan_failures_total", "counter", "Scans which failed.", failed)
+	metric("depose_scan_duration_seconds_total", "counter", "Time spent scanning.", duration.Seconds())
+	metric("depose_goroutines", "gauge", "Goroutines of the server.", runtime.NumGoroutine())
+	metric("depose_heap_bytes", "gauge", "Heap of the server.", ms.HeapAlloc)
+}
+
+// scanOptions reads the options of the scan from the query parameters.
+func scanOptions(r *http.Request) (depose.Options, error) {
+	q := r.URL.Query()
+	opts := depose.Options{Language: q.Get("lang"), Profile: q.Get("profile")}
+	if _, ok := engine.ManifestFiles(cmp.Or(opts.Language, "node")); !ok {
+		return opts, fmt.Errorf("unknown language %q", opts.Language)
+	}
+	if v := q.Get("strict"); v != "" {
+		strict, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid strict parameter %q", v)
+		}
+		opts.Strict = strict
+	}
+	return opts, nil
+}
+
+// projectDir returns the directory of the project at the path, which
+// must not leave the root of the server.
+func (s *scanServer) projectDir(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("missing path")
+	}
+	if filepath.IsAbs(path) || !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("path %q is outside of the root of the server", path)
+	}
+	dir := filepath.Join(s.root, filepath.FromSlash(path))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("no project at %q", path)
+	}
+	return dir, nil
+}
+
+// hasManifest reports whether the directory holds a manifest of the
+// language, node by default, which scanOptions validated. Otherwise, it
+// returns the name of the manifest expected. The scans never look for one
+// in the parent directories, which are outside of the root of the server.
+func hasManifest(dir, langName string) (string, bool) {
+	files, _ := engine.ManifestFiles(cmp.Or(langName, "node"))
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return file, true
+		}
+	}
+	return files[0], false
+}
+
+// extractTarball extracts the regular files and directories of the
+// tarball, gzipped or not, into dir. Entries leaving dir are rejected, and
+// so are the tarballs exceeding maxTarEntries or maxExtractedSize.
+func extractTarball(r io.Reader, dir string) error {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	tr := tar.NewReader(src)
+	var size int64
+	for entries := 0; ; entries++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if entries >= maxTarEntries {
+			return fmt.Errorf("more than %d entries", maxTarEntries)
+		}
+		name := filepath.FromSlash(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "" || name == "." {
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("entry %q is outside of the archive", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			// The bytes written are counted rather than trusting the
+			// size of the header, and the copy stops past the limit.
+			n, err := io.CopyN(f, tr, maxExtractedSize-size+1)
+			if err == io.EOF {
+				err = nil
+			}
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			if size += n; size > maxExtractedSize {
+				return fmt.Errorf("larger than %s once extracted", engine.FormatBytes(uint64(maxExtractedSize)))
+			}
+		}
+	}
+}
+
+// projectRoot returns the directory of the project extracted into dir:
+// the single top-level directory of tarballs wrapping the project in one,
+// like the archives of GitHub, or dir itself.
+func projectRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+// httpError writes the error as a JSON document.
+func httpError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// serveCommand implements "depose serve", which runs depose as an HTTP
+// service scanning projects on demand:
+//
+//	depose serve --addr :8080
+//	curl -X POST -H 'Content-Type: application/gzip' --data-binary @project.tar.gz localhost:8080/scan
+func serveCommand(fs *flag.FlagSet) func(args []string) {
+	addr := fs.String("addr", ":8080", "address to listen on")
+	root := fs.String("root", ".", "directory the paths of the scan requests are relative to")
+	return func(args []string) {
+		absRoot, err := filepath.Abs(*root)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s := &scanServer{root: absRoot}
+		server := &http.Server{Addr: *addr, Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+		fmt.Fprintf(os.Stderr, "Listening on %s\n", *addr)
+		log.Fatal(server.ListenAndServe())
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CoderParth/depose/pkg/depose"
+)
+
+func TestServeScan(t *testing.T) {
+	root := t.TempDir()
+	project := map[string]string{
+		"package.json": `{"dependencies": {"express": "^4.18.2", "lodash": "^4.17.21"}}`,
+		"server.js":    "const express = require(\"express\");\n",
+	}
+	for name, content := range project {
+		path := filepath.Join(root, "app", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var tarball bytes.Buffer
+	gz := gzip.NewWriter(&tarball)
+	tw := tar.NewWriter(gz)
+	for name, content := range project {
+		tw.WriteHeader(&tar.Header{Name: "repo-main/" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+
+	if err := os.MkdirAll(filepath.Join(root, "app", "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var bareTarball bytes.Buffer
+	bare := tar.NewWriter(&bareTarball)
+	bare.WriteHeader(&tar.Header{Name: "src/index.js", Mode: 0o644, Size: 1, Typeflag: tar.TypeReg})
+	bare.Write([]byte("\n"))
+	bare.Close()
+
+	s := &scanServer{root: root}
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"path", "application/json", `{"path": "app"}`, http.StatusOK},
+		{"tarball", "application/gzip", tarball.String(), http.StatusOK},
+		{"outside root", "application/json", `{"path": "../etc"}`, http.StatusBadRequest},
+		// The scans don't ascend to the project of app.
+		{"no manifest", "application/json", `{"path": "app/src"}`, http.StatusBadRequest},
+		{"tarball without manifest", "application/gzip", bareTarball.String(), http.StatusBadRequest},
+		{"unsupported body", "text/plain", "app", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(server.URL+"/scan?strict=true", tt.contentType, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var r depose.Report
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(r.Findings) != 1 || r.Findings[0].Package != "lodash" {
+			t.Errorf("%s: findings = %+v, want lodash unused", tt.name, r.Findings)
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/scan?lang=cobol", "application/json", strings.NewReader(`{"path": "app"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown language: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp, err = http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	metrics, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(metrics), "depose_scans_total 2\n") {
+		t.Errorf("GET /metrics = %s, want the 2 scans counted", metrics)
+	}
+}
+
+func TestExtractTarballRejectsTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "../evil.js", Mode: 0o644, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("x"))
+	tw.Close()
+
+	if err := extractTarball(&buf, t.TempDir()); err == nil {
+		t.Error("extractTarball() accepted an entry outside of the archive")
+	}
+}
+
+func TestExtractTarballLimits(t *testing.T) {
+	defer func(size int64, entries int) { maxExtractedSize, maxTarEntries = size, entries }(maxExtractedSize, maxTarEntries)
+	maxExtractedSize, maxTarEntries = 1<<20, 10
+
+	// A gzipped tarball of a few kilobytes expanding to 2 MiB.
+	tarball := func(files int, size int) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for i := 0; i < files; i++ {
+			tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("file%d.js", i), Mode: 0o644, Size: int64(size), Typeflag: tar.TypeReg})
+			tw.Write(make([]byte, size))
+		}
+		tw.Close()
+		gz.Close()
+		return &buf
+	}
+	bomb := tarball(2, 1<<20)
+	if bomb.Len() > 16<<10 {
+		t.Fatalf("the tarball is %d bytes, want a small one", bomb.Len())
+	}
+	dir := t.TempDir()
+	if err := extractTarball(bomb, dir); err == nil || !strings.Contains(err.Error(), "once extracted") {
+		t.Errorf("extractTarball() of a tarball expanding past the limit = %v", err)
+	}
+	var size int64
+	filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			info, _ := entry.Info()
+			size += info.Size()
+		}
+		return nil
+	})
+	if size > maxExtractedSize+1 {
+		t.Errorf("extracted %d bytes, want at most %d", size, maxExtractedSize+1)
+	}
+
+	if err := extractTarball(tarball(11, 1), t.TempDir()); err == nil || !strings.Contains(err.Error(), "entries") {
+		t.Errorf("extractTarball() of a tarball with too many entries = %v", err)
+	}
+	if err := extractTarball(tarball(10, 1), t.TempDir()); err != nil {
+		t.Errorf("extractTarball() of a tarball within the limits = %v", err)
+	}
+}